@@ -0,0 +1,13 @@
+package DTO
+
+// SetUserRoleRequestDTO 修改用户的全局角色
+type SetUserRoleRequestDTO struct {
+	Role string `json:"role" binding:"required,oneof=user moderator admin"`
+}
+
+// BanUserRequestDTO 封禁用户
+// Duration 为封禁时长, 单位小时, 0 表示永久封禁, 最长 10 年
+type BanUserRequestDTO struct {
+	Duration int    `json:"duration" binding:"min=0,max=87600"`
+	Reason   string `json:"reason" binding:"max=256"`
+}
@@ -0,0 +1,14 @@
+package DTO
+
+// Attachment 附件信息
+// URL 为文件的下载地址, 图片的 ThumbnailURL 为缩略图的下载地址, 可以直接在 Markdown 中引用
+type Attachment struct {
+	AttachmentID int64  `json:"attachment_id"`
+	FileName     string `json:"file_name"`
+	ContentType  string `json:"content_type"`
+	Size         int64  `json:"size"`
+	Width        int32  `json:"width,omitempty"`
+	Height       int32  `json:"height,omitempty"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+}
@@ -0,0 +1,10 @@
+package DTO
+
+// CaptchaResponseDTO 新的验证码
+// Image 为 data URI 格式的 PNG 图片, 可以直接作为 img 标签的 src
+type CaptchaResponseDTO struct {
+	CaptchaID string `json:"captcha_id"`
+	Image     string `json:"image"`
+	// ExpireIn 有效期, 单位秒
+	ExpireIn int `json:"expire_in"`
+}
@@ -1,20 +1,28 @@
 package DTO
 
+import "time"
+
 type Comment struct {
-	CommentID  int64  `json:"comment_id" db:"comment_id"`
-	PostID     int64  `json:"post_id" db:"post_id"`
-	AuthorID   int64  `json:"author_id" db:"author_id"`
-	AuthorName string `json:"author_name" db:"author_name"`
-	Content    string `json:"content" db:"content"`
+	CommentID     int64        `json:"comment_id" db:"comment_id"`
+	PostID        int64        `json:"post_id" db:"post_id"`
+	AuthorID      int64        `json:"author_id" db:"author_id"`
+	AuthorName    string       `json:"author_name" db:"author_name"`
+	Content       string       `json:"content" db:"content"`
+	ContentHTML   string       `json:"content_html,omitempty" db:"content_html"`
+	Version       int64        `json:"version,omitempty" db:"version"`
+	CreateTime    time.Time    `json:"create_time" db:"create_time"`
+	AttachmentIDs []int64      `json:"attachment_ids,omitempty" db:"-" gorm:"-"`
+	Attachments   []Attachment `json:"attachments,omitempty" db:"-" gorm:"-"`
 }
 
 type CreateCommentRequest struct {
-	PostID     int64  `json:"post_id" db:"post_id"`
-	AuthorID   int64  `json:"author_id" db:"author_id"`
-	Content    string `json:"content" db:"content"`
-	AuthorName string `json:"author_name" db:"author_name"`
-	ReplyID    int64  `json:"reply_id" db:"reply_id"`
-	ParentID   int64  `json:"parent_id" db:"parent_id"`
+	PostID        int64   `json:"post_id" db:"post_id"`
+	AuthorID      int64   `json:"author_id" db:"author_id"`
+	Content       string  `json:"content" db:"content"`
+	AuthorName    string  `json:"author_name" db:"author_name"`
+	ReplyID       int64   `json:"reply_id" db:"reply_id"`
+	ParentID      int64   `json:"parent_id" db:"parent_id"`
+	AttachmentIDs []int64 `json:"attachment_ids,omitempty" db:"-" gorm:"-"`
 }
 
 type CommentRelation struct {
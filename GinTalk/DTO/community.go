@@ -11,3 +11,14 @@ type CommunityDetailDTO struct {
 	*CommunityNameDTO
 	Introduction string `json:"introduction"`
 }
+
+// CommunityRequestDTO 创建或修改社区
+type CommunityRequestDTO struct {
+	CommunityName string `json:"community_name" binding:"required,max=128"`
+	Introduction  string `json:"introduction" binding:"required,max=256"`
+}
+
+// CommunityModeratorRequestDTO 任命社区版主
+type CommunityModeratorRequestDTO struct {
+	UserID int64 `json:"user_id" binding:"required"`
+}
@@ -0,0 +1,35 @@
+package DTO
+
+import "time"
+
+const (
+	DataExportStatusPending = "pending"
+	DataExportStatusRunning = "running"
+	DataExportStatusDone    = "done"
+	DataExportStatusFailed  = "failed"
+)
+
+// DataExportJob 个人数据导出任务
+// 任务完成后可以通过下载接口获取 ZIP 压缩包, 压缩包在 ExpireTime 之后删除
+type DataExportJob struct {
+	JobID      string     `json:"job_id"`
+	UserID     int64      `json:"-"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	CreateTime time.Time  `json:"create_time"`
+	FinishTime *time.Time `json:"finish_time,omitempty"`
+	ExpireTime time.Time  `json:"expire_time"`
+}
+
+// DataExportProfile 导出的用户资料, 不包含密码哈希
+type DataExportProfile struct {
+	UserID        int64     `json:"user_id"`
+	Username      string    `json:"username"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	Gender        int32     `json:"gender"`
+	Avatar        string    `json:"avatar"`
+	Bio           string    `json:"bio"`
+	Role          string    `json:"role"`
+	CreateTime    time.Time `json:"create_time"`
+}
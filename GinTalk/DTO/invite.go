@@ -0,0 +1,21 @@
+package DTO
+
+import "time"
+
+// CreateInviteRequestDTO 生成邀请码
+// MaxUses 为最多可以使用的次数, ExpireDays 为有效天数
+type CreateInviteRequestDTO struct {
+	MaxUses    int32 `json:"max_uses" binding:"required,min=1,max=100"`
+	ExpireDays int   `json:"expire_days" binding:"required,min=1,max=90"`
+}
+
+// InviteCode 邀请码
+type InviteCode struct {
+	ID         int64     `json:"id"`
+	Code       string    `json:"code"`
+	MaxUses    int32     `json:"max_uses"`
+	UsedCount  int32     `json:"used_count"`
+	ExpireTime time.Time `json:"expire_time"`
+	Revoked    bool      `json:"revoked"`
+	CreateTime time.Time `json:"create_time"`
+}
@@ -0,0 +1,9 @@
+package DTO
+
+// Page 游标分页的一页数据
+// NextCursor 为获取下一页时传入的游标, HasMore 为 false 时没有下一页, NextCursor 为空
+type Page[T any] struct {
+	List       []T    `json:"list"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
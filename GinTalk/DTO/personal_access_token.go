@@ -0,0 +1,31 @@
+package DTO
+
+import "time"
+
+// CreatePersonalAccessTokenRequestDTO 创建个人访问令牌
+// ExpireDays 为有效天数, 0 表示永不过期
+type CreatePersonalAccessTokenRequestDTO struct {
+	Name       string   `json:"name" binding:"required,max=64"`
+	Scopes     []string `json:"scopes" binding:"required,min=1,dive,oneof=read post:write comment:write"`
+	ExpireDays int      `json:"expire_days" binding:"min=0,max=365"`
+}
+
+// PersonalAccessToken 个人访问令牌, 不包含令牌明文
+type PersonalAccessToken struct {
+	ID     int64    `json:"id"`
+	Name   string   `json:"name"`
+	Prefix string   `json:"prefix"`
+	Scopes []string `json:"scopes"`
+	// ExpireTime 过期时间, 永不过期时为 nil
+	ExpireTime *time.Time `json:"expire_time"`
+	// LastUsedTime 最后使用时间, 从未使用时为 nil
+	LastUsedTime *time.Time `json:"last_used_time"`
+	CreateTime   time.Time  `json:"create_time"`
+}
+
+// CreatePersonalAccessTokenResponseDTO 创建个人访问令牌的结果
+type CreatePersonalAccessTokenResponseDTO struct {
+	PersonalAccessToken
+	// Token 令牌明文, 只在创建时返回一次
+	Token string `json:"token"`
+}
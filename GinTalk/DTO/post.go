@@ -1,22 +1,46 @@
 package DTO
 
+import "GinTalk/pkg/markdown"
+
 const MaxSummaryLength = 100
 
+// 帖子状态, 与 post 表的 status 字段对应
+// 草稿和定时发布的帖子只有作者本人可见, 定时发布的帖子到达 PublishAt 后由后台任务发布
+const (
+	PostStatusHidden    = 0
+	PostStatusPublished = 1
+	PostStatusDraft     = 2
+	PostStatusScheduled = 3
+)
+
 type PostDetail struct {
-	PostID        int64  `json:"post_id,omitempty" db:"post_id"`
-	Title         string `json:"title,omitempty" db:"title"`
-	Content       string `json:"content,omitempty" db:"content"`
-	AuthorId      int64  `json:"author_id,omitempty" db:"author_id"`
-	Username      string `json:"author_name,omitempty" db:"username"`
-	CommunityID   int64  `json:"community_id,omitempty" db:"community_id"`
-	CommunityName string `json:"community_name,omitempty" db:"community_name"`
-	Status        int32  `json:"status,omitempty" db:"status"`
+	PostID        int64        `json:"post_id,omitempty" db:"post_id"`
+	Title         string       `json:"title,omitempty" db:"title"`
+	Content       string       `json:"content,omitempty" db:"content"`
+	ContentHTML   string       `json:"content_html,omitempty" db:"content_html"`
+	AuthorId      int64        `json:"author_id,omitempty" db:"author_id"`
+	Username      string       `json:"author_name,omitempty" db:"username"`
+	CommunityID   int64        `json:"community_id,omitempty" db:"community_id"`
+	CommunityName string       `json:"community_name,omitempty" db:"community_name"`
+	Status        int32        `json:"status,omitempty" db:"status"`
+	PublishAt     int64        `json:"publish_at,omitempty" db:"publish_at"`
+	Version       int64        `json:"version,omitempty" db:"version"`
+	Tags          []string     `json:"tags,omitempty" db:"-" gorm:"-"`
+	AttachmentIDs []int64      `json:"attachment_ids,omitempty" db:"-" gorm:"-"`
+	Attachments   []Attachment `json:"attachments,omitempty" db:"-" gorm:"-"`
+}
+
+// IsPending 帖子是否为尚未发布的草稿或定时发布帖子
+func (p *PostDetail) IsPending() bool {
+	return p.Status == PostStatusDraft || p.Status == PostStatusScheduled
 }
 
+// GenerateSummary 从内容的纯文本生成摘要, 摘要中不包含 Markdown 标记
 func (p *PostDetail) GenerateSummary() string {
-	runes := []rune(p.Content)
+	text := markdown.PlainText(p.Content)
+	runes := []rune(text)
 	if len(runes) <= MaxSummaryLength {
-		return p.Content
+		return text
 	}
 	return string(runes[:MaxSummaryLength]) + "..."
 }
@@ -30,19 +54,38 @@ func (p *PostDetail) ConvertToSummary() *PostSummary {
 		CommunityID:   p.CommunityID,
 		CommunityName: p.CommunityName,
 		Summary:       p.GenerateSummary(),
+		Tags:          p.Tags,
 	}
 }
 
 // PostSummary 帖子摘要
 // 用于帖子列表展示
 type PostSummary struct {
-	PostID        int64  `json:"post_id,omitempty" db:"post_id"`
-	Title         string `json:"title,omitempty" db:"title"`
-	Summary       string `json:"summary,omitempty" db:"summary"`
-	AuthorId      int64  `json:"author_id,omitempty" db:"author_id"`
-	Username      string `json:"author_name,omitempty" db:"username"`
-	CommunityID   int64  `json:"community_id,omitempty" db:"community_id"`
-	CommunityName string `json:"community_name,omitempty" db:"community_name"`
+	PostID        int64    `json:"post_id,omitempty" db:"post_id"`
+	Title         string   `json:"title,omitempty" db:"title"`
+	Summary       string   `json:"summary,omitempty" db:"summary"`
+	AuthorId      int64    `json:"author_id,omitempty" db:"author_id"`
+	Username      string   `json:"author_name,omitempty" db:"username"`
+	CommunityID   int64    `json:"community_id,omitempty" db:"community_id"`
+	CommunityName string   `json:"community_name,omitempty" db:"community_name"`
+	PublishAt     int64    `json:"publish_at,omitempty" db:"publish_at"`
+	Tags          []string `json:"tags,omitempty" db:"-" gorm:"-"`
+}
+
+// PostRankData 重建帖子排行时使用的数据
+type PostRankData struct {
+	PostID      int64 `db:"post_id"`
+	CommunityID int64 `db:"community_id"`
+	PublishAt   int64 `db:"publish_at"`
+	Vote        int64 `db:"vote"`
+	Down        int64 `db:"down"`
+}
+
+// PostVoteBucket 帖子在一个时间段内收到的净票数, Bucket 为时间段开始的 Unix 时间
+type PostVoteBucket struct {
+	PostID int64 `db:"post_id"`
+	Bucket int64 `db:"bucket"`
+	Vote   int64 `db:"vote"`
 }
 
 // PostVoteCounts 帖子投票内容
@@ -50,6 +93,7 @@ type PostSummary struct {
 type PostVoteCounts struct {
 	PostID int64 `json:"post_id,omitempty" db:"post_id"`
 	Vote   int64 `json:"vote" db:"vote"`
+	Down   int64 `json:"down" db:"down"`
 }
 
 type UserVotePostRelationsDTO struct {
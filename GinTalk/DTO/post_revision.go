@@ -0,0 +1,43 @@
+package DTO
+
+import "time"
+
+// PostRevisionSummary 帖子版本列表中的一项, 不包含内容
+type PostRevisionSummary struct {
+	Revision   int32     `json:"revision" db:"revision"`
+	Title      string    `json:"title" db:"title"`
+	EditorID   int64     `json:"editor_id" db:"editor_id"`
+	EditorName string    `json:"editor_name" db:"editor_name"`
+	CreateTime time.Time `json:"create_time" db:"create_time"`
+}
+
+// PostRevision 帖子某个版本的完整内容
+type PostRevision struct {
+	PostID      int64     `json:"post_id" db:"post_id"`
+	Revision    int32     `json:"revision" db:"revision"`
+	Title       string    `json:"title" db:"title"`
+	Content     string    `json:"content" db:"content"`
+	ContentHTML string    `json:"content_html" db:"-" gorm:"-"`
+	EditorID    int64     `json:"editor_id" db:"editor_id"`
+	EditorName  string    `json:"editor_name" db:"editor_name"`
+	CreateTime  time.Time `json:"create_time" db:"create_time"`
+}
+
+// PostRevisionDiffLine 两个版本内容差异中的一行
+// Op 为 equal, insert 或 delete, 行号从 1 开始, 该行在对应版本中不存在时省略
+type PostRevisionDiffLine struct {
+	Op      string `json:"op"`
+	Text    string `json:"text"`
+	OldLine int    `json:"old_line,omitempty"`
+	NewLine int    `json:"new_line,omitempty"`
+}
+
+// PostRevisionDiff 两个版本之间按行的差异
+type PostRevisionDiff struct {
+	PostID    int64                  `json:"post_id"`
+	From      int32                  `json:"from"`
+	To        int32                  `json:"to"`
+	FromTitle string                 `json:"from_title"`
+	ToTitle   string                 `json:"to_title"`
+	Lines     []PostRevisionDiffLine `json:"lines"`
+}
@@ -0,0 +1,24 @@
+package DTO
+
+import "time"
+
+// SearchHit 一条搜索结果
+// Type 为 post 或 comment, ID 为帖子 ID 或评论 ID;
+// Title 和 Snippet 已经过 HTML 转义, 命中的词用 <em> 标签包裹, 评论没有标题
+type SearchHit struct {
+	Type        string    `json:"type"`
+	ID          int64     `json:"id"`
+	PostID      int64     `json:"post_id"`
+	CommunityID int64     `json:"community_id"`
+	AuthorID    int64     `json:"author_id"`
+	Title       string    `json:"title,omitempty"`
+	Snippet     string    `json:"snippet"`
+	Score       float64   `json:"score"`
+	CreateTime  time.Time `json:"create_time"`
+}
+
+// SearchResult 搜索结果, Total 为满足条件的结果总数
+type SearchResult struct {
+	Total int         `json:"total"`
+	Hits  []SearchHit `json:"hits"`
+}
@@ -0,0 +1,17 @@
+package DTO
+
+import "time"
+
+// Session 登录会话
+// 每次登录创建一个会话, 会话 ID 与刷新令牌家族 ID 相同
+type Session struct {
+	SessionID    string    `json:"session_id"`
+	UserID       int64     `json:"-"`
+	Device       string    `json:"device"`
+	IP           string    `json:"ip"`
+	UserAgent    string    `json:"user_agent"`
+	CreateTime   time.Time `json:"create_time"`
+	LastUsedTime time.Time `json:"last_used_time"`
+	// Current 是否为发起请求的会话
+	Current bool `json:"current"`
+}
@@ -0,0 +1,30 @@
+package DTO
+
+type TwoFactorEnrollResponseDTO struct {
+	Secret string `json:"secret"`
+	// URI otpauth URI, 客户端可以将其渲染为二维码供验证器扫描
+	URI string `json:"otpauth_uri"`
+}
+
+type TwoFactorConfirmRequestDTO struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorConfirmResponseDTO struct {
+	// RecoveryCodes 一次性恢复码, 只在启用时返回一次
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type TwoFactorDisableRequestDTO struct {
+	Password string `json:"password" binding:"required"`
+	IP       string `json:"-"`
+}
+
+type LoginTwoFactorRequestDTO struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	// Code TOTP 验证码或恢复码
+	Code      string `json:"code" binding:"required"`
+	Device    string `json:"device"`
+	IP        string `json:"-"`
+	UserAgent string `json:"-"`
+}
@@ -1,15 +1,28 @@
 package DTO
 
+import "time"
+
 type LoginRequestDTO struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	// Device 客户端自定义的设备名称, 用于在会话列表中区分不同的设备
+	Device    string `json:"device"`
+	IP        string `json:"-"`
+	UserAgent string `json:"-"`
+	// CaptchaID 和 CaptchaAnswer 在登录失败多次后必填
+	CaptchaID     string `json:"captcha_id"`
+	CaptchaAnswer string `json:"captcha_answer"`
 }
 
 type LoginResponseDTO struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
-	UserID       int64  `json:"user_id"`
-	Username     string `json:"username"`
+	AccessToken   string `json:"access_token"`
+	RefreshToken  string `json:"refresh_token"`
+	UserID        int64  `json:"user_id"`
+	Username      string `json:"username"`
+	EmailVerified bool   `json:"email_verified"`
+	// TwoFactorRequired 为 true 时不会返回令牌, 需要使用 ChallengeToken 和验证码调用 /login/2fa 完成登录
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	ChallengeToken    string `json:"challenge_token,omitempty"`
 }
 
 type SignUpRequestDTO struct {
@@ -17,4 +30,55 @@ type SignUpRequestDTO struct {
 	Password string `json:"password" binding:"required,min=8"`
 	Email    string `json:"email" binding:"required,email"`
 	Gender   string `json:"gender"`
+	// InviteCode 仅限邀请注册模式下必填
+	InviteCode string `json:"invite_code"`
+	// CaptchaID 和 CaptchaAnswer 开启验证码时必填
+	CaptchaID     string `json:"captcha_id"`
+	CaptchaAnswer string `json:"captcha_answer"`
+}
+
+type VerifyEmailRequestDTO struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ForgotPasswordRequestDTO struct {
+	Email string `json:"email" binding:"required,email"`
+	IP    string `json:"-"`
+}
+
+type ResetPasswordRequestDTO struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+// UserProfileDTO 用户主页信息
+// Email 和 EmailVerified 只返回给用户本人
+type UserProfileDTO struct {
+	UserID        int64     `json:"user_id"`
+	Username      string    `json:"username"`
+	Avatar        string    `json:"avatar"`
+	Bio           string    `json:"bio"`
+	Gender        int32     `json:"gender"`
+	CreateTime    time.Time `json:"create_time"`
+	PostCount     int64     `json:"post_count"`
+	CommentCount  int64     `json:"comment_count"`
+	Karma         int64     `json:"karma"`
+	Email         string    `json:"email,omitempty"`
+	EmailVerified *bool     `json:"email_verified,omitempty"`
+}
+
+// UpdateProfileRequestDTO 更新用户资料
+// 字段为 nil 时表示不修改, Avatar 为空字符串时表示清除头像
+type UpdateProfileRequestDTO struct {
+	Bio    *string `json:"bio" binding:"omitempty,max=512"`
+	Gender *int32  `json:"gender" binding:"omitempty,oneof=0 1 2"`
+	Email  *string `json:"email" binding:"omitempty,email,max=64"`
+	Avatar *string `json:"avatar" binding:"omitempty,max=255"`
+}
+
+// DeleteAccountRequestDTO 注销账号
+// 启用两步验证时需要同时提供验证码或恢复码
+type DeleteAccountRequestDTO struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code"`
 }
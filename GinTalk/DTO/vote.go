@@ -32,4 +32,5 @@ type UserVotePostDetailDTO struct {
 type VotePostDTO struct {
 	PostID int64 `json:"post_id" binding:"required"`
 	UserID int64 `json:"user_id" binding:"required"`
+	Vote   int   `json:"vote" binding:"omitempty,oneof=-1 1"` // -1: 踩 1: 赞, 不传时为赞
 }
@@ -3,4 +3,5 @@ package DTO
 type VoteComment struct {
 	UserID    int64 `json:"user_id" form:"user_id"`
 	CommentID int64 `json:"comment_id" form:"comment_id"`
+	Vote      int   `json:"vote" form:"vote" binding:"omitempty,oneof=-1 1"` // -1: 踩 1: 赞, 不传时为赞
 }
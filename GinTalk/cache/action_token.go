@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"GinTalk/dao/Redis"
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// SaveActionToken 保存一次性操作令牌, 令牌在过期或被使用后失效
+func SaveActionToken(ctx context.Context, purpose string, tokenID string, userID int64, expiration time.Duration) error {
+	key := GenerateRedisKey(ActionTokenTemplate, purpose, tokenID)
+	return Redis.GetRedisClient().Set(ctx, key, userID, expiration).Err()
+}
+
+// ConsumeActionToken 使用一次性操作令牌
+// 读取并删除是原子的, 同一个令牌并发使用时只有一次能成功
+//
+// 返回:
+//   - bool: 令牌是否有效且属于 userID。
+//   - error: 如果操作失败，则返回错误对象，否则返回 nil。
+func ConsumeActionToken(ctx context.Context, purpose string, tokenID string, userID int64) (bool, error) {
+	key := GenerateRedisKey(ActionTokenTemplate, purpose, tokenID)
+	owner, err := Redis.GetRedisClient().GetDel(ctx, key).Int64()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return owner == userID, nil
+}
+
+// PeekActionToken 检查一次性操作令牌是否有效且属于 userID, 但不使用它
+func PeekActionToken(ctx context.Context, purpose string, tokenID string, userID int64) (bool, error) {
+	key := GenerateRedisKey(ActionTokenTemplate, purpose, tokenID)
+	owner, err := Redis.GetRedisClient().Get(ctx, key).Int64()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return owner == userID, nil
+}
+
+// AcquireMailCooldown 检查并占用发送邮件的冷却时间
+// 冷却时间内重复调用返回 false, 用于防止通过接口向用户邮箱大量发送邮件
+func AcquireMailCooldown(ctx context.Context, purpose string, userID int64, interval time.Duration) (bool, error) {
+	key := GenerateRedisKey(MailCooldownTemplate, purpose, userID)
+	return Redis.GetRedisClient().SetNX(ctx, key, 1, interval).Result()
+}
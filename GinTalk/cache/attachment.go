@@ -0,0 +1,13 @@
+package cache
+
+import (
+	"GinTalk/dao/Redis"
+	"context"
+	"time"
+)
+
+// AcquireAttachmentCleanLock 获取清理未引用附件任务的锁, 锁在 interval 后自动释放
+func AcquireAttachmentCleanLock(ctx context.Context, interval time.Duration) (bool, error) {
+	key := GenerateRedisKey(AttachmentCleanLockTemplate)
+	return Redis.GetRedisClient().SetNX(ctx, key, 1, interval).Result()
+}
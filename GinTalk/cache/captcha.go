@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"GinTalk/dao/Redis"
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// SaveCaptcha 保存验证码的答案
+func SaveCaptcha(ctx context.Context, captchaID string, answer string, expiration time.Duration) error {
+	return Redis.GetRedisClient().Set(ctx, GenerateRedisKey(CaptchaTemplate, captchaID), answer, expiration).Err()
+}
+
+// TakeCaptcha 取出并删除验证码的答案, 每个验证码只能校验一次, 防止对同一个验证码反复猜测
+//
+// 返回:
+//   - string: 验证码的答案。
+//   - bool: 验证码是否存在, 不存在、已过期或已被使用时返回 false。
+//   - error: 如果操作失败，则返回错误对象，否则返回 nil。
+func TakeCaptcha(ctx context.Context, captchaID string) (string, bool, error) {
+	key := GenerateRedisKey(CaptchaTemplate, captchaID)
+	pipe := Redis.GetRedisClient().TxPipeline()
+	get := pipe.Get(ctx, key)
+	pipe.Del(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return "", false, err
+	}
+	answer, err := get.Result()
+	if err == redis.Nil {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return answer, true, nil
+}
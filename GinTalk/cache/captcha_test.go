@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"GinTalk/dao/Redis"
+	"GinTalk/pkg/redistest"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func setupRedis(t *testing.T) {
+	t.Helper()
+	server := redistest.NewServer(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	Redis.SetRedisClient(client)
+}
+
+func TestTakeCaptcha(t *testing.T) {
+	setupRedis(t)
+	ctx := context.Background()
+
+	if err := SaveCaptcha(ctx, "id", "ABCDE", time.Minute); err != nil {
+		t.Fatalf("SaveCaptcha() 失败: %v", err)
+	}
+	answer, exist, err := TakeCaptcha(ctx, "id")
+	if err != nil || !exist || answer != "ABCDE" {
+		t.Fatalf("第一次 TakeCaptcha() = (%q, %v, %v), 期望 (\"ABCDE\", true, nil)", answer, exist, err)
+	}
+
+	// 每个验证码只能校验一次
+	answer, exist, err = TakeCaptcha(ctx, "id")
+	if err != nil || exist || answer != "" {
+		t.Fatalf("第二次 TakeCaptcha() = (%q, %v, %v), 期望 (\"\", false, nil)", answer, exist, err)
+	}
+
+	_, exist, err = TakeCaptcha(ctx, "missing")
+	if err != nil || exist {
+		t.Fatalf("不存在的验证码 TakeCaptcha() = (%v, %v), 期望 (false, nil)", exist, err)
+	}
+}
+
+func TestTakeCaptchaExpired(t *testing.T) {
+	setupRedis(t)
+	ctx := context.Background()
+
+	if err := SaveCaptcha(ctx, "id", "ABCDE", 10*time.Millisecond); err != nil {
+		t.Fatalf("SaveCaptcha() 失败: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	_, exist, err := TakeCaptcha(ctx, "id")
+	if err != nil || exist {
+		t.Fatalf("过期的验证码 TakeCaptcha() = (%v, %v), 期望 (false, nil)", exist, err)
+	}
+}
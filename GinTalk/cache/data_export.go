@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"GinTalk/DTO"
+	"GinTalk/dao/Redis"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// SaveDataExportJob 保存数据导出任务, 同时记录为用户最近一次导出任务
+// 任务在 ExpireTime 之后从 Redis 中过期
+func SaveDataExportJob(ctx context.Context, job *DTO.DataExportJob) error {
+	value, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	expiration := time.Until(job.ExpireTime)
+	pipe := Redis.GetRedisClient().TxPipeline()
+	pipe.Set(ctx, GenerateRedisKey(DataExportJobTemplate, job.JobID), value, expiration)
+	pipe.Set(ctx, GenerateRedisKey(UserDataExportTemplate, job.UserID), job.JobID, expiration)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// GetDataExportJob 获取数据导出任务, 任务不存在或已过期时返回 nil
+func GetDataExportJob(ctx context.Context, jobID string) (*DTO.DataExportJob, error) {
+	value, err := Redis.GetRedisClient().Get(ctx, GenerateRedisKey(DataExportJobTemplate, jobID)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var job DTO.DataExportJob
+	if err := json.Unmarshal(value, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetUserDataExportJob 获取用户最近一次数据导出任务, 不存在或已过期时返回 nil
+func GetUserDataExportJob(ctx context.Context, userID int64) (*DTO.DataExportJob, error) {
+	jobID, err := Redis.GetRedisClient().Get(ctx, GenerateRedisKey(UserDataExportTemplate, userID)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return GetDataExportJob(ctx, jobID)
+}
+
+// DeleteDataExportJob 删除数据导出任务
+func DeleteDataExportJob(ctx context.Context, job *DTO.DataExportJob) error {
+	return Redis.GetRedisClient().Del(ctx,
+		GenerateRedisKey(DataExportJobTemplate, job.JobID),
+		GenerateRedisKey(UserDataExportTemplate, job.UserID),
+	).Err()
+}
+
+// AcquireDataExportCleanLock 获取清理过期数据压缩包任务的锁, 锁在 interval 后自动释放
+func AcquireDataExportCleanLock(ctx context.Context, interval time.Duration) (bool, error) {
+	key := GenerateRedisKey(DataExportCleanLockTemplate)
+	return Redis.GetRedisClient().SetNX(ctx, key, 1, interval).Result()
+}
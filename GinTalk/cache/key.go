@@ -5,14 +5,105 @@ import "fmt"
 const (
 	BlackListTokenKeyTemplate = "blacklist:token:%v"
 
+	// RefreshTokenFamilyTemplate 存储刷新令牌家族的状态, 参数为 family id
+	// 一个令牌家族即一个登录会话, 同时存储会话的设备信息
+	RefreshTokenFamilyTemplate = "refresh:family:%v"
+
+	// UserSessionsTemplate 存储用户所有会话 ID 的有序集合, 分数为创建时间, 参数为 user id
+	UserSessionsTemplate = "user:sessions:%v"
+
+	// ActionTokenTemplate 存储尚未使用的一次性操作令牌, 参数为用途和令牌 ID, 值为用户 ID
+	ActionTokenTemplate = "action:token:%v:%v"
+
+	// MailCooldownTemplate 限制同一用户同一类邮件的发送频率, 参数为用途和用户 ID
+	MailCooldownTemplate = "mail:cooldown:%v:%v"
+
+	// RequestRateTemplate 固定时间窗口内的请求次数计数器, 参数为接口用途、维度 (email 或 ip) 和对应的值
+	RequestRateTemplate = "rate:%v:%v:%v"
+
+	// TOTPUsedStepTemplate 记录已使用过的 TOTP 时间步, 防止验证码在有效期内被重放, 参数为用户 ID 和时间步
+	TOTPUsedStepTemplate = "totp:used:%v:%v"
+
+	// LoginChallengeAttemptsTemplate 记录两步验证登录挑战的尝试次数, 参数为挑战令牌 ID
+	LoginChallengeAttemptsTemplate = "login:challenge:attempts:%v"
+
+	// LoginFailureTemplate 登录失败次数计数器, 参数为维度 (user 或 ip) 和对应的值
+	LoginFailureTemplate = "login:fail:%v:%v"
+
+	// LoginLockoutTemplate 登录锁定标记, 过期时间即剩余的锁定时间, 参数与 LoginFailureTemplate 相同
+	LoginLockoutTemplate = "login:lock:%v:%v"
+
+	// CaptchaTemplate 存储验证码的答案, 过期时间即验证码的有效期, 参数为验证码 ID
+	CaptchaTemplate = "captcha:%v"
+
+	// UserRoleTemplate 缓存用户的全局角色, 参数为用户 ID
+	UserRoleTemplate = "user:role:%v"
+
+	// DataExportJobTemplate 存储个人数据导出任务的状态, 参数为任务 ID
+	DataExportJobTemplate = "export:job:%v"
+
+	// UserDataExportTemplate 存储用户最近一次数据导出任务的 ID, 参数为用户 ID
+	UserDataExportTemplate = "export:user:%v"
+
+	// DataExportCleanLockTemplate 清理过期数据压缩包任务的锁, 多个实例中同一时间只有一个实例清理
+	DataExportCleanLockTemplate = "export:clean:lock"
+
 	// PostSummaryTemplate 用于在 redis 中存储帖子的概述信息
 	PostSummaryTemplate = "post:id:%v"
 
 	// PostRankingTemplate 在redis中存储帖子的热度
 	PostRankingTemplate = "post:ranking"
 
+	// PostRankingAlgorithmTemplate 记录热度有序集合当前使用的排行算法, 参数为有序集合的 key
+	PostRankingAlgorithmTemplate = "post:ranking:algorithm:%v"
+
+	// PostRankingRebuildLockTemplate 检查和重建热度排行任务的锁, 多个实例中同一时间只有一个实例重建
+	PostRankingRebuildLockTemplate = "post:ranking:rebuild:lock"
+
 	// PostTimeTemplate 在 Redis 中存储帖子的时间
 	PostTimeTemplate = "post:time"
+
+	// PostTagRankingTemplate 在 Redis 中按标签存储帖子的热度, 参数为标签
+	PostTagRankingTemplate = "post:tag:ranking:%v"
+
+	// PostTagTimeTemplate 在 Redis 中按标签存储帖子的时间, 参数为标签
+	PostTagTimeTemplate = "post:tag:time:%v"
+
+	// PostCommunityRankingTemplate 在 Redis 中按社区存储帖子的热度, 参数为社区 ID
+	PostCommunityRankingTemplate = "post:community:ranking:%v"
+
+	// PostCommunityTimeTemplate 在 Redis 中按社区存储帖子的时间, 参数为社区 ID
+	PostCommunityTimeTemplate = "post:community:time:%v"
+
+	// PostCommunityTemplate 存储帖子所属的社区 ID, 用于更新热度和删除帖子时找到对应的社区有序集合, 参数为帖子 ID
+	PostCommunityTemplate = "post:community:%v"
+
+	// PostTagsTemplate 存储帖子当前的标签集合, 用于更新热度和删除帖子时找到对应的标签有序集合, 参数为帖子 ID
+	PostTagsTemplate = "post:tags:%v"
+
+	// PostTopHourTemplate 按小时统计帖子收到的净票数 (赞数减去踩数), 参数为 UTC 时间的 2006010215
+	PostTopHourTemplate = "post:top:hour:%v"
+
+	// PostTopDayTemplate 按天统计帖子收到的净票数, 参数为 UTC 日期的 20060102
+	PostTopDayTemplate = "post:top:day:%v"
+
+	// PostTopMonthTemplate 按月统计帖子收到的净票数, 参数为 UTC 月份的 200601
+	PostTopMonthTemplate = "post:top:month:%v"
+
+	// PostTopAllTemplate 帖子收到的净票数总和
+	PostTopAllTemplate = "post:top:all"
+
+	// PostTopWindowTemplate 时间窗口内帖子投票数的汇总, 由对应的统计有序集合合并而成, 参数为时间窗口
+	PostTopWindowTemplate = "post:top:window:%v"
+
+	// PostTopRebuildLockTemplate 重建投票数统计任务的锁, 多个实例中同一时间只有一个实例重建
+	PostTopRebuildLockTemplate = "post:top:rebuild:lock"
+
+	// PostPublishLockTemplate 定时发布任务的锁, 多个实例中同一时间只有一个实例扫描到期的帖子
+	PostPublishLockTemplate = "post:publish:lock"
+
+	// AttachmentCleanLockTemplate 清理未引用附件任务的锁, 多个实例中同一时间只有一个实例清理
+	AttachmentCleanLockTemplate = "attachment:clean:lock"
 )
 
 // GenerateRedisKey 通过格式化给定的模板字符串和提供的参数生成一个 Redis key。
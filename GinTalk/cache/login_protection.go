@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"GinTalk/dao/Redis"
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// LoginDimensionUser 按用户名统计登录失败次数
+	LoginDimensionUser = "user"
+	// LoginDimensionIP 按客户端 IP 统计登录失败次数
+	LoginDimensionIP = "ip"
+)
+
+// LoginFailurePolicy 登录失败的锁定策略
+type LoginFailurePolicy struct {
+	// Window 统计失败次数的时间窗口
+	Window time.Duration
+	// Threshold 失败多少次后开始锁定
+	Threshold int
+	// BaseLockout 首次锁定时间, 此后每次失败加倍
+	BaseLockout time.Duration
+	// MaxLockout 最长锁定时间
+	MaxLockout time.Duration
+}
+
+// recordLoginFailureScript 原子地增加失败次数, 并在达到阈值后设置锁定
+// KEYS[1]: 计数器 key; KEYS[2]: 锁定 key
+// ARGV[1]: 时间窗口 (秒); ARGV[2]: 阈值; ARGV[3]: 首次锁定时间 (秒); ARGV[4]: 最长锁定时间 (秒)
+// 返回本次设置的锁定时间 (秒), 未锁定时返回 0
+var recordLoginFailureScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('EXPIRE', KEYS[1], ARGV[1])
+end
+local threshold = tonumber(ARGV[2])
+if count < threshold then
+	return 0
+end
+local maxLockout = tonumber(ARGV[4])
+local lockout = maxLockout
+local exponent = count - threshold
+if exponent < 31 then
+	lockout = math.min(tonumber(ARGV[3]) * math.pow(2, exponent), maxLockout)
+end
+lockout = math.floor(lockout)
+redis.call('SET', KEYS[2], 1, 'EX', lockout)
+-- 计数器至少保留到锁定结束, 锁定结束后再次失败会继续加倍锁定时间
+if redis.call('TTL', KEYS[1]) < lockout then
+	redis.call('EXPIRE', KEYS[1], lockout)
+end
+return lockout
+`)
+
+// RecordLoginFailure 记录一次登录失败
+//
+// 参数:
+//   - ctx: 操作的上下文，允许取消和超时控制。
+//   - dimension: 统计维度, LoginDimensionUser 或 LoginDimensionIP。
+//   - value: 用户名或 IP。
+//   - policy: 该维度的锁定策略。
+//
+// 返回:
+//   - time.Duration: 本次失败导致的锁定时间, 未锁定时为 0。
+//   - error: 如果操作失败，则返回错误对象，否则返回 nil。
+func RecordLoginFailure(ctx context.Context, dimension string, value string, policy LoginFailurePolicy) (time.Duration, error) {
+	keys := []string{
+		GenerateRedisKey(LoginFailureTemplate, dimension, value),
+		GenerateRedisKey(LoginLockoutTemplate, dimension, value),
+	}
+	lockout, err := recordLoginFailureScript.Run(ctx, Redis.GetRedisClient(), keys,
+		int64(policy.Window.Seconds()), policy.Threshold,
+		int64(policy.BaseLockout.Seconds()), int64(policy.MaxLockout.Seconds()),
+	).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(lockout) * time.Second, nil
+}
+
+// GetLoginLockout 获取剩余的锁定时间, 多个维度同时被锁定时返回最长的剩余时间
+// values 的 key 为统计维度, value 为用户名或 IP; 未锁定时返回 0
+func GetLoginLockout(ctx context.Context, values map[string]string) (time.Duration, error) {
+	pipe := Redis.GetRedisClient().Pipeline()
+	cmds := make([]*redis.DurationCmd, 0, len(values))
+	for dimension, value := range values {
+		cmds = append(cmds, pipe.TTL(ctx, GenerateRedisKey(LoginLockoutTemplate, dimension, value)))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	var lockout time.Duration
+	for _, cmd := range cmds {
+		// key 不存在时 TTL 返回负数
+		if ttl := cmd.Val(); ttl > lockout {
+			lockout = ttl
+		}
+	}
+	return lockout, nil
+}
+
+// ResetLoginFailures 登录成功后清除该维度的失败次数
+func ResetLoginFailures(ctx context.Context, dimension string, value string) error {
+	return Redis.GetRedisClient().Del(ctx, GenerateRedisKey(LoginFailureTemplate, dimension, value)).Err()
+}
+
+// GetLoginFailures 获取各个维度在当前时间窗口内的失败次数, 返回最大值
+// values 的 key 为统计维度, value 为用户名或 IP
+func GetLoginFailures(ctx context.Context, values map[string]string) (int64, error) {
+	pipe := Redis.GetRedisClient().Pipeline()
+	cmds := make([]*redis.StringCmd, 0, len(values))
+	for dimension, value := range values {
+		cmds = append(cmds, pipe.Get(ctx, GenerateRedisKey(LoginFailureTemplate, dimension, value)))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return 0, err
+	}
+	var failures int64
+	for _, cmd := range cmds {
+		// key 不存在时视为 0 次
+		if count, err := cmd.Int64(); err == nil && count > failures {
+			failures = count
+		}
+	}
+	return failures, nil
+}
@@ -3,9 +3,11 @@ package cache
 import (
 	"GinTalk/DTO"
 	"GinTalk/dao/Redis"
+	"GinTalk/pkg/cursor"
+	"GinTalk/pkg/ranking"
 	"context"
 	"encoding/json"
-	"math"
+	"errors"
 	"strconv"
 	"time"
 
@@ -16,75 +18,21 @@ const (
 	_ = iota
 	OrderByHot
 	OrderByTime
+	OrderByTop
 
 	PostStoreTime = time.Hour * 24 * 7
 )
 
-// hot 函数计算一个帖子的热度分数。
-//
-// 参数：
-//   - ups: 赞成票的数量。
-//   - date: 帖子的发布时间，使用 time.Time 类型。
-//
-// 返回值：
-//   - 帖子的热度分数，使用 float64 类型。
-//
-// 计算方法：
-//  1. 计算赞成票和反对票的差值。
-//  2. 计算票数差值的对数值。
-//  3. 根据票数差值的正负确定符号。
-//  4. 使用 Unix 时间戳计算时间差值。
-//  5. 结合符号、对数值和时间差值计算最终的热度分数。
-func hot(ups int, date time.Time) float64 {
-	downs := 0
-	s := float64(ups - downs)                     // 计算赞成票和反对票的差值
-	order := math.Log10(math.Max(math.Abs(s), 1)) // 计算票数的对数
-
-	var sign float64
-	if s > 0 {
-		sign = 1
-	} else if s < 0 {
-		sign = -1
-	} else {
-		sign = 0
-	}
-
-	// 使用 Unix 时间戳进行时间计算
-	seconds := float64(date.Unix() - 1577808000)
-
-	// 计算热度，并四舍五入到最近的整数
-	ans := sign*order + seconds/45000
-	return ans
-}
-
-// deltaHot 计算两个赞成票数量之间的“热度”差异。
-// 它使用赞成票数量的以 10 为底的对数来确定热度的变化。
-//
-// 参数：
-// - oldUp: 之前的赞成票数量。
-// - newUp: 新的赞成票数量。
-//
-// 返回值：
-// - 一个表示热度变化的 float64 值。
-func deltaHot(oldUp, newUp int) float64 {
-	return math.Log10(max(float64(newUp), 1)) - math.Log10(max(float64(oldUp), 1))
-}
-
-// SavePost 将帖子存储到 Redis 中
+// SavePost 将新发布的帖子存储到 Redis 中, 并加入全局、社区和标签的热度和时间有序集合
 func SavePost(ctx context.Context, summary *DTO.PostSummary) error {
-	key := GenerateRedisKey(PostSummaryTemplate, summary.PostID)
-	data, err := json.Marshal(summary)
-	if err != nil {
-		return err
-	}
-
 	// 将帖子存储到 Redis 中
-	if err := Redis.GetRedisClient().Set(ctx, key, data, PostStoreTime).Err(); err != nil {
+	if err := SavePostSummary(ctx, summary); err != nil {
 		return err
 	}
 
-	timestamp := float64(time.Now().Unix())
-	hotScore := hot(0, time.Now())
+	now := time.Now()
+	timestamp := float64(now.Unix())
+	hotScore := ranking.Default().Score(0, 0, now, now)
 
 	if err := Redis.GetRedisClient().ZAdd(ctx, GenerateRedisKey(PostTimeTemplate), &redis.Z{
 		Score:  timestamp,
@@ -98,44 +46,157 @@ func SavePost(ctx context.Context, summary *DTO.PostSummary) error {
 	}).Err(); err != nil {
 		return err
 	}
-	return nil
+	if err := addPostCommunity(ctx, summary.PostID, summary.CommunityID, now); err != nil {
+		return err
+	}
+	return addPostTags(ctx, summary.PostID, summary.Tags, hotScore, timestamp)
+}
+
+// SavePostSummary 只把帖子摘要存储到 Redis 中, 不修改帖子在有序集合中的分数
+// 用于补充列表中已过期的摘要
+func SavePostSummary(ctx context.Context, summary *DTO.PostSummary) error {
+	key := GenerateRedisKey(PostSummaryTemplate, summary.PostID)
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	return Redis.GetRedisClient().Set(ctx, key, data, PostStoreTime).Err()
+}
+
+// addPostTags 把帖子加入各个标签的热度和时间有序集合, 并记录帖子的标签
+func addPostTags(ctx context.Context, postID int64, tags []string, hotScore float64, timestamp float64) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	pipe := Redis.GetRedisClient().TxPipeline()
+	for _, tag := range tags {
+		pipe.ZAdd(ctx, GenerateRedisKey(PostTagRankingTemplate, tag), &redis.Z{Score: hotScore, Member: postID})
+		pipe.ZAdd(ctx, GenerateRedisKey(PostTagTimeTemplate, tag), &redis.Z{Score: timestamp, Member: postID})
+		pipe.SAdd(ctx, GenerateRedisKey(PostTagsTemplate, postID), tag)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// getPostTags 获取 Redis 中记录的帖子标签
+func getPostTags(ctx context.Context, postID int64) ([]string, error) {
+	return Redis.GetRedisClient().SMembers(ctx, GenerateRedisKey(PostTagsTemplate, postID)).Result()
+}
+
+// UpdatePostTags 修改已发布帖子的标签
+// 帖子从不再使用的标签有序集合中移除, 并使用全局有序集合中的分数加入新的标签有序集合
+func UpdatePostTags(ctx context.Context, postID int64, tags []string) error {
+	oldTags, err := getPostTags(ctx, postID)
+	if err != nil {
+		return err
+	}
+	pipe := Redis.GetRedisClient().TxPipeline()
+	for _, tag := range oldTags {
+		pipe.ZRem(ctx, GenerateRedisKey(PostTagRankingTemplate, tag), postID)
+		pipe.ZRem(ctx, GenerateRedisKey(PostTagTimeTemplate, tag), postID)
+	}
+	pipe.Del(ctx, GenerateRedisKey(PostTagsTemplate, postID))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	member := strconv.FormatInt(postID, 10)
+	hotScore, err := Redis.GetRedisClient().ZScore(ctx, GenerateRedisKey(PostRankingTemplate), member).Result()
+	if errors.Is(err, redis.Nil) {
+		// 帖子不在排行中, 不需要加入标签有序集合
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	timestamp, err := Redis.GetRedisClient().ZScore(ctx, GenerateRedisKey(PostTimeTemplate), member).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	return addPostTags(ctx, postID, tags, hotScore, timestamp)
+}
+
+// PostListKey 返回帖子列表使用的有序集合, tag 不为空时使用该标签的有序集合
+// order 为 1 表示按热度排序，2 表示按时间排序，其他值返回空字符串。
+func PostListKey(order int, tag string) string {
+	if tag != "" {
+		tagTemplateMap := map[int]string{
+			OrderByHot:  PostTagRankingTemplate,
+			OrderByTime: PostTagTimeTemplate,
+		}
+		if template, ok := tagTemplateMap[order]; ok {
+			return GenerateRedisKey(template, tag)
+		}
+		return ""
+	}
+	caseTemplateMap := map[int]string{
+		OrderByHot:  PostRankingTemplate,
+		OrderByTime: PostTimeTemplate,
+	}
+	if template, ok := caseTemplateMap[order]; ok {
+		return GenerateRedisKey(template)
+	}
+	return ""
+}
+
+// CommunityPostListKey 返回社区帖子列表使用的有序集合, order 的含义与 PostListKey 相同
+func CommunityPostListKey(order int, communityID int64) string {
+	templateMap := map[int]string{
+		OrderByHot:  PostCommunityRankingTemplate,
+		OrderByTime: PostCommunityTimeTemplate,
+	}
+	if template, ok := templateMap[order]; ok {
+		return GenerateRedisKey(template, communityID)
+	}
+	return ""
 }
 
 // GetPostIDs 从 Redis 中获取帖子 ID 列表。
-// 它使用提供的排序方式、页码和每页帖子数量，从 Redis 中获取帖子 ID 列表。
+// 它使用提供的有序集合和游标，按分数倒序获取帖子 ID 列表。
+// 分数相同的帖子按成员的字典序倒序排列，与 ZREVRANGEBYSCORE 的顺序一致。
 //
 // 参数：
 //   - ctx: 操作的上下文，允许取消和超时控制。
-//   - order: 帖子的排序方式。其中 1 表示按热度排序，2 表示按时间排序。
-//   - pageNum: 分页的页码。
-//   - pageSize: 每页的帖子数量。
+//   - key: 有序集合的 key，由 PostListKey 或 CommunityPostListKey 生成。
+//   - after: 上一页最后一个帖子的位置，为 nil 时从第一页开始。
+//   - limit: 最多获取的帖子数量。
 //
 // 返回：
-//   - []int64: 一个帖子 ID 的切片。
+//   - []cursor.Cursor: 帖子 ID 及其在有序集合中的分数，可以直接作为下一页的游标。
 //   - error: 如果操作失败，则返回错误对象，否则返回 nil。
-func GetPostIDs(ctx context.Context, order, pageNum, pageSize int) ([]int64, error) {
-	var key string
-
-	caseTemplateMap := map[int]string{
-		OrderByHot:  PostRankingTemplate,
-		OrderByTime: PostTimeTemplate,
+func GetPostIDs(ctx context.Context, key string, after *cursor.Cursor, limit int) ([]cursor.Cursor, error) {
+	opt := &redis.ZRangeBy{Max: "+inf", Min: "-inf", Count: int64(limit)}
+	var member string
+	if after != nil {
+		opt.Max = strconv.FormatFloat(after.Score, 'g', -1, 64)
+		member = strconv.FormatInt(after.ID, 10)
 	}
 
-	key = GenerateRedisKey(caseTemplateMap[order])
-
-	// 计算分页的开始和结束位置
-	start := int64((pageNum - 1) * pageSize)
-	end := start + int64(pageSize) - 1
-
-	// 从 Redis 有序集合中获取帖子 ID 列表
-	postIDs, err := Redis.GetRedisClient().ZRevRange(ctx, key, start, end).Result()
-	if err != nil {
-		return nil, err
-	}
-	resp := make([]int64, len(postIDs))
-	for i, id := range postIDs {
-		_t, _ := strconv.Atoi(id)
-		resp[i] = int64(_t)
+	resp := make([]cursor.Cursor, 0, limit)
+	for len(resp) < limit {
+		// 从 Redis 有序集合中获取分数不大于游标的帖子, 分数与游标相同的帖子可能在游标之前, 需要跳过
+		zs, err := Redis.GetRedisClient().ZRevRangeByScoreWithScores(ctx, key, opt).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, z := range zs {
+			id, _ := z.Member.(string)
+			if after != nil && z.Score == after.Score && id >= member {
+				continue
+			}
+			postID, err := strconv.ParseInt(id, 10, 64)
+			if err != nil {
+				continue
+			}
+			resp = append(resp, cursor.Cursor{Score: z.Score, ID: postID})
+			if len(resp) == limit {
+				break
+			}
+		}
+		if int64(len(zs)) < opt.Count {
+			break
+		}
+		opt.Offset += int64(len(zs))
 	}
 	return resp, nil
 }
@@ -178,7 +239,18 @@ func GetPostSummary(ctx context.Context, postID []int64) ([]DTO.PostSummary, []i
 // 1. 删除帖子的摘要信息
 // 2. 删除帖子的时间排序
 // 3. 删除帖子的热度排序
+// 4. 从帖子所有标签和所属社区的排序中删除
+// 5. 从投票总数排行中删除
 func DeletePost(ctx context.Context, postID int64) error {
+	if err := UpdatePostTags(ctx, postID, nil); err != nil {
+		return err
+	}
+	if err := removePostCommunity(ctx, postID); err != nil {
+		return err
+	}
+	if err := RemovePostTop(ctx, postID); err != nil {
+		return err
+	}
 	key := GenerateRedisKey(PostSummaryTemplate, postID)
 	if err := Redis.GetRedisClient().Del(ctx, key).Err(); err != nil {
 		return err
@@ -201,3 +273,9 @@ func DeletePostSummary(ctx context.Context, postID int64) error {
 	}
 	return nil
 }
+
+// AcquirePostPublishLock 获取定时发布任务的锁, 锁在 interval 后自动释放
+func AcquirePostPublishLock(ctx context.Context, interval time.Duration) (bool, error) {
+	key := GenerateRedisKey(PostPublishLockTemplate)
+	return Redis.GetRedisClient().SetNX(ctx, key, 1, interval).Result()
+}
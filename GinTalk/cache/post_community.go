@@ -0,0 +1,118 @@
+package cache
+
+import (
+	"GinTalk/DTO"
+	"GinTalk/dao/Redis"
+	"GinTalk/pkg/ranking"
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// addPostCommunity 把新发布的帖子加入所属社区的热度和时间有序集合, 并记录帖子所属的社区
+// 社区的有序集合还没有建立时不加入, 第一次读取时从数据库重建的有序集合中会包含该帖子
+func addPostCommunity(ctx context.Context, postID int64, communityID int64, publishTime time.Time) error {
+	if communityID == 0 {
+		return nil
+	}
+	exists, err := HasCommunityPosts(ctx, communityID)
+	if err != nil || !exists {
+		return err
+	}
+	hotScore := ranking.ForCommunity(communityID).Score(0, 0, publishTime, publishTime)
+	pipe := Redis.GetRedisClient().TxPipeline()
+	pipe.ZAdd(ctx, GenerateRedisKey(PostCommunityRankingTemplate, communityID), &redis.Z{Score: hotScore, Member: postID})
+	pipe.ZAdd(ctx, GenerateRedisKey(PostCommunityTimeTemplate, communityID), &redis.Z{Score: float64(publishTime.Unix()), Member: postID})
+	pipe.Set(ctx, GenerateRedisKey(PostCommunityTemplate, postID), communityID, 0)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// getPostCommunity 获取 Redis 中记录的帖子所属社区, 没有记录时返回 0
+func getPostCommunity(ctx context.Context, postID int64) (int64, error) {
+	communityID, err := Redis.GetRedisClient().Get(ctx, GenerateRedisKey(PostCommunityTemplate, postID)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return communityID, err
+}
+
+// removePostCommunity 把帖子从所属社区的有序集合中移除
+func removePostCommunity(ctx context.Context, postID int64) error {
+	communityID, err := getPostCommunity(ctx, postID)
+	if err != nil || communityID == 0 {
+		return err
+	}
+	pipe := Redis.GetRedisClient().TxPipeline()
+	pipe.ZRem(ctx, GenerateRedisKey(PostCommunityRankingTemplate, communityID), postID)
+	pipe.ZRem(ctx, GenerateRedisKey(PostCommunityTimeTemplate, communityID), postID)
+	pipe.Del(ctx, GenerateRedisKey(PostCommunityTemplate, postID))
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// HasCommunityPosts 判断社区的有序集合是否已经建立
+// 有序集合在社区帖子列表第一次被读取时从数据库建立, 没有帖子的社区不会建立
+func HasCommunityPosts(ctx context.Context, communityID int64) (bool, error) {
+	n, err := Redis.GetRedisClient().Exists(ctx, GenerateRedisKey(PostCommunityTimeTemplate, communityID)).Result()
+	return n > 0, err
+}
+
+// RebuildCommunityPosts 根据数据库中的发布时间和点赞数建立社区的热度和时间有序集合
+// 热度使用社区配置的排行算法计算, 有序集合先写入临时 key 再重命名, 重建过程中读取到的始终是完整的数据
+func RebuildCommunityPosts(ctx context.Context, communityID int64, posts []DTO.PostRankData) error {
+	if len(posts) == 0 {
+		return nil
+	}
+	ranker := ranking.ForCommunity(communityID)
+	now := time.Now()
+	hotMembers := make([]*redis.Z, len(posts))
+	timeMembers := make([]*redis.Z, len(posts))
+	pipe := Redis.GetRedisClient().Pipeline()
+	for i, post := range posts {
+		member := strconv.FormatInt(post.PostID, 10)
+		hotMembers[i] = &redis.Z{Score: ranker.Score(post.Vote, post.Down, time.Unix(post.PublishAt, 0), now), Member: member}
+		timeMembers[i] = &redis.Z{Score: float64(post.PublishAt), Member: member}
+		pipe.Set(ctx, GenerateRedisKey(PostCommunityTemplate, post.PostID), communityID, 0)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	if err := replaceSortedSet(ctx, GenerateRedisKey(PostCommunityTimeTemplate, communityID), timeMembers, time.Time{}); err != nil {
+		return err
+	}
+	return replaceRanking(ctx, GenerateRedisKey(PostCommunityRankingTemplate, communityID), hotMembers, ranker)
+}
+
+// RestoreCommunityPosts 按帖子当前的票数把帖子写回社区的热度和时间有序集合
+// 用于补上重建社区有序集合时读取数据库之后发布或投票的帖子
+func RestoreCommunityPosts(ctx context.Context, communityID int64, posts []DTO.PostRankData) error {
+	if len(posts) == 0 {
+		return nil
+	}
+	ranker := ranking.ForCommunity(communityID)
+	now := time.Now()
+	pipe := Redis.GetRedisClient().TxPipeline()
+	for _, post := range posts {
+		member := strconv.FormatInt(post.PostID, 10)
+		score := ranker.Score(post.Vote, post.Down, time.Unix(post.PublishAt, 0), now)
+		pipe.ZAdd(ctx, GenerateRedisKey(PostCommunityRankingTemplate, communityID), &redis.Z{Score: score, Member: member})
+		pipe.ZAdd(ctx, GenerateRedisKey(PostCommunityTimeTemplate, communityID), &redis.Z{Score: float64(post.PublishAt), Member: member})
+		pipe.Set(ctx, GenerateRedisKey(PostCommunityTemplate, post.PostID), communityID, 0)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// CommunityRankingOutdated 判断社区的热度有序集合是否需要按配置的算法重建
+// 有序集合还没有建立时返回 false, 由第一次读取时建立
+func CommunityRankingOutdated(ctx context.Context, communityID int64) (bool, error) {
+	exists, err := HasCommunityPosts(ctx, communityID)
+	if err != nil || !exists {
+		return false, err
+	}
+	return rankingOutdated(ctx, GenerateRedisKey(PostCommunityRankingTemplate, communityID), ranking.ForCommunity(communityID))
+}
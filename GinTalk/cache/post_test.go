@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"GinTalk/dao/Redis"
+	"GinTalk/pkg/cursor"
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// collectPostIDs 从头开始按游标逐页读取, 返回所有帖子 ID
+func collectPostIDs(t *testing.T, key string, limit int) []int64 {
+	t.Helper()
+	var ids []int64
+	var after *cursor.Cursor
+	for range 100 {
+		page, err := GetPostIDs(context.Background(), key, after, limit)
+		if err != nil {
+			t.Fatalf("GetPostIDs() 失败: %v", err)
+		}
+		for _, c := range page {
+			ids = append(ids, c.ID)
+		}
+		if len(page) < limit {
+			return ids
+		}
+		// 游标经过编码和解码后传给下一页, 与接口的使用方式一致
+		after, err = cursor.Decode(page[len(page)-1].Encode())
+		if err != nil {
+			t.Fatalf("解码游标失败: %v", err)
+		}
+	}
+	t.Fatal("分页没有结束")
+	return nil
+}
+
+func TestGetPostIDsTieBreak(t *testing.T) {
+	setupRedis(t)
+	ctx := context.Background()
+	key := "test:post:ids"
+
+	// 分数相同的成员按字符串倒序排列, 因此 9 在 10 和 11 之前
+	members := []*redis.Z{
+		{Score: 3, Member: "1"},
+		{Score: 2, Member: "9"},
+		{Score: 2, Member: "10"},
+		{Score: 2, Member: "11"},
+		{Score: 2, Member: "100"},
+		{Score: 1.5, Member: "2"},
+		{Score: 1.5, Member: "20"},
+		{Score: -1, Member: "3"},
+	}
+	if err := Redis.GetRedisClient().ZAdd(ctx, key, members...).Err(); err != nil {
+		t.Fatalf("ZAdd() 失败: %v", err)
+	}
+	want := []int64{1, 9, 11, 100, 10, 20, 2, 3}
+
+	for _, limit := range []int{1, 2, 3, len(members)} {
+		got := collectPostIDs(t, key, limit)
+		if !slices.Equal(got, want) {
+			t.Errorf("每页 %d 条时的结果为 %v, 期望 %v", limit, got, want)
+		}
+	}
+}
+
+func TestGetPostIDsAfterMissingMember(t *testing.T) {
+	setupRedis(t)
+	ctx := context.Background()
+	key := "test:post:ids"
+
+	members := []*redis.Z{
+		{Score: 2, Member: "9"},
+		{Score: 2, Member: "10"},
+		{Score: 2, Member: "11"},
+		{Score: 1, Member: "12"},
+	}
+	if err := Redis.GetRedisClient().ZAdd(ctx, key, members...).Err(); err != nil {
+		t.Fatalf("ZAdd() 失败: %v", err)
+	}
+
+	// 游标对应的帖子已被删除时, 从分数相同且排在它之后的帖子继续
+	got, err := GetPostIDs(ctx, key, &cursor.Cursor{Score: 2, ID: 105}, 10)
+	if err != nil {
+		t.Fatalf("GetPostIDs() 失败: %v", err)
+	}
+	var ids []int64
+	for _, c := range got {
+		ids = append(ids, c.ID)
+	}
+	if want := []int64{10, 12}; !slices.Equal(ids, want) {
+		t.Errorf("GetPostIDs() = %v, 期望 %v", ids, want)
+	}
+}
@@ -0,0 +1,222 @@
+package cache
+
+import (
+	"GinTalk/DTO"
+	"GinTalk/dao/Redis"
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// 按投票数排行的时间窗口
+const (
+	TopWindowDay   = "day"
+	TopWindowWeek  = "week"
+	TopWindowMonth = "month"
+	TopWindowYear  = "year"
+	TopWindowAll   = "all"
+)
+
+// topRollupExpire 时间窗口汇总结果的缓存时间, 过期后在下一次读取时重新合并
+const topRollupExpire = time.Minute
+
+// topGranularity 投票数统计有序集合的时间粒度
+// 每个时间段使用一个有序集合, 时间段按 UTC 时间划分, 超过保留时间后自动过期
+type topGranularity struct {
+	template string
+	layout   string
+	// start 返回 t 所在时间段的开始时间
+	start func(t time.Time) time.Time
+	// shift 返回 start 之后第 n 个时间段的开始时间, n 为负数时向前
+	shift func(start time.Time, n int) time.Time
+	// expireAt 返回开始于 start 的时间段的过期时间
+	expireAt func(start time.Time) time.Time
+}
+
+func (g topGranularity) key(start time.Time) string {
+	return GenerateRedisKey(g.template, start.Format(g.layout))
+}
+
+var (
+	topHour = topGranularity{
+		template: PostTopHourTemplate,
+		layout:   "2006010215",
+		start:    func(t time.Time) time.Time { return t.UTC().Truncate(time.Hour) },
+		shift:    func(start time.Time, n int) time.Time { return start.Add(time.Duration(n) * time.Hour) },
+		expireAt: func(start time.Time) time.Time { return start.Add(26 * time.Hour) },
+	}
+	topDay = topGranularity{
+		template: PostTopDayTemplate,
+		layout:   "20060102",
+		start: func(t time.Time) time.Time {
+			t = t.UTC()
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		},
+		shift:    func(start time.Time, n int) time.Time { return start.AddDate(0, 0, n) },
+		expireAt: func(start time.Time) time.Time { return start.AddDate(0, 0, 32) },
+	}
+	topMonth = topGranularity{
+		template: PostTopMonthTemplate,
+		layout:   "200601",
+		start: func(t time.Time) time.Time {
+			t = t.UTC()
+			return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		},
+		shift:    func(start time.Time, n int) time.Time { return start.AddDate(0, n, 0) },
+		expireAt: func(start time.Time) time.Time { return start.AddDate(0, 13, 0) },
+	}
+	topGranularities = []topGranularity{topHour, topDay, topMonth}
+)
+
+// topWindows 每个时间窗口合并的时间段粒度和数量, 包括当前尚未结束的时间段
+// all 直接使用投票总数, 不需要合并
+var topWindows = map[string]struct {
+	granularity topGranularity
+	count       int
+}{
+	TopWindowDay:   {topHour, 24},
+	TopWindowWeek:  {topDay, 7},
+	TopWindowMonth: {topDay, 30},
+	TopWindowYear:  {topMonth, 12},
+}
+
+// IsTopWindow 判断是否为支持的时间窗口
+func IsTopWindow(window string) bool {
+	_, ok := topWindows[window]
+	return ok || window == TopWindowAll
+}
+
+// AddPostTopVote 把一次投票的净票数变化计入投票时间所在的各个时间段和投票总数
+// delta 为投票修改前后的差值, 例如赞改为踩时为 -2; 已过保留期的时间段不再修改
+func AddPostTopVote(ctx context.Context, postID int64, voteTime time.Time, delta int64) error {
+	member := strconv.FormatInt(postID, 10)
+	now := time.Now()
+	pipe := Redis.GetRedisClient().TxPipeline()
+	for _, g := range topGranularities {
+		start := g.start(voteTime)
+		expireAt := g.expireAt(start)
+		if !expireAt.After(now) {
+			continue
+		}
+		key := g.key(start)
+		pipe.ZIncrBy(ctx, key, float64(delta), member)
+		pipe.ExpireAt(ctx, key, expireAt)
+	}
+	pipe.ZIncrBy(ctx, GenerateRedisKey(PostTopAllTemplate), float64(delta), member)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// TopWindowKey 返回时间窗口内按净票数排序的有序集合
+// 汇总结果不存在时, 合并时间窗口内各个时间段的有序集合并缓存 topRollupExpire, 净票数不大于 0 的帖子被移除
+func TopWindowKey(ctx context.Context, window string) (string, error) {
+	if window == TopWindowAll {
+		return GenerateRedisKey(PostTopAllTemplate), nil
+	}
+	w, ok := topWindows[window]
+	if !ok {
+		return "", errors.New("不支持的时间窗口")
+	}
+	dest := GenerateRedisKey(PostTopWindowTemplate, window)
+	exists, err := Redis.GetRedisClient().Exists(ctx, dest).Result()
+	if err != nil {
+		return "", err
+	}
+	if exists > 0 {
+		return dest, nil
+	}
+
+	current := w.granularity.start(time.Now())
+	keys := make([]string, w.count)
+	for i := range keys {
+		keys[i] = w.granularity.key(w.granularity.shift(current, -i))
+	}
+	pipe := Redis.GetRedisClient().TxPipeline()
+	pipe.ZUnionStore(ctx, dest, &redis.ZStore{Keys: keys})
+	pipe.ZRemRangeByScore(ctx, dest, "-inf", "0")
+	pipe.Expire(ctx, dest, topRollupExpire)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// RemovePostTop 从投票总数中移除帖子, 时间段的有序集合会自动过期, 读取时跳过已删除的帖子
+func RemovePostTop(ctx context.Context, postID int64) error {
+	return Redis.GetRedisClient().ZRem(ctx, GenerateRedisKey(PostTopAllTemplate), postID).Err()
+}
+
+// TopRebuildSince 重建时需要从数据库读取的投票时间范围
+// hourly 之后的投票按小时统计, daily 之后的投票按天统计后再汇总为月
+func TopRebuildSince(now time.Time) (hourly time.Time, daily time.Time) {
+	return oldestTopBucket(topHour, now), oldestTopBucket(topMonth, now)
+}
+
+// oldestTopBucket 返回仍在保留期内的最早时间段的开始时间
+func oldestTopBucket(g topGranularity, now time.Time) time.Time {
+	start := g.start(now)
+	for g.expireAt(g.shift(start, -1)).After(now) {
+		start = g.shift(start, -1)
+	}
+	return start
+}
+
+// RebuildPostTop 根据数据库中的投票记录重建所有保留期内的投票数统计和投票总数
+// hourly 为按小时统计的投票数, daily 为按天统计的投票数, 时间范围由 TopRebuildSince 给出
+// 每个有序集合先写入临时 key 再重命名, 重建过程中读取到的始终是完整的数据
+func RebuildPostTop(ctx context.Context, hourly []DTO.PostVoteBucket, daily []DTO.PostVoteBucket, total []DTO.PostVoteCounts) error {
+	now := time.Now()
+	sources := map[string][]DTO.PostVoteBucket{
+		PostTopHourTemplate:  hourly,
+		PostTopDayTemplate:   daily,
+		PostTopMonthTemplate: daily,
+	}
+	for _, g := range topGranularities {
+		counts := make(map[time.Time]map[int64]int64)
+		for _, bucket := range sources[g.template] {
+			start := g.start(time.Unix(bucket.Bucket, 0))
+			if counts[start] == nil {
+				counts[start] = make(map[int64]int64)
+			}
+			counts[start][bucket.PostID] += bucket.Vote
+		}
+		for start := g.start(now); g.expireAt(start).After(now); start = g.shift(start, -1) {
+			if err := replaceTopSet(ctx, g.key(start), counts[start], g.expireAt(start)); err != nil {
+				return err
+			}
+		}
+	}
+
+	all := make(map[int64]int64, len(total))
+	for _, count := range total {
+		all[count.PostID] = count.Vote
+	}
+	if err := replaceTopSet(ctx, GenerateRedisKey(PostTopAllTemplate), all, time.Time{}); err != nil {
+		return err
+	}
+
+	// 删除已有的汇总结果, 下一次读取时使用重建后的数据合并
+	windows := make([]string, 0, len(topWindows))
+	for window := range topWindows {
+		windows = append(windows, GenerateRedisKey(PostTopWindowTemplate, window))
+	}
+	return Redis.GetRedisClient().Del(ctx, windows...).Err()
+}
+
+// replaceTopSet 用 counts 替换有序集合的内容, expireAt 为零值时不过期
+func replaceTopSet(ctx context.Context, key string, counts map[int64]int64, expireAt time.Time) error {
+	members := make([]*redis.Z, 0, len(counts))
+	for postID, vote := range counts {
+		members = append(members, &redis.Z{Score: float64(vote), Member: strconv.FormatInt(postID, 10)})
+	}
+	return replaceSortedSet(ctx, key, members, expireAt)
+}
+
+// AcquirePostTopRebuildLock 获取重建投票数统计任务的锁, 锁在 interval 后自动释放
+func AcquirePostTopRebuildLock(ctx context.Context, interval time.Duration) (bool, error) {
+	key := GenerateRedisKey(PostTopRebuildLockTemplate)
+	return Redis.GetRedisClient().SetNX(ctx, key, 1, interval).Result()
+}
@@ -0,0 +1,198 @@
+package cache
+
+import (
+	"GinTalk/DTO"
+	"GinTalk/dao/Redis"
+	"GinTalk/pkg/ranking"
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// sortedSetBatchSize 替换有序集合时每条 ZADD 命令写入的成员数量
+const sortedSetBatchSize = 1000
+
+// replaceSortedSet 用 members 替换有序集合的内容, expireAt 为零值时不过期
+// 先写入临时 key 再重命名, 替换过程中读取到的始终是完整的数据; members 为空时删除有序集合
+func replaceSortedSet(ctx context.Context, key string, members []*redis.Z, expireAt time.Time) error {
+	pipe := Redis.GetRedisClient().TxPipeline()
+	queueReplaceSortedSet(ctx, pipe, key, members, expireAt)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// queueReplaceSortedSet 把替换有序集合的命令加入 pipe, 由调用方在同一个事务中执行
+func queueReplaceSortedSet(ctx context.Context, pipe redis.Pipeliner, key string, members []*redis.Z, expireAt time.Time) {
+	if len(members) == 0 {
+		pipe.Del(ctx, key)
+		return
+	}
+	tmp := key + ":rebuild"
+	pipe.Del(ctx, tmp)
+	for start := 0; start < len(members); start += sortedSetBatchSize {
+		pipe.ZAdd(ctx, tmp, members[start:min(start+sortedSetBatchSize, len(members))]...)
+	}
+	if !expireAt.IsZero() {
+		pipe.ExpireAt(ctx, tmp, expireAt)
+	}
+	pipe.Rename(ctx, tmp, key)
+}
+
+// replaceRanking 替换热度有序集合, 并在同一个事务中记录计算分数使用的排行算法
+func replaceRanking(ctx context.Context, key string, members []*redis.Z, ranker ranking.Ranker) error {
+	pipe := Redis.GetRedisClient().TxPipeline()
+	queueReplaceSortedSet(ctx, pipe, key, members, time.Time{})
+	pipe.Set(ctx, GenerateRedisKey(PostRankingAlgorithmTemplate, key), ranker.Name(), 0)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// rankingAlgorithms 返回各个热度有序集合记录的排行算法
+// 没有记录时为切换前的 reddit 算法
+func rankingAlgorithms(ctx context.Context, keys ...string) ([]string, error) {
+	markers := make([]string, len(keys))
+	for i, key := range keys {
+		markers[i] = GenerateRedisKey(PostRankingAlgorithmTemplate, key)
+	}
+	values, err := Redis.GetRedisClient().MGet(ctx, markers...).Result()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(keys))
+	for i, value := range values {
+		name, ok := value.(string)
+		if !ok {
+			name = ranking.NameReddit
+		}
+		names[i] = name
+	}
+	return names, nil
+}
+
+// rankingOutdated 判断热度有序集合是否需要重建
+// 记录的算法与 ranker 不同, 或者 ranker 的分数随时间变化时需要重建
+func rankingOutdated(ctx context.Context, key string, ranker ranking.Ranker) (bool, error) {
+	if ranker.TimeDependent() {
+		return true, nil
+	}
+	names, err := rankingAlgorithms(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	return names[0] != ranker.Name(), nil
+}
+
+// GlobalRankingOutdated 判断全局和标签的热度有序集合是否需要按配置的算法重建
+func GlobalRankingOutdated(ctx context.Context) (bool, error) {
+	return rankingOutdated(ctx, GenerateRedisKey(PostRankingTemplate), ranking.Default())
+}
+
+// RebuildPostRanking 根据数据库中的发布时间和点赞数, 使用配置的算法重建全局和所有标签的热度有序集合
+// 标签有序集合中的帖子不变, 分数取重建后全局有序集合中的分数
+func RebuildPostRanking(ctx context.Context, posts []DTO.PostRankData) error {
+	ranker := ranking.Default()
+	now := time.Now()
+	members := make([]*redis.Z, len(posts))
+	for i, post := range posts {
+		members[i] = &redis.Z{
+			Score:  ranker.Score(post.Vote, post.Down, time.Unix(post.PublishAt, 0), now),
+			Member: strconv.FormatInt(post.PostID, 10),
+		}
+	}
+	globalKey := GenerateRedisKey(PostRankingTemplate)
+	if err := replaceRanking(ctx, globalKey, members, ranker); err != nil {
+		return err
+	}
+
+	// 用标签有序集合和全局有序集合的交集更新标签中帖子的分数, 标签有序集合的权重为 0
+	prefix := strings.TrimSuffix(PostTagRankingTemplate, "%v")
+	iter := Redis.GetRedisClient().Scan(ctx, 0, prefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		if strings.HasSuffix(key, ":rebuild") {
+			continue
+		}
+		tmp := key + ":rebuild"
+		n, err := Redis.GetRedisClient().ZInterStore(ctx, tmp, &redis.ZStore{Keys: []string{key, globalKey}, Weights: []float64{0, 1}}).Result()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			// 标签中的帖子都已不在全局排行中
+			err = Redis.GetRedisClient().Del(ctx, key).Err()
+		} else {
+			err = Redis.GetRedisClient().Rename(ctx, tmp, key).Err()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
+// UpdatePostRanking 按帖子当前的票数重新计算帖子在全局、标签和社区热度有序集合中的分数
+// 标签和社区的有序集合只更新已有的帖子;
+// 有序集合记录的算法与本实例的算法不同时 (切换算法后尚未重建, 或其他实例的配置不同) 不更新, 等待按新算法重建
+func UpdatePostRanking(ctx context.Context, post *DTO.PostRankData) error {
+	tags, err := getPostTags(ctx, post.PostID)
+	if err != nil {
+		return err
+	}
+
+	globalKey := GenerateRedisKey(PostRankingTemplate)
+	communityKey := GenerateRedisKey(PostCommunityRankingTemplate, post.CommunityID)
+	names, err := rankingAlgorithms(ctx, globalKey, communityKey)
+	if err != nil {
+		return err
+	}
+	ranker, communityRanker := ranking.Default(), ranking.ForCommunity(post.CommunityID)
+
+	now := time.Now()
+	publishTime := time.Unix(post.PublishAt, 0)
+	member := strconv.FormatInt(post.PostID, 10)
+
+	// 使用 Redis Pipeline 更新 ZSet，确保高效和一致性
+	pipe := Redis.GetRedisClient().TxPipeline()
+	if names[0] == ranker.Name() {
+		// 标签有序集合与全局有序集合一起重建, 使用全局有序集合记录的算法
+		score := ranker.Score(post.Vote, post.Down, publishTime, now)
+		pipe.ZAdd(ctx, globalKey, &redis.Z{Score: score, Member: member})
+		for _, tag := range tags {
+			pipe.ZAddXX(ctx, GenerateRedisKey(PostTagRankingTemplate, tag), &redis.Z{Score: score, Member: member})
+		}
+	}
+	if post.CommunityID != 0 && names[1] == communityRanker.Name() {
+		communityScore := communityRanker.Score(post.Vote, post.Down, publishTime, now)
+		pipe.ZAddXX(ctx, communityKey, &redis.Z{Score: communityScore, Member: member})
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// RestorePostRanking 按帖子当前的票数把帖子写回全局和标签热度有序集合
+// 重建排行时读取数据库之后发布的帖子和新的投票会被替换覆盖, 重建完成后对这些帖子调用;
+// 新发布的帖子在更新标签有序集合时已被移除, 因此标签有序集合也直接加入帖子
+func RestorePostRanking(ctx context.Context, post *DTO.PostRankData) error {
+	tags, err := getPostTags(ctx, post.PostID)
+	if err != nil {
+		return err
+	}
+	score := ranking.Default().Score(post.Vote, post.Down, time.Unix(post.PublishAt, 0), time.Now())
+	member := strconv.FormatInt(post.PostID, 10)
+	pipe := Redis.GetRedisClient().TxPipeline()
+	pipe.ZAdd(ctx, GenerateRedisKey(PostRankingTemplate), &redis.Z{Score: score, Member: member})
+	for _, tag := range tags {
+		pipe.ZAdd(ctx, GenerateRedisKey(PostTagRankingTemplate, tag), &redis.Z{Score: score, Member: member})
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// AcquirePostRankingRebuildLock 获取检查和重建热度排行任务的锁, 锁在 interval 后自动释放
+func AcquirePostRankingRebuildLock(ctx context.Context, interval time.Duration) (bool, error) {
+	key := GenerateRedisKey(PostRankingRebuildLockTemplate)
+	return Redis.GetRedisClient().SetNX(ctx, key, 1, interval).Result()
+}
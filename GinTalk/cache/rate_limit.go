@@ -0,0 +1,42 @@
+package cache
+
+import (
+	"GinTalk/dao/Redis"
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// incrRequestRateScript 原子地增加请求次数, 第一次请求时设置时间窗口
+// KEYS[1]: 计数器 key; ARGV[1]: 时间窗口 (秒)
+// 返回时间窗口内的请求次数
+var incrRequestRateScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('EXPIRE', KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// AllowRequest 记录一次请求, 判断 window 时间窗口内的请求次数是否超过 limit
+//
+// 参数:
+//   - ctx: 操作的上下文，允许取消和超时控制。
+//   - purpose: 接口用途, 不同接口分别计数。
+//   - dimension: 统计维度, 例如 email 或 ip。
+//   - value: 维度对应的值。
+//   - limit: 时间窗口内允许的最大请求次数。
+//   - window: 统计请求次数的时间窗口。
+//
+// 返回:
+//   - bool: 未超过限制时返回 true。
+//   - error: 如果操作失败，则返回错误对象，否则返回 nil。
+func AllowRequest(ctx context.Context, purpose string, dimension string, value string, limit int64, window time.Duration) (bool, error) {
+	key := GenerateRedisKey(RequestRateTemplate, purpose, dimension, value)
+	count, err := incrRequestRateScript.Run(ctx, Redis.GetRedisClient(), []string{key}, int64(window.Seconds())).Int64()
+	if err != nil {
+		return false, err
+	}
+	return count <= limit, nil
+}
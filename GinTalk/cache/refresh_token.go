@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"GinTalk/dao/Redis"
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RotateResult 刷新令牌轮换的结果
+type RotateResult int
+
+const (
+	// RefreshTokenRotated 轮换成功
+	RefreshTokenRotated RotateResult = 1
+	// RefreshTokenFamilyNotFound 令牌家族不存在或已过期
+	RefreshTokenFamilyNotFound RotateResult = 0
+	// RefreshTokenFamilyRevoked 令牌家族已被撤销
+	RefreshTokenFamilyRevoked RotateResult = -1
+	// RefreshTokenReused 检测到已轮换的刷新令牌被重复使用, 整个家族已被撤销
+	RefreshTokenReused RotateResult = -2
+)
+
+// rotateRefreshTokenScript 原子地校验并轮换刷新令牌
+// KEYS[1]: 家族 key; ARGV[1]: 旧令牌 ID; ARGV[2]: 新令牌 ID; ARGV[3]: 过期时间 (秒)
+// 只有家族中当前有效的令牌可以被轮换, 使用其他令牌说明该令牌已被轮换过, 可能已泄露, 此时撤销整个家族
+var rotateRefreshTokenScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], 'current')
+if not current then
+	return 0
+end
+if redis.call('HGET', KEYS[1], 'revoked') == '1' then
+	return -1
+end
+if current ~= ARGV[1] then
+	redis.call('HSET', KEYS[1], 'revoked', '1')
+	return -2
+end
+redis.call('HSET', KEYS[1], 'current', ARGV[2])
+redis.call('EXPIRE', KEYS[1], ARGV[3])
+return 1
+`)
+
+// RotateRefreshToken 将家族中当前有效的刷新令牌替换为新令牌
+//
+// 返回:
+//   - RotateResult: 轮换结果, 只有 RefreshTokenRotated 表示成功。
+//   - error: Redis 操作失败时返回错误。
+func RotateRefreshToken(ctx context.Context, familyID, oldTokenID, newTokenID string, expiration time.Duration) (RotateResult, error) {
+	key := GenerateRedisKey(RefreshTokenFamilyTemplate, familyID)
+	result, err := rotateRefreshTokenScript.Run(ctx, Redis.GetRedisClient(), []string{key},
+		oldTokenID, newTokenID, int64(expiration.Seconds()),
+	).Int()
+	if err != nil {
+		return RefreshTokenFamilyNotFound, err
+	}
+	return RotateResult(result), nil
+}
@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"GinTalk/dao/Redis"
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// GetUserRole 获取缓存的用户角色
+//
+// 返回:
+//   - string: 用户角色。
+//   - bool: 是否命中缓存。
+//   - error: 如果操作失败，则返回错误对象，否则返回 nil。
+func GetUserRole(ctx context.Context, userID int64) (string, bool, error) {
+	role, err := Redis.GetRedisClient().Get(ctx, GenerateRedisKey(UserRoleTemplate, userID)).Result()
+	if err == redis.Nil {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return role, true, nil
+}
+
+// SetUserRole 缓存用户角色
+func SetUserRole(ctx context.Context, userID int64, role string, expiration time.Duration) error {
+	return Redis.GetRedisClient().Set(ctx, GenerateRedisKey(UserRoleTemplate, userID), role, expiration).Err()
+}
+
+// DeleteUserRole 用户角色变更后删除缓存
+func DeleteUserRole(ctx context.Context, userID int64) error {
+	return Redis.GetRedisClient().Del(ctx, GenerateRedisKey(UserRoleTemplate, userID)).Err()
+}
@@ -0,0 +1,221 @@
+package cache
+
+import (
+	"GinTalk/DTO"
+	"GinTalk/dao/Redis"
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// 会话与刷新令牌家族一一对应, 存储在同一个 Redis 哈希中
+const (
+	sessionFieldUserID     = "user_id"
+	sessionFieldCurrent    = "current"
+	sessionFieldRevoked    = "revoked"
+	sessionFieldDevice     = "device"
+	sessionFieldIP         = "ip"
+	sessionFieldUserAgent  = "user_agent"
+	sessionFieldCreateTime = "create_time"
+	sessionFieldLastUsed   = "last_used"
+
+	// SessionTouchInterval 更新会话最后使用时间的最小间隔, 避免每个请求都写 Redis
+	SessionTouchInterval = time.Minute
+)
+
+// CreateSession 登录时创建一个新的会话, 同时作为一个新的刷新令牌家族
+//
+// 参数:
+//   - ctx: 操作的上下文，允许取消和超时控制。
+//   - session: 会话信息, SessionID 即令牌家族 ID。
+//   - tokenID: 家族中当前有效的刷新令牌 ID (jti)。
+//   - expiration: 会话的过期时间, 与刷新令牌的有效期一致, 每次轮换刷新令牌时延长。
+func CreateSession(ctx context.Context, session *DTO.Session, tokenID string, expiration time.Duration) error {
+	key := GenerateRedisKey(RefreshTokenFamilyTemplate, session.SessionID)
+	now := time.Now().Unix()
+
+	pipe := Redis.GetRedisClient().TxPipeline()
+	pipe.HSet(ctx, key,
+		sessionFieldUserID, session.UserID,
+		sessionFieldCurrent, tokenID,
+		sessionFieldRevoked, 0,
+		sessionFieldDevice, session.Device,
+		sessionFieldIP, session.IP,
+		sessionFieldUserAgent, session.UserAgent,
+		sessionFieldCreateTime, now,
+		sessionFieldLastUsed, now,
+	)
+	pipe.Expire(ctx, key, expiration)
+	pipe.ZAdd(ctx, GenerateRedisKey(UserSessionsTemplate, session.UserID), &redis.Z{
+		Score:  float64(now),
+		Member: session.SessionID,
+	})
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// ValidateSession 判断会话是否仍然有效, 有效时更新会话的最后使用时间和 IP
+// 会话不存在 (已过期) 或已被撤销时返回 false
+func ValidateSession(ctx context.Context, sessionID string, ip string) (bool, error) {
+	key := GenerateRedisKey(RefreshTokenFamilyTemplate, sessionID)
+	values, err := Redis.GetRedisClient().HMGet(ctx, key, sessionFieldRevoked, sessionFieldLastUsed).Result()
+	if err != nil {
+		return false, err
+	}
+	revoked, ok := values[0].(string)
+	if !ok || revoked == "1" {
+		return false, nil
+	}
+
+	lastUsed, _ := values[1].(string)
+	lastUsedUnix, _ := strconv.ParseInt(lastUsed, 10, 64)
+	if now := time.Now(); now.Sub(time.Unix(lastUsedUnix, 0)) >= SessionTouchInterval {
+		err = Redis.GetRedisClient().HSet(ctx, key,
+			sessionFieldLastUsed, now.Unix(),
+			sessionFieldIP, ip,
+		).Err()
+		if err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// GetSessionUserID 获取会话所属的用户
+//
+// 返回:
+//   - int64: 会话所属的用户 ID。
+//   - bool: 会话是否存在且未被撤销。
+//   - error: 如果操作失败，则返回错误对象，否则返回 nil。
+func GetSessionUserID(ctx context.Context, sessionID string) (int64, bool, error) {
+	key := GenerateRedisKey(RefreshTokenFamilyTemplate, sessionID)
+	values, err := Redis.GetRedisClient().HMGet(ctx, key, sessionFieldUserID, sessionFieldRevoked).Result()
+	if err != nil {
+		return 0, false, err
+	}
+	userID, ok := values[0].(string)
+	if !ok || values[1] == "1" {
+		return 0, false, nil
+	}
+	id, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		return 0, false, err
+	}
+	return id, true, nil
+}
+
+// ListSessions 获取用户所有有效的会话, 按创建时间倒序排列
+// 已过期或已撤销的会话会同时从用户的会话集合中移除
+func ListSessions(ctx context.Context, userID int64) ([]DTO.Session, error) {
+	setKey := GenerateRedisKey(UserSessionsTemplate, userID)
+	ids, err := Redis.GetRedisClient().ZRevRange(ctx, setKey, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return []DTO.Session{}, nil
+	}
+
+	pipe := Redis.GetRedisClient().Pipeline()
+	cmds := make([]*redis.StringStringMapCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.HGetAll(ctx, GenerateRedisKey(RefreshTokenFamilyTemplate, id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	sessions := make([]DTO.Session, 0, len(ids))
+	stale := make([]interface{}, 0)
+	for i, cmd := range cmds {
+		fields := cmd.Val()
+		if len(fields) == 0 || fields[sessionFieldRevoked] == "1" {
+			stale = append(stale, ids[i])
+			continue
+		}
+		createTime, _ := strconv.ParseInt(fields[sessionFieldCreateTime], 10, 64)
+		lastUsed, _ := strconv.ParseInt(fields[sessionFieldLastUsed], 10, 64)
+		sessions = append(sessions, DTO.Session{
+			SessionID:    ids[i],
+			UserID:       userID,
+			Device:       fields[sessionFieldDevice],
+			IP:           fields[sessionFieldIP],
+			UserAgent:    fields[sessionFieldUserAgent],
+			CreateTime:   time.Unix(createTime, 0),
+			LastUsedTime: time.Unix(lastUsed, 0),
+		})
+	}
+
+	if len(stale) > 0 {
+		if err := Redis.GetRedisClient().ZRem(ctx, setKey, stale...).Err(); err != nil {
+			return nil, err
+		}
+	}
+	return sessions, nil
+}
+
+// RevokeSession 撤销会话, 会话中的所有令牌随之失效
+// 撤销标记会保留到会话过期, 以便之后继续识别该会话的令牌
+func RevokeSession(ctx context.Context, sessionID string) error {
+	_, err := revokeSession(ctx, sessionID)
+	return err
+}
+
+// revokeSession 撤销会话, 返回会话是否由本次调用撤销
+// 会话不存在 (已过期) 或已被撤销时返回 false
+func revokeSession(ctx context.Context, sessionID string) (bool, error) {
+	key := GenerateRedisKey(RefreshTokenFamilyTemplate, sessionID)
+	values, err := Redis.GetRedisClient().HMGet(ctx, key, sessionFieldUserID, sessionFieldRevoked).Result()
+	if err != nil {
+		return false, err
+	}
+	userID, ok := values[0].(string)
+	if !ok || values[1] == "1" {
+		return false, nil
+	}
+
+	pipe := Redis.GetRedisClient().TxPipeline()
+	pipe.HSet(ctx, key, sessionFieldRevoked, 1)
+	pipe.ZRem(ctx, GenerateRedisKey(UserSessionsTemplate, userID), sessionID)
+	_, err = pipe.Exec(ctx)
+	return err == nil, err
+}
+
+// RevokeUserSessions 撤销用户除 exceptSessionID 以外的所有会话
+// exceptSessionID 为空时撤销用户的全部会话, 已过期或已撤销的会话从用户的会话集合中移除, 不计入撤销数量
+//
+// 返回:
+//   - int: 被撤销的会话数量。
+//   - error: 如果操作失败，则返回错误对象，否则返回 nil。
+func RevokeUserSessions(ctx context.Context, userID int64, exceptSessionID string) (int, error) {
+	setKey := GenerateRedisKey(UserSessionsTemplate, userID)
+	ids, err := Redis.GetRedisClient().ZRange(ctx, setKey, 0, -1).Result()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	stale := make([]interface{}, 0)
+	for _, id := range ids {
+		if id == exceptSessionID {
+			continue
+		}
+		revoked, err := revokeSession(ctx, id)
+		if err != nil {
+			return count, err
+		}
+		if !revoked {
+			stale = append(stale, id)
+			continue
+		}
+		count++
+	}
+
+	if len(stale) > 0 {
+		if err := Redis.GetRedisClient().ZRem(ctx, setKey, stale...).Err(); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,26 @@
+package cache
+
+import (
+	"GinTalk/dao/Redis"
+	"context"
+	"time"
+)
+
+// MarkTOTPStepUsed 标记用户在某个时间步的验证码已被使用
+// 同一时间步的验证码第二次使用时返回 false
+func MarkTOTPStepUsed(ctx context.Context, userID int64, step int64, expiration time.Duration) (bool, error) {
+	key := GenerateRedisKey(TOTPUsedStepTemplate, userID, step)
+	return Redis.GetRedisClient().SetNX(ctx, key, 1, expiration).Result()
+}
+
+// IncrLoginChallengeAttempts 增加登录挑战的尝试次数, 返回增加后的次数
+func IncrLoginChallengeAttempts(ctx context.Context, tokenID string, expiration time.Duration) (int64, error) {
+	key := GenerateRedisKey(LoginChallengeAttemptsTemplate, tokenID)
+	pipe := Redis.GetRedisClient().TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, expiration)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"GinTalk/DTO"
+	"GinTalk/pkg/code"
+	"GinTalk/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// VerifyEmailHandler 验证邮箱
+// @Summary 验证邮箱
+// @Description 使用验证邮件中的令牌验证邮箱, 令牌只能使用一次
+// @Tags 账号
+// @Accept json
+// @Produce json
+// @Param token body string true "邮件中的令牌"
+// @Success 200 {object} Response
+// @Router /api/v1/verify-email [post]
+func VerifyEmailHandler(c *gin.Context) {
+	var dto DTO.VerifyEmailRequestDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		return
+	}
+	if apiError := service.VerifyEmailService(c.Request.Context(), dto.Token); apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		zap.L().Info("service.VerifyEmailService() 失败", zap.Error(apiError))
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// ResendVerificationEmailHandler 重新发送验证邮件
+// @Summary 重新发送验证邮件
+// @Description 向当前用户的邮箱重新发送验证邮件, 每分钟最多一次
+// @Tags 账号
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Success 200 {object} Response
+// @Router /api/v1/verify-email/resend [post]
+func ResendVerificationEmailHandler(c *gin.Context) {
+	userID, exist := getCurrentUserID(c)
+	if !exist {
+		ResponseErrorWithCode(c, code.InvalidAuth)
+		return
+	}
+	if apiError := service.ResendVerificationEmailService(c.Request.Context(), userID); apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// ForgotPasswordHandler 忘记密码
+// @Summary 忘记密码
+// @Description 向邮箱发送重置密码邮件, 无论邮箱是否注册都返回成功; 同一邮箱或同一 IP 请求过于频繁时返回错误
+// @Tags 账号
+// @Accept json
+// @Produce json
+// @Param email body string true "注册邮箱"
+// @Success 200 {object} Response
+// @Router /api/v1/password/forgot [post]
+func ForgotPasswordHandler(c *gin.Context) {
+	var dto DTO.ForgotPasswordRequestDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		return
+	}
+	dto.IP = c.ClientIP()
+	if apiError := service.ForgotPasswordService(c.Request.Context(), dto.Email, dto.IP); apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// ResetPasswordHandler 重置密码
+// @Summary 重置密码
+// @Description 使用重置密码邮件中的令牌设置新密码, 成功后所有设备都需要重新登录, 所有个人访问令牌失效
+// @Tags 账号
+// @Accept json
+// @Produce json
+// @Param token body string true "邮件中的令牌"
+// @Param password body string true "新密码"
+// @Success 200 {object} Response
+// @Router /api/v1/password/reset [post]
+func ResetPasswordHandler(c *gin.Context) {
+	var dto DTO.ResetPasswordRequestDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		return
+	}
+	if apiError := service.ResetPasswordService(c.Request.Context(), dto.Token, dto.Password); apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		zap.L().Info("service.ResetPasswordService() 失败", zap.Error(apiError))
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// RequireVerifiedEmailMiddleware 限制邮箱未验证的用户只能进行只读操作
+// 必须在 JWTAuthMiddleware 之后使用, GET、HEAD 和 OPTIONS 请求不受限制
+func RequireVerifiedEmailMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		userID, exist := getCurrentUserID(c)
+		if !exist {
+			ResponseErrorWithCode(c, code.InvalidAuth)
+			c.Abort()
+			return
+		}
+		verified, apiError := service.IsEmailVerifiedService(c.Request.Context(), userID)
+		if apiError != nil {
+			ResponseErrorWithApiError(c, apiError)
+			c.Abort()
+			return
+		}
+		if !verified {
+			ResponseErrorWithMsg(c, code.EmailNotVerified, "请先验证邮箱")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// DeleteAccountHandler 注销账号
+// @Summary 注销账号
+// @Description 注销当前账号, 需要验证密码, 启用两步验证时还需要验证码或恢复码, 注销后所有设备立即下线且无法恢复
+// @Tags 账号
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Param account body DTO.DeleteAccountRequestDTO true "密码和验证码"
+// @Success 200 {object} Response
+// @Router /api/v1/account [delete]
+func DeleteAccountHandler(c *gin.Context) {
+	userID, exist := getCurrentUserID(c)
+	if !exist {
+		ResponseErrorWithCode(c, code.InvalidAuth)
+		return
+	}
+	var dto DTO.DeleteAccountRequestDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		return
+	}
+	if apiError := service.DeleteAccountService(c.Request.Context(), userID, dto.Password, dto.Code); apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		zap.L().Info("service.DeleteAccountService() 失败", zap.Int64("user_id", userID), zap.Error(apiError))
+		return
+	}
+	ResponseSuccess(c, nil)
+}
@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"GinTalk/DTO"
+	"GinTalk/pkg/code"
+	"GinTalk/pkg/rbac"
+	"GinTalk/service"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RequireRole 限制只有不低于 role 的用户才能访问
+// 必须在 JWTAuthMiddleware 之后使用
+func RequireRole(role rbac.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exist := getCurrentUserID(c)
+		if !exist {
+			ResponseErrorWithCode(c, code.InvalidAuth)
+			c.Abort()
+			return
+		}
+		current, err := service.GetUserRole(c.Request.Context(), userID)
+		if err != nil {
+			zap.L().Error("service.GetUserRole() 失败", zap.Int64("user_id", userID), zap.Error(err))
+			ResponseErrorWithCode(c, code.ServerError)
+			c.Abort()
+			return
+		}
+		if !current.AtLeast(role) {
+			ResponseErrorWithMsg(c, code.PermissionDenied, "无权限操作")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission 限制只有拥有全局权限 perm 的用户才能访问
+// 社区版主的社区范围权限需要在 service 中结合具体资源校验
+// 必须在 JWTAuthMiddleware 之后使用
+func RequirePermission(perm rbac.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exist := getCurrentUserID(c)
+		if !exist {
+			ResponseErrorWithCode(c, code.InvalidAuth)
+			c.Abort()
+			return
+		}
+		ok, err := service.HasPermission(c.Request.Context(), userID, perm, 0)
+		if err != nil {
+			zap.L().Error("service.HasPermission() 失败", zap.Int64("user_id", userID), zap.Error(err))
+			ResponseErrorWithCode(c, code.ServerError)
+			c.Abort()
+			return
+		}
+		if !ok {
+			ResponseErrorWithMsg(c, code.PermissionDenied, "无权限操作")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// SetUserRoleHandler 修改用户的全局角色
+// @Summary 修改角色
+// @Description 管理员修改其他用户的全局角色, 可选 user、moderator、admin
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "用户ID"
+// @Param role body DTO.SetUserRoleRequestDTO true "角色"
+// @Success 200 {object} Response
+// @Router /api/v1/admin/user/{id}/role [put]
+func SetUserRoleHandler(c *gin.Context) {
+	operatorID, exist := getCurrentUserID(c)
+	if !exist {
+		ResponseErrorWithCode(c, code.InvalidAuth)
+		return
+	}
+	targetID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithCode(c, code.InvalidParam)
+		return
+	}
+	var dto DTO.SetUserRoleRequestDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		return
+	}
+	if apiError := service.SetUserRoleService(c.Request.Context(), operatorID, targetID, rbac.Role(dto.Role)); apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		zap.L().Info("service.SetUserRoleService() 失败", zap.Int64("user_id", targetID), zap.Error(apiError))
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// BanUserHandler 封禁用户
+// @Summary 封禁用户
+// @Description 封禁用户并使其所有设备下线, duration 为封禁小时数, 0 表示永久封禁
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "用户ID"
+// @Param ban body DTO.BanUserRequestDTO true "封禁信息"
+// @Success 200 {object} Response
+// @Router /api/v1/admin/user/{id}/ban [post]
+func BanUserHandler(c *gin.Context) {
+	operatorID, exist := getCurrentUserID(c)
+	if !exist {
+		ResponseErrorWithCode(c, code.InvalidAuth)
+		return
+	}
+	targetID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithCode(c, code.InvalidParam)
+		return
+	}
+	var dto DTO.BanUserRequestDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		return
+	}
+	duration := time.Duration(dto.Duration) * time.Hour
+	if apiError := service.BanUserService(c.Request.Context(), operatorID, targetID, duration, dto.Reason); apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		zap.L().Info("service.BanUserService() 失败", zap.Int64("user_id", targetID), zap.Error(apiError))
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// UnbanUserHandler 解除封禁
+// @Summary 解除封禁
+// @Tags 管理
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "用户ID"
+// @Success 200 {object} Response
+// @Router /api/v1/admin/user/{id}/ban [delete]
+func UnbanUserHandler(c *gin.Context) {
+	operatorID, exist := getCurrentUserID(c)
+	if !exist {
+		ResponseErrorWithCode(c, code.InvalidAuth)
+		return
+	}
+	targetID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithCode(c, code.InvalidParam)
+		return
+	}
+	if apiError := service.UnbanUserService(c.Request.Context(), operatorID, targetID); apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		zap.L().Info("service.UnbanUserService() 失败", zap.Int64("user_id", targetID), zap.Error(apiError))
+		return
+	}
+	ResponseSuccess(c, nil)
+}
@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"GinTalk/pkg/code"
+	"GinTalk/service"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// multipartOverhead 上传接口的请求体中除文件内容以外的部分 (multipart 边界、头部等) 允许的大小
+const multipartOverhead = 64 * 1024
+
+// UploadLimitBodySizeOption 上传接口的请求体大小限制, 为最大的单个文件大小加上 multipart 的开销
+// 每种文件的大小上限在识别文件类型之后由 service 检查
+func UploadLimitBodySizeOption() limitBodySizeOption {
+	return WithRouteLimitBodySizeOption("/api/v1/upload", service.MaxUploadSize()+multipartOverhead)
+}
+
+// UploadHandler 上传附件
+// @Summary 上传附件
+// @Description 上传图片或文件, 返回的附件 ID 可以在创建或修改帖子和评论时通过 attachment_ids 引用。
+// @Description 文件类型根据内容识别, 支持 JPEG、PNG、GIF 图片和 PDF、纯文本、ZIP 文件, 每种类型有各自的大小上限;
+// @Description 图片会重新编码以去掉 EXIF 等元数据, 并生成缩略图。上传后长时间未被引用的附件会被删除
+// @Tags 附件
+// @Accept multipart/form-data
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Param file formData file true "上传的文件"
+// @Success 200 {object} Response{data=DTO.Attachment}
+// @Router /api/v1/upload [post]
+func UploadHandler(c *gin.Context) {
+	userID, exist := getCurrentUserID(c)
+	if !exist {
+		ResponseErrorWithCode(c, code.InvalidAuth)
+		return
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			ResponseRequestEntityTooLarge(c, code.FileTooLarge.GetMsg())
+			return
+		}
+		ResponseErrorWithMsg(c, code.InvalidParam, "请通过 file 字段上传文件")
+		zap.L().Info("c.FormFile() 失败", zap.Error(err))
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		ResponseErrorWithMsg(c, code.ServerError, "读取上传文件失败")
+		zap.L().Error("fileHeader.Open() 失败", zap.Error(err))
+		return
+	}
+	defer file.Close()
+
+	attachment, apiError := service.UploadAttachment(c.Request.Context(), userID, fileHeader.Filename, file)
+	if apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		zap.L().Info("service.UploadAttachment() 失败", zap.Int64("user_id", userID), zap.Error(apiError))
+		return
+	}
+	ResponseSuccess(c, attachment)
+}
+
+// GetAttachmentHandler 获取附件信息
+// @Summary 附件信息
+// @Description 获取附件的文件名、类型、大小、图片尺寸和下载地址
+// @Tags 附件
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "附件ID"
+// @Success 200 {object} Response{data=DTO.Attachment}
+// @Router /api/v1/attachment/{id} [get]
+func GetAttachmentHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithCode(c, code.InvalidParam)
+		return
+	}
+	attachment, apiError := service.GetAttachment(c.Request.Context(), id)
+	if apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		return
+	}
+	ResponseSuccess(c, attachment)
+}
+
+// DeleteAttachmentHandler 删除附件
+// @Summary 删除附件
+// @Description 删除自己上传且尚未被帖子或评论引用的附件
+// @Tags 附件
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "附件ID"
+// @Success 200 {object} Response
+// @Router /api/v1/attachment/{id} [delete]
+func DeleteAttachmentHandler(c *gin.Context) {
+	userID, exist := getCurrentUserID(c)
+	if !exist {
+		ResponseErrorWithCode(c, code.InvalidAuth)
+		return
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithCode(c, code.InvalidParam)
+		return
+	}
+	if apiError := service.DeleteAttachment(c.Request.Context(), id, userID); apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		zap.L().Info("service.DeleteAttachment() 失败", zap.Int64("attachment_id", id), zap.Error(apiError))
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// GetAttachmentFileHandler 下载附件
+// @Summary 下载附件
+// @Description 下载附件文件, 不需要认证, 帖子和评论中的图片由浏览器直接加载
+// @Tags 附件
+// @Produce octet-stream
+// @Param id path int true "附件ID"
+// @Success 200 {file} binary
+// @Router /api/v1/attachment/{id}/file [get]
+func GetAttachmentFileHandler(c *gin.Context) {
+	serveAttachment(c, false)
+}
+
+// GetAttachmentThumbnailHandler 下载图片附件的缩略图
+// @Summary 下载缩略图
+// @Description 下载图片附件的缩略图, 不需要认证
+// @Tags 附件
+// @Produce image/jpeg,image/png
+// @Param id path int true "附件ID"
+// @Success 200 {file} binary
+// @Router /api/v1/attachment/{id}/thumbnail [get]
+func GetAttachmentThumbnailHandler(c *gin.Context) {
+	serveAttachment(c, true)
+}
+
+// serveAttachment 输出附件文件
+// 附件内容不会改变, 允许长期缓存; 图片直接显示, 其他文件作为下载处理, 并禁止浏览器猜测类型和执行脚本
+func serveAttachment(c *gin.Context, thumbnail bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithCode(c, code.InvalidParam)
+		return
+	}
+	file, apiError := service.OpenAttachmentFile(c.Request.Context(), id, thumbnail)
+	if apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		return
+	}
+	defer file.Reader.Close()
+
+	disposition := "attachment"
+	if strings.HasPrefix(file.ContentType, "image/") {
+		disposition = "inline"
+	}
+	c.DataFromReader(http.StatusOK, file.Size, file.ContentType, file.Reader, map[string]string{
+		"Content-Disposition":     mime.FormatMediaType(disposition, map[string]string{"filename": file.FileName}),
+		"Cache-Control":           "public, max-age=31536000, immutable",
+		"X-Content-Type-Options":  "nosniff",
+		"Content-Security-Policy": "default-src 'none'; sandbox",
+	})
+}
@@ -4,9 +4,13 @@ import (
 	"GinTalk/cache"
 	"GinTalk/pkg/code"
 	"GinTalk/pkg/jwt"
+	"GinTalk/pkg/pat"
+	"GinTalk/service"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"net/http"
+	"slices"
 	"strings"
 )
 
@@ -15,8 +19,44 @@ const (
 	ContextUserIDKey = "user_id"
 	// ContextUsernameKey 是上下文中用户名的key
 	ContextUsernameKey = "username"
+	// ContextSessionIDKey 是上下文中当前会话ID的key
+	ContextSessionIDKey = "session_id"
+	// ContextTokenScopesKey 是上下文中个人访问令牌授权范围的key, 使用 JWT 登录时不存在
+	ContextTokenScopesKey = "token_scopes"
 )
 
+// personalAccessTokenRoutes 个人访问令牌可以访问的路由前缀
+// 读取这些路由需要 read 范围, 写入需要 write 范围, write 为空时不允许写入
+// 未列出的路由 (会话、两步验证、令牌管理、管理后台等) 只能使用 JWT 访问
+var personalAccessTokenRoutes = []struct {
+	prefix string
+	write  pat.Scope
+}{
+	{prefix: "/api/v1/post", write: pat.ScopePostWrite},
+	{prefix: "/api/v1/comment", write: pat.ScopeCommentWrite},
+	{prefix: "/api/v1/community"},
+	{prefix: "/api/v1/vote"},
+	{prefix: "/api/v1/user/:id"},
+	{prefix: "/api/v1/search"},
+}
+
+// personalAccessTokenScope 返回使用个人访问令牌访问路由所需的授权范围
+// 第二个返回值为 false 表示该路由不允许使用个人访问令牌访问
+func personalAccessTokenScope(method string, fullPath string) (pat.Scope, bool) {
+	for _, route := range personalAccessTokenRoutes {
+		if !strings.HasPrefix(fullPath, route.prefix) {
+			continue
+		}
+		switch method {
+		case http.MethodGet, http.MethodHead:
+			return pat.ScopeRead, true
+		default:
+			return route.write, route.write != ""
+		}
+	}
+	return "", false
+}
+
 // JWTAuthMiddleware 是一个 Gin 的中间件函数, 用于处理 JWT 认证。
 // 它检查请求的 Authorization 头中是否存在有效的 JWT token。
 // 如果 token 缺失、格式错误或无效, 它会返回未授权错误并中止请求。
@@ -38,6 +78,10 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 		token := parts[1]
+		if pat.IsToken(token) {
+			personalAccessTokenAuth(c, token)
+			return
+		}
 		myClaims, err := jwt.ParseToken(token)
 		if err != nil {
 			ResponseUnAuthorized(c, "token 解析失败")
@@ -53,21 +97,67 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 		}
 
 		exist, err := cache.IsTokenInBlacklist(c.Request.Context(), token)
-		if exist {
-			ResponseUnAuthorized(c, "token 已失效")
-			zap.L().Info("token 已失效")
-			c.Abort()
-		}
 		if err != nil {
 			ResponseErrorWithMsg(c, code.ServerError, fmt.Sprintf("authCache.IsTokenInBlacklist() 出错: %v", err))
 			zap.L().Error("authCache.IsTokenInBlacklist() 出错", zap.Error(err))
 			c.Abort()
 			return
 		}
+		if exist {
+			ResponseUnAuthorized(c, "token 已失效")
+			zap.L().Info("token 已失效")
+			c.Abort()
+			return
+		}
+
+		// token 所属的会话被撤销 (退出登录、在会话管理中移除或检测到刷新令牌重复使用) 后, 访问令牌也随之失效
+		if myClaims.FamilyID != "" {
+			valid, err := cache.ValidateSession(c.Request.Context(), myClaims.FamilyID, c.ClientIP())
+			if err != nil {
+				ResponseErrorWithMsg(c, code.ServerError, fmt.Sprintf("cache.ValidateSession() 出错: %v", err))
+				zap.L().Error("cache.ValidateSession() 出错", zap.Error(err))
+				c.Abort()
+				return
+			}
+			if !valid {
+				ResponseUnAuthorized(c, "token 已失效")
+				zap.L().Info("token 所属的会话已失效", zap.String("session_id", myClaims.FamilyID))
+				c.Abort()
+				return
+			}
+		}
 
 		c.Set(ContextUserIDKey, myClaims.UserID)
 		c.Set(ContextUsernameKey, myClaims.Username)
+		c.Set(ContextSessionIDKey, myClaims.FamilyID)
 		c.Next()
 		return
 	}
 }
+
+// personalAccessTokenAuth 使用个人访问令牌认证, 并检查令牌的授权范围是否允许访问当前路由
+func personalAccessTokenAuth(c *gin.Context, token string) {
+	identity, apiError := service.AuthenticatePersonalAccessToken(c.Request.Context(), token)
+	if apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		zap.L().Info("个人访问令牌认证失败", zap.Error(apiError))
+		c.Abort()
+		return
+	}
+	required, allowed := personalAccessTokenScope(c.Request.Method, c.FullPath())
+	if !allowed {
+		ResponseErrorWithMsg(c, code.TokenScopeDenied, "该接口不支持使用个人访问令牌访问")
+		c.Abort()
+		return
+	}
+	if !slices.Contains(identity.Scopes, required) {
+		ResponseErrorWithMsg(c, code.TokenScopeDenied, fmt.Sprintf("令牌缺少授权范围 %v", required))
+		c.Abort()
+		return
+	}
+
+	c.Set(ContextUserIDKey, identity.UserID)
+	c.Set(ContextUsernameKey, identity.Username)
+	c.Set(ContextTokenScopesKey, identity.Scopes)
+	c.Next()
+}
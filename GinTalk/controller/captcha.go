@@ -0,0 +1,23 @@
+package controller
+
+import (
+	"GinTalk/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CaptchaHandler 获取验证码
+// @Summary 获取验证码
+// @Description 生成一个新的图片验证码, 注册和多次登录失败后登录时需要提交 captcha_id 和 captcha_answer, 每个验证码只能校验一次
+// @Tags 登录
+// @Produce json
+// @Success 200 {object} Response
+// @Router /api/v1/captcha [get]
+func CaptchaHandler(c *gin.Context) {
+	resp, apiError := service.NewCaptchaService(c.Request.Context())
+	if apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		return
+	}
+	ResponseSuccess(c, resp)
+}
@@ -15,14 +15,14 @@ import (
 // @Accept json
 // @Produce json
 // @Param post_id query string true "帖子ID"
-// @Param page_size query string true "每页数量"
-// @Param page_num query string true "页码"
+// @Param page_size query string false "每页数量"
+// @Param cursor query string false "上一页返回的 next_cursor, 为空时获取第一页"
 // @Success 200 {object} CommentListResponse
 // @Router /api/v1/comment/top [get]
 func GetTopComments(c *gin.Context) {
 	// 1. 从请求中获取参数
 	_postID := c.Query("post_id")
-	pageNum, pageSize := getPageInfo(c)
+	after, pageSize := getCursorInfo(c)
 
 	// 2. 参数校验
 	_postIDInt, err := strconv.Atoi(_postID)
@@ -33,7 +33,7 @@ func GetTopComments(c *gin.Context) {
 	postID := int64(_postIDInt)
 
 	// 3. 调用 service 获取数据
-	commentList, apiError := service.GetTopComments(c, postID, pageSize, pageNum)
+	commentList, apiError := service.GetTopComments(c, postID, after, pageSize)
 	if apiError != nil {
 		ResponseErrorWithApiError(c, apiError)
 		return
@@ -49,15 +49,15 @@ func GetTopComments(c *gin.Context) {
 // @Produce json
 // @Param post_id query string true "帖子ID"
 // @Param parent_id query string true "父评论ID"
-// @Param page_size query string true "每页数量"
-// @Param page_num query string true "页码"
+// @Param page_size query string false "每页数量"
+// @Param cursor query string false "上一页返回的 next_cursor, 为空时获取第一页"
 // @Success 200 {object} CommentListResponse
 // @Router /api/v1/comment/sub [get]
 func GetSubComments(c *gin.Context) {
 	// 1. 从请求中获取参数
 	_postID := c.Query("post_id")
 	_parentID := c.Query("parent_id")
-	pageNum, pageSize := getPageInfo(c)
+	after, pageSize := getCursorInfo(c)
 
 	// 2. 参数校验
 	_postIDInt, err := strconv.Atoi(_postID)
@@ -73,7 +73,7 @@ func GetSubComments(c *gin.Context) {
 	}
 	parentID := int64(_parentIDInt)
 	// 3. 调用 service 获取数据
-	commentList, apiError := service.GetSubComments(c, postID, parentID, pageSize, pageNum)
+	commentList, apiError := service.GetSubComments(c, postID, parentID, after, pageSize)
 	if apiError != nil {
 		ResponseErrorWithApiError(c, apiError)
 		return
@@ -152,8 +152,9 @@ func UpdateComment(c *gin.Context) {
 		ResponseErrorWithMsg(c, code.InvalidParam, "content 参数错误")
 		return
 	}
-	// 3. 调用 service 获取数据
-	apiError := service.UpdateComment(c, &comment)
+	userID, _ := getCurrentUserID(c)
+	// 3. 调用 service 获取数据, If-Match 与当前版本不一致时返回 412
+	apiError := service.UpdateComment(c, &comment, userID, ifMatchVersions(c))
 	if apiError != nil {
 		ResponseErrorWithApiError(c, apiError)
 		return
@@ -173,8 +174,13 @@ func DeleteComment(c *gin.Context) {
 		ResponseErrorWithMsg(c, code.InvalidParam, "comment_id 参数错误")
 		return
 	}
+	userID, exist := getCurrentUserID(c)
+	if !exist {
+		ResponseErrorWithCode(c, code.InvalidAuth)
+		return
+	}
 	// 3. 调用 service 获取数据
-	apiError := service.DeleteComment(c, int64(commentID))
+	apiError := service.DeleteComment(c, int64(commentID), userID)
 	if apiError != nil {
 		ResponseErrorWithApiError(c, apiError)
 		return
@@ -279,6 +285,9 @@ func GetCommentByCommentID(c *gin.Context) {
 		ResponseErrorWithApiError(c, apiError)
 		return
 	}
-	//4. 返回响应
+	//4. 返回响应, If-None-Match 与当前 ETag 一致时返回 304
+	if comment.CommentID != 0 && writeETag(c, comment.Version, comment) {
+		return
+	}
 	ResponseSuccess(c, comment)
 }
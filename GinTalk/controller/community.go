@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"GinTalk/DTO"
 	"GinTalk/pkg/code"
 	"GinTalk/service"
 	"strconv"
@@ -45,3 +46,135 @@ func CommunityDetailHandler(c *gin.Context) {
 	ResponseSuccess(c, community)
 	return
 }
+
+// CreateCommunityHandler 创建社区
+// @Summary 创建社区
+// @Tags 社区
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Param community body DTO.CommunityRequestDTO true "社区信息"
+// @Success 200 {object} Response
+// @Router /api/v1/community [post]
+func CreateCommunityHandler(c *gin.Context) {
+	var dto DTO.CommunityRequestDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		return
+	}
+	if apiError := service.CreateCommunity(c.Request.Context(), &dto); apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		zap.L().Error("service.CreateCommunity() 失败", zap.Error(apiError))
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// UpdateCommunityHandler 修改社区
+// @Summary 修改社区
+// @Tags 社区
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "社区ID"
+// @Param community body DTO.CommunityRequestDTO true "社区信息"
+// @Success 200 {object} Response
+// @Router /api/v1/community/{id} [put]
+func UpdateCommunityHandler(c *gin.Context) {
+	communityID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithCode(c, code.InvalidParam)
+		return
+	}
+	var dto DTO.CommunityRequestDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		return
+	}
+	if apiError := service.UpdateCommunity(c.Request.Context(), communityID, &dto); apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		zap.L().Error("service.UpdateCommunity() 失败", zap.Error(apiError))
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// GetCommunityModeratorsHandler 获取社区的版主列表
+// @Summary 版主列表
+// @Tags 社区
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "社区ID"
+// @Success 200 {object} Response
+// @Router /api/v1/community/{id}/moderators [get]
+func GetCommunityModeratorsHandler(c *gin.Context) {
+	communityID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithCode(c, code.InvalidParam)
+		return
+	}
+	moderators, apiError := service.GetCommunityModerators(c.Request.Context(), communityID)
+	if apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		return
+	}
+	ResponseSuccess(c, moderators)
+}
+
+// AddCommunityModeratorHandler 任命社区版主
+// @Summary 任命版主
+// @Description 社区版主可以删除本社区内任何人的帖子和评论
+// @Tags 社区
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "社区ID"
+// @Param moderator body DTO.CommunityModeratorRequestDTO true "版主"
+// @Success 200 {object} Response
+// @Router /api/v1/community/{id}/moderators [post]
+func AddCommunityModeratorHandler(c *gin.Context) {
+	communityID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithCode(c, code.InvalidParam)
+		return
+	}
+	var dto DTO.CommunityModeratorRequestDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		return
+	}
+	if apiError := service.AddCommunityModerator(c.Request.Context(), communityID, dto.UserID); apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		zap.L().Error("service.AddCommunityModerator() 失败", zap.Error(apiError))
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// RemoveCommunityModeratorHandler 撤销社区版主
+// @Summary 撤销版主
+// @Tags 社区
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "社区ID"
+// @Param user_id path int true "用户ID"
+// @Success 200 {object} Response
+// @Router /api/v1/community/{id}/moderators/{user_id} [delete]
+func RemoveCommunityModeratorHandler(c *gin.Context) {
+	communityID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithCode(c, code.InvalidParam)
+		return
+	}
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithCode(c, code.InvalidParam)
+		return
+	}
+	if apiError := service.RemoveCommunityModerator(c.Request.Context(), communityID, userID); apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		zap.L().Error("service.RemoveCommunityModerator() 失败", zap.Error(apiError))
+		return
+	}
+	ResponseSuccess(c, nil)
+}
@@ -18,7 +18,7 @@ func newCorsConfig(options ...Option) *CorsConfig {
 	defaultCfg := &CorsConfig{
 		AllowOrigins: []string{"*"},
 		AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders: []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		AllowHeaders: []string{"Origin", "Content-Type", "Accept", "Authorization", "If-Match", "If-None-Match"},
 	}
 	for _, option := range options {
 		option(defaultCfg)
@@ -59,6 +59,8 @@ func CorsMiddleware(options ...Option) gin.HandlerFunc {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", origins)
 		c.Writer.Header().Set("Access-Control-Allow-Methods", methods)
 		c.Writer.Header().Set("Access-Control-Allow-Headers", headers)
+		// 条件请求需要读取响应中的 ETag
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "ETag")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(200)
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"GinTalk/pkg/code"
+	"GinTalk/service"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RequestDataExportHandler 申请导出个人数据
+// @Summary 导出个人数据
+// @Description 将用户的资料、帖子、评论和投票记录导出为 ZIP 压缩包, 数据量较大时在后台生成, 需要通过任务状态接口查询进度
+// @Tags 账号
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Success 200 {object} Response
+// @Router /api/v1/account/export [post]
+func RequestDataExportHandler(c *gin.Context) {
+	userID, exist := getCurrentUserID(c)
+	if !exist {
+		ResponseErrorWithCode(c, code.InvalidAuth)
+		return
+	}
+	job, apiError := service.RequestDataExportService(c.Request.Context(), userID)
+	if apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		return
+	}
+	ResponseSuccess(c, job)
+}
+
+// GetDataExportHandler 查询数据导出任务
+// @Summary 数据导出任务状态
+// @Description 查询数据导出任务的状态, status 为 pending、running、done 或 failed
+// @Tags 账号
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Param id path string true "任务ID"
+// @Success 200 {object} Response
+// @Router /api/v1/account/export/{id} [get]
+func GetDataExportHandler(c *gin.Context) {
+	userID, exist := getCurrentUserID(c)
+	if !exist {
+		ResponseErrorWithCode(c, code.InvalidAuth)
+		return
+	}
+	job, apiError := service.GetDataExportJobService(c.Request.Context(), userID, c.Param("id"))
+	if apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		return
+	}
+	ResponseSuccess(c, job)
+}
+
+// DownloadDataExportHandler 下载个人数据压缩包
+// @Summary 下载个人数据
+// @Description 下载已完成的数据导出任务生成的 ZIP 压缩包
+// @Tags 账号
+// @Produce application/zip
+// @Param Authorization header string true "Authorization"
+// @Param id path string true "任务ID"
+// @Success 200 {file} file
+// @Router /api/v1/account/export/{id}/download [get]
+func DownloadDataExportHandler(c *gin.Context) {
+	userID, exist := getCurrentUserID(c)
+	if !exist {
+		ResponseErrorWithCode(c, code.InvalidAuth)
+		return
+	}
+	path, apiError := service.GetDataExportFileService(c.Request.Context(), userID, c.Param("id"))
+	if apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		zap.L().Info("service.GetDataExportFileService() 失败", zap.Int64("user_id", userID), zap.Error(apiError))
+		return
+	}
+	c.FileAttachment(path, fmt.Sprintf("gintalk-%d-%s.zip", userID, time.Now().Format("20060102")))
+}
@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// formatETag 生成强 ETag, 格式为 "<乐观锁版本号>-<响应数据的摘要>"
+// 用户名、附件和投票数等关联数据可能在版本号不变时发生变化, 摘要保证这些变化也会使 ETag 改变
+func formatETag(version int64, data any) string {
+	body, _ := json.Marshal(data)
+	sum := sha256.Sum256(body)
+	return `"` + strconv.FormatInt(version, 10) + "-" + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// parseETag 从 If-Match 中的 ETag 解析出版本号
+// If-Match 使用强比较, 弱 ETag 不与任何版本匹配; 只比较版本号,
+// 不受乐观锁保护的关联数据 (如投票数) 发生变化时不会导致修改失败
+func parseETag(tag string) (int64, bool) {
+	tag = strings.TrimSpace(tag)
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	value, _, _ := strings.Cut(tag[1:len(tag)-1], "-")
+	version, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return version, true
+}
+
+// ifMatchVersions 获取 If-Match 请求头中的版本号
+// 请求头不存在或为 * 时返回 nil, 表示不检查版本; 无法解析的 ETag 被忽略, 不会与任何版本匹配
+func ifMatchVersions(c *gin.Context) []int64 {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return nil
+	}
+	versions := make([]int64, 0, 1)
+	for _, tag := range strings.Split(header, ",") {
+		if version, ok := parseETag(tag); ok {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
+
+// writeETag 设置响应的 ETag, 如果请求的 If-None-Match 与之匹配则返回 304 并返回 true
+// If-None-Match 使用弱比较, 忽略 W/ 前缀后与完整的 ETag 比较
+func writeETag(c *gin.Context, version int64, data any) bool {
+	etag := formatETag(version, data)
+	c.Header("ETag", etag)
+	header := strings.TrimSpace(c.GetHeader("If-None-Match"))
+	if header == "" {
+		return false
+	}
+	if header != "*" {
+		matched := false
+		for _, tag := range strings.Split(header, ",") {
+			if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == etag {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	c.Status(http.StatusNotModified)
+	return true
+}
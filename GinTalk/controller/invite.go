@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"GinTalk/DTO"
+	"GinTalk/pkg/code"
+	"GinTalk/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetRegistrationModeHandler 获取注册模式
+// @Summary 注册模式
+// @Description 获取当前的注册模式, open 为开放注册, invite 为仅限邀请, closed 为关闭注册
+// @Tags 登录
+// @Produce json
+// @Success 200 {object} Response
+// @Router /api/v1/signup/mode [get]
+func GetRegistrationModeHandler(c *gin.Context) {
+	ResponseSuccess(c, gin.H{
+		"mode": service.RegistrationMode(),
+	})
+}
+
+// CreateInviteHandler 生成邀请码
+// @Summary 生成邀请码
+// @Description 管理员和版主生成邀请码, 用于仅限邀请注册模式
+// @Tags 邀请码
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Param invite body DTO.CreateInviteRequestDTO true "使用次数和有效天数"
+// @Success 200 {object} Response
+// @Router /api/v1/invites [post]
+func CreateInviteHandler(c *gin.Context) {
+	userID, exist := getCurrentUserID(c)
+	if !exist {
+		ResponseErrorWithCode(c, code.InvalidAuth)
+		return
+	}
+	var dto DTO.CreateInviteRequestDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		return
+	}
+	invite, apiError := service.CreateInviteService(c.Request.Context(), userID, &dto)
+	if apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		return
+	}
+	ResponseSuccess(c, invite)
+}
+
+// GetInviteListHandler 获取自己生成的邀请码
+// @Summary 邀请码列表
+// @Description 获取当前用户生成的所有邀请码及其使用情况
+// @Tags 邀请码
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Success 200 {object} Response
+// @Router /api/v1/invites [get]
+func GetInviteListHandler(c *gin.Context) {
+	userID, exist := getCurrentUserID(c)
+	if !exist {
+		ResponseErrorWithCode(c, code.InvalidAuth)
+		return
+	}
+	invites, apiError := service.GetInviteListService(c.Request.Context(), userID)
+	if apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		return
+	}
+	ResponseSuccess(c, invites)
+}
+
+// RevokeInviteHandler 作废邀请码
+// @Summary 作废邀请码
+// @Description 作废自己生成的邀请码, 已经使用该邀请码注册的用户不受影响
+// @Tags 邀请码
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "邀请码ID"
+// @Success 200 {object} Response
+// @Router /api/v1/invites/{id} [delete]
+func RevokeInviteHandler(c *gin.Context) {
+	userID, exist := getCurrentUserID(c)
+	if !exist {
+		ResponseErrorWithCode(c, code.InvalidAuth)
+		return
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithCode(c, code.InvalidParam)
+		return
+	}
+	if apiError := service.RevokeInviteService(c.Request.Context(), userID, id); apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		zap.L().Info("service.RevokeInviteService() 失败", zap.Int64("invite_id", id), zap.Error(apiError))
+		return
+	}
+	ResponseSuccess(c, nil)
+}
@@ -0,0 +1,21 @@
+package controller
+
+import (
+	"GinTalk/pkg/jwt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSHandler 公开 JWT 验签公钥
+// @Summary 获取 JWKS
+// @Description 返回所有非对称签名密钥的公钥 (RFC 7517), 对称密钥不会被公开
+// @Tags 登录
+// @Produce json
+// @Success 200 {object} jwt.JWKS
+// @Router /.well-known/jwks.json [get]
+func JWKSHandler(c *gin.Context) {
+	// JWKS 是标准格式, 不使用 Response 包装
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwt.PublicJWKS())
+}
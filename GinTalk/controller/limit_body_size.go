@@ -7,6 +7,8 @@ import (
 
 type limitBodySizeConfig struct {
 	LimitBytes int64
+	// RouteLimitBytes 按路由设置的限制, key 为注册路由时的完整路径
+	RouteLimitBytes map[string]int64
 }
 
 type limitBodySizeOption func(*limitBodySizeConfig)
@@ -15,7 +17,11 @@ type limitBodySizeOption func(*limitBodySizeConfig)
 func LimitBodySizeMiddleware(options ...limitBodySizeOption) gin.HandlerFunc {
 	cfg := newLimitBodySizeConfig(options...)
 	return func(c *gin.Context) {
-		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, cfg.LimitBytes)
+		limit, ok := cfg.RouteLimitBytes[c.FullPath()]
+		if !ok {
+			limit = cfg.LimitBytes
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
 		c.Next()
 	}
 }
@@ -27,6 +33,17 @@ func WithLimitBodySizeOption(limitBytes int64) limitBodySizeOption {
 	}
 }
 
+// WithRouteLimitBodySizeOption 为某个路由单独设置请求体大小限制, 例如上传文件的接口
+// fullPath 为注册路由时的完整路径, 如 /api/v1/upload
+func WithRouteLimitBodySizeOption(fullPath string, limitBytes int64) limitBodySizeOption {
+	return func(config *limitBodySizeConfig) {
+		if config.RouteLimitBytes == nil {
+			config.RouteLimitBytes = make(map[string]int64)
+		}
+		config.RouteLimitBytes[fullPath] = limitBytes
+	}
+}
+
 func newLimitBodySizeConfig(options ...limitBodySizeOption) *limitBodySizeConfig {
 	config := limitBodySizeConfig{
 		LimitBytes: 1024 * 1024,
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"GinTalk/DTO"
+	"GinTalk/pkg/code"
+	"GinTalk/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CreatePersonalAccessTokenHandler 创建个人访问令牌
+// @Summary 创建个人访问令牌
+// @Description 创建供机器人和第三方集成使用的令牌, 可选范围 read、post:write、comment:write, 令牌明文只返回一次
+// @Tags 个人访问令牌
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Param token body DTO.CreatePersonalAccessTokenRequestDTO true "令牌信息"
+// @Success 200 {object} Response
+// @Router /api/v1/tokens [post]
+func CreatePersonalAccessTokenHandler(c *gin.Context) {
+	userID, exist := getCurrentUserID(c)
+	if !exist {
+		ResponseErrorWithCode(c, code.InvalidAuth)
+		return
+	}
+	var dto DTO.CreatePersonalAccessTokenRequestDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		return
+	}
+	resp, apiError := service.CreatePersonalAccessTokenService(c.Request.Context(), userID, &dto)
+	if apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		zap.L().Info("service.CreatePersonalAccessTokenService() 失败", zap.Int64("user_id", userID), zap.Error(apiError))
+		return
+	}
+	ResponseSuccess(c, resp)
+}
+
+// GetPersonalAccessTokenListHandler 获取当前用户的个人访问令牌
+// @Summary 个人访问令牌列表
+// @Description 获取当前用户的所有个人访问令牌, 只返回令牌前缀, 不返回令牌明文
+// @Tags 个人访问令牌
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Success 200 {object} Response
+// @Router /api/v1/tokens [get]
+func GetPersonalAccessTokenListHandler(c *gin.Context) {
+	userID, exist := getCurrentUserID(c)
+	if !exist {
+		ResponseErrorWithCode(c, code.InvalidAuth)
+		return
+	}
+	tokens, apiError := service.GetPersonalAccessTokenListService(c.Request.Context(), userID)
+	if apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		return
+	}
+	ResponseSuccess(c, tokens)
+}
+
+// RevokePersonalAccessTokenHandler 撤销个人访问令牌
+// @Summary 撤销个人访问令牌
+// @Description 撤销当前用户的一个个人访问令牌, 撤销后立即失效
+// @Tags 个人访问令牌
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "令牌ID"
+// @Success 200 {object} Response
+// @Router /api/v1/tokens/{id} [delete]
+func RevokePersonalAccessTokenHandler(c *gin.Context) {
+	userID, exist := getCurrentUserID(c)
+	if !exist {
+		ResponseErrorWithCode(c, code.InvalidAuth)
+		return
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithCode(c, code.InvalidParam)
+		return
+	}
+	if apiError := service.RevokePersonalAccessTokenService(c.Request.Context(), userID, id); apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		zap.L().Info("service.RevokePersonalAccessTokenService() 失败", zap.Int64("token_id", id), zap.Error(apiError))
+		return
+	}
+	ResponseSuccess(c, nil)
+}
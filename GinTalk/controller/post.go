@@ -2,6 +2,7 @@ package controller
 
 import (
 	"GinTalk/DTO"
+	"GinTalk/cache"
 	"GinTalk/pkg/code"
 	"GinTalk/service"
 	"go.uber.org/zap"
@@ -48,19 +49,22 @@ func CreatePostHandler(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "
-// @Param page_num query int false "页码"
+// @Param cursor query string false "上一页返回的 next_cursor, 为空时获取第一页"
 // @Param page_size query int false "每页数量"
+// @Param order query string true "排序方式, 1 或 hot 为热度, 2 或 time 为时间, 3 或 top 为时间窗口内的投票数"
+// @Param window query string false "按投票数排序时的时间窗口: day、week、month、year 或 all, 默认为 day"
+// @Param tag query string false "只获取带有该标签的帖子, 按投票数排序时不支持"
 // @Success 200 {object} Response
 // @Router /api/v1/post [get]
 func GetPostListHandler(c *gin.Context) {
-	pageNum, pageSize := getPageInfo(c)
-	order, err := strconv.Atoi(c.Query("order"))
-	if err != nil {
+	after, pageSize := getCursorInfo(c)
+	order, ok := parseOrder(c.Query("order"))
+	if !ok {
 		ResponseBadRequest(c, "order 字段不正确")
-		zap.L().Info("GetPostListHandler strconv.Atoi() 失败", zap.Error(err))
+		zap.L().Info("GetPostListHandler parseOrder() 失败", zap.String("order", c.Query("order")))
 		return
 	}
-	postList, apiError := service.GetPostList(c.Request.Context(), pageNum, pageSize, order)
+	postList, apiError := service.GetPostList(c.Request.Context(), after, pageSize, order, c.Query("tag"), c.Query("window"))
 	if apiError != nil {
 		ResponseErrorWithApiError(c, apiError)
 		zap.L().Error("PostServiceInterface.GetPostList() 失败", zap.Error(apiError))
@@ -78,19 +82,29 @@ func GetPostListHandler(c *gin.Context) {
 // @Produce json
 // @Param Authorization header string true "
 // @Param community_id query int true "社区ID"
-// @Param page_num query int false "页码"
+// @Param cursor query string false "上一页返回的 next_cursor, 为空时获取第一页"
 // @Param page_size query int false "每页数量"
+// @Param order query string false "排序方式, 1 或 hot 为热度, 2 或 time 为时间, 默认为时间"
 // @Success 200 {object} Response
 // @Router /api/v1/post/community [get]
 func GetPostListByCommunityID(c *gin.Context) {
-	pageNum, pageSize := getPageInfo(c)
+	after, pageSize := getCursorInfo(c)
 	communityID, err := strconv.ParseInt(c.Query("community_id"), 10, 64)
 	if err != nil {
 		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
 		zap.L().Info("GetPostListByCommunityID strconv.ParseInt() 失败", zap.Error(err))
 		return
 	}
-	postList, apiError := service.GetPostListByCommunityID(c.Request.Context(), communityID, pageNum, pageSize)
+	order := cache.OrderByTime
+	if _order := c.Query("order"); _order != "" {
+		var ok bool
+		if order, ok = parseOrder(_order); !ok {
+			ResponseBadRequest(c, "order 字段不正确")
+			zap.L().Info("GetPostListByCommunityID parseOrder() 失败", zap.String("order", _order))
+			return
+		}
+	}
+	postList, apiError := service.GetPostListByCommunityID(c.Request.Context(), communityID, after, pageSize, order)
 	if apiError != nil {
 		ResponseErrorWithApiError(c, apiError)
 		zap.L().Error("PostServiceInterface.GetPostListByCommunityID() 失败", zap.Error(apiError))
@@ -107,7 +121,9 @@ func GetPostListByCommunityID(c *gin.Context) {
 // @Produce json
 // @Param Authorization header string true "
 // @Param ID path int true "帖子ID"
+// @Param If-None-Match header string false "上次返回的 ETag, 帖子及关联数据未变化时返回 304"
 // @Success 200 {object} Response
+// @Success 304 "帖子未修改"
 // @Router /api/v1/post/{ID} [get]
 func GetPostDetailHandler(c *gin.Context) {
 	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
@@ -117,15 +133,47 @@ func GetPostDetailHandler(c *gin.Context) {
 		return
 	}
 
-	post, apiError := service.GetPostDetail(c.Request.Context(), postID)
+	// 未登录时 viewerID 为 0, 只能查看已发布的帖子
+	viewerID, _ := getCurrentUserID(c)
+	post, apiError := service.GetPostDetail(c.Request.Context(), postID, viewerID)
 	if apiError != nil {
 		ResponseErrorWithApiError(c, apiError)
 		zap.L().Error("PostServiceInterface.GetPostDetail() 失败", zap.Error(apiError))
 		return
 	}
+	if post.PostID != 0 && writeETag(c, post.Version, post) {
+		return
+	}
 	ResponseSuccess(c, post)
 }
 
+// GetDraftListHandler 获取当前用户的草稿和定时发布帖子
+// @Summary 获取草稿列表
+// @Description 获取当前用户尚未发布的草稿和定时发布帖子
+// @Tags 帖子
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "
+// @Param page_num query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} Response
+// @Router /api/v1/post/drafts [get]
+func GetDraftListHandler(c *gin.Context) {
+	userID, exist := getCurrentUserID(c)
+	if !exist {
+		ResponseErrorWithCode(c, code.InvalidAuth)
+		return
+	}
+	pageNum, pageSize := getPageInfo(c)
+	list, apiError := service.GetDraftList(c.Request.Context(), userID, pageNum, pageSize)
+	if apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		zap.L().Error("service.GetDraftList() 失败", zap.Error(apiError))
+		return
+	}
+	ResponseSuccess(c, list)
+}
+
 // UpdatePostHandler 更新帖子
 // @Summary 更新帖子
 // @Description 更新帖子
@@ -133,8 +181,10 @@ func GetPostDetailHandler(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "
+// @Param If-Match header string false "帖子详情返回的强 ETag, 其中的版本号与当前版本不一致时返回 412"
 // @Param post body DTO.PostDetail true "帖子信息"
 // @Success 200 {object} Response
+// @Failure 412 {object} Response
 // @Router /api/v1/post [put]
 func UpdatePostHandler(c *gin.Context) {
 	var post DTO.PostDetail
@@ -148,7 +198,7 @@ func UpdatePostHandler(c *gin.Context) {
 		zap.L().Info("UpdatePostHandler.isUserIDMatch() 失败")
 		return
 	}
-	if apiError := service.UpdatePost(c.Request.Context(), &post); apiError != nil {
+	if apiError := service.UpdatePost(c.Request.Context(), &post, ifMatchVersions(c)); apiError != nil {
 		ResponseErrorWithApiError(c, apiError)
 		zap.L().Error("PostServiceInterface.UpdatePost() 失败", zap.Error(apiError))
 		return
@@ -169,7 +219,7 @@ func DeletePostHandler(c *gin.Context) {
 		return
 	}
 
-	if apiError := service.DeletePost(c.Request.Context(), p.PostID); apiError != nil {
+	if apiError := service.DeletePost(c.Request.Context(), p.PostID, p.UserID); apiError != nil {
 		ResponseErrorWithApiError(c, apiError)
 		zap.L().Error("PostServiceInterface.DeletePost() 失败", zap.Error(apiError))
 		return
@@ -181,23 +231,45 @@ func DeletePostHandler(c *gin.Context) {
 func getPageInfo(c *gin.Context) (pageNum int, pageSize int) {
 	var err error
 	_n := c.Query("page_num")
-	_s := c.Query("page_size")
 	if _n == "" {
 		_n = c.Query("pageNum")
 	}
-	if _s == "" {
-		_s = c.Query("pageSize")
-	}
 	pageNum, err = strconv.Atoi(_n)
 	if err != nil || pageNum <= 0 {
 		pageNum = 1
 	}
-	pageSize, err = strconv.Atoi(_s)
+	return pageNum, getPageSize(c)
+}
+
+// parseOrder 解析帖子列表的排序方式, 支持数字和名称两种写法
+func parseOrder(order string) (int, bool) {
+	switch order {
+	case "1", "hot":
+		return cache.OrderByHot, true
+	case "2", "time", "new":
+		return cache.OrderByTime, true
+	case "3", "top":
+		return cache.OrderByTop, true
+	}
+	return 0, false
+}
+
+// getCursorInfo 获取游标分页的参数, cursor 为上一页返回的 next_cursor, 为空时获取第一页
+func getCursorInfo(c *gin.Context) (cursor string, pageSize int) {
+	return c.Query("cursor"), getPageSize(c)
+}
+
+func getPageSize(c *gin.Context) int {
+	_s := c.Query("page_size")
+	if _s == "" {
+		_s = c.Query("pageSize")
+	}
+	pageSize, err := strconv.Atoi(_s)
 	if err != nil || pageSize <= 0 {
 		pageSize = 10
 	}
 	if pageSize > 100 {
 		pageSize = 20
 	}
-	return pageNum, pageSize
+	return pageSize
 }
@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"GinTalk/pkg/code"
+	"GinTalk/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// parseRevision 解析版本号参数, 版本号从 1 开始
+func parseRevision(s string) (int32, bool) {
+	revision, err := strconv.ParseInt(s, 10, 32)
+	if err != nil || revision <= 0 {
+		return 0, false
+	}
+	return int32(revision), true
+}
+
+// GetPostRevisionsHandler 获取帖子的版本列表
+// @Summary 获取帖子的版本列表
+// @Description 获取帖子的所有版本, 按版本号倒序排列, 不包含内容
+// @Tags 帖子
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "帖子ID"
+// @Success 200 {object} Response
+// @Router /api/v1/post/{id}/revisions [get]
+func GetPostRevisionsHandler(c *gin.Context) {
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithCode(c, code.InvalidParam)
+		return
+	}
+	viewerID, _ := getCurrentUserID(c)
+	revisions, apiError := service.GetPostRevisions(c.Request.Context(), postID, viewerID)
+	if apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		zap.L().Info("service.GetPostRevisions() 失败", zap.Int64("post_id", postID), zap.Error(apiError))
+		return
+	}
+	ResponseSuccess(c, revisions)
+}
+
+// GetPostRevisionHandler 获取帖子某个版本的内容
+// @Summary 获取帖子某个版本的内容
+// @Description 获取帖子某个版本的标题和内容
+// @Tags 帖子
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "帖子ID"
+// @Param rev path int true "版本号"
+// @Success 200 {object} Response
+// @Router /api/v1/post/{id}/revisions/{rev} [get]
+func GetPostRevisionHandler(c *gin.Context) {
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithCode(c, code.InvalidParam)
+		return
+	}
+	revision, ok := parseRevision(c.Param("rev"))
+	if !ok {
+		ResponseErrorWithCode(c, code.InvalidParam)
+		return
+	}
+	viewerID, _ := getCurrentUserID(c)
+	rev, apiError := service.GetPostRevision(c.Request.Context(), postID, revision, viewerID)
+	if apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		zap.L().Info("service.GetPostRevision() 失败", zap.Int64("post_id", postID), zap.Error(apiError))
+		return
+	}
+	ResponseSuccess(c, rev)
+}
+
+// DiffPostRevisionsHandler 比较帖子的两个版本
+// @Summary 比较帖子的两个版本
+// @Description 计算帖子两个版本内容之间按行的差异
+// @Tags 帖子
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "帖子ID"
+// @Param from query int true "旧版本号"
+// @Param to query int true "新版本号"
+// @Success 200 {object} Response
+// @Router /api/v1/post/{id}/revisions/diff [get]
+func DiffPostRevisionsHandler(c *gin.Context) {
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithCode(c, code.InvalidParam)
+		return
+	}
+	from, ok := parseRevision(c.Query("from"))
+	if !ok {
+		ResponseErrorWithMsg(c, code.InvalidParam, "from 字段不正确")
+		return
+	}
+	to, ok := parseRevision(c.Query("to"))
+	if !ok {
+		ResponseErrorWithMsg(c, code.InvalidParam, "to 字段不正确")
+		return
+	}
+	viewerID, _ := getCurrentUserID(c)
+	result, apiError := service.DiffPostRevisions(c.Request.Context(), postID, from, to, viewerID)
+	if apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		zap.L().Info("service.DiffPostRevisions() 失败", zap.Int64("post_id", postID), zap.Error(apiError))
+		return
+	}
+	ResponseSuccess(c, result)
+}
+
+// RestorePostRevisionHandler 把帖子恢复到以前的版本
+// @Summary 恢复帖子版本
+// @Description 把帖子的标题和内容恢复到以前的版本, 恢复后保存为新的版本, 作者本人和版主可以操作
+// @Tags 帖子
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "帖子ID"
+// @Param rev path int true "版本号"
+// @Success 200 {object} Response
+// @Router /api/v1/post/{id}/revisions/{rev}/restore [post]
+func RestorePostRevisionHandler(c *gin.Context) {
+	userID, exist := getCurrentUserID(c)
+	if !exist {
+		ResponseErrorWithCode(c, code.InvalidAuth)
+		return
+	}
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithCode(c, code.InvalidParam)
+		return
+	}
+	revision, ok := parseRevision(c.Param("rev"))
+	if !ok {
+		ResponseErrorWithCode(c, code.InvalidParam)
+		return
+	}
+	if apiError := service.RestorePostRevision(c.Request.Context(), postID, revision, userID); apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		zap.L().Info("service.RestorePostRevision() 失败", zap.Int64("post_id", postID), zap.Error(apiError))
+		return
+	}
+	ResponseSuccess(c, nil)
+}
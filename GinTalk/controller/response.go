@@ -3,7 +3,9 @@ package controller
 import (
 	"GinTalk/pkg/apiError"
 	"GinTalk/pkg/code"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -52,6 +54,12 @@ func ResponseErrorWithMsg(c *gin.Context, respCode code.RespCode, msg string) {
 	case code.InvalidAuth:
 		ResponseUnAuthorized(c, msg)
 		return
+	case code.EmailNotVerified, code.PermissionDenied, code.UserBanned, code.TokenScopeDenied, code.RegistrationClosed:
+		ResponseForbidden(c, respCode, msg)
+		return
+	case code.PreconditionFailed:
+		ResponsePreconditionFailed(c, msg)
+		return
 	case code.TimeOut:
 		ResponseTimeout(c, msg)
 		return
@@ -69,13 +77,31 @@ func ResponseErrorWithMsg(c *gin.Context, respCode code.RespCode, msg string) {
 }
 
 func ResponseErrorWithApiError(c *gin.Context, apiError *apiError.ApiError) {
+	if apiError.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.FormatInt(int64(math.Ceil(apiError.RetryAfter.Seconds())), 10))
+	}
 	switch apiError.Code {
+	case code.LoginLocked:
+		ResponseTooManyRequests(c, apiError.Code, apiError.Msg)
+		return
 	case code.InvalidParam:
 		ResponseBadRequest(c, apiError.Msg)
 		return
 	case code.InvalidAuth:
 		ResponseUnAuthorized(c, apiError.Msg)
 		return
+	case code.EmailNotVerified, code.PermissionDenied, code.UserBanned, code.TokenScopeDenied, code.RegistrationClosed:
+		ResponseForbidden(c, apiError.Code, apiError.Msg)
+		return
+	case code.PreconditionFailed:
+		ResponsePreconditionFailed(c, apiError.Msg)
+		return
+	case code.FileTooLarge:
+		ResponseRequestEntityTooLarge(c, apiError.Msg)
+		return
+	case code.UnsupportedFileType:
+		ResponseUnsupportedMediaType(c, apiError.Msg)
+		return
 	case code.TimeOut:
 		ResponseTimeout(c, apiError.Msg)
 		return
@@ -129,6 +155,56 @@ func ResponseUnAuthorized(c *gin.Context, msg string) {
 	})
 }
 
+// ResponseForbidden 已认证但无权执行该操作
+// 返回 403 状态码
+func ResponseForbidden(c *gin.Context, respCode code.RespCode, msg string) {
+	c.JSON(http.StatusForbidden, Response{
+		Code: respCode,
+		Msg:  msg,
+		Data: nil,
+	})
+}
+
+// ResponseTooManyRequests 请求过于频繁
+// 返回 429 状态码
+func ResponseTooManyRequests(c *gin.Context, respCode code.RespCode, msg string) {
+	c.JSON(http.StatusTooManyRequests, Response{
+		Code: respCode,
+		Msg:  msg,
+		Data: nil,
+	})
+}
+
+// ResponsePreconditionFailed 条件请求的前提条件不满足, 例如 If-Match 与当前版本不一致
+// 返回 412 状态码
+func ResponsePreconditionFailed(c *gin.Context, msg string) {
+	c.JSON(http.StatusPreconditionFailed, Response{
+		Code: code.PreconditionFailed,
+		Msg:  msg,
+		Data: nil,
+	})
+}
+
+// ResponseRequestEntityTooLarge 上传的文件超过大小限制
+// 返回 413 状态码
+func ResponseRequestEntityTooLarge(c *gin.Context, msg string) {
+	c.JSON(http.StatusRequestEntityTooLarge, Response{
+		Code: code.FileTooLarge,
+		Msg:  msg,
+		Data: nil,
+	})
+}
+
+// ResponseUnsupportedMediaType 上传的文件类型不在允许的范围内
+// 返回 415 状态码
+func ResponseUnsupportedMediaType(c *gin.Context, msg string) {
+	c.JSON(http.StatusUnsupportedMediaType, Response{
+		Code: code.UnsupportedFileType,
+		Msg:  msg,
+		Data: nil,
+	})
+}
+
 func ResponseTimeout(c *gin.Context, msg string) {
 	c.JSON(http.StatusRequestTimeout, Response{
 		Code: code.TimeOut,
@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"GinTalk/pkg/code"
+	"GinTalk/service"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// SearchHandler 搜索帖子和评论
+// @Summary 搜索
+// @Description 按相关度搜索帖子和评论, 结果中的标题和摘要已经过 HTML 转义, 命中的词用 <em> 标签包裹
+// @Tags 搜索
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "
+// @Param q query string true "搜索词"
+// @Param type query string false "post 或 comment, 为空时同时搜索帖子和评论"
+// @Param community_id query int false "社区ID, 为空时不限制社区"
+// @Param page_num query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} Response
+// @Router /api/v1/search [get]
+func SearchHandler(c *gin.Context) {
+	pageNum, pageSize := getPageInfo(c)
+	var communityID int64
+	if s := c.Query("community_id"); s != "" {
+		var err error
+		communityID, err = strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			ResponseErrorWithMsg(c, code.InvalidParam, "community_id 格式错误")
+			return
+		}
+	}
+	q := strings.TrimSpace(c.Query("q"))
+	result, apiError := service.Search(c.Request.Context(), q, c.Query("type"), communityID, pageNum, pageSize)
+	if apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		zap.L().Info("service.Search() 失败", zap.Error(apiError))
+		return
+	}
+	ResponseSuccess(c, result)
+}
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"GinTalk/pkg/code"
+	"GinTalk/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetSessionListHandler 获取当前用户登录的所有设备
+// @Summary 会话列表
+// @Description 获取当前用户登录的所有设备, 包括设备名称、IP、User-Agent、登录时间和最后使用时间
+// @Tags 会话
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Success 200 {object} Response
+// @Router /api/v1/sessions [get]
+func GetSessionListHandler(c *gin.Context) {
+	userID, exist := getCurrentUserID(c)
+	if !exist {
+		ResponseErrorWithCode(c, code.InvalidAuth)
+		return
+	}
+	sessions, apiError := service.GetSessionList(c.Request.Context(), userID, c.GetString(ContextSessionIDKey))
+	if apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		return
+	}
+	ResponseSuccess(c, sessions)
+}
+
+// RevokeSessionHandler 退出指定设备
+// @Summary 移除会话
+// @Description 移除当前用户的一个会话, 该设备需要重新登录
+// @Tags 会话
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Param id path string true "会话ID"
+// @Success 200 {object} Response
+// @Router /api/v1/sessions/{id} [delete]
+func RevokeSessionHandler(c *gin.Context) {
+	userID, exist := getCurrentUserID(c)
+	if !exist {
+		ResponseErrorWithCode(c, code.InvalidAuth)
+		return
+	}
+	sessionID := c.Param("id")
+	if apiError := service.RevokeSession(c.Request.Context(), userID, sessionID); apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		zap.L().Info("service.RevokeSession() 失败", zap.String("session_id", sessionID), zap.Error(apiError))
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// RevokeOtherSessionsHandler 退出除当前设备以外的所有设备
+// @Summary 退出其他设备
+// @Description 移除当前用户除当前会话以外的所有会话
+// @Tags 会话
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Success 200 {object} Response
+// @Router /api/v1/sessions [delete]
+func RevokeOtherSessionsHandler(c *gin.Context) {
+	userID, exist := getCurrentUserID(c)
+	if !exist {
+		ResponseErrorWithCode(c, code.InvalidAuth)
+		return
+	}
+	count, apiError := service.RevokeOtherSessions(c.Request.Context(), userID, c.GetString(ContextSessionIDKey))
+	if apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		return
+	}
+	ResponseSuccess(c, gin.H{
+		"revoked": count,
+	})
+}
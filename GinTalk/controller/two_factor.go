@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"GinTalk/DTO"
+	"GinTalk/pkg/code"
+	"GinTalk/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// LoginTwoFactorHandler 两步验证登录
+// @Summary 两步验证登录
+// @Description 使用登录接口返回的挑战令牌和 TOTP 验证码 (或恢复码) 完成登录
+// @Tags 登录
+// @Accept json
+// @Produce json
+// @Param challenge_token body string true "登录挑战令牌"
+// @Param code body string true "验证码或恢复码"
+// @Param device body string false "设备名称"
+// @Success 200 {object} Response
+// @Router /api/v1/login/2fa [post]
+func LoginTwoFactorHandler(c *gin.Context) {
+	var dto DTO.LoginTwoFactorRequestDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		return
+	}
+	dto.IP = c.ClientIP()
+	dto.UserAgent = c.Request.UserAgent()
+
+	resp, apiError := service.LoginTwoFactorService(c.Request.Context(), &dto)
+	if apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		zap.L().Info("service.LoginTwoFactorService() 失败", zap.Error(apiError))
+		return
+	}
+	ResponseSuccess(c, resp)
+}
+
+// EnrollTwoFactorHandler 设置两步验证
+// @Summary 设置两步验证
+// @Description 生成 TOTP 密钥和 otpauth URI, 使用验证码确认后才会启用
+// @Tags 两步验证
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Success 200 {object} Response
+// @Router /api/v1/2fa/enroll [post]
+func EnrollTwoFactorHandler(c *gin.Context) {
+	userID, exist := getCurrentUserID(c)
+	if !exist {
+		ResponseErrorWithCode(c, code.InvalidAuth)
+		return
+	}
+	resp, apiError := service.EnrollTwoFactorService(c.Request.Context(), userID)
+	if apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		return
+	}
+	ResponseSuccess(c, resp)
+}
+
+// ConfirmTwoFactorHandler 启用两步验证
+// @Summary 启用两步验证
+// @Description 使用验证器生成的验证码确认并启用两步验证, 返回一次性恢复码
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Param code body string true "验证码"
+// @Success 200 {object} Response
+// @Router /api/v1/2fa/confirm [post]
+func ConfirmTwoFactorHandler(c *gin.Context) {
+	userID, exist := getCurrentUserID(c)
+	if !exist {
+		ResponseErrorWithCode(c, code.InvalidAuth)
+		return
+	}
+	var dto DTO.TwoFactorConfirmRequestDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		return
+	}
+	resp, apiError := service.ConfirmTwoFactorService(c.Request.Context(), userID, dto.Code)
+	if apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		return
+	}
+	ResponseSuccess(c, resp)
+}
+
+// DisableTwoFactorHandler 关闭两步验证
+// @Summary 关闭两步验证
+// @Description 关闭两步验证, 需要重新输入密码, 密码错误次数与登录共用失败计数, 过多时暂时锁定
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Param password body string true "密码"
+// @Success 200 {object} Response
+// @Router /api/v1/2fa/disable [post]
+func DisableTwoFactorHandler(c *gin.Context) {
+	userID, exist := getCurrentUserID(c)
+	if !exist {
+		ResponseErrorWithCode(c, code.InvalidAuth)
+		return
+	}
+	var dto DTO.TwoFactorDisableRequestDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		return
+	}
+	dto.IP = c.ClientIP()
+	if apiError := service.DisableTwoFactorService(c.Request.Context(), userID, &dto); apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
@@ -4,7 +4,6 @@ import (
 	"GinTalk/DTO"
 	"GinTalk/pkg/code"
 	"GinTalk/service"
-	"context"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -18,6 +17,9 @@ import (
 // @Produce json
 // @Param username body string true "用户名"
 // @Param password body string true "密码"
+// @Param device body string false "设备名称"
+// @Param captcha_id body string false "验证码ID, 登录失败多次后必填"
+// @Param captcha_answer body string false "验证码答案, 登录失败多次后必填"
 // @Success 200 {object} Response
 // @Router /api/v1/login [post]
 func LoginHandler(c *gin.Context) {
@@ -28,6 +30,8 @@ func LoginHandler(c *gin.Context) {
 		return
 	}
 
+	loginDTO.IP = c.ClientIP()
+	loginDTO.UserAgent = c.Request.UserAgent()
 	ctx := c.Request.Context()
 
 	resp, apiError := service.LoginService(ctx, &loginDTO)
@@ -50,6 +54,9 @@ func LoginHandler(c *gin.Context) {
 // @Param password body string true "密码"
 // @Param email body string true "邮箱"
 // @Param gender body string true "性别"
+// @Param invite_code body string false "邀请码, 仅限邀请注册模式下必填"
+// @Param captcha_id body string false "验证码ID, 开启验证码时必填"
+// @Param captcha_answer body string false "验证码答案, 开启验证码时必填"
 // @Success 200 {object} Response
 // @Router /api/v1/signup [post]
 func SignUpHandler(c *gin.Context) {
@@ -87,12 +94,6 @@ func RefreshHandler(c *gin.Context) {
 		zap.L().Error("AuthServiceInterface.RefreshTokenService() 失败", zap.Error(apiError))
 		return
 	}
-	go func() {
-		err := service.LogoutService(context.Background(), oldRefreshToken)
-		if err != nil {
-			zap.L().Error("refresh token logout failed", zap.Error(err))
-		}
-	}()
 	ResponseSuccess(c, gin.H{
 		"access_token":  accessToken,
 		"refresh_token": refreshToken,
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"GinTalk/DTO"
+	"GinTalk/pkg/code"
+	"GinTalk/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetUserProfileHandler 获取用户主页
+// @Summary 用户主页
+// @Description 获取用户的公开资料和帖子数、评论数、声望 (所有帖子和评论的赞数减去踩数), 查看自己的主页时额外返回邮箱
+// @Tags 用户
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "用户ID"
+// @Success 200 {object} Response
+// @Router /api/v1/user/{id} [get]
+func GetUserProfileHandler(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithCode(c, code.InvalidParam)
+		return
+	}
+	viewerID, _ := getCurrentUserID(c)
+	profile, apiError := service.GetUserProfileService(c.Request.Context(), userID, viewerID)
+	if apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		return
+	}
+	ResponseSuccess(c, profile)
+}
+
+// UpdateProfileHandler 更新当前用户的资料
+// @Summary 更新资料
+// @Description 更新简介、性别、邮箱和头像, 未传的字段不修改; 修改邮箱后需要重新验证
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization"
+// @Param profile body DTO.UpdateProfileRequestDTO true "用户资料"
+// @Success 200 {object} Response
+// @Router /api/v1/user/me [put]
+func UpdateProfileHandler(c *gin.Context) {
+	userID, exist := getCurrentUserID(c)
+	if !exist {
+		ResponseErrorWithCode(c, code.InvalidAuth)
+		return
+	}
+	var dto DTO.UpdateProfileRequestDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		return
+	}
+	if apiError := service.UpdateProfileService(c.Request.Context(), userID, &dto); apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		zap.L().Error("service.UpdateProfileService() 失败", zap.Error(apiError))
+		return
+	}
+	ResponseSuccess(c, nil)
+}
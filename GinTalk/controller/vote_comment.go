@@ -10,6 +10,7 @@ import (
 
 // VoteCommentController 投票评论
 // @Summary 投票评论
+// @Description 对评论投赞或踩, 已经投过票时改为新的投票值; user_id 必须是当前登录的用户
 // @Tags 评论
 // @Accept json
 // @Produce json
@@ -22,7 +23,14 @@ func VoteCommentController(c *gin.Context) {
 		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
 		return
 	}
-	if apiError := service.VoteComment(voteComment.UserID, voteComment.CommentID); apiError != nil {
+	if !isUserIDMatch(c, voteComment.UserID) {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "无权限操作")
+		return
+	}
+	if voteComment.Vote == 0 {
+		voteComment.Vote = 1
+	}
+	if apiError := service.VoteComment(voteComment.UserID, voteComment.CommentID, voteComment.Vote); apiError != nil {
 		ResponseErrorWithApiError(c, apiError)
 		return
 	}
@@ -43,6 +51,10 @@ func RemoveVoteCommentController(c *gin.Context) {
 		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
 		return
 	}
+	if !isUserIDMatch(c, voteComment.UserID) {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "无权限操作")
+		return
+	}
 	if apiError := service.RemoveVoteComment(voteComment.UserID, voteComment.CommentID); apiError != nil {
 		ResponseErrorWithApiError(c, apiError)
 		return
@@ -11,7 +11,7 @@ import (
 
 // VotePostHandler 投票
 // @Summary 投票
-// @Description 投票
+// @Description 对帖子投赞或踩, 已经投过票时改为新的投票值; user_id 必须是当前登录的用户
 // @Tags 投票
 // @Accept json
 // @Produce json
@@ -25,7 +25,14 @@ func VotePostHandler(c *gin.Context) {
 		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
 		return
 	}
-	if apiError := service.VotePost(c.Request.Context(), vote.PostID, vote.UserID); apiError != nil {
+	if !isUserIDMatch(c, vote.UserID) {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "无权限操作")
+		return
+	}
+	if vote.Vote == 0 {
+		vote.Vote = 1
+	}
+	if apiError := service.VotePost(c.Request.Context(), vote.PostID, vote.UserID, vote.Vote); apiError != nil {
 		ResponseErrorWithApiError(c, apiError)
 		return
 	}
@@ -48,6 +55,10 @@ func RevokeVoteHandler(c *gin.Context) {
 		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
 		return
 	}
+	if !isUserIDMatch(c, vote.UserID) {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "无权限操作")
+		return
+	}
 	if apiError := service.RevokeVotePost(c.Request.Context(), vote.PostID, vote.UserID); apiError != nil {
 		ResponseErrorWithApiError(c, apiError)
 		return
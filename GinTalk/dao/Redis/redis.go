@@ -55,3 +55,9 @@ func GetRedisClient() *redis.Client {
 		})
 	return redisClient
 }
+
+// SetRedisClient 替换 Redis 连接而不根据配置初始化, 用于测试中连接 redistest 等临时服务
+func SetRedisClient(client *redis.Client) {
+	once.Do(func() {})
+	redisClient = client
+}
@@ -0,0 +1,124 @@
+package dao
+
+import (
+	"GinTalk/dao/MySQL"
+	"GinTalk/model"
+	"context"
+	"time"
+)
+
+// CountUserDataRows 统计导出用户数据需要读取的行数, 用于判断是否需要在后台导出
+func CountUserDataRows(ctx context.Context, userID int64) (int64, error) {
+	var count int64
+	sqlStr := `
+		SELECT (SELECT COUNT(*) FROM post WHERE author_id = ?)
+		     + (SELECT COUNT(*) FROM comment WHERE author_id = ?)
+		     + (SELECT COUNT(*) FROM vote_post WHERE user_id = ?)
+		     + (SELECT COUNT(*) FROM vote_comment WHERE user_id = ?)`
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, userID, userID, userID, userID).Scan(&count).Error
+	return count, err
+}
+
+// GetUserPostsForExport 获取用户的所有帖子, 包括已删除的帖子
+func GetUserPostsForExport(ctx context.Context, userID int64) ([]model.Post, error) {
+	var posts []model.Post
+	sqlStr := `SELECT * FROM post WHERE author_id = ? ORDER BY id`
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, userID).Scan(&posts).Error
+	return posts, err
+}
+
+// GetUserPostContentsForExport 获取用户所有帖子的正文, 包括已删除的帖子
+func GetUserPostContentsForExport(ctx context.Context, userID int64) ([]model.PostContent, error) {
+	var contents []model.PostContent
+	sqlStr := `
+		SELECT pc.* FROM post_content pc
+		JOIN post p ON p.post_id = pc.post_id
+		WHERE p.author_id = ?
+		ORDER BY p.id`
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, userID).Scan(&contents).Error
+	return contents, err
+}
+
+// GetUserCommentsForExport 获取用户的所有评论, 包括已删除的评论
+func GetUserCommentsForExport(ctx context.Context, userID int64) ([]model.Comment, error) {
+	var comments []model.Comment
+	sqlStr := `SELECT * FROM comment WHERE author_id = ? ORDER BY id`
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, userID).Scan(&comments).Error
+	return comments, err
+}
+
+// GetUserPostVotesForExport 获取用户对帖子的所有投票记录
+func GetUserPostVotesForExport(ctx context.Context, userID int64) ([]model.VotePost, error) {
+	var votes []model.VotePost
+	sqlStr := `SELECT * FROM vote_post WHERE user_id = ? ORDER BY id`
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, userID).Scan(&votes).Error
+	return votes, err
+}
+
+// GetUserCommentVotesForExport 获取用户对评论的所有投票记录
+func GetUserCommentVotesForExport(ctx context.Context, userID int64) ([]model.VoteComment, error) {
+	var votes []model.VoteComment
+	sqlStr := `SELECT * FROM vote_comment WHERE user_id = ? ORDER BY id`
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, userID).Scan(&votes).Error
+	return votes, err
+}
+
+// DeleteUserAccount 注销用户
+// 逻辑删除用户并清除邮箱、头像、简介和密码, 用户名改为 anonymousName 加用户 ID, 评论中的作者名替换为 anonymousName,
+// 删除用户的令牌、两步验证和版主任命; removeContent 为 true 时同时删除用户的帖子和评论
+//
+// 返回:
+//   - []int64: 用户的帖子 ID, 用于清理缓存; removeContent 为 true 时这些帖子已被删除。
+//   - bool: 是否注销成功, 用户不存在或已注销时返回 false。
+//   - error: 如果操作失败，则返回错误对象，否则返回 nil。
+func DeleteUserAccount(ctx context.Context, userID int64, anonymousName string, removeContent bool) ([]int64, bool, error) {
+	now := time.Now().Unix()
+	tx := MySQL.GetDB().WithContext(ctx).Begin()
+
+	// 帖子查询通过 user 表获取作者名, 用户名也要替换, 加上用户 ID 避免与同一时间注销的其他用户冲突
+	result := tx.Exec(`UPDATE user SET delete_time = ?, username = CONCAT(?, '_', user_id), email = NULL, avatar = '', bio = '', password = ''
+                       WHERE user_id = ? AND delete_time = 0`, now, anonymousName, userID)
+	if result.Error != nil {
+		tx.Rollback()
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return nil, false, nil
+	}
+
+	type statement struct {
+		sql  string
+		args []any
+	}
+	var postIDs []int64
+	statements := []statement{
+		{`UPDATE comment SET author_name = ? WHERE author_id = ?`, []any{anonymousName, userID}},
+		{`DELETE FROM personal_access_token WHERE user_id = ?`, []any{userID}},
+		{`DELETE FROM user_two_factor WHERE user_id = ?`, []any{userID}},
+		{`DELETE FROM user_recovery_code WHERE user_id = ?`, []any{userID}},
+		{`DELETE FROM community_moderator WHERE user_id = ?`, []any{userID}},
+	}
+	err := tx.Raw(`SELECT post_id FROM post WHERE author_id = ? AND delete_time = 0`, userID).Scan(&postIDs).Error
+	if err != nil {
+		tx.Rollback()
+		return nil, false, err
+	}
+	if removeContent {
+		statements = append(statements, []statement{
+			{`UPDATE post_content pc JOIN post p ON p.post_id = pc.post_id
+              SET pc.delete_time = ? WHERE p.author_id = ? AND p.delete_time = 0 AND pc.delete_time = 0`, []any{now, userID}},
+			{`UPDATE post SET delete_time = ? WHERE author_id = ? AND delete_time = 0`, []any{now, userID}},
+			{`UPDATE comment_relation cr JOIN comment c ON c.comment_id = cr.comment_id
+              SET cr.delete_time = ? WHERE c.author_id = ? AND c.delete_time = 0 AND cr.delete_time = 0`, []any{now, userID}},
+			{`UPDATE comment SET delete_time = ? WHERE author_id = ? AND delete_time = 0`, []any{now, userID}},
+		}...)
+	}
+	for _, stmt := range statements {
+		if err := tx.Exec(stmt.sql, stmt.args...).Error; err != nil {
+			tx.Rollback()
+			return nil, false, err
+		}
+	}
+	return postIDs, true, tx.Commit().Error
+}
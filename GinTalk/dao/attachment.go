@@ -0,0 +1,146 @@
+package dao
+
+import (
+	"GinTalk/dao/MySQL"
+	"GinTalk/model"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CreateAttachment 保存上传的附件, 附件在被帖子或评论引用之前不属于任何内容
+func CreateAttachment(ctx context.Context, attachment *model.Attachment) error {
+	sqlStr := `
+		INSERT INTO attachment (attachment_id, uploader_id, file_name, content_type, size, width, height, storage_key, thumbnail_key)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	return MySQL.GetDB().WithContext(ctx).Exec(sqlStr, attachment.AttachmentID, attachment.UploaderID, attachment.FileName,
+		attachment.ContentType, attachment.Size, attachment.Width, attachment.Height, attachment.StorageKey, attachment.ThumbnailKey).Error
+}
+
+// GetAttachment 根据附件 ID 获取附件, 附件不存在时返回 nil
+func GetAttachment(ctx context.Context, attachmentID int64) (*model.Attachment, error) {
+	var attachments []model.Attachment
+	sqlStr := `SELECT * FROM attachment WHERE attachment_id = ?`
+	if err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, attachmentID).Scan(&attachments).Error; err != nil {
+		return nil, err
+	}
+	if len(attachments) == 0 {
+		return nil, nil
+	}
+	return &attachments[0], nil
+}
+
+// GetAttachmentsByPostIDs 批量获取帖子引用的附件, 返回帖子 ID 到附件列表的映射, 附件按上传顺序排列
+func GetAttachmentsByPostIDs(ctx context.Context, postIDs []int64) (map[int64][]model.Attachment, error) {
+	result := make(map[int64][]model.Attachment, len(postIDs))
+	if len(postIDs) == 0 {
+		return result, nil
+	}
+	var attachments []model.Attachment
+	sqlStr := `SELECT * FROM attachment WHERE post_id IN (?) ORDER BY id`
+	if err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, postIDs).Scan(&attachments).Error; err != nil {
+		return nil, err
+	}
+	for _, attachment := range attachments {
+		result[attachment.PostID] = append(result[attachment.PostID], attachment)
+	}
+	return result, nil
+}
+
+// GetAttachmentsByCommentIDs 批量获取评论引用的附件, 返回评论 ID 到附件列表的映射, 附件按上传顺序排列
+func GetAttachmentsByCommentIDs(ctx context.Context, commentIDs []int64) (map[int64][]model.Attachment, error) {
+	result := make(map[int64][]model.Attachment, len(commentIDs))
+	if len(commentIDs) == 0 {
+		return result, nil
+	}
+	var attachments []model.Attachment
+	sqlStr := `SELECT * FROM attachment WHERE comment_id IN (?) ORDER BY id`
+	if err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, commentIDs).Scan(&attachments).Error; err != nil {
+		return nil, err
+	}
+	for _, attachment := range attachments {
+		result[attachment.CommentID] = append(result[attachment.CommentID], attachment)
+	}
+	return result, nil
+}
+
+// CountUsableAttachments 统计 attachmentIDs 中可以被帖子 postID 或评论 commentID 引用的附件数量
+// 可以引用的附件为 uploaderID 上传的尚未被引用的附件, 以及已经被该帖子或评论引用的附件
+func CountUsableAttachments(ctx context.Context, attachmentIDs []int64, uploaderID int64, postID int64, commentID int64) (int64, error) {
+	var count int64
+	sqlStr := `
+		SELECT COUNT(*) FROM attachment
+		WHERE attachment_id IN (?)
+			AND ((uploader_id = ? AND post_id = 0 AND comment_id = 0) OR (? != 0 AND post_id = ?) OR (? != 0 AND comment_id = ?))`
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, attachmentIDs, uploaderID, postID, postID, commentID, commentID).Scan(&count).Error
+	return count, err
+}
+
+// bindPostAttachments 在事务中把 uploaderID 上传的尚未被引用的附件关联到帖子
+// replace 为 true 时同时解除帖子与不在 attachmentIDs 中的附件的关联, 解除关联的附件之后由清理任务删除
+func bindPostAttachments(tx *gorm.DB, postID int64, uploaderID int64, attachmentIDs []int64, replace bool) error {
+	if replace {
+		sqlStr := `UPDATE attachment SET post_id = 0 WHERE post_id = ?`
+		args := []any{postID}
+		if len(attachmentIDs) > 0 {
+			sqlStr += ` AND attachment_id NOT IN (?)`
+			args = append(args, attachmentIDs)
+		}
+		if err := tx.Exec(sqlStr, args...).Error; err != nil {
+			return err
+		}
+	}
+	if len(attachmentIDs) == 0 {
+		return nil
+	}
+	sqlStr := `
+		UPDATE attachment SET post_id = ?
+		WHERE attachment_id IN (?) AND uploader_id = ? AND post_id = 0 AND comment_id = 0`
+	return tx.Exec(sqlStr, postID, attachmentIDs, uploaderID).Error
+}
+
+// bindCommentAttachments 在事务中把附件关联到评论, 规则与 bindPostAttachments 相同
+func bindCommentAttachments(tx *gorm.DB, commentID int64, uploaderID int64, attachmentIDs []int64, replace bool) error {
+	if replace {
+		sqlStr := `UPDATE attachment SET comment_id = 0 WHERE comment_id = ?`
+		args := []any{commentID}
+		if len(attachmentIDs) > 0 {
+			sqlStr += ` AND attachment_id NOT IN (?)`
+			args = append(args, attachmentIDs)
+		}
+		if err := tx.Exec(sqlStr, args...).Error; err != nil {
+			return err
+		}
+	}
+	if len(attachmentIDs) == 0 {
+		return nil
+	}
+	sqlStr := `
+		UPDATE attachment SET comment_id = ?
+		WHERE attachment_id IN (?) AND uploader_id = ? AND post_id = 0 AND comment_id = 0`
+	return tx.Exec(sqlStr, commentID, attachmentIDs, uploaderID).Error
+}
+
+// DeleteUnreferencedAttachment 删除尚未被引用的附件记录
+//
+// 返回:
+//   - bool: 是否删除成功, 附件不存在或已被帖子或评论引用时返回 false。
+//   - error: 如果操作失败，则返回错误对象，否则返回 nil。
+func DeleteUnreferencedAttachment(ctx context.Context, attachmentID int64) (bool, error) {
+	sqlStr := `DELETE FROM attachment WHERE attachment_id = ? AND post_id = 0 AND comment_id = 0`
+	result := MySQL.GetDB().WithContext(ctx).Exec(sqlStr, attachmentID)
+	return result.RowsAffected == 1, result.Error
+}
+
+// GetOrphanAttachments 获取在 before 之前上传且没有被帖子或评论引用的附件
+func GetOrphanAttachments(ctx context.Context, before time.Time, limit int) ([]model.Attachment, error) {
+	var attachments []model.Attachment
+	sqlStr := `
+		SELECT * FROM attachment
+		WHERE post_id = 0 AND comment_id = 0 AND create_time < ?
+		ORDER BY create_time
+		LIMIT ?`
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, before, limit).Scan(&attachments).Error
+	return attachments, err
+}
@@ -3,54 +3,67 @@ package dao
 import (
 	"GinTalk/dao/MySQL"
 	"GinTalk/model"
+	"GinTalk/pkg/cursor"
 	"context"
 	"time"
 )
 
 // GetTopComments retrieves the top-level comments for a post.
 // It fetches comments that are not deleted and have a status of 1,
-// ordered by creation time in descending order. Pagination is supported
-// through pageSize and pageNum parameters.
+// ordered by creation time and then comment ID in descending order.
+// Keyset pagination is supported through the after and limit parameters.
 //
 // Parameters:
 //   - ctx: The context for managing request-scoped values, cancellation, and deadlines.
 //   - postID: The ID of the post for which to retrieve comments.
-//   - pageSize: The number of comments to retrieve per page.
-//   - pageNum: The page number to retrieve.
+//   - after: The position of the last comment on the previous page, whose score is
+//     the Unix time of its creation. A nil cursor starts from the first page.
+//   - limit: The maximum number of comments to retrieve.
 //
 // Returns:
 //   - A slice of model.Comment containing the retrieved comments.
 //   - An error if the operation fails.
-func GetTopComments(ctx context.Context, postID int64, pageSize, pageNum int) ([]model.Comment, error) {
+func GetTopComments(ctx context.Context, postID int64, after *cursor.Cursor, limit int) ([]model.Comment, error) {
 	var comment []model.Comment
 	sqlStr := `
-		SELECT * 
+		SELECT comment.* 
 		FROM comment
 		INNER JOIN comment_relation ON comment.comment_id = comment_relation.comment_id
-		WHERE comment.post_id = ? AND comment.status = 1 AND comment.delete_time = 0 AND comment_relation.delete_time = 0 AND comment_relation.parent_id = 0
-		ORDER BY comment.create_time DESC
-		LIMIT ? OFFSET ?
-		`
-	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, postID, pageSize, (pageNum-1)*pageSize).Scan(&comment).Error
+		WHERE comment.post_id = ? AND comment.status = 1 AND comment.delete_time = 0 AND comment_relation.delete_time = 0 AND comment_relation.parent_id = 0`
+	sqlStr, args := appendCommentCursor(sqlStr, []interface{}{postID}, after, limit)
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, args...).Scan(&comment).Error
 
 	return comment, err
 }
 
-// GetSubComments 获取某一个顶层评论的子评论
-func GetSubComments(ctx context.Context, postID, parentID int64, pageSize, pageNum int) ([]model.Comment, error) {
+// GetSubComments 获取某一个顶层评论的子评论, 排序和游标的含义与 GetTopComments 相同
+func GetSubComments(ctx context.Context, postID, parentID int64, after *cursor.Cursor, limit int) ([]model.Comment, error) {
 	var comments []model.Comment
 	sqlStr := `
-		SELECT * 
+		SELECT comment.* 
 		FROM comment
 		INNER JOIN comment_relation ON comment.comment_id = comment_relation.comment_id
-		WHERE comment.post_id = ? AND parent_id = ? AND status = 1 AND comment.delete_time = 0 AND comment_relation.delete_time = 0
-		ORDER BY comment.create_time DESC
-		LIMIT ? OFFSET ?`
-	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, postID, parentID, pageSize, (pageNum-1)*pageSize).Scan(&comments).Error
+		WHERE comment.post_id = ? AND parent_id = ? AND status = 1 AND comment.delete_time = 0 AND comment_relation.delete_time = 0`
+	sqlStr, args := appendCommentCursor(sqlStr, []interface{}{postID, parentID}, after, limit)
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, args...).Scan(&comments).Error
 
 	return comments, err
 }
 
+// appendCommentCursor 为评论列表的查询加上游标条件、排序和数量限制
+func appendCommentCursor(sqlStr string, args []interface{}, after *cursor.Cursor, limit int) (string, []interface{}) {
+	if after != nil {
+		sqlStr += `
+		AND (comment.create_time < ? OR (comment.create_time = ? AND comment.comment_id < ?))`
+		createTime := time.Unix(int64(after.Score), 0)
+		args = append(args, createTime, createTime, after.ID)
+	}
+	sqlStr += `
+		ORDER BY comment.create_time DESC, comment.comment_id DESC
+		LIMIT ?`
+	return sqlStr, append(args, limit)
+}
+
 // GetCommentByID 根据评论 ID 获取评论
 func GetCommentByID(ctx context.Context, commentID int64) (*model.Comment, error) {
 	var comment model.Comment
@@ -80,13 +93,13 @@ func GetCommentParentUserID(ctx context.Context, commentID int64) (int64, error)
 	return userID, err
 }
 
-// CreateComment 创建评论
-func CreateComment(ctx context.Context, comment *model.Comment, replyID int64, parentID int64) error {
+// CreateComment 创建评论, 并关联评论作者上传的附件 attachmentIDs
+func CreateComment(ctx context.Context, comment *model.Comment, replyID int64, parentID int64, attachmentIDs []int64) error {
 	tx := MySQL.GetDB().Begin().WithContext(ctx)
 	sqlStrCreateComment := `
-		INSERT INTO comment (comment_id, content, post_id, author_id, author_name)
-			VALUES (?, ?, ?, ?, ?)`
-	err := tx.Exec(sqlStrCreateComment, comment.CommentID, comment.Content, comment.PostID, comment.AuthorID, comment.AuthorName).Error
+		INSERT INTO comment (comment_id, content, content_html, post_id, author_id, author_name)
+			VALUES (?, ?, ?, ?, ?, ?)`
+	err := tx.Exec(sqlStrCreateComment, comment.CommentID, comment.Content, comment.ContentHTML, comment.PostID, comment.AuthorID, comment.AuthorName).Error
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -99,16 +112,44 @@ func CreateComment(ctx context.Context, comment *model.Comment, replyID int64, p
 		tx.Rollback()
 		return err
 	}
+	if err = bindCommentAttachments(tx, comment.CommentID, comment.AuthorID, attachmentIDs, false); err != nil {
+		tx.Rollback()
+		return err
+	}
 	return tx.Commit().Error
 }
 
 // UpdateComment 更新评论
-func UpdateComment(ctx context.Context, commentID int64, content string) error {
+//
+// 参数:
+//   - contentHTML: 评论内容渲染后的 HTML。
+//   - attachmentIDs: 评论引用的附件, 为 nil 时不修改, 新关联的附件必须由 editorID 上传。
+//   - expectedVersion: 评论当前应有的乐观锁版本号, 为 0 时不检查。
+//
+// 返回:
+//   - bool: 是否更新成功, 评论不存在或版本号与 expectedVersion 不一致时返回 false。
+//   - error: 如果操作失败，则返回错误对象，否则返回 nil。
+func UpdateComment(ctx context.Context, commentID int64, content string, contentHTML string, attachmentIDs []int64, editorID int64, expectedVersion int64) (bool, error) {
 	sqlStr := `
 		UPDATE comment
-		SET content = ?
-		WHERE comment_id = ?`
-	return MySQL.GetDB().WithContext(ctx).Exec(sqlStr, content, commentID).Error
+		SET content = ?, content_html = ?, version = version + 1
+		WHERE comment_id = ? AND status = 1 AND delete_time = 0 AND (? = 0 OR version = ?)`
+	tx := MySQL.GetDB().WithContext(ctx).Begin()
+	result := tx.Exec(sqlStr, content, contentHTML, commentID, expectedVersion, expectedVersion)
+	if result.Error != nil || result.RowsAffected != 1 {
+		tx.Rollback()
+		return false, result.Error
+	}
+	if attachmentIDs != nil {
+		if err := bindCommentAttachments(tx, commentID, editorID, attachmentIDs, true); err != nil {
+			tx.Rollback()
+			return false, err
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 // DeleteComment 删除评论
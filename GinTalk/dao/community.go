@@ -25,3 +25,17 @@ func GetCommunityDetail(ctx context.Context, communityID int32) (*DTO.CommunityD
 	}
 	return &communityDetail, nil
 }
+
+// CreateCommunity 创建社区, 社区 ID 为当前最大 ID 加一
+func CreateCommunity(ctx context.Context, name string, introduction string) error {
+	sqlStr := `
+		INSERT INTO community (community_id, community_name, introduction)
+		SELECT COALESCE(MAX(community_id), 0) + 1, ?, ? FROM community`
+	return MySQL.GetDB().WithContext(ctx).Exec(sqlStr, name, introduction).Error
+}
+
+// UpdateCommunity 修改社区的名称和简介
+func UpdateCommunity(ctx context.Context, communityID int64, name string, introduction string) error {
+	sqlStr := `UPDATE community SET community_name = ?, introduction = ? WHERE community_id = ? AND delete_time = 0`
+	return MySQL.GetDB().WithContext(ctx).Exec(sqlStr, name, introduction, communityID).Error
+}
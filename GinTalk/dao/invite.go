@@ -0,0 +1,65 @@
+package dao
+
+import (
+	"GinTalk/dao/MySQL"
+	"GinTalk/model"
+	"context"
+	"time"
+)
+
+// CreateInviteCode 保存新的邀请码, 保存后回填自增 ID
+func CreateInviteCode(ctx context.Context, invite *model.InviteCode) error {
+	return MySQL.GetDB().WithContext(ctx).Create(invite).Error
+}
+
+// GetInviteCodesByCreator 获取用户生成的所有邀请码, 按创建时间倒序
+func GetInviteCodesByCreator(ctx context.Context, creatorID int64) ([]model.InviteCode, error) {
+	var invites []model.InviteCode
+	sqlStr := `SELECT id, code, creator_id, max_uses, used_count, expire_time, revoked, create_time
+               FROM invite_code WHERE creator_id = ? ORDER BY id DESC`
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, creatorID).Scan(&invites).Error
+	return invites, err
+}
+
+// RevokeInviteCode 作废用户生成的一个邀请码, 已使用的记录保留用于审计
+//
+// 返回:
+//   - bool: 是否作废成功, 邀请码不存在、不属于该用户或已作废时返回 false。
+//   - error: 如果操作失败，则返回错误对象，否则返回 nil。
+func RevokeInviteCode(ctx context.Context, creatorID int64, id int64) (bool, error) {
+	sqlStr := `UPDATE invite_code SET revoked = 1 WHERE id = ? AND creator_id = ? AND revoked = 0`
+	result := MySQL.GetDB().WithContext(ctx).Exec(sqlStr, id, creatorID)
+	return result.RowsAffected == 1, result.Error
+}
+
+// CreateUserWithInvite 使用邀请码注册用户, 在同一个事务中占用一次邀请码的使用次数并记录使用的邀请码
+//
+// 返回:
+//   - bool: 邀请码是否有效, 邀请码不存在、已作废、已过期或次数已用完时返回 false 且不会创建用户。
+//   - error: 如果操作失败，则返回错误对象，否则返回 nil。
+func CreateUserWithInvite(ctx context.Context, user *model.User, code string) (bool, error) {
+	tx := MySQL.GetDB().WithContext(ctx).Begin()
+	var inviteID int64
+	err := tx.Raw(`SELECT id FROM invite_code
+                   WHERE code = ? AND revoked = 0 AND expire_time > ? AND used_count < max_uses
+                   FOR UPDATE`, code, time.Now().Unix()).Scan(&inviteID).Error
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	if inviteID == 0 {
+		tx.Rollback()
+		return false, nil
+	}
+	if err := tx.Exec(`UPDATE invite_code SET used_count = used_count + 1 WHERE id = ?`, inviteID).Error; err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	sqlStr := `INSERT INTO user (user_id, username, password, email, gender, invite_code_id) VALUES (?, ?, ?, ?, ?, ?)`
+	if err := tx.Exec(sqlStr, user.UserID, user.Username, user.Password, user.Email, user.Gender, inviteID).Error; err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	user.InviteCodeID = inviteID
+	return true, tx.Commit().Error
+}
@@ -0,0 +1,76 @@
+package dao
+
+import (
+	"GinTalk/dao/MySQL"
+	"GinTalk/model"
+	"context"
+)
+
+// PersonalAccessTokenOwner 通过令牌哈希查询到的令牌及其所属用户
+type PersonalAccessTokenOwner struct {
+	model.PersonalAccessToken
+	Username    string
+	BannedUntil int64
+}
+
+// CreatePersonalAccessToken 保存新的个人访问令牌, 只保存令牌的哈希, 保存后回填自增 ID
+func CreatePersonalAccessToken(ctx context.Context, token *model.PersonalAccessToken) error {
+	return MySQL.GetDB().WithContext(ctx).Create(token).Error
+}
+
+// CountPersonalAccessTokens 查询用户的个人访问令牌数量
+func CountPersonalAccessTokens(ctx context.Context, userID int64) (int64, error) {
+	var count int64
+	sqlStr := `SELECT COUNT(*) FROM personal_access_token WHERE user_id = ?`
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, userID).Scan(&count).Error
+	return count, err
+}
+
+// GetPersonalAccessTokens 获取用户的所有个人访问令牌, 按创建时间倒序
+func GetPersonalAccessTokens(ctx context.Context, userID int64) ([]model.PersonalAccessToken, error) {
+	var tokens []model.PersonalAccessToken
+	sqlStr := `SELECT id, user_id, name, token_prefix, scopes, expire_time, last_used_time, create_time
+               FROM personal_access_token WHERE user_id = ? ORDER BY id DESC`
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, userID).Scan(&tokens).Error
+	return tokens, err
+}
+
+// DeletePersonalAccessToken 删除用户的一个个人访问令牌
+//
+// 返回:
+//   - bool: 是否删除成功, 令牌不存在或不属于该用户时返回 false。
+//   - error: 如果操作失败，则返回错误对象，否则返回 nil。
+func DeletePersonalAccessToken(ctx context.Context, userID int64, id int64) (bool, error) {
+	sqlStr := `DELETE FROM personal_access_token WHERE id = ? AND user_id = ?`
+	result := MySQL.GetDB().WithContext(ctx).Exec(sqlStr, id, userID)
+	return result.RowsAffected == 1, result.Error
+}
+
+// DeleteUserPersonalAccessTokens 删除用户的所有个人访问令牌, 返回删除的数量
+func DeleteUserPersonalAccessTokens(ctx context.Context, userID int64) (int64, error) {
+	sqlStr := `DELETE FROM personal_access_token WHERE user_id = ?`
+	result := MySQL.GetDB().WithContext(ctx).Exec(sqlStr, userID)
+	return result.RowsAffected, result.Error
+}
+
+// FindPersonalAccessTokenByHash 通过令牌哈希查询令牌和所属用户, 令牌不存在或用户已注销时返回 nil
+func FindPersonalAccessTokenByHash(ctx context.Context, tokenHash string) (*PersonalAccessTokenOwner, error) {
+	var owner PersonalAccessTokenOwner
+	sqlStr := `SELECT t.id, t.user_id, t.name, t.scopes, t.expire_time, t.last_used_time, u.username, u.banned_until
+               FROM personal_access_token t JOIN user u ON u.user_id = t.user_id
+               WHERE t.token_hash = ? AND u.delete_time = 0`
+	result := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, tokenHash).Scan(&owner)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &owner, nil
+}
+
+// UpdatePersonalAccessTokenLastUsed 更新令牌的最后使用时间
+func UpdatePersonalAccessTokenLastUsed(ctx context.Context, id int64, lastUsedTime int64) error {
+	sqlStr := `UPDATE personal_access_token SET last_used_time = ? WHERE id = ?`
+	return MySQL.GetDB().WithContext(ctx).Exec(sqlStr, lastUsedTime, id).Error
+}
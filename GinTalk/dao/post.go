@@ -3,6 +3,7 @@ package dao
 import (
 	"GinTalk/DTO"
 	"GinTalk/dao/MySQL"
+	"GinTalk/pkg/cursor"
 	"context"
 	"fmt"
 	"time"
@@ -26,22 +27,41 @@ func CreatePost(ctx context.Context, post *DTO.PostDetail) error {
 		return fmt.Errorf("社区ID不能为空")
 	}
 
-	sqlStr1 := `INSERT INTO post (post_id, title,summary, author_id, community_id) VALUES (?, ?, ?, ?, ?)`
+	// 未指定状态的帖子直接发布
+	if post.Status == DTO.PostStatusHidden {
+		post.Status = DTO.PostStatusPublished
+	}
+	if post.Status == DTO.PostStatusPublished && post.PublishAt == 0 {
+		post.PublishAt = time.Now().Unix()
+	}
+
+	sqlStr1 := `INSERT INTO post (post_id, title,summary, author_id, community_id, status, publish_at) VALUES (?, ?, ?, ?, ?, ?, ?)`
 	sqlStr2 := `INSERT INTO content_votes (post_id) VALUES (?)`
-	sqlStr3 := `INSERT INTO post_content (post_id, content) VALUES (?, ?)`
+	sqlStr3 := `INSERT INTO post_content (post_id, content, content_html) VALUES (?, ?, ?)`
 
 	tx := MySQL.GetDB().WithContext(ctx).Begin()
-	err := tx.WithContext(ctx).Exec(sqlStr1, post.PostID, post.Title, post.GenerateSummary(), post.AuthorId, post.CommunityID).Error
+	err := tx.WithContext(ctx).Exec(sqlStr1, post.PostID, post.Title, post.GenerateSummary(), post.AuthorId, post.CommunityID, post.Status, post.PublishAt).Error
 	if err != nil {
 		tx.Rollback()
+		return err
 	}
 	err = tx.WithContext(ctx).Exec(sqlStr2, post.PostID).Error
 	if err != nil {
 		tx.Rollback()
+		return err
 	}
-	err = tx.WithContext(ctx).Exec(sqlStr3, post.PostID, post.Content).Error
+	err = tx.WithContext(ctx).Exec(sqlStr3, post.PostID, post.Content, post.ContentHTML).Error
 	if err != nil {
 		tx.Rollback()
+		return err
+	}
+	if err = replacePostTags(tx, post.PostID, post.Tags); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err = bindPostAttachments(tx, post.PostID, post.AuthorId, post.AttachmentIDs, false); err != nil {
+		tx.Rollback()
+		return err
 	}
 	err = tx.Commit().Error
 	if err != nil {
@@ -50,7 +70,9 @@ func CreatePost(ctx context.Context, post *DTO.PostDetail) error {
 	return nil
 }
 
-func GetPostList(ctx context.Context, pageNum int, pageSize int) ([]DTO.PostSummary, error) {
+// GetPostList 按发布时间倒序获取已发布的帖子, 发布时间相同时按帖子 ID 倒序
+// after 为上一页最后一个帖子的位置 (排序值为发布时间), 为 nil 时从第一页开始
+func GetPostList(ctx context.Context, after *cursor.Cursor, limit int) ([]DTO.PostSummary, error) {
 	sqlStr := `SELECT 
                     post.post_id,
                     post.title,
@@ -59,7 +81,8 @@ func GetPostList(ctx context.Context, pageNum int, pageSize int) ([]DTO.PostSumm
                     user.username,
                     post.community_id,
                     community.community_name,
-                    post.status 
+                    post.status,
+                    post.publish_at 
                 FROM 
                     post
                 INNER JOIN 
@@ -67,17 +90,36 @@ func GetPostList(ctx context.Context, pageNum int, pageSize int) ([]DTO.PostSumm
                 INNER JOIN 
                     user ON user.user_id = post.author_id
                 WHERE 
-                    post.delete_time = 0 
-                LIMIT ? OFFSET ?`
+                    post.status = 1
+                    AND post.delete_time = 0`
+	args := []interface{}{}
+	sqlStr, args = appendPostCursor(sqlStr, args, after, limit)
 
 	var posts []DTO.PostSummary
-	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, pageSize, (pageNum-1)*pageSize).Scan(&posts).Error
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, args...).Scan(&posts).Error
 	if err != nil {
 		return nil, err
 	}
+	if err := fillPostSummaryTags(ctx, posts); err != nil {
+		return nil, err
+	}
 	return posts, nil
 }
 
+// appendPostCursor 为帖子列表的查询加上游标条件、排序和数量限制
+func appendPostCursor(sqlStr string, args []interface{}, after *cursor.Cursor, limit int) (string, []interface{}) {
+	if after != nil {
+		sqlStr += `
+                    AND (post.publish_at < ? OR (post.publish_at = ? AND post.post_id < ?))`
+		publishAt := int64(after.Score)
+		args = append(args, publishAt, publishAt, after.ID)
+	}
+	sqlStr += `
+                ORDER BY post.publish_at DESC, post.post_id DESC
+                LIMIT ?`
+	return sqlStr, append(args, limit)
+}
+
 func GetPostListBatch(ctx context.Context, postIDs []int64) ([]DTO.PostSummary, error) {
 	sqlStr := `SELECT 
 					post.post_id,
@@ -87,7 +129,8 @@ func GetPostListBatch(ctx context.Context, postIDs []int64) ([]DTO.PostSummary,
 					user.username,
 					post.community_id,
 					community.community_name,
-					post.status 
+					post.status,
+					post.publish_at 
 				FROM 
 					post
 				INNER JOIN 
@@ -96,6 +139,7 @@ func GetPostListBatch(ctx context.Context, postIDs []int64) ([]DTO.PostSummary,
 					user ON user.user_id = post.author_id
 				WHERE 
 					post.post_id IN (?) 
+					AND post.status = 1
 					AND post.delete_time = 0`
 
 	var posts []DTO.PostSummary
@@ -103,6 +147,9 @@ func GetPostListBatch(ctx context.Context, postIDs []int64) ([]DTO.PostSummary,
 	if err != nil {
 		return nil, err
 	}
+	if err := fillPostSummaryTags(ctx, posts); err != nil {
+		return nil, err
+	}
 	return posts, nil
 }
 
@@ -111,11 +158,14 @@ func GetPostDetail(ctx context.Context, postID int64) (*DTO.PostDetail, error) {
 					post.post_id,
 					post.title,
 					post_content.content,
+					post_content.content_html,
 					post.author_id,
 					user.username,
 					post.community_id,
 					community.community_name,
-					post.status 
+					post.status,
+					post.publish_at,
+					post.version 
 				FROM 
 					post
 				INNER JOIN 
@@ -133,34 +183,111 @@ func GetPostDetail(ctx context.Context, postID int64) (*DTO.PostDetail, error) {
 	if err != nil {
 		return nil, err
 	}
+	if post.PostID != 0 {
+		tags, err := GetPostTags(ctx, []int64{post.PostID})
+		if err != nil {
+			return nil, err
+		}
+		post.Tags = tags[post.PostID]
+	}
 	return &post, nil
 }
 
-func UpdatePost(ctx context.Context, post *DTO.PostDetail, summary string) error {
+// UpdatePost 更新帖子的标题和内容, 并在同一个事务中保存新的版本
+// 帖子第一次编辑时先把原始内容保存为版本 1, 编辑者为作者
+//
+// 参数:
+//   - expectedVersion: 帖子当前应有的乐观锁版本号, 为 0 时不检查。
+//
+// 返回:
+//   - bool: 是否更新成功, 帖子的版本号与 expectedVersion 不一致时返回 false 且不会修改帖子。
+//   - error: 如果操作失败，则返回错误对象，否则返回 nil。
+func UpdatePost(ctx context.Context, post *DTO.PostDetail, summary string, editorID int64, expectedVersion int64) (bool, error) {
 	if post.PostID == 0 {
-		return fmt.Errorf("postID 不能为空")
+		return false, fmt.Errorf("postID 不能为空")
 	}
 	if post.Title == "" {
-		return fmt.Errorf("标题不能为空")
+		return false, fmt.Errorf("标题不能为空")
 	}
 	if post.Content == "" {
-		return fmt.Errorf("内容不能为空")
+		return false, fmt.Errorf("内容不能为空")
 	}
 	tx := MySQL.GetDB().WithContext(ctx).Begin()
-	sqlStr := `UPDATE post SET title = ?, summary = ? WHERE post_id = ?`
-	err := tx.Exec(sqlStr, post.Title, summary, post.PostID).Error
+
+	// 锁定帖子, 保证同一帖子的版本号连续
+	var current struct {
+		PostID  int64
+		Version int64
+	}
+	err := tx.Raw(`SELECT post_id, version FROM post WHERE post_id = ? AND delete_time = 0 FOR UPDATE`, post.PostID).Scan(&current).Error
 	if err != nil {
 		tx.Rollback()
+		return false, err
 	}
-	sqlStr = `UPDATE post_content SET content = ? WHERE post_id = ?`
-	err = tx.Exec(sqlStr, post.Content, post.PostID).Error
+	if current.PostID == 0 {
+		tx.Rollback()
+		return false, fmt.Errorf("帖子不存在")
+	}
+	if expectedVersion != 0 && current.Version != expectedVersion {
+		tx.Rollback()
+		return false, nil
+	}
+	var revision int32
+	err = tx.Raw(`SELECT COALESCE(MAX(revision), 0) FROM post_revision WHERE post_id = ?`, post.PostID).Scan(&revision).Error
 	if err != nil {
 		tx.Rollback()
+		return false, err
+	}
+	if revision == 0 {
+		sqlStr := `INSERT INTO post_revision (post_id, revision, title, content, editor_id, create_time)
+				   SELECT post.post_id, 1, post.title, post_content.content, post.author_id, post.create_time
+				   FROM post
+				   INNER JOIN post_content ON post_content.post_id = post.post_id
+				   WHERE post.post_id = ?`
+		if err := tx.Exec(sqlStr, post.PostID).Error; err != nil {
+			tx.Rollback()
+			return false, err
+		}
+		revision = 1
+	}
+
+	sqlStr := `UPDATE post SET title = ?, summary = ?, version = version + 1 WHERE post_id = ?`
+	if err := tx.Exec(sqlStr, post.Title, summary, post.PostID).Error; err != nil {
+		tx.Rollback()
+		return false, err
 	}
-	return tx.Commit().Error
+	sqlStr = `UPDATE post_content SET content = ?, content_html = ? WHERE post_id = ?`
+	if err := tx.Exec(sqlStr, post.Content, post.ContentHTML, post.PostID).Error; err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	sqlStr = `INSERT INTO post_revision (post_id, revision, title, content, editor_id) VALUES (?, ?, ?, ?, ?)`
+	if err := tx.Exec(sqlStr, post.PostID, revision+1, post.Title, post.Content, editorID).Error; err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	// 标签为 nil 时不修改标签
+	if post.Tags != nil {
+		if err := replacePostTags(tx, post.PostID, post.Tags); err != nil {
+			tx.Rollback()
+			return false, err
+		}
+	}
+	// 附件列表为 nil 时不修改附件, 新关联的附件必须由编辑者本人上传
+	if post.AttachmentIDs != nil {
+		if err := bindPostAttachments(tx, post.PostID, editorID, post.AttachmentIDs, true); err != nil {
+			tx.Rollback()
+			return false, err
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
-func GetPostListByCommunityID(ctx context.Context, communityID int64, pageNum int, pageSize int) ([]DTO.PostSummary, error) {
+// GetPostListByCommunityID 按发布时间倒序获取社区中已发布的帖子, 游标的含义与 GetPostList 相同
+func GetPostListByCommunityID(ctx context.Context, communityID int64, after *cursor.Cursor, limit int) ([]DTO.PostSummary, error) {
 	sqlStr := `SELECT 
 					post.post_id,
 					post.title,
@@ -168,7 +295,8 @@ func GetPostListByCommunityID(ctx context.Context, communityID int64, pageNum in
 					post.author_id,
 					user.username,
 					post.community_id,
-					community.community_name
+					community.community_name,
+					post.publish_at
 				FROM 
 					post
 				INNER JOIN 
@@ -178,18 +306,193 @@ func GetPostListByCommunityID(ctx context.Context, communityID int64, pageNum in
 				WHERE 
 					post.community_id = ? 
 				    AND	post.status = 1
-					AND post.delete_time = 0 
-				LIMIT ? OFFSET ?`
+					AND post.delete_time = 0`
+	args := []interface{}{communityID}
+	sqlStr, args = appendPostCursor(sqlStr, args, after, limit)
 
 	var posts []DTO.PostSummary
-	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, communityID, pageSize, (pageNum-1)*pageSize).Scan(&posts).Error
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, args...).Scan(&posts).Error
 	if err != nil {
 		return nil, err
 	}
+	if err := fillPostSummaryTags(ctx, posts); err != nil {
+		return nil, err
+	}
 	return posts, nil
 }
 
+// postRankDataSQL 查询帖子排行数据的语句, 调用方追加 WHERE 之后的条件
+const postRankDataSQL = `SELECT 
+					post.post_id,
+					post.community_id,
+					post.publish_at,
+					COALESCE(content_votes.vote, 0) AS vote,
+					COALESCE(content_votes.count - content_votes.vote, 0) AS down
+				FROM 
+					post
+				LEFT JOIN 
+					content_votes ON content_votes.post_id = post.post_id AND content_votes.delete_time = 0
+				WHERE 
+					post.status = 1
+					AND post.delete_time = 0`
+
+// GetCommunityPostRankData 获取社区中所有已发布帖子的发布时间和赞数和踩数, 用于重建社区的帖子排行
+func GetCommunityPostRankData(ctx context.Context, communityID int64) ([]DTO.PostRankData, error) {
+	var posts []DTO.PostRankData
+	err := MySQL.GetDB().WithContext(ctx).Raw(postRankDataSQL+` AND post.community_id = ?`, communityID).Scan(&posts).Error
+	return posts, err
+}
+
+// GetAllPostRankData 获取所有已发布帖子的发布时间和赞数和踩数, 用于重建全局的帖子排行
+func GetAllPostRankData(ctx context.Context) ([]DTO.PostRankData, error) {
+	var posts []DTO.PostRankData
+	err := MySQL.GetDB().WithContext(ctx).Raw(postRankDataSQL).Scan(&posts).Error
+	return posts, err
+}
+
+// GetPostRankDataSince 获取 since 之后发布或投票数发生变化的已发布帖子, communityID 为 0 时不限制社区
+// 用于重建排行之后补上读取数据库到替换有序集合之间的变化, since 为 Unix 时间
+func GetPostRankDataSince(ctx context.Context, since int64, communityID int64) ([]DTO.PostRankData, error) {
+	sqlStr := postRankDataSQL + ` AND (post.publish_at >= ? OR content_votes.update_time >= FROM_UNIXTIME(?))`
+	args := []any{since, since}
+	if communityID != 0 {
+		sqlStr += ` AND post.community_id = ?`
+		args = append(args, communityID)
+	}
+	var posts []DTO.PostRankData
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, args...).Scan(&posts).Error
+	return posts, err
+}
+
+// GetPostRankData 获取一个已发布帖子的发布时间和赞数和踩数, 帖子不存在或未发布时返回 nil
+func GetPostRankData(ctx context.Context, postID int64) (*DTO.PostRankData, error) {
+	var posts []DTO.PostRankData
+	err := MySQL.GetDB().WithContext(ctx).Raw(postRankDataSQL+` AND post.post_id = ?`, postID).Scan(&posts).Error
+	if err != nil || len(posts) == 0 {
+		return nil, err
+	}
+	return &posts[0], nil
+}
+
 func DeletePost(ctx context.Context, postID int64) error {
 	sqlStr := `UPDATE post SET delete_time = ? WHERE post_id = ?`
 	return MySQL.GetDB().WithContext(ctx).Exec(sqlStr, time.Now().Unix(), postID).Error
 }
+
+// GetPostCountByUserID 获取用户已发布的帖子数量, 不包括草稿和定时发布的帖子
+func GetPostCountByUserID(ctx context.Context, userID int64) (int64, error) {
+	var count int64
+	sqlStr := `
+		SELECT COUNT(*) FROM post
+		WHERE author_id = ? AND status = 1 AND delete_time = 0`
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, userID).Scan(&count).Error
+	return count, err
+}
+
+// GetPostOwner 获取帖子的作者和所属社区, 用于权限校验
+func GetPostOwner(ctx context.Context, postID int64) (authorID int64, communityID int64, err error) {
+	var owner struct {
+		AuthorID    int64
+		CommunityID int64
+	}
+	sqlStr := `SELECT author_id, community_id FROM post WHERE post_id = ? AND delete_time = 0`
+	err = MySQL.GetDB().WithContext(ctx).Raw(sqlStr, postID).Scan(&owner).Error
+	return owner.AuthorID, owner.CommunityID, err
+}
+
+// GetPendingPostsByAuthor 获取作者尚未发布的草稿和定时发布帖子, 按更新时间倒序排列
+func GetPendingPostsByAuthor(ctx context.Context, authorID int64, pageNum int, pageSize int) ([]DTO.PostDetail, error) {
+	sqlStr := `SELECT 
+					post.post_id,
+					post.title,
+					post_content.content,
+					post_content.content_html,
+					post.author_id,
+					user.username,
+					post.community_id,
+					community.community_name,
+					post.status,
+					post.publish_at 
+				FROM 
+					post
+				INNER JOIN 
+					community ON community.community_id = post.community_id
+				INNER JOIN 
+					user ON user.user_id = post.author_id
+				INNER JOIN
+					post_content ON post_content.post_id = post.post_id
+				WHERE 
+					post.author_id = ? 
+					AND post.status IN (?, ?)
+					AND post.delete_time = 0
+				ORDER BY post.update_time DESC
+				LIMIT ? OFFSET ?`
+
+	var posts []DTO.PostDetail
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, authorID, DTO.PostStatusDraft, DTO.PostStatusScheduled, pageSize, (pageNum-1)*pageSize).Scan(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := fillPostDetailTags(ctx, posts); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// GetDuePosts 获取已到发布时间的定时发布帖子
+func GetDuePosts(ctx context.Context, now int64, limit int) ([]DTO.PostDetail, error) {
+	sqlStr := `SELECT 
+					post.post_id,
+					post.title,
+					post_content.content,
+					post_content.content_html,
+					post.author_id,
+					user.username,
+					post.community_id,
+					community.community_name,
+					post.status,
+					post.publish_at 
+				FROM 
+					post
+				INNER JOIN 
+					community ON community.community_id = post.community_id
+				INNER JOIN 
+					user ON user.user_id = post.author_id
+				INNER JOIN
+					post_content ON post_content.post_id = post.post_id
+				WHERE 
+					post.status = ? 
+					AND post.publish_at <= ?
+					AND post.delete_time = 0
+				ORDER BY post.publish_at
+				LIMIT ?`
+
+	var posts []DTO.PostDetail
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, DTO.PostStatusScheduled, now, limit).Scan(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := fillPostDetailTags(ctx, posts); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// PublishPost 将草稿或定时发布的帖子改为已发布
+// status 和 scheduledAt 为发送发布消息时帖子的状态和定时发布时间, 只有两者都未改变时才发布,
+// 防止作者在消息发出后改回草稿或修改了发布时间的帖子被提前发布
+// 帖子已经发布、已删除或状态已改变时返回 false, 用于保证同一帖子只被发布一次
+func PublishPost(ctx context.Context, postID int64, status int32, scheduledAt int64, publishAt int64) (bool, error) {
+	sqlStr := `UPDATE post SET status = ?, publish_at = ? WHERE post_id = ? AND status = ? AND publish_at = ? AND delete_time = 0`
+	result := MySQL.GetDB().WithContext(ctx).Exec(sqlStr, DTO.PostStatusPublished, publishAt, postID, status, scheduledAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// UpdatePostSchedule 修改尚未发布的帖子的状态和定时发布时间
+func UpdatePostSchedule(ctx context.Context, postID int64, status int32, publishAt int64) error {
+	sqlStr := `UPDATE post SET status = ?, publish_at = ? WHERE post_id = ? AND status IN (?, ?) AND delete_time = 0`
+	return MySQL.GetDB().WithContext(ctx).Exec(sqlStr, status, publishAt, postID, DTO.PostStatusDraft, DTO.PostStatusScheduled).Error
+}
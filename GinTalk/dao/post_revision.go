@@ -0,0 +1,113 @@
+package dao
+
+import (
+	"GinTalk/DTO"
+	"GinTalk/dao/MySQL"
+	"context"
+)
+
+// GetPostRevisions 获取帖子的所有版本, 按版本号倒序排列
+// 从未编辑过的帖子没有版本记录, 此时把当前内容作为版本 1 返回
+func GetPostRevisions(ctx context.Context, postID int64) ([]DTO.PostRevisionSummary, error) {
+	sqlStr := `SELECT 
+					post_revision.revision,
+					post_revision.title,
+					post_revision.editor_id,
+					user.username AS editor_name,
+					post_revision.create_time
+				FROM 
+					post_revision
+				LEFT JOIN 
+					user ON user.user_id = post_revision.editor_id
+				WHERE 
+					post_revision.post_id = ?
+				ORDER BY post_revision.revision DESC`
+
+	var revisions []DTO.PostRevisionSummary
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, postID).Scan(&revisions).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) > 0 {
+		return revisions, nil
+	}
+
+	original, err := getOriginalRevision(ctx, postID)
+	if err != nil || original == nil {
+		return nil, err
+	}
+	return []DTO.PostRevisionSummary{{
+		Revision:   original.Revision,
+		Title:      original.Title,
+		EditorID:   original.EditorID,
+		EditorName: original.EditorName,
+		CreateTime: original.CreateTime,
+	}}, nil
+}
+
+// GetPostRevision 获取帖子的某个版本, 版本不存在时返回 nil
+func GetPostRevision(ctx context.Context, postID int64, revision int32) (*DTO.PostRevision, error) {
+	sqlStr := `SELECT 
+					post_revision.post_id,
+					post_revision.revision,
+					post_revision.title,
+					post_revision.content,
+					post_revision.editor_id,
+					user.username AS editor_name,
+					post_revision.create_time
+				FROM 
+					post_revision
+				LEFT JOIN 
+					user ON user.user_id = post_revision.editor_id
+				WHERE 
+					post_revision.post_id = ? 
+					AND post_revision.revision = ?`
+
+	var revisions []DTO.PostRevision
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, postID, revision).Scan(&revisions).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) > 0 {
+		return &revisions[0], nil
+	}
+	if revision != 1 {
+		return nil, nil
+	}
+
+	// 版本 1 不存在时帖子可能从未编辑过
+	var count int64
+	err = MySQL.GetDB().WithContext(ctx).Raw(`SELECT COUNT(*) FROM post_revision WHERE post_id = ?`, postID).Scan(&count).Error
+	if err != nil || count > 0 {
+		return nil, err
+	}
+	return getOriginalRevision(ctx, postID)
+}
+
+// getOriginalRevision 把从未编辑过的帖子的当前内容作为版本 1, 帖子不存在时返回 nil
+func getOriginalRevision(ctx context.Context, postID int64) (*DTO.PostRevision, error) {
+	sqlStr := `SELECT 
+					post.post_id,
+					1 AS revision,
+					post.title,
+					post_content.content,
+					post.author_id AS editor_id,
+					user.username AS editor_name,
+					post.create_time
+				FROM 
+					post
+				INNER JOIN
+					post_content ON post_content.post_id = post.post_id
+				LEFT JOIN 
+					user ON user.user_id = post.author_id
+				WHERE 
+					post.post_id = ? 
+					AND post.delete_time = 0`
+
+	var revisions []DTO.PostRevision
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, postID).Scan(&revisions).Error
+	if err != nil || len(revisions) == 0 {
+		return nil, err
+	}
+	return &revisions[0], nil
+}
@@ -0,0 +1,74 @@
+package dao
+
+import (
+	"GinTalk/DTO"
+	"GinTalk/dao/MySQL"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// replacePostTags 在事务中把帖子的标签替换为 tags
+func replacePostTags(tx *gorm.DB, postID int64, tags []string) error {
+	if err := tx.Exec(`DELETE FROM post_tag WHERE post_id = ?`, postID).Error; err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if err := tx.Exec(`INSERT INTO post_tag (post_id, tag) VALUES (?, ?)`, postID, tag).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetPostTags 批量获取帖子的标签, 返回帖子 ID 到标签列表的映射
+func GetPostTags(ctx context.Context, postIDs []int64) (map[int64][]string, error) {
+	tags := make(map[int64][]string, len(postIDs))
+	if len(postIDs) == 0 {
+		return tags, nil
+	}
+	var rows []struct {
+		PostID int64
+		Tag    string
+	}
+	sqlStr := `SELECT post_id, tag FROM post_tag WHERE post_id IN (?) ORDER BY id`
+	if err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, postIDs).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		tags[row.PostID] = append(tags[row.PostID], row.Tag)
+	}
+	return tags, nil
+}
+
+// fillPostDetailTags 为帖子详情填充标签
+func fillPostDetailTags(ctx context.Context, posts []DTO.PostDetail) error {
+	postIDs := make([]int64, len(posts))
+	for i := range posts {
+		postIDs[i] = posts[i].PostID
+	}
+	tags, err := GetPostTags(ctx, postIDs)
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		posts[i].Tags = tags[posts[i].PostID]
+	}
+	return nil
+}
+
+// fillPostSummaryTags 为帖子摘要填充标签
+func fillPostSummaryTags(ctx context.Context, posts []DTO.PostSummary) error {
+	postIDs := make([]int64, len(posts))
+	for i := range posts {
+		postIDs[i] = posts[i].PostID
+	}
+	tags, err := GetPostTags(ctx, postIDs)
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		posts[i].Tags = tags[posts[i].PostID]
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package dao
+
+import (
+	"GinTalk/dao/MySQL"
+	"context"
+)
+
+// GetUserRole 查询用户的全局角色, 用户不存在时返回空字符串
+func GetUserRole(ctx context.Context, userID int64) (string, error) {
+	var role string
+	sqlStr := `SELECT role FROM user WHERE user_id = ? AND delete_time = 0`
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, userID).Scan(&role).Error
+	return role, err
+}
+
+// SetUserRole 修改用户的全局角色
+func SetUserRole(ctx context.Context, userID int64, role string) error {
+	sqlStr := `UPDATE user SET role = ? WHERE user_id = ? AND delete_time = 0`
+	return MySQL.GetDB().WithContext(ctx).Exec(sqlStr, role, userID).Error
+}
+
+// SetUserBannedUntil 设置用户的封禁截止时间, 0 表示解除封禁
+func SetUserBannedUntil(ctx context.Context, userID int64, bannedUntil int64) error {
+	sqlStr := `UPDATE user SET banned_until = ? WHERE user_id = ? AND delete_time = 0`
+	return MySQL.GetDB().WithContext(ctx).Exec(sqlStr, bannedUntil, userID).Error
+}
+
+// IsCommunityModerator 判断用户是否是社区的版主
+func IsCommunityModerator(ctx context.Context, communityID int64, userID int64) (bool, error) {
+	var count int64
+	sqlStr := `SELECT COUNT(*) FROM community_moderator WHERE community_id = ? AND user_id = ?`
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, communityID, userID).Scan(&count).Error
+	return count > 0, err
+}
+
+// AddCommunityModerator 任命社区版主, 重复任命不会报错
+func AddCommunityModerator(ctx context.Context, communityID int64, userID int64) error {
+	sqlStr := `INSERT IGNORE INTO community_moderator (community_id, user_id) VALUES (?, ?)`
+	return MySQL.GetDB().WithContext(ctx).Exec(sqlStr, communityID, userID).Error
+}
+
+// RemoveCommunityModerator 撤销社区版主
+func RemoveCommunityModerator(ctx context.Context, communityID int64, userID int64) error {
+	sqlStr := `DELETE FROM community_moderator WHERE community_id = ? AND user_id = ?`
+	return MySQL.GetDB().WithContext(ctx).Exec(sqlStr, communityID, userID).Error
+}
+
+// GetCommunityModerators 获取社区的所有版主
+func GetCommunityModerators(ctx context.Context, communityID int64) ([]int64, error) {
+	var userIDs []int64
+	sqlStr := `SELECT user_id FROM community_moderator WHERE community_id = ? ORDER BY id`
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, communityID).Scan(&userIDs).Error
+	return userIDs, err
+}
@@ -0,0 +1,155 @@
+package dao
+
+import (
+	"GinTalk/dao/MySQL"
+	"GinTalk/pkg/markdown"
+	"GinTalk/pkg/search"
+	"context"
+	"time"
+)
+
+// SearchPost 建立搜索索引需要的帖子字段
+type SearchPost struct {
+	PostID      int64
+	AuthorID    int64
+	CommunityID int64
+	Title       string
+	Content     string
+	CreateTime  time.Time
+}
+
+// SearchComment 建立搜索索引需要的评论字段, CommunityID 为评论所属帖子的社区
+type SearchComment struct {
+	CommentID   int64
+	PostID      int64
+	AuthorID    int64
+	CommunityID int64
+	Content     string
+	CreateTime  time.Time
+}
+
+// Document 转换为搜索索引的文档, 内容只保留 Markdown 的纯文本
+func (p *SearchPost) Document() *search.Document {
+	return &search.Document{
+		Type:        search.DocPost,
+		ID:          p.PostID,
+		PostID:      p.PostID,
+		CommunityID: p.CommunityID,
+		AuthorID:    p.AuthorID,
+		Title:       p.Title,
+		Content:     markdown.PlainText(p.Content),
+		CreateTime:  p.CreateTime,
+	}
+}
+
+// Document 转换为搜索索引的文档, 内容只保留 Markdown 的纯文本
+func (c *SearchComment) Document() *search.Document {
+	return &search.Document{
+		Type:        search.DocComment,
+		ID:          c.CommentID,
+		PostID:      c.PostID,
+		CommunityID: c.CommunityID,
+		AuthorID:    c.AuthorID,
+		Content:     markdown.PlainText(c.Content),
+		CreateTime:  c.CreateTime,
+	}
+}
+
+const searchPostSQL = `SELECT
+					post.post_id,
+					post.author_id,
+					post.community_id,
+					post.title,
+					post_content.content,
+					post.create_time
+				FROM
+					post
+				INNER JOIN
+					post_content ON post_content.post_id = post.post_id
+				WHERE
+					post.status = 1
+					AND post.delete_time = 0 `
+
+const searchCommentSQL = `SELECT
+					comment.comment_id,
+					comment.post_id,
+					comment.author_id,
+					post.community_id,
+					comment.content,
+					comment.create_time
+				FROM
+					comment
+				INNER JOIN
+					post ON post.post_id = comment.post_id AND post.status = 1 AND post.delete_time = 0
+				WHERE
+					comment.status = 1
+					AND comment.delete_time = 0 `
+
+// GetPostsForSearch 按帖子 ID 顺序分批获取已发布的帖子, 用于重建搜索索引
+// afterPostID 为上一批最后一个帖子的 ID, 第一批传 0
+func GetPostsForSearch(ctx context.Context, afterPostID int64, limit int) ([]SearchPost, error) {
+	sqlStr := searchPostSQL + `AND post.post_id > ? ORDER BY post.post_id LIMIT ?`
+	var posts []SearchPost
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, afterPostID, limit).Scan(&posts).Error
+	return posts, err
+}
+
+// GetPostForSearch 获取一个帖子的索引字段, 帖子不存在或未发布时返回 nil
+func GetPostForSearch(ctx context.Context, postID int64) (*SearchPost, error) {
+	sqlStr := searchPostSQL + `AND post.post_id = ?`
+	var posts []SearchPost
+	if err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, postID).Scan(&posts).Error; err != nil {
+		return nil, err
+	}
+	if len(posts) == 0 {
+		return nil, nil
+	}
+	return &posts[0], nil
+}
+
+// GetCommentsForSearch 按评论 ID 顺序分批获取已发布帖子下的正常评论, 用于重建搜索索引
+// afterCommentID 为上一批最后一条评论的 ID, 第一批传 0
+func GetCommentsForSearch(ctx context.Context, afterCommentID int64, limit int) ([]SearchComment, error) {
+	sqlStr := searchCommentSQL + `AND comment.comment_id > ? ORDER BY comment.comment_id LIMIT ?`
+	var comments []SearchComment
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, afterCommentID, limit).Scan(&comments).Error
+	return comments, err
+}
+
+// GetCommentForSearch 获取一条评论的索引字段, 评论不存在或所属帖子未发布时返回 nil
+func GetCommentForSearch(ctx context.Context, commentID int64) (*SearchComment, error) {
+	sqlStr := searchCommentSQL + `AND comment.comment_id = ?`
+	var comments []SearchComment
+	if err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, commentID).Scan(&comments).Error; err != nil {
+		return nil, err
+	}
+	if len(comments) == 0 {
+		return nil, nil
+	}
+	return &comments[0], nil
+}
+
+// GetVisiblePostIDs 返回 postIDs 中已发布且未删除的帖子 ID, 用于过滤搜索索引中已经过期的结果
+func GetVisiblePostIDs(ctx context.Context, postIDs []int64) ([]int64, error) {
+	if len(postIDs) == 0 {
+		return nil, nil
+	}
+	sqlStr := `SELECT post_id FROM post WHERE post_id IN (?) AND status = 1 AND delete_time = 0`
+	var ids []int64
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, postIDs).Scan(&ids).Error
+	return ids, err
+}
+
+// GetVisibleCommentIDs 返回 commentIDs 中正常且所属帖子已发布的评论 ID, 用于过滤搜索索引中已经过期的结果
+func GetVisibleCommentIDs(ctx context.Context, commentIDs []int64) ([]int64, error) {
+	if len(commentIDs) == 0 {
+		return nil, nil
+	}
+	sqlStr := `SELECT comment.comment_id
+				FROM comment
+				INNER JOIN post ON post.post_id = comment.post_id AND post.status = 1 AND post.delete_time = 0
+				WHERE comment.comment_id IN (?) AND comment.status = 1 AND comment.delete_time = 0`
+	var ids []int64
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, commentIDs).Scan(&ids).Error
+	return ids, err
+}
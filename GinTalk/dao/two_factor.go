@@ -0,0 +1,85 @@
+package dao
+
+import (
+	"GinTalk/dao/MySQL"
+	"GinTalk/model"
+	"context"
+	"time"
+)
+
+// FindUserTwoFactor 查询用户的两步验证配置, 用户未设置时返回 nil
+func FindUserTwoFactor(ctx context.Context, userID int64) (*model.UserTwoFactor, error) {
+	var tf model.UserTwoFactor
+	sqlStr := `SELECT user_id, secret, enabled FROM user_two_factor WHERE user_id = ?`
+	result := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, userID).Scan(&tf)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &tf, nil
+}
+
+// SavePendingTwoFactor 保存等待确认的 TOTP 密钥, 覆盖之前未确认的密钥
+// 已启用两步验证时不会修改
+func SavePendingTwoFactor(ctx context.Context, userID int64, secret string) error {
+	sqlStr := `INSERT INTO user_two_factor (user_id, secret, enabled) VALUES (?, ?, 0)
+               ON DUPLICATE KEY UPDATE secret = IF(enabled = 1, secret, VALUES(secret))`
+	return MySQL.GetDB().WithContext(ctx).Exec(sqlStr, userID, secret).Error
+}
+
+// EnableTwoFactor 启用两步验证, 同时替换用户的所有恢复码
+//
+// 返回:
+//   - bool: 是否启用成功, 已经启用或没有等待确认的密钥时返回 false。
+//   - error: 如果操作失败，则返回错误对象，否则返回 nil。
+func EnableTwoFactor(ctx context.Context, userID int64, codeHashes []string) (bool, error) {
+	tx := MySQL.GetDB().WithContext(ctx).Begin()
+	result := tx.Exec(`UPDATE user_two_factor SET enabled = 1 WHERE user_id = ? AND enabled = 0`, userID)
+	if result.Error != nil {
+		tx.Rollback()
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return false, nil
+	}
+	if err := tx.Exec(`DELETE FROM user_recovery_code WHERE user_id = ?`, userID).Error; err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	for _, codeHash := range codeHashes {
+		err := tx.Exec(`INSERT INTO user_recovery_code (user_id, code_hash) VALUES (?, ?)`, userID, codeHash).Error
+		if err != nil {
+			tx.Rollback()
+			return false, err
+		}
+	}
+	return true, tx.Commit().Error
+}
+
+// DeleteTwoFactor 关闭两步验证, 删除密钥和所有恢复码
+func DeleteTwoFactor(ctx context.Context, userID int64) error {
+	tx := MySQL.GetDB().WithContext(ctx).Begin()
+	if err := tx.Exec(`DELETE FROM user_two_factor WHERE user_id = ?`, userID).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Exec(`DELETE FROM user_recovery_code WHERE user_id = ?`, userID).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// UseRecoveryCode 使用一个恢复码, 每个恢复码只能使用一次
+//
+// 返回:
+//   - bool: 恢复码是否有效。
+//   - error: 如果操作失败，则返回错误对象，否则返回 nil。
+func UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	sqlStr := `UPDATE user_recovery_code SET used_time = ? WHERE user_id = ? AND code_hash = ? AND used_time = 0`
+	result := MySQL.GetDB().WithContext(ctx).Exec(sqlStr, time.Now().Unix(), userID, codeHash)
+	return result.RowsAffected == 1, result.Error
+}
@@ -1,9 +1,11 @@
 package dao
 
 import (
+	"GinTalk/DTO"
 	"GinTalk/dao/MySQL"
 	"GinTalk/model"
 	"context"
+	"strings"
 )
 
 func CreateUser(ctx context.Context, user *model.User) error {
@@ -13,7 +15,7 @@ func CreateUser(ctx context.Context, user *model.User) error {
 
 func FindUserByUsername(ctx context.Context, username string) (*model.User, error) {
 	var user model.User
-	sqlStr := `SELECT user_id, username, password FROM user WHERE username = ? AND delete_time = 0`
+	sqlStr := `SELECT user_id, username, password, email, email_verified, banned_until FROM user WHERE username = ? AND delete_time = 0`
 	result := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, username).Scan(&user)
 	if result.Error != nil {
 		return nil, result.Error
@@ -26,10 +28,92 @@ func FindUserByUsername(ctx context.Context, username string) (*model.User, erro
 
 func FindUserByID(ctx context.Context, userID int64) (*model.User, error) {
 	var user model.User
-	sqlStr := `SELECT user_id, username, password FROM user WHERE user_id = ? AND delete_time = 0`
+	sqlStr := `SELECT user_id, username, password, email, email_verified, gender, avatar, bio, role, banned_until, create_time FROM user WHERE user_id = ? AND delete_time = 0`
 	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, userID).Scan(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
+
+// UpdateUserPassword 更新用户的密码哈希
+func UpdateUserPassword(ctx context.Context, userID int64, password string) error {
+	sqlStr := `UPDATE user SET password = ? WHERE user_id = ? AND delete_time = 0`
+	return MySQL.GetDB().WithContext(ctx).Exec(sqlStr, password, userID).Error
+}
+
+// FindUsersByEmail 查询使用该邮箱的所有用户, 邮箱不是唯一的
+func FindUsersByEmail(ctx context.Context, email string) ([]model.User, error) {
+	var users []model.User
+	sqlStr := `SELECT user_id, username, email, email_verified FROM user WHERE email = ? AND delete_time = 0`
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, email).Scan(&users).Error
+	return users, err
+}
+
+// IsUserEmailVerified 查询用户的邮箱是否已验证
+func IsUserEmailVerified(ctx context.Context, userID int64) (bool, error) {
+	var verified bool
+	sqlStr := `SELECT email_verified FROM user WHERE user_id = ? AND delete_time = 0`
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, userID).Scan(&verified).Error
+	return verified, err
+}
+
+// MarkUserEmailVerified 将用户的邮箱标记为已验证
+// 只有用户当前的邮箱与验证链接中的邮箱一致时才会更新
+//
+// 返回:
+//   - bool: 是否更新成功。
+//   - error: 如果操作失败，则返回错误对象，否则返回 nil。
+func MarkUserEmailVerified(ctx context.Context, userID int64, email string) (bool, error) {
+	sqlStr := `UPDATE user SET email_verified = 1 WHERE user_id = ? AND email = ? AND delete_time = 0`
+	result := MySQL.GetDB().WithContext(ctx).Exec(sqlStr, userID, email)
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdateUserProfile 更新用户资料, 只更新请求中非 nil 的字段
+// 修改邮箱时同时将邮箱标记为未验证
+func UpdateUserProfile(ctx context.Context, userID int64, profile *DTO.UpdateProfileRequestDTO) error {
+	sets := make([]string, 0, 5)
+	args := make([]any, 0, 6)
+	if profile.Bio != nil {
+		sets = append(sets, "bio = ?")
+		args = append(args, *profile.Bio)
+	}
+	if profile.Gender != nil {
+		sets = append(sets, "gender = ?")
+		args = append(args, *profile.Gender)
+	}
+	if profile.Avatar != nil {
+		sets = append(sets, "avatar = ?")
+		args = append(args, *profile.Avatar)
+	}
+	if profile.Email != nil {
+		sets = append(sets, "email_verified = IF(email = ?, email_verified, 0)", "email = ?")
+		args = append(args, *profile.Email, *profile.Email)
+	}
+	if len(sets) == 0 {
+		return nil
+	}
+	sqlStr := `UPDATE user SET ` + strings.Join(sets, ", ") + ` WHERE user_id = ? AND delete_time = 0`
+	args = append(args, userID)
+	return MySQL.GetDB().WithContext(ctx).Exec(sqlStr, args...).Error
+}
+
+// GetUserKarma 获取用户的声望, 即用户所有帖子和评论获得的净票数 (赞数减去踩数) 之和
+// content_votes.vote 和 comment_votes.up 为赞数, count 为投票总数, 踩数为 count 减去赞数
+func GetUserKarma(ctx context.Context, userID int64) (int64, error) {
+	var karma int64
+	sqlStr := `
+		SELECT
+			(SELECT COALESCE(SUM(content_votes.vote - (content_votes.count - content_votes.vote)), 0)
+			 FROM post
+			 INNER JOIN content_votes ON content_votes.post_id = post.post_id AND content_votes.delete_time = 0
+			 WHERE post.author_id = ? AND post.delete_time = 0)
+			+
+			(SELECT COALESCE(SUM(comment_votes.up - (comment_votes.count - comment_votes.up)), 0)
+			 FROM comment
+			 INNER JOIN comment_votes ON comment_votes.comment_id = comment.comment_id AND comment_votes.delete_time = 0
+			 WHERE comment.author_id = ? AND comment.status = 1 AND comment.delete_time = 0)`
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, userID, userID).Scan(&karma).Error
+	return karma, err
+}
@@ -2,20 +2,57 @@ package dao
 
 import (
 	"GinTalk/dao/MySQL"
+	"fmt"
+
+	"gorm.io/gorm"
 )
 
-func VoteComment(userID, commentID int64) error {
-	sqlStr := `
-	INSERT INTO vote_comment (user_id, comment_id, vote) 
-	VALUES (?, ?, 1)`
-	return MySQL.GetDB().Exec(sqlStr, userID, commentID).Error
-}
+// VoteCommentWithTx 在事务中设置用户对评论的投票, 并相应地更新 comment_votes 表中的赞数和投票总数
+// vote 为 1 表示赞, -1 表示踩, 0 表示取消投票; 返回用户原来的投票值, 与 vote 相同时不做任何修改
+func VoteCommentWithTx(userID, commentID int64, vote int) (int, error) {
+	tx := MySQL.GetDB().Begin()
+	if err := tx.Error; err != nil {
+		return 0, err
+	}
+	var votes []int
+	err := tx.Raw(`SELECT vote FROM vote_comment WHERE user_id = ? AND comment_id = ? AND delete_time = 0 FOR UPDATE`, userID, commentID).Scan(&votes).Error
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	oldVote := 0
+	if len(votes) > 0 {
+		oldVote = votes[0]
+	}
+	if oldVote == vote {
+		return oldVote, tx.Commit().Error
+	}
 
-func RemoveVoteComment(userID, commentID int64) error {
+	var result *gorm.DB
+	switch {
+	case vote == 0:
+		result = tx.Exec(`DELETE FROM vote_comment WHERE user_id = ? AND comment_id = ? AND delete_time = 0`, userID, commentID)
+	case oldVote == 0:
+		result = tx.Exec(`INSERT INTO vote_comment (user_id, comment_id, vote) VALUES (?, ?, ?)`, userID, commentID, vote)
+	default:
+		result = tx.Exec(`UPDATE vote_comment SET vote = ? WHERE user_id = ? AND comment_id = ? AND delete_time = 0`, vote, userID, commentID)
+	}
+	if result.Error != nil || result.RowsAffected == 0 {
+		tx.Rollback()
+		return 0, fmt.Errorf("vote failed")
+	}
+
+	// 评论创建时没有 comment_votes 记录, 第一次投票时插入
+	up, count := voteCountDelta(oldVote, vote)
 	sqlStr := `
-	DELETE FROM vote_comment
-	WHERE user_id = ? AND comment_id = ?`
-	return MySQL.GetDB().Exec(sqlStr, userID, commentID).Error
+	INSERT INTO comment_votes (comment_id, up, count)
+	VALUES (?, ?, ?)
+	ON DUPLICATE KEY UPDATE up = up + VALUES(up), count = count + VALUES(count)`
+	if err := tx.Exec(sqlStr, commentID, up, count).Error; err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	return oldVote, tx.Commit().Error
 }
 
 func GetVoteComment(userID, commentID int64) (int, error) {
@@ -57,19 +94,3 @@ func GetCommentVoteStatusList(userID int64, commentIDs []int64) (map[int64]int,
 	}
 	return voteMap, nil
 }
-
-func IncrCommentVoteCount(commentID int64) error {
-	sqlStr := `
-	UPDATE comment_votes
-	SET up = up + 1
-	WHERE comment_id = ? AND delete_time = 0`
-	return MySQL.GetDB().Exec(sqlStr, commentID).Error
-}
-
-func DecrCommentVoteCount(commentID int64) error {
-	sqlStr := `
-	UPDATE comment_votes
-	SET up = up - 1
-	WHERE comment_id = ? AND delete_time = 0`
-	return MySQL.GetDB().Exec(sqlStr, commentID).Error
-}
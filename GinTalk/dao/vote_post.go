@@ -7,6 +7,8 @@ import (
 	"context"
 	"fmt"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 func AddPostVote(ctx context.Context, postID int64, userID int64) error {
@@ -59,7 +61,7 @@ func GetUserVoteList(ctx context.Context, userID int64, pageNum int, pageSize in
 func GetPostVoteCount(ctx context.Context, postID int64) (*DTO.PostVoteCounts, error) {
 	var voteCount DTO.PostVoteCounts
 	sqlStr := `
-		SELECT post_id, vote
+		SELECT post_id, vote, count - vote AS down
 		FROM content_votes
 		WHERE post_id = ? AND delete_time = 0`
 	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, postID).Scan(&voteCount).Error
@@ -69,7 +71,7 @@ func GetPostVoteCount(ctx context.Context, postID int64) (*DTO.PostVoteCounts, e
 func GetBatchPostVoteCount(ctx context.Context, postIDs []int64) ([]DTO.PostVoteCounts, error) {
 	var voteCount []DTO.PostVoteCounts
 	sqlStr := `
-		SELECT post_id, vote
+		SELECT post_id, vote, count - vote AS down
 		FROM content_votes
 		WHERE post_id IN (?) AND delete_time = 0`
 	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, postIDs).Scan(&voteCount).Error
@@ -165,52 +167,105 @@ func CheckUserVotedPost(ctx context.Context, postID int64, userID int64) (bool,
 	return count > 0, err
 }
 
-// AddPostVoteWithTx 在事务中添加或删除帖子投票。
-// 它根据投票值在 vote_post 表中插入或删除记录，并相应地更新 content_votes 表中的投票计数。
+// AddPostVoteWithTx 在事务中设置用户对帖子的投票。
+// 它在 vote_post 表中插入、修改或删除用户的投票记录，并相应地更新 content_votes 表中的赞数和投票总数。
 //
 // 参数:
 //   - ctx: 用于管理请求范围内的值、取消和截止日期的上下文。
 //   - postID: 要投票的帖子的 ID。
 //   - userID: 投票用户的 ID。
-//   - vote: 投票值，正值表示赞成票，非正值表示反对票。
+//   - vote: 投票值，1 表示赞，-1 表示踩，0 表示取消投票。
 //
 // 返回:
+//   - int: 用户原来的投票值，没有投票时为 0，与 vote 相同时不做任何修改。
+//   - time.Time: 投票的创建时间，修改或取消投票时为原投票的创建时间。
 //   - error: 如果事务失败则返回错误，否则返回 nil。
-func AddPostVoteWithTx(ctx context.Context, postID int64, userID int64, vote int) error {
+func AddPostVoteWithTx(ctx context.Context, postID int64, userID int64, vote int) (int, time.Time, error) {
 	tx := MySQL.GetDB().WithContext(ctx).Begin()
 	if err := tx.Error; err != nil {
-		return err
+		return 0, time.Time{}, err
 	}
-	var sqlStr string
-	if vote > 0 {
-		sqlStr = `
-		INSERT INTO vote_post (post_id, user_id)
-		VALUES (?, ?)	
-`
-	} else {
-		sqlStr = `
-		DELETE FROM vote_post
-		WHERE post_id = ? AND user_id = ?`
+	// 修改或取消投票时需要知道原投票的时间, 用于修改对应时间段的排行
+	var votes []model.VotePost
+	err := tx.Raw(`SELECT vote, create_time FROM vote_post WHERE post_id = ? AND user_id = ? AND delete_time = 0 FOR UPDATE`, postID, userID).Scan(&votes).Error
+	if err != nil {
+		tx.Rollback()
+		return 0, time.Time{}, err
+	}
+	oldVote, voteTime := 0, time.Now()
+	if len(votes) > 0 {
+		oldVote, voteTime = int(votes[0].Vote), votes[0].CreateTime
+	}
+	if oldVote == vote {
+		return oldVote, voteTime, tx.Commit().Error
+	}
+
+	var result *gorm.DB
+	switch {
+	case vote == 0:
+		result = tx.Exec(`DELETE FROM vote_post WHERE post_id = ? AND user_id = ? AND delete_time = 0`, postID, userID)
+	case oldVote == 0:
+		result = tx.Exec(`INSERT INTO vote_post (post_id, user_id, vote) VALUES (?, ?, ?)`, postID, userID, vote)
+	default:
+		result = tx.Exec(`UPDATE vote_post SET vote = ? WHERE post_id = ? AND user_id = ? AND delete_time = 0`, vote, postID, userID)
 	}
-	result := tx.Exec(sqlStr, postID, userID)
 	if result.Error != nil || result.RowsAffected == 0 {
 		tx.Rollback()
-		return fmt.Errorf("vote failed")
+		return 0, time.Time{}, fmt.Errorf("vote failed")
 	}
-	if vote > 0 {
-		sqlStr = `
-		UPDATE content_votes
-		SET vote = vote + 1
-		WHERE post_id = ? AND delete_time = 0`
-	} else {
-		sqlStr = `
+
+	up, count := voteCountDelta(oldVote, vote)
+	sqlStr := `
 		UPDATE content_votes
-		SET vote = vote - 1
+		SET vote = vote + ?, count = count + ?
 		WHERE post_id = ? AND delete_time = 0`
-	}
-	if err := tx.Exec(sqlStr, postID).Error; err != nil {
+	if err := tx.Exec(sqlStr, up, count, postID).Error; err != nil {
 		tx.Rollback()
-		return err
+		return 0, time.Time{}, err
+	}
+	return oldVote, voteTime, tx.Commit().Error
+}
+
+// voteCountDelta 返回投票从 oldVote 改为 newVote 时赞数和投票总数的变化量
+func voteCountDelta(oldVote int, newVote int) (up int, count int) {
+	if oldVote == 1 {
+		up--
+	}
+	if newVote == 1 {
+		up++
+	}
+	if oldVote != 0 {
+		count--
+	}
+	if newVote != 0 {
+		count++
 	}
-	return tx.Commit().Error
+	return up, count
+}
+
+// GetPostVoteBuckets 按时间段统计 since 之后每个帖子收到的净票数 (赞数减去踩数), 只统计已发布且未删除的帖子
+// 时间段从 Unix 纪元开始按 bucketSeconds 划分, Bucket 为时间段开始的 Unix 时间
+func GetPostVoteBuckets(ctx context.Context, since time.Time, bucketSeconds int64) ([]DTO.PostVoteBucket, error) {
+	var buckets []DTO.PostVoteBucket
+	sqlStr := `
+		SELECT vote_post.post_id, FLOOR(UNIX_TIMESTAMP(vote_post.create_time) / ?) * ? AS bucket, SUM(vote_post.vote) AS vote
+		FROM vote_post
+		INNER JOIN post ON post.post_id = vote_post.post_id
+		WHERE vote_post.create_time >= ? AND vote_post.delete_time = 0 AND post.status = 1 AND post.delete_time = 0
+		GROUP BY vote_post.post_id, bucket`
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr, bucketSeconds, bucketSeconds, since).Scan(&buckets).Error
+	return buckets, err
+}
+
+// GetAllPostVoteCounts 统计每个已发布且未删除的帖子收到的净票数
+func GetAllPostVoteCounts(ctx context.Context) ([]DTO.PostVoteCounts, error) {
+	var counts []DTO.PostVoteCounts
+	sqlStr := `
+		SELECT vote_post.post_id, SUM(vote_post.vote) AS vote
+		FROM vote_post
+		INNER JOIN post ON post.post_id = vote_post.post_id
+		WHERE vote_post.delete_time = 0 AND post.status = 1 AND post.delete_time = 0
+		GROUP BY vote_post.post_id`
+	err := MySQL.GetDB().WithContext(ctx).Raw(sqlStr).Scan(&counts).Error
+	return counts, err
 }
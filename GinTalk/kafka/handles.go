@@ -5,10 +5,12 @@ import (
 	"GinTalk/cache"
 	"GinTalk/dao"
 	"GinTalk/model"
+	"GinTalk/pkg/markdown"
 	"GinTalk/websocket"
 	"context"
 	"encoding/json"
 	"strconv"
+	"time"
 
 	"github.com/segmentio/kafka-go"
 	"go.uber.org/zap"
@@ -22,8 +24,8 @@ import (
 // 该函数执行以下步骤:
 //  1. 将 JSON 消息反序列化为 Vote DTO。
 //  2. 将投票记录保存到数据库。
-//  3. 更新 Redis 热度。
-//  4. 如果是点赞，发送通知给帖子作者。
+//  3. 按投票前后的净票数变化更新时间段的投票数统计和 Redis 热度。
+//  4. 如果是新的赞，发送通知给帖子作者。
 //
 // 如果任何步骤失败，记录相应的错误消息。
 func handleLikeMessage(msg kafka.Message) {
@@ -42,30 +44,45 @@ func handleLikeMessage(msg kafka.Message) {
 		zap.L().Error("转换 user id 失败", zap.Error(err))
 		return
 	}
+	if voteMsg.Vote < -1 || voteMsg.Vote > 1 {
+		zap.L().Error("投票值错误", zap.Int("vote", voteMsg.Vote))
+		return
+	}
 
 	// 向数据库中添加投票记录和更新投票数
-	err = dao.AddPostVoteWithTx(context.Background(), postID, userID, voteMsg.Vote)
+	oldVote, voteTime, err := dao.AddPostVoteWithTx(context.Background(), postID, userID, voteMsg.Vote)
 	if err != nil {
 		zap.L().Error("添加投票记录失败", zap.Error(err))
 		return
 	}
+	// 重复投票不需要更新统计和热度
+	if oldVote == voteMsg.Vote {
+		return
+	}
+
+	// 更新投票时间所在时间段的净票数
+	delta := int64(voteMsg.Vote - oldVote)
+	if err := cache.AddPostTopVote(context.Background(), postID, voteTime, delta); err != nil {
+		zap.L().Error("更新投票数统计失败", zap.Int64("post_id", postID), zap.Error(err))
+	}
 
-	// 更新 Redis 热度
-	oldUp, err := dao.GetPostVoteCount(context.Background(), postID)
+	// 按最新的票数更新 Redis 热度
+	rankData, err := dao.GetPostRankData(context.Background(), postID)
 	if err != nil {
 		zap.L().Error("获取帖子投票数失败", zap.Error(err))
 		return
 	}
-	err = cache.AddPostHot(context.Background(), postID, int(oldUp.Vote), int(oldUp.Vote)+voteMsg.Vote)
-	if err != nil {
-		zap.L().Error("更新 Redis 热度失败", zap.Error(err))
-		return
+	if rankData != nil {
+		if err := cache.UpdatePostRanking(context.Background(), rankData); err != nil {
+			zap.L().Error("更新 Redis 热度失败", zap.Error(err))
+			return
+		}
 	}
 
 	zap.L().Info("更新 Redis 热度成功", zap.Int64("post_id", postID), zap.Int("vote", voteMsg.Vote))
 
-	// 如果是取消点赞，不发送通知
-	if voteMsg.Vote == 0 {
+	// 如果是踩或取消投票，不发送通知
+	if voteMsg.Vote != 1 {
 		return
 	}
 
@@ -115,13 +132,14 @@ func handleCommentMessage(msg kafka.Message) {
 		return
 	}
 	commentModel := model.Comment{
-		CommentID:  commentMsg.Comment.CommentID,
-		PostID:     commentMsg.Comment.PostID,
-		AuthorID:   commentMsg.Comment.AuthorID,
-		AuthorName: commentMsg.Comment.AuthorName,
-		Content:    commentMsg.Comment.Content,
-	}
-	err := dao.CreateComment(context.Background(), &commentModel, commentMsg.CommentRelation.ReplyID, commentMsg.CommentRelation.ParentID)
+		CommentID:   commentMsg.Comment.CommentID,
+		PostID:      commentMsg.Comment.PostID,
+		AuthorID:    commentMsg.Comment.AuthorID,
+		AuthorName:  commentMsg.Comment.AuthorName,
+		Content:     commentMsg.Comment.Content,
+		ContentHTML: markdown.ToHTML(commentMsg.Comment.Content),
+	}
+	err := dao.CreateComment(context.Background(), &commentModel, commentMsg.CommentRelation.ReplyID, commentMsg.CommentRelation.ParentID, nil)
 	if err != nil {
 		zap.L().Error("保存评论到数据库失败", zap.Error(err))
 		return
@@ -161,8 +179,9 @@ func handleCommentMessage(msg kafka.Message) {
 //
 // 它执行以下步骤：
 //  1. 将消息值反序列化为 PostDetail DTO。
-//  2. 将帖子保存到数据库。
-//  3. 将帖子摘要保存到 Redis。
+//  2. 将帖子保存到数据库, 已保存的草稿或定时发布帖子只修改为已发布状态。
+//  3. 通知所有实例将帖子加入搜索索引。
+//  4. 将帖子摘要保存到 Redis。
 func handleCreatePostMessage(msg kafka.Message) {
 	var postMsg DTO.PostDetail
 	if err := json.Unmarshal(msg.Value, &postMsg); err != nil {
@@ -170,14 +189,32 @@ func handleCreatePostMessage(msg kafka.Message) {
 		return
 	}
 
-	// 保存帖子到数据库
-	err := dao.CreatePost(context.Background(), &postMsg)
-	if err != nil {
-		zap.L().Error("保存帖子到数据库失败", zap.Error(err))
-		return
-	}
+	if postMsg.IsPending() {
+		// 草稿或定时发布的帖子已经在数据库中, 同一帖子的重复消息和状态已改变的帖子直接忽略
+		published, err := dao.PublishPost(context.Background(), postMsg.PostID, postMsg.Status, postMsg.PublishAt, time.Now().Unix())
+		if err != nil {
+			zap.L().Error("发布帖子失败", zap.Error(err))
+			return
+		}
+		if !published {
+			zap.L().Info("帖子已发布、已删除或发布状态已修改", zap.Int64("post_id", postMsg.PostID))
+			return
+		}
+	} else {
+		if postMsg.ContentHTML == "" {
+			postMsg.ContentHTML = markdown.ToHTML(postMsg.Content)
+		}
+		// 保存帖子到数据库
+		err := dao.CreatePost(context.Background(), &postMsg)
+		if err != nil {
+			zap.L().Error("保存帖子到数据库失败", zap.Error(err))
+			return
+		}
+	}
+	// 只有确实发布的帖子才加入搜索索引
+	sendSearchIndexMessage(context.Background(), SearchIndexPost, postMsg.PostID)
 	// 保存帖子到 Redis
-	err = cache.SavePost(context.Background(), postMsg.ConvertToSummary())
+	err := cache.SavePost(context.Background(), postMsg.ConvertToSummary())
 	if err != nil {
 		zap.L().Error("保存帖子到 Redis 失败", zap.Error(err))
 		return
@@ -11,4 +11,6 @@ const (
 	TopicComment = "comment"
 	// TopicNotification 通知主题
 	TopicNotification = "notification"
+	// TopicSearchIndex 搜索索引更新主题, 每个实例都消费全部消息以更新本实例的索引
+	TopicSearchIndex = "search_index"
 )
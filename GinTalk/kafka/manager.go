@@ -21,6 +21,8 @@ type Manager struct {
 	Brokers []string
 	Writers map[string]*kafka.Writer
 	Readers map[string]*kafka.Reader
+	// SearchReaders 更新本实例搜索索引的消费者, 不加入消费者组, 每个实例都能收到全部消息
+	SearchReaders map[string]*kafka.Reader
 }
 
 // newKafkaManager 使用提供的 brokers、topics 和 group ID 初始化一个新的 Kafka 管理器。
@@ -73,8 +75,11 @@ func newKafkaManager(brokers []string, topics []string, groupID string) *Manager
 		}
 	}
 
-	// 初始化消费者
+	// 初始化消费者, 没有处理函数的主题只需要生产者
 	for _, topic := range topics {
+		if _, ok := handles[topic]; !ok {
+			continue
+		}
 		readers[topic] = kafka.NewReader(kafka.ReaderConfig{
 			Brokers:  brokers,
 			GroupID:  groupID,
@@ -173,6 +178,24 @@ func SendCommentMessage(ctx context.Context, commentMsg *DTO.CommentDetail) erro
 	return GetKafkaManager().sendMessage(ctx, topic, nil, value)
 }
 
+// SendSearchIndexMessage 发送搜索索引更新消息, 所有实例都会按数据库中的最新状态更新本实例的索引
+//
+// 参数:
+//   - ctx: 控制取消和截止日期的上下文。
+//   - docType: 消息类型, 取值为 SearchIndexPost、SearchIndexComment 或 SearchIndexAuthor。
+//   - id: 帖子、评论或用户的 ID。
+//
+// 返回值:
+//   - error: 如果消息无法序列化或发送，则返回错误。
+func SendSearchIndexMessage(ctx context.Context, docType string, id int64) error {
+	topic := TopicSearchIndex
+	value, err := json.Marshal(&SearchIndexMessage{Type: docType, ID: id})
+	if err != nil {
+		return err
+	}
+	return GetKafkaManager().sendMessage(ctx, topic, nil, value)
+}
+
 // SendNotificationMessage 发送通知消息到 Kafka 主题。
 // 它将提供的通知消息序列化为 JSON 格式并使用 Kafka 管理器发送。
 //
@@ -217,6 +240,12 @@ func (km *Manager) Close() {
 			zap.L().Error("关闭消费者失败", zap.Error(err))
 		}
 	}
+	for _, reader := range km.SearchReaders {
+		err := reader.Close()
+		if err != nil {
+			zap.L().Error("关闭消费者失败", zap.Error(err))
+		}
+	}
 }
 
 // InitKafkaManager 初始化 KafkaManager 单例实例。
@@ -224,14 +253,20 @@ func (km *Manager) Close() {
 // 此函数使用 sync.Once 机制确保初始化只执行一次。
 func InitKafkaManager() {
 	brokers := settings.GetConfig().KafkaConfig.Brokers
-	topics := []string{TopicCreatePost, TopicLike, TopicComment, TopicNotification}
+	topics := []string{TopicCreatePost, TopicLike, TopicComment, TopicNotification, TopicSearchIndex}
 
 	// 初始化 KafkaManager
 	manager = newKafkaManager(brokers, topics, "example-group")
 
-	for _, topic := range topics {
+	for topic := range manager.Readers {
 		go manager.startConsuming(context.Background(), topic)
 	}
+
+	// 每个实例都维护自己的搜索索引, 需要消费搜索索引主题的全部消息
+	manager.SearchReaders = newSearchReaders(brokers)
+	for topic := range manager.SearchReaders {
+		go manager.startSearchConsuming(context.Background(), topic)
+	}
 }
 
 func GetKafkaManager() *Manager {
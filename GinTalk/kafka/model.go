@@ -5,3 +5,18 @@ type Vote struct {
 	UserID string `json:"user_id"`
 	Vote   int    `json:"vote"`
 }
+
+// 搜索索引消息的类型, 帖子和评论与 search.DocType 的取值相同
+const (
+	SearchIndexPost    = "post"
+	SearchIndexComment = "comment"
+	SearchIndexAuthor  = "author"
+)
+
+// SearchIndexMessage 搜索索引更新消息, 只包含文档类型和 ID
+// 消费者按数据库中的最新状态处理: 帖子或评论存在且可见时加入索引, 否则从索引中删除;
+// 类型为 author 时删除该用户的所有内容
+type SearchIndexMessage struct {
+	Type string `json:"type"`
+	ID   int64  `json:"id"`
+}
@@ -0,0 +1,105 @@
+package kafka
+
+import (
+	"GinTalk/dao"
+	"GinTalk/pkg/search"
+	"context"
+	"encoding/json"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// newSearchReaders 为搜索索引主题创建更新本实例索引的消费者
+// 消费者不加入消费者组, 从启动时的最新位置开始读取, 启动之前的内容由数据库重建索引覆盖
+// 主题创建时只有一个分区, 因此只读取分区 0
+func newSearchReaders(brokers []string) map[string]*kafka.Reader {
+	readers := make(map[string]*kafka.Reader)
+	for topic := range searchHandles {
+		reader := kafka.NewReader(kafka.ReaderConfig{
+			Brokers:   brokers,
+			Topic:     topic,
+			Partition: 0,
+			MinBytes:  10e3, // 10KB
+			MaxBytes:  10e6, // 10MB
+		})
+		if err := reader.SetOffset(kafka.LastOffset); err != nil {
+			zap.L().Fatal("设置搜索消费者位置失败", zap.String("topic", topic), zap.Error(err))
+		}
+		readers[topic] = reader
+	}
+	return readers
+}
+
+// startSearchConsuming 启动搜索消费者消费指定Topic的消息
+func (km *Manager) startSearchConsuming(ctx context.Context, topic string) {
+	reader, exists := km.SearchReaders[topic]
+	if !exists {
+		zap.L().Error("搜索消费者不存在", zap.String("topic", topic))
+		return
+	}
+	for {
+		msg, err := reader.ReadMessage(ctx)
+		if err != nil {
+			zap.L().Error("读取消息失败", zap.Error(err))
+			break
+		}
+		searchHandles[topic](msg)
+	}
+}
+
+var searchHandles = map[string]handleFunc{
+	TopicSearchIndex: handleSearchIndexMessage,
+}
+
+// sendSearchIndexMessage 通知所有实例更新搜索索引, 发送失败只记录日志
+// 消费者处理完帖子后调用, 此时数据库中已经是最新状态
+func sendSearchIndexMessage(ctx context.Context, docType string, id int64) {
+	if err := SendSearchIndexMessage(ctx, docType, id); err != nil {
+		zap.L().Error("发送搜索索引消息失败", zap.String("type", docType), zap.Int64("id", id), zap.Error(err))
+	}
+}
+
+// handleSearchIndexMessage 按数据库中的最新状态更新本实例的搜索索引
+// 消息只包含 ID, 同一文档的多条消息无论以什么顺序处理, 最终都与数据库一致
+func handleSearchIndexMessage(msg kafka.Message) {
+	var indexMsg SearchIndexMessage
+	if err := json.Unmarshal(msg.Value, &indexMsg); err != nil {
+		zap.L().Error("序列化消息失败", zap.Error(err))
+		return
+	}
+	ctx := context.Background()
+	engine := search.GetEngine()
+	var err error
+	switch indexMsg.Type {
+	case SearchIndexPost:
+		var post *dao.SearchPost
+		if post, err = dao.GetPostForSearch(ctx, indexMsg.ID); err != nil {
+			break
+		}
+		if post == nil {
+			// 帖子已删除或未发布时, 帖子下的评论也不再可以搜索
+			err = engine.DeletePost(ctx, indexMsg.ID)
+		} else {
+			err = engine.Index(ctx, post.Document())
+		}
+	case SearchIndexComment:
+		var comment *dao.SearchComment
+		if comment, err = dao.GetCommentForSearch(ctx, indexMsg.ID); err != nil {
+			break
+		}
+		if comment == nil {
+			err = engine.Delete(ctx, search.DocComment, indexMsg.ID)
+		} else {
+			err = engine.Index(ctx, comment.Document())
+		}
+	case SearchIndexAuthor:
+		err = engine.DeleteAuthor(ctx, indexMsg.ID)
+	default:
+		zap.L().Error("未知的搜索索引消息类型", zap.String("type", indexMsg.Type))
+		return
+	}
+	if err != nil {
+		zap.L().Error("更新搜索索引失败", zap.String("type", indexMsg.Type), zap.Int64("id", indexMsg.ID), zap.Error(err))
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"GinTalk/metrics"
 	"GinTalk/pkg/snowflake"
 	"GinTalk/router"
+	"GinTalk/service"
 	"GinTalk/settings"
 	"fmt"
 	"go.uber.org/zap"
@@ -29,6 +30,24 @@ func main() {
 	// 初始化配置
 	kafka.InitKafkaManager()
 
+	// 从数据库重建本实例的搜索索引
+	service.StartSearchIndexRebuild()
+
+	// 启动定时发布帖子的后台任务
+	service.StartPostPublisher()
+
+	// 启动清理未引用附件的后台任务
+	service.StartAttachmentCleaner()
+
+	// 启动清理过期数据压缩包的后台任务
+	service.StartDataExportCleaner()
+
+	// 启动定期重建帖子投票数统计的后台任务
+	service.StartPostTopRebuild()
+
+	// 启动检查排行算法是否切换并重建热度排行的后台任务
+	service.StartPostRankingRebuild()
+
 	etcd.NewService()
 	if err := etcd.GetService().Register(); err != nil {
 		zap.L().Fatal("注册服务失败", zap.Error(err))
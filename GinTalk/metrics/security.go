@@ -0,0 +1,43 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+const (
+	// SecurityEventRefreshTokenReuse 已轮换的刷新令牌被重复使用
+	SecurityEventRefreshTokenReuse = "refresh_token_reuse"
+	// SecurityEventLoginLockoutUser 同一用户名登录失败次数过多被锁定
+	SecurityEventLoginLockoutUser = "login_lockout_user"
+	// SecurityEventLoginLockoutIP 同一 IP 登录失败次数过多被锁定
+	SecurityEventLoginLockoutIP = "login_lockout_ip"
+)
+
+// SecurityEvents 安全事件指标
+var SecurityEvents = NewSecurityEventMetrics()
+
+type SecurityEventMetrics struct {
+	securityEventCounter *prometheus.CounterVec
+}
+
+// NewSecurityEventMetrics 创建安全事件指标
+func NewSecurityEventMetrics() *SecurityEventMetrics {
+	securityEventCounter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: ProjectNameSpace,
+		Subsystem: "security",
+		Name:      "event",
+		Help:      "安全事件次数",
+	}, []string{"event"})
+
+	prometheus.MustRegister(securityEventCounter)
+	return &SecurityEventMetrics{
+		securityEventCounter: securityEventCounter,
+	}
+}
+
+// AddCounter 记录一次安全事件
+//
+// 使用示例:
+//
+//	metrics.SecurityEvents.AddCounter(metrics.SecurityEventRefreshTokenReuse)
+func (m *SecurityEventMetrics) AddCounter(event string) {
+	m.securityEventCounter.WithLabelValues(event).Add(1)
+}
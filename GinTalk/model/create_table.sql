@@ -8,9 +8,15 @@ CREATE TABLE `user`
     `id`          bigint(20)                             NOT NULL AUTO_INCREMENT COMMENT '自增主键，唯一标识用户记录',
     `user_id`     bigint(20)                             NOT NULL COMMENT '用户ID，用于业务中的用户唯一标识',
     `username`    varchar(64) COLLATE utf8mb4_general_ci NOT NULL COMMENT '用户名，唯一且不区分大小写',
-    `password`    varchar(64) COLLATE utf8mb4_general_ci NOT NULL COMMENT '用户密码，存储的是哈希值',
+    `password`    varchar(255) COLLATE utf8mb4_general_ci NOT NULL COMMENT '用户密码，存储的是自描述格式的哈希值',
     `email`       varchar(64) COLLATE utf8mb4_general_ci COMMENT '用户邮箱，可为空',
     `gender`      tinyint(4)                             NOT NULL DEFAULT '0' COMMENT '用户性别：0-未知，1-男，2-女',
+    `email_verified` tinyint(1)                          NOT NULL DEFAULT '0' COMMENT '邮箱是否已验证：0-未验证，1-已验证',
+    `avatar`      varchar(255) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' COMMENT '头像地址',
+    `bio`         varchar(512) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' COMMENT '个人简介',
+    `role`        varchar(16) COLLATE utf8mb4_general_ci NOT NULL DEFAULT 'user' COMMENT '全局角色：user, moderator, admin',
+    `banned_until` bigint                                NOT NULL DEFAULT 0 COMMENT '封禁截止时间，0 表示未封禁',
+    `invite_code_id` bigint(20)                          NOT NULL DEFAULT 0 COMMENT '注册时使用的邀请码ID，0 表示未使用邀请码',
     `create_time` timestamp                              NULL     DEFAULT CURRENT_TIMESTAMP COMMENT '记录的创建时间',
     `update_time` timestamp                              NULL     DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '记录的最后更新时间',
     `delete_time` bigint                           NULL DEFAULT 0 COMMENT '逻辑删除时间，NULL表示未删除',
@@ -63,7 +69,9 @@ CREATE TABLE `post`
     `summary` varchar(120) COLLATE utf8mb4_general_ci NOT NULL  COMMENT '帖子摘要',
     `author_id`    bigint(20)                               NOT NULL COMMENT '作者的用户ID，用于关联用户表',
     `community_id` bigint(20)                               NOT NULL COMMENT '所属社区ID，用于关联社区表',
-    `status`       tinyint(4)                               NOT NULL DEFAULT '1' COMMENT '帖子状态：1-正常，0-隐藏或删除',
+    `status`       tinyint(4)                               NOT NULL DEFAULT '1' COMMENT '帖子状态：1-正常，0-隐藏或删除，2-草稿，3-定时发布',
+    `publish_at`   bigint                                   NOT NULL DEFAULT 0 COMMENT '发布时间，定时发布的帖子在此时间后发布，0 表示未发布',
+    `version`      int(11)                                  NOT NULL DEFAULT 1 COMMENT '版本号，每次修改时加 1，用于乐观锁',
     `create_time`  timestamp                                NULL     DEFAULT CURRENT_TIMESTAMP COMMENT '帖子创建时间，默认当前时间',
     `update_time`  timestamp                                NULL     DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '帖子更新时间，每次更新时自动修改',
     `delete_time`  bigint                               NULL DEFAULT 0 COMMENT '逻辑删除时间，NULL表示未删除',
@@ -74,7 +82,11 @@ CREATE TABLE `post`
 
     INDEX `idx_author_id` (`author_id`) COMMENT '普通索引：按作者ID查询帖子',
 
-    INDEX `idx_community_id` (`community_id`) COMMENT '普通索引：按社区ID查询帖子'
+    INDEX `idx_community_id` (`community_id`) COMMENT '普通索引：按社区ID查询帖子',
+
+    INDEX `idx_status_publish_at` (`status`, `publish_at`) COMMENT '联合索引：查找到期的定时发布帖子',
+
+    INDEX `idx_community_id_status_publish_at` (`community_id`, `status`, `publish_at`) COMMENT '联合索引：按发布时间分页获取社区帖子'
 ) ENGINE = InnoDB
   DEFAULT CHARSET = utf8mb4
   COLLATE = utf8mb4_general_ci
@@ -85,6 +97,7 @@ CREATE TABLE `post_content`
 (
     `post_id` bigint(20) NOT NULL COMMENT '帖子ID',
     `content` text COLLATE utf8mb4_general_ci NOT NULL COMMENT '帖子内容',
+    `content_html` mediumtext COLLATE utf8mb4_general_ci NOT NULL COMMENT '帖子内容渲染后的 HTML',
     `create_time` timestamp  NULL DEFAULT CURRENT_TIMESTAMP COMMENT '帖子内容创建时间，默认当前时间',
     `update_time` timestamp  NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '帖子内容更新时间，每次更新时自动修改',
     `delete_time` bigint  NULL DEFAULT 0 COMMENT '逻辑删除时间，NULL表示未删除',
@@ -97,6 +110,38 @@ CREATE TABLE `post_content`
   COLLATE = utf8mb4_general_ci
     COMMENT = '帖子内容表：存储帖子的详细内容';
 
+DROP TABLE IF EXISTS `post_revision`;
+CREATE TABLE `post_revision`
+(
+    `id`          bigint(20)                              NOT NULL AUTO_INCREMENT COMMENT '自增主键',
+    `post_id`     bigint(20)                              NOT NULL COMMENT '帖子ID',
+    `revision`    int(11)                                 NOT NULL COMMENT '版本号，从 1 开始递增',
+    `title`       varchar(128) COLLATE utf8mb4_general_ci NOT NULL COMMENT '该版本的帖子标题',
+    `content`     text COLLATE utf8mb4_general_ci         NOT NULL COMMENT '该版本的帖子内容',
+    `editor_id`   bigint(20)                              NOT NULL COMMENT '编辑者的用户ID',
+    `create_time` timestamp                               NULL     DEFAULT CURRENT_TIMESTAMP COMMENT '该版本的创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_post_id_revision` (`post_id`, `revision`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci
+    COMMENT = '帖子版本表：存储帖子每次编辑后的标题和内容';
+
+DROP TABLE IF EXISTS `post_tag`;
+CREATE TABLE `post_tag`
+(
+    `id`          bigint(20)                             NOT NULL AUTO_INCREMENT COMMENT '自增主键',
+    `post_id`     bigint(20)                             NOT NULL COMMENT '帖子ID',
+    `tag`         varchar(32) COLLATE utf8mb4_general_ci NOT NULL COMMENT '规范化后的标签',
+    `create_time` timestamp                              NULL     DEFAULT CURRENT_TIMESTAMP COMMENT '记录的创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_post_id_tag` (`post_id`, `tag`),
+    INDEX `idx_tag_post_id` (`tag`, `post_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci
+    COMMENT = '帖子标签表：存储帖子和标签的关联';
+
 
 DROP TABLE IF EXISTS `comment`;
 CREATE TABLE `comment`
@@ -104,17 +149,20 @@ CREATE TABLE `comment`
     `id`          bigint(20)                      NOT NULL AUTO_INCREMENT COMMENT '自增主键，唯一标识每条评论记录',
     `comment_id`  bigint(20) unsigned             NOT NULL COMMENT '评论ID，用于业务中的评论唯一标识',
     `content`     text COLLATE utf8mb4_general_ci NOT NULL COMMENT '评论内容',
+    `content_html` mediumtext COLLATE utf8mb4_general_ci NOT NULL COMMENT '评论内容渲染后的 HTML',
     `summary` VARCHAR(256) COLLATE utf8mb4_general_ci NOT NULL COMMENT '评论概览',
     `post_id`     bigint(20)                      NOT NULL COMMENT '评论所属的帖子ID',
     `author_id`   bigint(20)                      NOT NULL COMMENT '评论作者的用户ID',
     `author_name` varchar(64)                     NOT NULL COMMENT '评论时的用户的名字',
     `status`      tinyint(3) unsigned             NOT NULL DEFAULT '1' COMMENT '评论状态：1-正常，0-删除',
+    `version`     int(11)                         NOT NULL DEFAULT 1 COMMENT '版本号，每次修改时加 1，用于乐观锁',
     `create_time` timestamp                       NULL     DEFAULT CURRENT_TIMESTAMP COMMENT '评论创建时间，默认当前时间',
     `update_time` timestamp                       NULL     DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '评论更新时间，每次更新时自动修改',
     `delete_time` bigint                      NULL DEFAULT 0 COMMENT '逻辑删除时间，NULL表示未删除',
     PRIMARY KEY (`id`),
     UNIQUE INDEX `idx_comment_id_delete_time` (`comment_id`, `delete_time`) COMMENT '联合索引：评论ID和删除时间确保未删除的评论ID唯一',
     INDEX `idx_create_time` (`create_time`),
+    INDEX `idx_post_id_create_time` (`post_id`, `create_time`) COMMENT '联合索引：按创建时间分页获取帖子的评论',
     KEY `idx_author_Id` (`author_id`)
 ) ENGINE = InnoDB
   DEFAULT CHARSET = utf8mb4
@@ -145,6 +193,7 @@ CREATE TABLE `comment_votes`
 (
     `comment_id` bigint(20) NOT NULL COMMENT '投票所属的评论ID',
     `up`         int(11)    NOT NULL DEFAULT '0' COMMENT '赞数',
+    `count`      int(11)    NOT NULL DEFAULT '0' COMMENT '投票总数',
     `create_time` timestamp  NULL     DEFAULT CURRENT_TIMESTAMP COMMENT '投票创建时间，默认当前时间',
     `update_time` timestamp  NULL     DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '投票更新时间，每次更新时自动修改',
     `delete_time` bigint  NULL DEFAULT 0 COMMENT '逻辑删除时间，NULL表示未删除',
@@ -160,14 +209,15 @@ CREATE TABLE `vote_post`
     `id`          bigint(20) NOT NULL AUTO_INCREMENT COMMENT '自增主键，唯一标识每条投票记录',
     `post_id`     bigint(20) NOT NULL COMMENT '投票所属的帖子ID',
     `user_id`     bigint(20) NOT NULL COMMENT '投票用户的用户ID',
-    `vote`        tinyint(4) NOT NULL DEFAULT 1 COMMENT '投票类型：1-赞',
+    `vote`        tinyint(4) NOT NULL DEFAULT 1 COMMENT '投票类型：1-赞，-1-踩',
     `create_time` timestamp  NULL DEFAULT CURRENT_TIMESTAMP COMMENT '投票创建时间，默认当前时间',
     `update_time` timestamp  NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '投票更新时间，每次更新时自动修改',
     `delete_time` bigint  NULL DEFAULT 0 COMMENT '逻辑删除时间，NULL表示未删除',
     PRIMARY KEY (`id`),
     UNIQUE INDEX `idx_post_id_user_id_delete_time` (`post_id`, `user_id`, `delete_time`),
     INDEX `idx_post_id` (`post_id`),
-    INDEX `idx_user_id` (`user_id`)
+    INDEX `idx_user_id` (`user_id`),
+    INDEX `idx_create_time` (`create_time`) COMMENT '普通索引：按投票时间统计帖子排行'
 ) ENGINE = InnoDB
   DEFAULT CHARSET = utf8mb4
   COLLATE = utf8mb4_general_ci
@@ -179,7 +229,7 @@ CREATE TABLE `vote_comment`
     `id`          bigint(20) NOT NULL AUTO_INCREMENT COMMENT '自增主键，唯一标识每条投票记录',
     `comment_id`     bigint(20) NOT NULL COMMENT '投票所属的评论ID',
     `user_id`     bigint(20) NOT NULL COMMENT '投票用户的用户ID',
-    `vote`        tinyint(4) NOT NULL COMMENT '投票类型：1-赞',
+    `vote`        tinyint(4) NOT NULL COMMENT '投票类型：1-赞，-1-踩',
     `create_time` timestamp  NULL DEFAULT CURRENT_TIMESTAMP COMMENT '投票创建时间，默认当前时间',
     `update_time` timestamp  NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '投票更新时间，每次更新时自动修改',
     `delete_time` bigint  NULL DEFAULT 0 COMMENT '逻辑删除时间，NULL表示未删除',
@@ -207,4 +257,114 @@ INDEX `idx_post_id` (`post_id`)
 ) ENGINE = InnoDB
   DEFAULT CHARSET = utf8mb4
   COLLATE = utf8mb4_general_ci
-    COMMENT = '帖子投票表：存储用户对帖子的投票记录';
\ No newline at end of file
+    COMMENT = '帖子投票表：存储用户对帖子的投票记录';
+
+DROP TABLE IF EXISTS `user_two_factor`;
+CREATE TABLE `user_two_factor`
+(
+    `id`          bigint(20)                             NOT NULL AUTO_INCREMENT COMMENT '自增主键',
+    `user_id`     bigint(20)                             NOT NULL COMMENT '用户ID',
+    `secret`      varchar(64) COLLATE utf8mb4_general_ci NOT NULL COMMENT 'base32 编码的 TOTP 密钥',
+    `enabled`     tinyint(1)                             NOT NULL DEFAULT '0' COMMENT '是否已启用：0-等待确认，1-已启用',
+    `create_time` timestamp                              NULL     DEFAULT CURRENT_TIMESTAMP COMMENT '记录的创建时间',
+    `update_time` timestamp                              NULL     DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '记录的最后更新时间',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_user_id` (`user_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci
+    COMMENT = '两步验证表：存储用户的 TOTP 密钥';
+
+DROP TABLE IF EXISTS `user_recovery_code`;
+CREATE TABLE `user_recovery_code`
+(
+    `id`          bigint(20)                          NOT NULL AUTO_INCREMENT COMMENT '自增主键',
+    `user_id`     bigint(20)                          NOT NULL COMMENT '用户ID',
+    `code_hash`   char(64) COLLATE utf8mb4_general_ci NOT NULL COMMENT '恢复码的 SHA-256 哈希',
+    `used_time`   bigint                              NOT NULL DEFAULT 0 COMMENT '使用时间，0 表示未使用',
+    `create_time` timestamp                           NULL     DEFAULT CURRENT_TIMESTAMP COMMENT '记录的创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_user_id_code_hash` (`user_id`, `code_hash`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci
+    COMMENT = '两步验证恢复码表：存储一次性恢复码';
+
+DROP TABLE IF EXISTS `community_moderator`;
+CREATE TABLE `community_moderator`
+(
+    `id`           bigint(20) NOT NULL AUTO_INCREMENT COMMENT '自增主键',
+    `community_id` bigint(20) NOT NULL COMMENT '社区ID',
+    `user_id`      bigint(20) NOT NULL COMMENT '版主的用户ID',
+    `create_time`  timestamp  NULL DEFAULT CURRENT_TIMESTAMP COMMENT '任命时间',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_community_id_user_id` (`community_id`, `user_id`),
+    INDEX `idx_user_id` (`user_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci
+    COMMENT = '社区版主表：存储用户在社区中的版主权限';
+
+DROP TABLE IF EXISTS `personal_access_token`;
+CREATE TABLE `personal_access_token`
+(
+    `id`             bigint(20)                              NOT NULL AUTO_INCREMENT COMMENT '自增主键',
+    `user_id`        bigint(20)                              NOT NULL COMMENT '令牌所属的用户ID',
+    `name`           varchar(64) COLLATE utf8mb4_general_ci  NOT NULL COMMENT '令牌名称',
+    `token_hash`     char(64) COLLATE utf8mb4_general_ci     NOT NULL COMMENT '令牌的 SHA-256 哈希',
+    `token_prefix`   varchar(16) COLLATE utf8mb4_general_ci  NOT NULL COMMENT '令牌的前几位，用于在列表中辨认令牌',
+    `scopes`         varchar(255) COLLATE utf8mb4_general_ci NOT NULL COMMENT '授权范围，多个之间用逗号分隔',
+    `expire_time`    bigint                                  NOT NULL DEFAULT 0 COMMENT '过期时间，0 表示永不过期',
+    `last_used_time` bigint                                  NOT NULL DEFAULT 0 COMMENT '最后使用时间，0 表示从未使用',
+    `create_time`    timestamp                               NULL     DEFAULT CURRENT_TIMESTAMP COMMENT '记录的创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_token_hash` (`token_hash`),
+    INDEX `idx_user_id` (`user_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci
+    COMMENT = '个人访问令牌表：存储供机器人和第三方集成使用的令牌';
+
+DROP TABLE IF EXISTS `invite_code`;
+CREATE TABLE `invite_code`
+(
+    `id`          bigint(20)                             NOT NULL AUTO_INCREMENT COMMENT '自增主键',
+    `code`        varchar(32) COLLATE utf8mb4_general_ci NOT NULL COMMENT '邀请码',
+    `creator_id`  bigint(20)                             NOT NULL COMMENT '生成邀请码的用户ID',
+    `max_uses`    int(11)                                NOT NULL COMMENT '最多可以使用的次数',
+    `used_count`  int(11)                                NOT NULL DEFAULT 0 COMMENT '已使用的次数',
+    `expire_time` bigint                                 NOT NULL COMMENT '过期时间',
+    `revoked`     tinyint(1)                             NOT NULL DEFAULT '0' COMMENT '是否已作废：0-有效，1-已作废',
+    `create_time` timestamp                              NULL     DEFAULT CURRENT_TIMESTAMP COMMENT '记录的创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_code` (`code`),
+    INDEX `idx_creator_id` (`creator_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci
+    COMMENT = '邀请码表：存储仅限邀请注册模式下使用的邀请码';
+
+DROP TABLE IF EXISTS `attachment`;
+CREATE TABLE `attachment`
+(
+    `id`            bigint(20)                              NOT NULL AUTO_INCREMENT COMMENT '自增主键',
+    `attachment_id` bigint(20)                              NOT NULL COMMENT '附件ID',
+    `uploader_id`   bigint(20)                              NOT NULL COMMENT '上传者的用户ID',
+    `post_id`       bigint(20)                              NOT NULL DEFAULT 0 COMMENT '引用附件的帖子ID，0 表示未被帖子引用',
+    `comment_id`    bigint(20)                              NOT NULL DEFAULT 0 COMMENT '引用附件的评论ID，0 表示未被评论引用',
+    `file_name`     varchar(255) COLLATE utf8mb4_general_ci NOT NULL COMMENT '上传时的文件名',
+    `content_type`  varchar(64) COLLATE utf8mb4_general_ci  NOT NULL COMMENT '根据文件内容识别的 MIME 类型',
+    `size`          bigint                                  NOT NULL COMMENT '保存的文件大小，单位字节',
+    `width`         int(11)                                 NOT NULL DEFAULT 0 COMMENT '图片宽度，非图片为 0',
+    `height`        int(11)                                 NOT NULL DEFAULT 0 COMMENT '图片高度，非图片为 0',
+    `storage_key`   varchar(255) COLLATE utf8mb4_general_ci NOT NULL COMMENT '文件在存储后端中的 key',
+    `thumbnail_key` varchar(255) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' COMMENT '缩略图在存储后端中的 key，非图片为空',
+    `create_time`   timestamp                               NULL     DEFAULT CURRENT_TIMESTAMP COMMENT '上传时间',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_attachment_id` (`attachment_id`),
+    INDEX `idx_post_id_comment_id_create_time` (`post_id`, `comment_id`, `create_time`),
+    INDEX `idx_comment_id` (`comment_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci
+    COMMENT = '附件表：存储上传的图片和文件，以及引用它们的帖子或评论';
@@ -18,7 +18,9 @@ type Post struct {
 	Summary     string    `gorm:"column:summary;not null;comment:帖子摘要" json:"summary"`                                      // 帖子摘要
 	AuthorID    int64     `gorm:"column:author_id;not null;comment:作者的用户ID，用于关联用户表" json:"author_id"`                       // 作者的用户ID，用于关联用户表
 	CommunityID int64     `gorm:"column:community_id;not null;comment:所属社区ID，用于关联社区表" json:"community_id"`                  // 所属社区ID，用于关联社区表
-	Status      int32     `gorm:"column:status;not null;default:1;comment:帖子状态：1-正常，0-隐藏或删除" json:"status"`                 // 帖子状态：1-正常，0-隐藏或删除
+	Status      int32     `gorm:"column:status;not null;default:1;comment:帖子状态：1-正常，0-隐藏或删除，2-草稿，3-定时发布" json:"status"`     // 帖子状态：1-正常，0-隐藏或删除，2-草稿，3-定时发布
+	PublishAt   int64     `gorm:"column:publish_at;not null;comment:发布时间，定时发布的帖子在此时间后发布，0 表示未发布" json:"publish_at"`         // 发布时间，定时发布的帖子在此时间后发布，0 表示未发布
+	Version     int64     `gorm:"column:version;not null;default:1;comment:版本号，每次修改时加 1，用于乐观锁" json:"version"`              // 版本号，每次修改时加 1，用于乐观锁
 	CreateTime  time.Time `gorm:"column:create_time;default:CURRENT_TIMESTAMP;comment:帖子创建时间，默认当前时间" json:"create_time"`    // 帖子创建时间，默认当前时间
 	UpdateTime  time.Time `gorm:"column:update_time;default:CURRENT_TIMESTAMP;comment:帖子更新时间，每次更新时自动修改" json:"update_time"` // 帖子更新时间，每次更新时自动修改
 	DeleteTime  int       `gorm:"column:delete_time;comment:逻辑删除时间，NULL表示未删除" json:"delete_time"`                           // 逻辑删除时间，NULL表示未删除
@@ -0,0 +1,216 @@
+-- 已有数据库的升级语句, 新部署直接使用 create_table.sql 即可
+-- 按顺序执行尚未执行过的部分
+
+USE `GinTalk`;
+
+-- 密码哈希改为 argon2id 自描述格式, 长度超过原来的 64 位
+ALTER TABLE `user`
+    MODIFY COLUMN `password` varchar(255) COLLATE utf8mb4_general_ci NOT NULL COMMENT '用户密码，存储的是自描述格式的哈希值';
+
+-- 邮箱验证, 升级前注册的用户视为已验证, 避免已有用户被限制为只读
+ALTER TABLE `user`
+    ADD COLUMN `email_verified` tinyint(1) NOT NULL DEFAULT '0' COMMENT '邮箱是否已验证：0-未验证，1-已验证' AFTER `gender`;
+UPDATE `user` SET `email_verified` = 1;
+
+-- TOTP 两步验证
+CREATE TABLE IF NOT EXISTS `user_two_factor`
+(
+    `id`          bigint(20)                             NOT NULL AUTO_INCREMENT COMMENT '自增主键',
+    `user_id`     bigint(20)                             NOT NULL COMMENT '用户ID',
+    `secret`      varchar(64) COLLATE utf8mb4_general_ci NOT NULL COMMENT 'base32 编码的 TOTP 密钥',
+    `enabled`     tinyint(1)                             NOT NULL DEFAULT '0' COMMENT '是否已启用：0-等待确认，1-已启用',
+    `create_time` timestamp                              NULL     DEFAULT CURRENT_TIMESTAMP COMMENT '记录的创建时间',
+    `update_time` timestamp                              NULL     DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '记录的最后更新时间',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_user_id` (`user_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci
+    COMMENT = '两步验证表：存储用户的 TOTP 密钥';
+
+CREATE TABLE IF NOT EXISTS `user_recovery_code`
+(
+    `id`          bigint(20)                          NOT NULL AUTO_INCREMENT COMMENT '自增主键',
+    `user_id`     bigint(20)                          NOT NULL COMMENT '用户ID',
+    `code_hash`   char(64) COLLATE utf8mb4_general_ci NOT NULL COMMENT '恢复码的 SHA-256 哈希',
+    `used_time`   bigint                              NOT NULL DEFAULT 0 COMMENT '使用时间，0 表示未使用',
+    `create_time` timestamp                           NULL     DEFAULT CURRENT_TIMESTAMP COMMENT '记录的创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_user_id_code_hash` (`user_id`, `code_hash`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci
+    COMMENT = '两步验证恢复码表：存储一次性恢复码';
+
+-- 用户资料
+ALTER TABLE `user`
+    ADD COLUMN `avatar` varchar(255) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' COMMENT '头像地址' AFTER `email_verified`,
+    ADD COLUMN `bio`    varchar(512) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' COMMENT '个人简介' AFTER `avatar`;
+
+-- 角色和权限, 升级后使用 UPDATE user SET role = 'admin' WHERE username = '...' 指定第一个管理员
+ALTER TABLE `user`
+    ADD COLUMN `role`         varchar(16) COLLATE utf8mb4_general_ci NOT NULL DEFAULT 'user' COMMENT '全局角色：user, moderator, admin' AFTER `bio`,
+    ADD COLUMN `banned_until` bigint NOT NULL DEFAULT 0 COMMENT '封禁截止时间，0 表示未封禁' AFTER `role`;
+CREATE TABLE IF NOT EXISTS `community_moderator`
+(
+    `id`           bigint(20) NOT NULL AUTO_INCREMENT COMMENT '自增主键',
+    `community_id` bigint(20) NOT NULL COMMENT '社区ID',
+    `user_id`      bigint(20) NOT NULL COMMENT '版主的用户ID',
+    `create_time`  timestamp  NULL DEFAULT CURRENT_TIMESTAMP COMMENT '任命时间',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_community_id_user_id` (`community_id`, `user_id`),
+    INDEX `idx_user_id` (`user_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci
+    COMMENT = '社区版主表：存储用户在社区中的版主权限';
+
+-- 个人访问令牌
+CREATE TABLE IF NOT EXISTS `personal_access_token`
+(
+    `id`             bigint(20)                              NOT NULL AUTO_INCREMENT COMMENT '自增主键',
+    `user_id`        bigint(20)                              NOT NULL COMMENT '令牌所属的用户ID',
+    `name`           varchar(64) COLLATE utf8mb4_general_ci  NOT NULL COMMENT '令牌名称',
+    `token_hash`     char(64) COLLATE utf8mb4_general_ci     NOT NULL COMMENT '令牌的 SHA-256 哈希',
+    `token_prefix`   varchar(16) COLLATE utf8mb4_general_ci  NOT NULL COMMENT '令牌的前几位，用于在列表中辨认令牌',
+    `scopes`         varchar(255) COLLATE utf8mb4_general_ci NOT NULL COMMENT '授权范围，多个之间用逗号分隔',
+    `expire_time`    bigint                                  NOT NULL DEFAULT 0 COMMENT '过期时间，0 表示永不过期',
+    `last_used_time` bigint                                  NOT NULL DEFAULT 0 COMMENT '最后使用时间，0 表示从未使用',
+    `create_time`    timestamp                               NULL     DEFAULT CURRENT_TIMESTAMP COMMENT '记录的创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_token_hash` (`token_hash`),
+    INDEX `idx_user_id` (`user_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci
+    COMMENT = '个人访问令牌表：存储供机器人和第三方集成使用的令牌';
+
+-- 注册模式和邀请码
+ALTER TABLE `user`
+    ADD COLUMN `invite_code_id` bigint(20) NOT NULL DEFAULT 0 COMMENT '注册时使用的邀请码ID，0 表示未使用邀请码' AFTER `banned_until`;
+CREATE TABLE IF NOT EXISTS `invite_code`
+(
+    `id`          bigint(20)                             NOT NULL AUTO_INCREMENT COMMENT '自增主键',
+    `code`        varchar(32) COLLATE utf8mb4_general_ci NOT NULL COMMENT '邀请码',
+    `creator_id`  bigint(20)                             NOT NULL COMMENT '生成邀请码的用户ID',
+    `max_uses`    int(11)                                NOT NULL COMMENT '最多可以使用的次数',
+    `used_count`  int(11)                                NOT NULL DEFAULT 0 COMMENT '已使用的次数',
+    `expire_time` bigint                                 NOT NULL COMMENT '过期时间',
+    `revoked`     tinyint(1)                             NOT NULL DEFAULT '0' COMMENT '是否已作废：0-有效，1-已作废',
+    `create_time` timestamp                              NULL     DEFAULT CURRENT_TIMESTAMP COMMENT '记录的创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_code` (`code`),
+    INDEX `idx_creator_id` (`creator_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci
+    COMMENT = '邀请码表：存储仅限邀请注册模式下使用的邀请码';
+
+-- 草稿和定时发布, 已有帖子的发布时间取创建时间
+ALTER TABLE `post`
+    MODIFY COLUMN `status` tinyint(4) NOT NULL DEFAULT '1' COMMENT '帖子状态：1-正常，0-隐藏或删除，2-草稿，3-定时发布',
+    ADD COLUMN `publish_at` bigint NOT NULL DEFAULT 0 COMMENT '发布时间，定时发布的帖子在此时间后发布，0 表示未发布' AFTER `status`,
+    ADD INDEX `idx_status_publish_at` (`status`, `publish_at`) COMMENT '联合索引：查找到期的定时发布帖子';
+UPDATE `post` SET `publish_at` = UNIX_TIMESTAMP(`create_time`) WHERE `status` = 1;
+
+-- 帖子版本历史, 已有帖子在第一次编辑时补充原始版本
+CREATE TABLE IF NOT EXISTS `post_revision`
+(
+    `id`          bigint(20)                              NOT NULL AUTO_INCREMENT COMMENT '自增主键',
+    `post_id`     bigint(20)                              NOT NULL COMMENT '帖子ID',
+    `revision`    int(11)                                 NOT NULL COMMENT '版本号，从 1 开始递增',
+    `title`       varchar(128) COLLATE utf8mb4_general_ci NOT NULL COMMENT '该版本的帖子标题',
+    `content`     text COLLATE utf8mb4_general_ci         NOT NULL COMMENT '该版本的帖子内容',
+    `editor_id`   bigint(20)                              NOT NULL COMMENT '编辑者的用户ID',
+    `create_time` timestamp                               NULL     DEFAULT CURRENT_TIMESTAMP COMMENT '该版本的创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_post_id_revision` (`post_id`, `revision`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci
+    COMMENT = '帖子版本表：存储帖子每次编辑后的标题和内容';
+
+-- 帖子和评论的乐观锁版本号
+ALTER TABLE `post`
+    ADD COLUMN `version` int(11) NOT NULL DEFAULT 1 COMMENT '版本号，每次修改时加 1，用于乐观锁' AFTER `publish_at`;
+ALTER TABLE `comment`
+    ADD COLUMN `version` int(11) NOT NULL DEFAULT 1 COMMENT '版本号，每次修改时加 1，用于乐观锁' AFTER `status`;
+
+-- 帖子标签
+CREATE TABLE IF NOT EXISTS `post_tag`
+(
+    `id`          bigint(20)                             NOT NULL AUTO_INCREMENT COMMENT '自增主键',
+    `post_id`     bigint(20)                             NOT NULL COMMENT '帖子ID',
+    `tag`         varchar(32) COLLATE utf8mb4_general_ci NOT NULL COMMENT '规范化后的标签',
+    `create_time` timestamp                              NULL     DEFAULT CURRENT_TIMESTAMP COMMENT '记录的创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_post_id_tag` (`post_id`, `tag`),
+    INDEX `idx_tag_post_id` (`tag`, `post_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci
+    COMMENT = '帖子标签表：存储帖子和标签的关联';
+
+-- 帖子和评论的 Markdown 渲染结果, 已有数据为空字符串, 读取时再渲染
+ALTER TABLE `post_content`
+    ADD COLUMN `content_html` mediumtext COLLATE utf8mb4_general_ci NOT NULL COMMENT '帖子内容渲染后的 HTML' AFTER `content`;
+ALTER TABLE `comment`
+    ADD COLUMN `content_html` mediumtext COLLATE utf8mb4_general_ci NOT NULL COMMENT '评论内容渲染后的 HTML' AFTER `content`;
+
+-- 附件
+CREATE TABLE IF NOT EXISTS `attachment`
+(
+    `id`            bigint(20)                              NOT NULL AUTO_INCREMENT COMMENT '自增主键',
+    `attachment_id` bigint(20)                              NOT NULL COMMENT '附件ID',
+    `uploader_id`   bigint(20)                              NOT NULL COMMENT '上传者的用户ID',
+    `post_id`       bigint(20)                              NOT NULL DEFAULT 0 COMMENT '引用附件的帖子ID，0 表示未被帖子引用',
+    `comment_id`    bigint(20)                              NOT NULL DEFAULT 0 COMMENT '引用附件的评论ID，0 表示未被评论引用',
+    `file_name`     varchar(255) COLLATE utf8mb4_general_ci NOT NULL COMMENT '上传时的文件名',
+    `content_type`  varchar(64) COLLATE utf8mb4_general_ci  NOT NULL COMMENT '根据文件内容识别的 MIME 类型',
+    `size`          bigint                                  NOT NULL COMMENT '保存的文件大小，单位字节',
+    `width`         int(11)                                 NOT NULL DEFAULT 0 COMMENT '图片宽度，非图片为 0',
+    `height`        int(11)                                 NOT NULL DEFAULT 0 COMMENT '图片高度，非图片为 0',
+    `storage_key`   varchar(255) COLLATE utf8mb4_general_ci NOT NULL COMMENT '文件在存储后端中的 key',
+    `thumbnail_key` varchar(255) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' COMMENT '缩略图在存储后端中的 key，非图片为空',
+    `create_time`   timestamp                               NULL     DEFAULT CURRENT_TIMESTAMP COMMENT '上传时间',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_attachment_id` (`attachment_id`),
+    INDEX `idx_post_id_comment_id_create_time` (`post_id`, `comment_id`, `create_time`),
+    INDEX `idx_comment_id` (`comment_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci
+    COMMENT = '附件表：存储上传的图片和文件，以及引用它们的帖子或评论';
+
+-- 帖子和评论列表改为游标分页, 按发布时间或创建时间排序
+ALTER TABLE `post`
+    ADD INDEX `idx_community_id_status_publish_at` (`community_id`, `status`, `publish_at`) COMMENT '联合索引：按发布时间分页获取社区帖子';
+ALTER TABLE `comment`
+    ADD INDEX `idx_post_id_create_time` (`post_id`, `create_time`) COMMENT '联合索引：按创建时间分页获取帖子的评论';
+
+-- 按时间窗口统计帖子的投票数
+ALTER TABLE `vote_post`
+    ADD INDEX `idx_create_time` (`create_time`) COMMENT '普通索引：按投票时间统计帖子排行';
+
+-- 帖子和评论支持踩, vote 为 1 表示赞, -1 表示踩; content_votes 和 comment_votes 分别记录赞数和投票总数
+ALTER TABLE `vote_post`
+    MODIFY `vote` tinyint(4) NOT NULL DEFAULT 1 COMMENT '投票类型：1-赞，-1-踩';
+ALTER TABLE `vote_comment`
+    MODIFY `vote` tinyint(4) NOT NULL COMMENT '投票类型：1-赞，-1-踩';
+ALTER TABLE `comment_votes`
+    ADD COLUMN `count` int(11) NOT NULL DEFAULT '0' COMMENT '投票总数' AFTER `up`;
+-- 按已有的投票记录修正赞数和投票总数
+UPDATE `content_votes`
+    LEFT JOIN (SELECT `post_id`, SUM(`vote` = 1) AS `up`, COUNT(*) AS `total`
+               FROM `vote_post`
+               WHERE `delete_time` = 0
+               GROUP BY `post_id`) AS `v` ON `v`.`post_id` = `content_votes`.`post_id`
+SET `content_votes`.`vote`  = COALESCE(`v`.`up`, 0),
+    `content_votes`.`count` = COALESCE(`v`.`total`, 0)
+WHERE `content_votes`.`delete_time` = 0;
+INSERT INTO `comment_votes` (`comment_id`, `up`, `count`)
+SELECT `comment_id`, SUM(`vote` = 1), COUNT(*)
+FROM `vote_comment`
+WHERE `delete_time` = 0
+GROUP BY `comment_id`
+ON DUPLICATE KEY UPDATE `up` = VALUES(`up`), `count` = VALUES(`count`);
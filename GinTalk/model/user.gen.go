@@ -12,15 +12,21 @@ const TableNameUser = "user"
 
 // User 用户信息表：存储用户基本信息及状态
 type User struct {
-	ID         int64     `gorm:"column:id;primaryKey;autoIncrement:true;comment:自增主键，唯一标识用户记录" json:"id"`           // 自增主键，唯一标识用户记录
-	UserID     int64     `gorm:"column:user_id;not null;comment:用户ID，用于业务中的用户唯一标识" json:"user_id"`                  // 用户ID，用于业务中的用户唯一标识
-	Username   string    `gorm:"column:username;not null;comment:用户名，唯一且不区分大小写" json:"username"`                    // 用户名，唯一且不区分大小写
-	Password   string    `gorm:"column:password;not null;comment:用户密码，存储的是哈希值" json:"password"`                     // 用户密码，存储的是哈希值
-	Email      string    `gorm:"column:email;comment:用户邮箱，可为空" json:"email"`                                        // 用户邮箱，可为空
-	Gender     int32     `gorm:"column:gender;not null;comment:用户性别：0-未知，1-男，2-女" json:"gender"`                    // 用户性别：0-未知，1-男，2-女
-	CreateTime time.Time `gorm:"column:create_time;default:CURRENT_TIMESTAMP;comment:记录的创建时间" json:"create_time"`   // 记录的创建时间
-	UpdateTime time.Time `gorm:"column:update_time;default:CURRENT_TIMESTAMP;comment:记录的最后更新时间" json:"update_time"` // 记录的最后更新时间
-	DeleteTime int       `gorm:"column:delete_time;comment:逻辑删除时间，NULL表示未删除" json:"delete_time"`                    // 逻辑删除时间，NULL表示未删除
+	ID            int64     `gorm:"column:id;primaryKey;autoIncrement:true;comment:自增主键，唯一标识用户记录" json:"id"`             // 自增主键，唯一标识用户记录
+	UserID        int64     `gorm:"column:user_id;not null;comment:用户ID，用于业务中的用户唯一标识" json:"user_id"`                    // 用户ID，用于业务中的用户唯一标识
+	Username      string    `gorm:"column:username;not null;comment:用户名，唯一且不区分大小写" json:"username"`                      // 用户名，唯一且不区分大小写
+	Password      string    `gorm:"column:password;not null;comment:用户密码，存储的是哈希值" json:"password"`                       // 用户密码，存储的是哈希值
+	Email         string    `gorm:"column:email;comment:用户邮箱，可为空" json:"email"`                                          // 用户邮箱，可为空
+	Gender        int32     `gorm:"column:gender;not null;comment:用户性别：0-未知，1-男，2-女" json:"gender"`                      // 用户性别：0-未知，1-男，2-女
+	EmailVerified bool      `gorm:"column:email_verified;not null;comment:邮箱是否已验证：0-未验证，1-已验证" json:"email_verified"`    // 邮箱是否已验证：0-未验证，1-已验证
+	Avatar        string    `gorm:"column:avatar;not null;comment:头像地址" json:"avatar"`                                   // 头像地址
+	Bio           string    `gorm:"column:bio;not null;comment:个人简介" json:"bio"`                                         // 个人简介
+	Role          string    `gorm:"column:role;not null;default:user;comment:全局角色：user, moderator, admin" json:"role"`   // 全局角色：user, moderator, admin
+	BannedUntil   int64     `gorm:"column:banned_until;not null;comment:封禁截止时间，0 表示未封禁" json:"banned_until"`             // 封禁截止时间，0 表示未封禁
+	InviteCodeID  int64     `gorm:"column:invite_code_id;not null;comment:注册时使用的邀请码ID，0 表示未使用邀请码" json:"invite_code_id"` // 注册时使用的邀请码ID，0 表示未使用邀请码
+	CreateTime    time.Time `gorm:"column:create_time;default:CURRENT_TIMESTAMP;comment:记录的创建时间" json:"create_time"`     // 记录的创建时间
+	UpdateTime    time.Time `gorm:"column:update_time;default:CURRENT_TIMESTAMP;comment:记录的最后更新时间" json:"update_time"`   // 记录的最后更新时间
+	DeleteTime    int       `gorm:"column:delete_time;comment:逻辑删除时间，NULL表示未删除" json:"delete_time"`                      // 逻辑删除时间，NULL表示未删除
 }
 
 // TableName User's table name
@@ -1,10 +1,15 @@
 package apiError
 
-import "GinTalk/pkg/code"
+import (
+	"GinTalk/pkg/code"
+	"time"
+)
 
 type ApiError struct {
 	Code code.RespCode `json:"code"`
 	Msg  string        `json:"msg"`
+	// RetryAfter 客户端需要等待多久才能重试, 大于 0 时响应中会携带 Retry-After 头
+	RetryAfter time.Duration `json:"-"`
 }
 
 func (e ApiError) Error() string {
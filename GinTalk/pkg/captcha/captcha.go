@@ -0,0 +1,131 @@
+package captcha
+
+import (
+	"GinTalk/settings"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// DriverText 扭曲文字验证码, 需要输入图片中的字符
+	DriverText = "text"
+	// DriverMath 算术验证码, 需要输入图片中算式的结果
+	DriverMath = "math"
+	// DriverFixed 答案固定的验证码, 只用于自动化测试, 不要在生产环境使用
+	DriverFixed = "fixed"
+
+	// textAlphabet 文字验证码使用的字符, 去掉了容易混淆的 I、L、O、0 和 1
+	textAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+)
+
+var (
+	driver     Driver
+	driverOnce sync.Once
+)
+
+// Driver 生成验证码的接口
+// 验证码完全在本地生成, 不依赖第三方服务
+type Driver interface {
+	// Generate 生成一个验证码, 返回 PNG 图片和答案
+	Generate() (image []byte, answer string, err error)
+}
+
+// GetDriver 获取配置的验证码生成器
+// 使用单例模式, 第一次调用时根据配置创建, 配置错误时直接退出
+func GetDriver() Driver {
+	driverOnce.Do(func() {
+		cfg := settings.GetConfig().CaptchaConfig
+		switch cfg.Driver {
+		case DriverText:
+			driver = &TextDriver{Length: cfg.Length}
+		case DriverMath:
+			driver = &MathDriver{}
+		case DriverFixed:
+			zap.L().Warn("验证码使用固定答案, 只能用于测试环境")
+			driver = &FixedDriver{Answer: cfg.FixedAnswer}
+		default:
+			zap.L().Fatal("不支持的验证码驱动", zap.String("driver", cfg.Driver))
+		}
+	})
+	return driver
+}
+
+// Normalize 规范化用户输入的答案, 忽略大小写和首尾空白
+func Normalize(answer string) string {
+	return strings.ToUpper(strings.TrimSpace(answer))
+}
+
+// randomInt 返回 [0, n) 范围内的安全随机数
+func randomInt(n int) (int, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(v.Int64()), nil
+}
+
+// TextDriver 扭曲文字验证码
+type TextDriver struct {
+	// Length 验证码的字符数, 不大于 0 时使用 5
+	Length int
+}
+
+func (d *TextDriver) Generate() ([]byte, string, error) {
+	length := d.Length
+	if length <= 0 {
+		length = 5
+	}
+	buf := make([]byte, length)
+	for i := range buf {
+		n, err := randomInt(len(textAlphabet))
+		if err != nil {
+			return nil, "", err
+		}
+		buf[i] = textAlphabet[n]
+	}
+	answer := string(buf)
+	img, err := renderImage(answer)
+	return img, answer, err
+}
+
+// MathDriver 算术验证码, 随机生成 20 以内的加法或减法, 结果不为负数
+type MathDriver struct{}
+
+func (d *MathDriver) Generate() ([]byte, string, error) {
+	a, err := randomInt(20)
+	if err != nil {
+		return nil, "", err
+	}
+	b, err := randomInt(20)
+	if err != nil {
+		return nil, "", err
+	}
+	op, err := randomInt(2)
+	if err != nil {
+		return nil, "", err
+	}
+	question := fmt.Sprintf("%d+%d=?", a, b)
+	result := a + b
+	if op == 1 {
+		a, b = max(a, b), min(a, b)
+		question = fmt.Sprintf("%d-%d=?", a, b)
+		result = a - b
+	}
+	img, err := renderImage(question)
+	return img, fmt.Sprint(result), err
+}
+
+// FixedDriver 答案固定的验证码, 用于自动化测试中完成注册和登录
+type FixedDriver struct {
+	Answer string
+}
+
+func (d *FixedDriver) Generate() ([]byte, string, error) {
+	img, err := renderImage(Normalize(d.Answer))
+	return img, Normalize(d.Answer), err
+}
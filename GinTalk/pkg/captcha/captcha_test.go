@@ -0,0 +1,91 @@
+package captcha
+
+import (
+	"bytes"
+	"image/png"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func decodePNG(t *testing.T, img []byte) {
+	t.Helper()
+	if _, err := png.Decode(bytes.NewReader(img)); err != nil {
+		t.Fatalf("验证码图片不是有效的 PNG: %v", err)
+	}
+}
+
+func TestTextDriver(t *testing.T) {
+	tests := []struct {
+		length int
+		want   int
+	}{
+		{length: 0, want: 5},
+		{length: 4, want: 4},
+		{length: 8, want: 8},
+	}
+	for _, tt := range tests {
+		img, answer, err := (&TextDriver{Length: tt.length}).Generate()
+		if err != nil {
+			t.Fatalf("Generate() 失败: %v", err)
+		}
+		if len(answer) != tt.want {
+			t.Errorf("Length=%d 时答案 %q 的长度为 %d, 期望 %d", tt.length, answer, len(answer), tt.want)
+		}
+		for _, r := range answer {
+			if !strings.ContainsRune(textAlphabet, r) {
+				t.Errorf("答案 %q 包含字符表以外的字符 %q", answer, r)
+			}
+		}
+		if Normalize(answer) != answer {
+			t.Errorf("答案 %q 不是规范化的形式", answer)
+		}
+		decodePNG(t, img)
+	}
+}
+
+func TestMathDriver(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		img, answer, err := (&MathDriver{}).Generate()
+		if err != nil {
+			t.Fatalf("Generate() 失败: %v", err)
+		}
+		result, err := strconv.Atoi(answer)
+		if err != nil {
+			t.Fatalf("答案 %q 不是整数", answer)
+		}
+		// 两个 20 以内的数相加或大数减小数
+		if result < 0 || result > 38 {
+			t.Errorf("答案 %d 超出范围 [0, 38]", result)
+		}
+		decodePNG(t, img)
+	}
+}
+
+func TestFixedDriver(t *testing.T) {
+	img, answer, err := (&FixedDriver{Answer: " ab12 "}).Generate()
+	if err != nil {
+		t.Fatalf("Generate() 失败: %v", err)
+	}
+	if answer != "AB12" {
+		t.Errorf("答案为 %q, 期望 %q", answer, "AB12")
+	}
+	decodePNG(t, img)
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "abcde", want: "ABCDE"},
+		{in: "  AbC2 \n", want: "ABC2"},
+		{in: "12", want: "12"},
+		{in: "", want: ""},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.in); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, 期望 %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package captcha
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"math/rand/v2"
+)
+
+const (
+	// pixelScale 点阵字体中每个点放大后的像素数
+	pixelScale = 4
+	// charSpacing 字符之间的间距, 单位像素
+	charSpacing = 6
+	// imagePadding 图片四周的留白, 单位像素
+	imagePadding = 12
+	// noiseLines 干扰线的数量
+	noiseLines = 5
+	// noiseDots 干扰点占图片像素的比例
+	noiseDots = 0.04
+)
+
+// renderImage 将文本渲染为带干扰的 PNG 图片
+// 每个字符随机上下偏移并使用不同的颜色, 整张图片再做正弦扭曲, 最后叠加干扰线和干扰点
+func renderImage(text string) ([]byte, error) {
+	runes := []rune(text)
+	width := imagePadding*2 + len(runes)*(glyphWidth*pixelScale+charSpacing) - charSpacing
+	height := imagePadding*2 + glyphHeight*pixelScale
+	background := color.RGBA{R: 245, G: 245, B: 240, A: 255}
+
+	src := image.NewRGBA(image.Rect(0, 0, width, height))
+	fill(src, background)
+	x := imagePadding
+	for _, r := range runes {
+		glyph, ok := glyphs[r]
+		if ok {
+			drawGlyph(src, glyph, x+rand.IntN(5)-2, imagePadding+rand.IntN(imagePadding)-imagePadding/2, randomInk())
+		}
+		x += glyphWidth*pixelScale + charSpacing
+	}
+
+	dst := image.NewRGBA(src.Bounds())
+	fill(dst, background)
+	amplitude := 2 + rand.Float64()*2
+	period := 20 + rand.Float64()*20
+	phase := rand.Float64() * 2 * math.Pi
+	for py := 0; py < height; py++ {
+		for px := 0; px < width; px++ {
+			sx := px + int(amplitude*math.Sin(2*math.Pi*float64(py)/period+phase))
+			sy := py + int(amplitude*math.Cos(2*math.Pi*float64(px)/period+phase))
+			if image.Pt(sx, sy).In(src.Bounds()) {
+				dst.SetRGBA(px, py, src.RGBAAt(sx, sy))
+			}
+		}
+	}
+
+	for i := 0; i < noiseLines; i++ {
+		drawLine(dst, rand.IntN(width), rand.IntN(height), rand.IntN(width), rand.IntN(height), randomInk())
+	}
+	for i := 0; i < int(float64(width*height)*noiseDots); i++ {
+		dst.SetRGBA(rand.IntN(width), rand.IntN(height), randomInk())
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func fill(img *image.RGBA, c color.RGBA) {
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+}
+
+// randomInk 随机的深色, 保证与浅色背景有足够的对比度
+func randomInk() color.RGBA {
+	return color.RGBA{R: uint8(rand.IntN(120)), G: uint8(rand.IntN(120)), B: uint8(rand.IntN(120)), A: 255}
+}
+
+// drawGlyph 以 (x, y) 为左上角绘制一个放大后的点阵字符
+func drawGlyph(img *image.RGBA, glyph [glyphHeight]string, x int, y int, c color.RGBA) {
+	for row, line := range glyph {
+		for col, dot := range line {
+			if dot != '#' {
+				continue
+			}
+			for dy := 0; dy < pixelScale; dy++ {
+				for dx := 0; dx < pixelScale; dx++ {
+					p := image.Pt(x+col*pixelScale+dx, y+row*pixelScale+dy)
+					if p.In(img.Bounds()) {
+						img.SetRGBA(p.X, p.Y, c)
+					}
+				}
+			}
+		}
+	}
+}
+
+// drawLine 使用 Bresenham 算法绘制干扰线
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.RGBA) {
+	dx := abs(x1 - x0)
+	dy := -abs(y1 - y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		img.SetRGBA(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
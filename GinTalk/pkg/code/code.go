@@ -13,19 +13,57 @@ const (
 	PasswordError
 	UserRefreshTokenError
 	TimeOut
+	SessionNotExist
+	EmailNotVerified
+	ActionTokenInvalid
+	RequestTooFrequent
+	TwoFactorCodeError
+	TwoFactorAlreadyEnabled
+	TwoFactorNotEnabled
+	LoginLocked
+	PermissionDenied
+	UserBanned
+	TokenNotExist
+	TokenScopeDenied
+	RegistrationClosed
+	InviteCodeInvalid
+	CaptchaRequired
+	CaptchaInvalid
+	PreconditionFailed
+	FileTooLarge
+	UnsupportedFileType
 )
 
 var codeMsg = map[RespCode]string{
-	Success:               "success",
-	InvalidParam:          "请求参数错误",
-	InvalidPassword:       "密码错误",
-	InvalidToken:          "无效的token",
-	InvalidAuth:           "无效的授权",
-	ServerError:           "服务器错误",
-	UserNotExist:          "用户不存在",
-	PasswordError:         "密码错误",
-	UserRefreshTokenError: "刷新token错误",
-	TimeOut:               "超时",
+	Success:                 "success",
+	InvalidParam:            "请求参数错误",
+	InvalidPassword:         "密码错误",
+	InvalidToken:            "无效的token",
+	InvalidAuth:             "无效的授权",
+	ServerError:             "服务器错误",
+	UserNotExist:            "用户不存在",
+	PasswordError:           "密码错误",
+	UserRefreshTokenError:   "刷新token错误",
+	TimeOut:                 "超时",
+	SessionNotExist:         "会话不存在",
+	EmailNotVerified:        "邮箱未验证",
+	ActionTokenInvalid:      "链接无效或已过期",
+	RequestTooFrequent:      "请求过于频繁",
+	TwoFactorCodeError:      "验证码错误",
+	TwoFactorAlreadyEnabled: "两步验证已启用",
+	TwoFactorNotEnabled:     "两步验证未启用",
+	LoginLocked:             "登录失败次数过多, 请稍后再试",
+	PermissionDenied:        "没有权限",
+	UserBanned:              "用户已被封禁",
+	TokenNotExist:           "令牌不存在",
+	TokenScopeDenied:        "令牌的授权范围不足",
+	RegistrationClosed:      "暂不开放注册",
+	InviteCodeInvalid:       "邀请码无效或已过期",
+	CaptchaRequired:         "需要验证码",
+	CaptchaInvalid:          "验证码错误或已过期",
+	PreconditionFailed:      "内容已被修改, 请刷新后重试",
+	FileTooLarge:            "文件过大",
+	UnsupportedFileType:     "不支持的文件类型",
 }
 
 func (c RespCode) GetMsg() string {
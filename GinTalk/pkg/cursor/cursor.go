@@ -0,0 +1,48 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalid 游标格式不正确
+var ErrInvalid = errors.New("游标格式不正确")
+
+// Cursor 列表中一条记录的位置, 由排序值和记录 ID 组成
+// 列表按排序值倒序排列, 排序值相同时按 ID 倒序排列, 下一页从游标之后的记录开始
+type Cursor struct {
+	Score float64
+	ID    int64
+}
+
+// Encode 把游标编码为不透明的字符串, 客户端只需原样传回
+func (c Cursor) Encode() string {
+	raw := strconv.FormatFloat(c.Score, 'g', -1, 64) + ":" + strconv.FormatInt(c.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// Decode 解析 Encode 生成的字符串, 空字符串表示从第一页开始, 返回 nil
+func Decode(token string) (*Cursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	score, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, ErrInvalid
+	}
+	c := &Cursor{}
+	if c.Score, err = strconv.ParseFloat(score, 64); err != nil || math.IsNaN(c.Score) || math.IsInf(c.Score, 0) {
+		return nil, ErrInvalid
+	}
+	if c.ID, err = strconv.ParseInt(id, 10, 64); err != nil {
+		return nil, ErrInvalid
+	}
+	return c, nil
+}
@@ -0,0 +1,59 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	cursors := []Cursor{
+		{Score: 0, ID: 0},
+		{Score: 1700000000, ID: 123456789012345678},
+		{Score: -1.25, ID: 42},
+		{Score: 0.1 + 0.2, ID: 7},
+		{Score: math.MaxFloat64, ID: math.MaxInt64},
+		{Score: -math.SmallestNonzeroFloat64, ID: -1},
+	}
+	for _, c := range cursors {
+		got, err := Decode(c.Encode())
+		if err != nil {
+			t.Fatalf("Decode(%v.Encode()) 失败: %v", c, err)
+		}
+		if *got != c {
+			t.Errorf("Decode(Encode(%v)) = %v", c, *got)
+		}
+	}
+}
+
+func TestDecodeEmpty(t *testing.T) {
+	c, err := Decode("")
+	if c != nil || err != nil {
+		t.Errorf("Decode(\"\") = %v, %v, 期望 nil, nil", c, err)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+	tokens := map[string]string{
+		"不是 base64":   "!!!",
+		"带填充的 base64": base64.URLEncoding.EncodeToString([]byte("1:23")),
+		"缺少分隔符":       encode("12"),
+		"分数不是数字":      encode("abc:1"),
+		"ID 不是整数":     encode("1:1.5"),
+		"ID 为空":       encode("1:"),
+		"NaN":         encode("NaN:1"),
+		"正无穷":         encode("+Inf:1"),
+		"负无穷":         encode("-Inf:1"),
+		"多余的分隔符":      encode("1:2:3"),
+	}
+	for name, token := range tokens {
+		c, err := Decode(token)
+		if !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: Decode(%q) = %v, %v, 期望 ErrInvalid", name, token, c, err)
+		}
+	}
+}
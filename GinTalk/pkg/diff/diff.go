@@ -0,0 +1,167 @@
+// Package diff 计算两段文本之间按行的差异
+// 使用 Myers 差分算法得到最短的编辑序列
+package diff
+
+import (
+	"slices"
+	"strings"
+)
+
+// Op 差异中一行的操作类型
+type Op string
+
+const (
+	OpEqual  Op = "equal"
+	OpInsert Op = "insert"
+	OpDelete Op = "delete"
+)
+
+// maxEditDistance 编辑距离超过该值时不再计算最短差异, 直接删除全部旧行并插入全部新行
+// 避免两段完全不同的长文本占用过多内存
+const maxEditDistance = 1000
+
+// Line 差异中的一行
+// OldLine 和 NewLine 分别为该行在旧文本和新文本中的行号, 从 1 开始, 该行不存在时为 0
+type Line struct {
+	Op      Op     `json:"op"`
+	Text    string `json:"text"`
+	OldLine int    `json:"old_line,omitempty"`
+	NewLine int    `json:"new_line,omitempty"`
+}
+
+// Lines 计算从 oldText 到 newText 按行的差异
+func Lines(oldText, newText string) []Line {
+	a := splitLines(oldText)
+	b := splitLines(newText)
+
+	// 相同的前缀和后缀不参与差分计算
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	result := make([]Line, 0, len(a)+len(b)-prefix-suffix)
+	for i := 0; i < prefix; i++ {
+		result = append(result, Line{Op: OpEqual, Text: a[i], OldLine: i + 1, NewLine: i + 1})
+	}
+	for _, line := range shortestEdit(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]) {
+		if line.OldLine > 0 {
+			line.OldLine += prefix
+		}
+		if line.NewLine > 0 {
+			line.NewLine += prefix
+		}
+		result = append(result, line)
+	}
+	for i := suffix; i > 0; i-- {
+		result = append(result, Line{Op: OpEqual, Text: a[len(a)-i], OldLine: len(a) - i + 1, NewLine: len(b) - i + 1})
+	}
+	return result
+}
+
+// splitLines 按行拆分文本, 忽略末尾的换行符
+func splitLines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// shortestEdit 使用 Myers 算法计算最短编辑序列
+// v[k] 记录对角线 k = x - y 上能到达的最远 x, 每一轮保存一份快照用于回溯
+func shortestEdit(a, b []string) []Line {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil
+	}
+	limit := min(n+m, maxEditDistance)
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	trace := make([][]int, 0, limit+1)
+
+	for d := 0; d <= limit; d++ {
+		// 第 d 轮开始前的状态, 回溯时只会用到 [-d, d] 范围内的对角线
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[offset-d:offset+d+1])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace)
+			}
+		}
+	}
+	return replaceAll(a, b)
+}
+
+// backtrack 从终点沿保存的快照回溯出编辑序列
+func backtrack(a, b []string, trace [][]int) []Line {
+	x, y := len(a), len(b)
+	lines := make([]Line, 0, x+y)
+	for d := len(trace) - 1; d >= 0; d-- {
+		if d == 0 {
+			// 第 0 轮只有对角线 0 上的相同行
+			for x > 0 && y > 0 {
+				lines = append(lines, Line{Op: OpEqual, Text: a[x-1], OldLine: x, NewLine: y})
+				x--
+				y--
+			}
+			break
+		}
+
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[k-1+d] < v[k+1+d]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[prevK+d]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			lines = append(lines, Line{Op: OpEqual, Text: a[x-1], OldLine: x, NewLine: y})
+			x--
+			y--
+		}
+		if x == prevX {
+			lines = append(lines, Line{Op: OpInsert, Text: b[prevY], NewLine: prevY + 1})
+		} else {
+			lines = append(lines, Line{Op: OpDelete, Text: a[prevX], OldLine: prevX + 1})
+		}
+		x, y = prevX, prevY
+	}
+	slices.Reverse(lines)
+	return lines
+}
+
+// replaceAll 删除全部旧行并插入全部新行
+func replaceAll(a, b []string) []Line {
+	lines := make([]Line, 0, len(a)+len(b))
+	for i, text := range a {
+		lines = append(lines, Line{Op: OpDelete, Text: text, OldLine: i + 1})
+	}
+	for i, text := range b {
+		lines = append(lines, Line{Op: OpInsert, Text: text, NewLine: i + 1})
+	}
+	return lines
+}
@@ -3,13 +3,131 @@ package pkg
 import (
 	"GinTalk/settings"
 	"crypto/md5"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	// PasswordAlgorithmArgon2id 当前使用的密码哈希算法
+	PasswordAlgorithmArgon2id = "argon2id"
+
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+var (
+	// ErrInvalidPasswordHash 存储的密码哈希无法解析
+	ErrInvalidPasswordHash = errors.New("无法解析的密码哈希")
+	// ErrIncompatibleArgon2Version argon2 版本不兼容
+	ErrIncompatibleArgon2Version = errors.New("不兼容的 argon2 版本")
 )
 
-// EncryptPassword 用于加密密码
-func EncryptPassword(password string) string {
+// argon2Params argon2id 的参数
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+}
+
+// currentArgon2Params 从配置中读取 argon2id 参数
+func currentArgon2Params() argon2Params {
+	cfg := settings.GetConfig().PasswordHashConfig
+	return argon2Params{
+		memory:      cfg.Memory,
+		iterations:  cfg.Iterations,
+		parallelism: cfg.Parallelism,
+	}
+}
+
+// HashPassword 使用 argon2id 对密码进行哈希
+// 返回自描述的 PHC 格式字符串, 包含算法、版本、参数、盐值和哈希值:
+//
+//	$argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+func HashPassword(password string) (string, error) {
+	p := currentArgon2Params()
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	hash := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, argon2KeyLength)
+
+	b64 := base64.RawStdEncoding
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		PasswordAlgorithmArgon2id, argon2.Version,
+		p.memory, p.iterations, p.parallelism,
+		b64.EncodeToString(salt), b64.EncodeToString(hash),
+	), nil
+}
+
+// VerifyPassword 校验密码是否与存储的哈希匹配, 比较过程为常量时间
+//
+// 参数:
+//   - password: 用户输入的明文密码
+//   - encoded: 数据库中存储的密码哈希
+//
+// 返回值:
+//   - match: 密码是否正确
+//   - needsRehash: 存储的哈希是否需要升级 (旧的 MD5 格式或 argon2 参数已变更)
+//   - err: 哈希格式无法解析时返回错误
+func VerifyPassword(password, encoded string) (match bool, needsRehash bool, err error) {
+	if !strings.HasPrefix(encoded, "$") {
+		return verifyLegacyPassword(password, encoded), true, nil
+	}
+
+	p, salt, hash, err := decodeArgon2Hash(encoded)
+	if err != nil {
+		return false, false, err
+	}
+	other := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, uint32(len(hash)))
+	if subtle.ConstantTimeCompare(hash, other) != 1 {
+		return false, false, nil
+	}
+	return true, p != currentArgon2Params(), nil
+}
+
+// decodeArgon2Hash 解析 PHC 格式的 argon2id 哈希
+func decodeArgon2Hash(encoded string) (p argon2Params, salt, hash []byte, err error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != PasswordAlgorithmArgon2id {
+		return p, nil, nil, ErrInvalidPasswordHash
+	}
+
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return p, nil, nil, ErrInvalidPasswordHash
+	}
+	if version != argon2.Version {
+		return p, nil, nil, ErrIncompatibleArgon2Version
+	}
+
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.iterations, &p.parallelism); err != nil {
+		return p, nil, nil, ErrInvalidPasswordHash
+	}
+
+	b64 := base64.RawStdEncoding
+	if salt, err = b64.DecodeString(parts[4]); err != nil {
+		return p, nil, nil, ErrInvalidPasswordHash
+	}
+	if hash, err = b64.DecodeString(parts[5]); err != nil || len(hash) == 0 {
+		return p, nil, nil, ErrInvalidPasswordHash
+	}
+	return p, salt, hash, nil
+}
+
+// verifyLegacyPassword 校验旧版 MD5 格式的密码
+// 旧版格式为 hex(password + md5(password_secret)), 仅用于兼容已有数据,
+// 校验通过后应立即使用 HashPassword 重新哈希
+func verifyLegacyPassword(password, encoded string) bool {
 	var secret = settings.GetConfig().PasswordSecret
 	h := md5.New()
 	h.Write([]byte(secret))
-	return hex.EncodeToString(h.Sum([]byte(password)))
+	legacy := hex.EncodeToString(h.Sum([]byte(password)))
+	return subtle.ConstantTimeCompare([]byte(legacy), []byte(encoded)) == 1
 }
@@ -0,0 +1,148 @@
+// Package imaging 处理上传的图片
+// 图片解码后重新编码, 丢弃 EXIF、XMP、文本块等元数据, 并生成缩略图;
+// JPEG 的 EXIF 方向在丢弃之前先应用到像素上, 保证重新编码后的图片方向不变
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+const (
+	jpegQuality          = 90
+	thumbnailJPEGQuality = 80
+)
+
+// ErrTooLarge 图片的像素数超过限制
+var ErrTooLarge = errors.New("imaging: image too large")
+
+// Result 重新编码后的图片
+// 缩略图的格式: JPEG 图片的缩略图为 JPEG, PNG 和 GIF 的缩略图为 PNG (GIF 取第一帧), 保留透明度
+type Result struct {
+	Data          []byte
+	Width         int
+	Height        int
+	Thumbnail     []byte
+	ThumbnailType string
+}
+
+// Process 重新编码图片并生成最大边长为 thumbnailSize 的缩略图
+// contentType 为 image/jpeg、image/png 或 image/gif, 像素数 (GIF 为所有帧的总和) 超过 maxPixels 时返回 ErrTooLarge
+func Process(data []byte, contentType string, maxPixels int, thumbnailSize int) (*Result, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > maxPixels/cfg.Height {
+		return nil, ErrTooLarge
+	}
+
+	switch contentType {
+	case "image/jpeg":
+		return processJPEG(data, thumbnailSize)
+	case "image/png":
+		return processPNG(data, thumbnailSize)
+	case "image/gif":
+		return processGIF(data, cfg, maxPixels, thumbnailSize)
+	default:
+		return nil, fmt.Errorf("imaging: unsupported content type %q", contentType)
+	}
+}
+
+func processJPEG(data []byte, thumbnailSize int) (*Result, error) {
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	img = applyOrientation(img, jpegOrientation(data))
+
+	var buf, thumb bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, err
+	}
+	if err := jpeg.Encode(&thumb, Thumbnail(img, thumbnailSize), &jpeg.Options{Quality: thumbnailJPEGQuality}); err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	return &Result{
+		Data:          buf.Bytes(),
+		Width:         b.Dx(),
+		Height:        b.Dy(),
+		Thumbnail:     thumb.Bytes(),
+		ThumbnailType: "image/jpeg",
+	}, nil
+}
+
+func processPNG(data []byte, thumbnailSize int) (*Result, error) {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	var buf, thumb bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	if err := png.Encode(&thumb, Thumbnail(img, thumbnailSize)); err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	return &Result{
+		Data:          buf.Bytes(),
+		Width:         b.Dx(),
+		Height:        b.Dy(),
+		Thumbnail:     thumb.Bytes(),
+		ThumbnailType: "image/png",
+	}, nil
+}
+
+// processGIF 重新编码 GIF 的所有帧, 保留帧间隔、处置方式和循环次数, 丢弃注释和应用扩展
+func processGIF(data []byte, cfg image.Config, maxPixels int, thumbnailSize int) (*Result, error) {
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(g.Image) == 0 {
+		return nil, errors.New("imaging: gif has no frames")
+	}
+	total := 0
+	for _, frame := range g.Image {
+		b := frame.Bounds()
+		total += b.Dx() * b.Dy()
+		if total > maxPixels {
+			return nil, ErrTooLarge
+		}
+	}
+
+	var buf, thumb bytes.Buffer
+	out := &gif.GIF{
+		Image:           g.Image,
+		Delay:           g.Delay,
+		LoopCount:       g.LoopCount,
+		Disposal:        g.Disposal,
+		Config:          g.Config,
+		BackgroundIndex: g.BackgroundIndex,
+	}
+	if err := gif.EncodeAll(&buf, out); err != nil {
+		return nil, err
+	}
+
+	// 第一帧可能小于画布, 先画到画布上再缩小
+	canvas := image.NewRGBA(image.Rect(0, 0, cfg.Width, cfg.Height))
+	draw.Draw(canvas, g.Image[0].Bounds(), g.Image[0], g.Image[0].Bounds().Min, draw.Src)
+	if err := png.Encode(&thumb, Thumbnail(canvas, thumbnailSize)); err != nil {
+		return nil, err
+	}
+	return &Result{
+		Data:          buf.Bytes(),
+		Width:         cfg.Width,
+		Height:        cfg.Height,
+		Thumbnail:     thumb.Bytes(),
+		ThumbnailType: "image/png",
+	}, nil
+}
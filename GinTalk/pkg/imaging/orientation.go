@@ -0,0 +1,122 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+)
+
+const exifOrientationTag = 0x0112
+
+// jpegOrientation 读取 JPEG 中 EXIF 的方向 (1 到 8), 没有 EXIF 或解析失败时返回 1
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		// 图像数据开始, 之后不会再有 EXIF
+		if marker == 0xDA || marker == 0xD9 {
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+// exifOrientation 从 TIFF 格式的 EXIF 数据的第一个 IFD 中读取方向
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return 1
+	}
+	offset := int(order.Uint32(tiff[4:]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		// 方向的类型为 SHORT, 值直接保存在条目中
+		if order.Uint16(tiff[entry:]) == exifOrientationTag && order.Uint16(tiff[entry+2:]) == 3 {
+			v := int(order.Uint16(tiff[entry+8:]))
+			if v < 1 || v > 8 {
+				return 1
+			}
+			return v
+		}
+	}
+	return 1
+}
+
+// applyOrientation 按 EXIF 方向旋转或翻转图片, 使图片按正常方向显示
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	// src 返回目标坐标 (x, y) 对应的原图坐标
+	var src func(x, y int) (int, int)
+	switch orientation {
+	case 2: // 水平翻转
+		src = func(x, y int) (int, int) { return w - 1 - x, y }
+	case 3: // 旋转 180 度
+		src = func(x, y int) (int, int) { return w - 1 - x, h - 1 - y }
+	case 4: // 垂直翻转
+		src = func(x, y int) (int, int) { return x, h - 1 - y }
+	case 5: // 沿左上到右下的对角线翻转
+		src = func(x, y int) (int, int) { return y, x }
+	case 6: // 顺时针旋转 90 度
+		src = func(x, y int) (int, int) { return y, h - 1 - x }
+	case 7: // 沿右上到左下的对角线翻转
+		src = func(x, y int) (int, int) { return w - 1 - y, h - 1 - x }
+	case 8: // 逆时针旋转 90 度
+		src = func(x, y int) (int, int) { return w - 1 - y, x }
+	}
+
+	at := pixelReader(img)
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			sx, sy := src(x, y)
+			r, g, bl, a := at(b.Min.X+sx, b.Min.Y+sy)
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8(r >> 8)
+			dst.Pix[i+1] = uint8(g >> 8)
+			dst.Pix[i+2] = uint8(bl >> 8)
+			dst.Pix[i+3] = uint8(a >> 8)
+		}
+	}
+	return dst
+}
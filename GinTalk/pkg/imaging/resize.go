@@ -0,0 +1,77 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+)
+
+// pixelReader 返回读取像素的函数, 结果为 16 位预乘 alpha 的 RGBA
+// 常见的图片类型直接读取, 避免通过 image.Image.At 为每个像素分配内存
+func pixelReader(img image.Image) func(x, y int) (r, g, b, a uint32) {
+	switch src := img.(type) {
+	case *image.YCbCr:
+		return func(x, y int) (uint32, uint32, uint32, uint32) {
+			c := src.YCbCrAt(x, y)
+			r, g, b := color.YCbCrToRGB(c.Y, c.Cb, c.Cr)
+			return uint32(r) * 0x101, uint32(g) * 0x101, uint32(b) * 0x101, 0xffff
+		}
+	case *image.RGBA:
+		return func(x, y int) (uint32, uint32, uint32, uint32) {
+			return src.RGBAAt(x, y).RGBA()
+		}
+	case *image.NRGBA:
+		return func(x, y int) (uint32, uint32, uint32, uint32) {
+			return src.NRGBAAt(x, y).RGBA()
+		}
+	case *image.Gray:
+		return func(x, y int) (uint32, uint32, uint32, uint32) {
+			return src.GrayAt(x, y).RGBA()
+		}
+	default:
+		return func(x, y int) (uint32, uint32, uint32, uint32) {
+			return img.At(x, y).RGBA()
+		}
+	}
+}
+
+// Thumbnail 按比例缩小图片, 使宽和高都不超过 maxSize
+// 使用区域平均缩小, 图片本身不超过 maxSize 时原样返回
+func Thumbnail(img image.Image, maxSize int) image.Image {
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	if sw <= maxSize && sh <= maxSize {
+		return img
+	}
+	dw, dh := maxSize, maxSize
+	if sw >= sh {
+		dh = max(1, sh*maxSize/sw)
+	} else {
+		dw = max(1, sw*maxSize/sh)
+	}
+
+	at := pixelReader(img)
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		y0, y1 := dy*sh/dh, (dy+1)*sh/dh
+		for dx := 0; dx < dw; dx++ {
+			x0, x1 := dx*sw/dw, (dx+1)*sw/dw
+			var sr, sg, sb, sa, n uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					r, g, bl, a := at(b.Min.X+x, b.Min.Y+y)
+					sr += uint64(r)
+					sg += uint64(g)
+					sb += uint64(bl)
+					sa += uint64(a)
+					n++
+				}
+			}
+			i := dst.PixOffset(dx, dy)
+			dst.Pix[i+0] = uint8(sr / n >> 8)
+			dst.Pix[i+1] = uint8(sg / n >> 8)
+			dst.Pix[i+2] = uint8(sb / n >> 8)
+			dst.Pix[i+3] = uint8(sa / n >> 8)
+		}
+	}
+	return dst
+}
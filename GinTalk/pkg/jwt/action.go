@@ -0,0 +1,75 @@
+package jwt
+
+import (
+	"GinTalk/settings"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// PurposeVerifyEmail 验证邮箱
+	PurposeVerifyEmail = "verify_email"
+	// PurposeResetPassword 重置密码
+	PurposeResetPassword = "reset_password"
+	// PurposeLoginChallenge 两步验证登录挑战, 密码验证通过后签发
+	PurposeLoginChallenge = "login_challenge"
+)
+
+// ActionClaims 一次性操作令牌, 例如通过邮件链接发送给用户的验证令牌
+// 签名保证令牌不可伪造, 令牌 ID 存储在 Redis 中保证只能使用一次
+type ActionClaims struct {
+	UserID  int64  `json:"user_id"`
+	Purpose string `json:"purpose"`
+	// Email 签发时用户的邮箱, 邮箱变更后旧的验证链接随之失效
+	Email string `json:"email,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// GenerateActionToken 签发一次性操作令牌
+//
+// 返回:
+//   - string: 签名后的令牌。
+//   - string: 令牌 ID, 调用方需要将其保存到 Redis 中。
+//   - error: 签名失败时返回错误。
+func GenerateActionToken(userID int64, purpose string, email string, expiration time.Duration) (string, string, error) {
+	now := time.Now()
+	tokenID := uuid.NewString()
+	token, err := GetKeySet().sign(ActionClaims{
+		UserID:  userID,
+		Purpose: purpose,
+		Email:   email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiration)),
+			Issuer:    settings.GetConfig().JWTConfig.Issuer,
+		},
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return token, tokenID, nil
+}
+
+// ParseActionToken 解析一次性操作令牌, 并校验令牌的用途
+// 访问令牌和刷新令牌没有 purpose, 不能被当作操作令牌使用
+func ParseActionToken(tokenString string, purpose string) (*ActionClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &ActionClaims{}, GetKeySet().keyFunc,
+		jwt.WithIssuer(settings.GetConfig().JWTConfig.Issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := token.Claims.(*ActionClaims)
+	if !ok {
+		return nil, fmt.Errorf("token 格式错误")
+	}
+	if claims.Purpose != purpose || claims.ID == "" {
+		return nil, fmt.Errorf("token 用途错误")
+	}
+	return claims, nil
+}
@@ -1,15 +1,14 @@
 package jwt
 
 import (
+	"GinTalk/settings"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"strconv"
-	"sync"
 	"time"
 )
 
-var mySecret = "111"
-
 const (
 	// AccessTokenName 是访问令牌的key
 	AccessTokenName = "access"
@@ -21,11 +20,33 @@ type MyClaims struct {
 	UserID    int64  `json:"user_id"`
 	Username  string `json:"username"`
 	TokenType string `json:"token_type"`
+	// FamilyID 同一次登录中不断轮换出的 token 属于同一个家族
+	FamilyID string `json:"fid,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// TokenPair 一次签发的访问令牌和刷新令牌
+type TokenPair struct {
+	AccessToken      string
+	RefreshToken     string
+	RefreshTokenID   string
+	FamilyID         string
+	RefreshExpiresAt time.Time
+}
+
+// AccessTokenTTL 访问令牌有效期
+func AccessTokenTTL() time.Duration {
+	return time.Duration(settings.GetConfig().JWTConfig.AccessTokenExpire) * time.Minute
+}
+
+// RefreshTokenTTL 刷新令牌有效期
+func RefreshTokenTTL() time.Duration {
+	return time.Duration(settings.GetConfig().JWTConfig.RefreshTokenExpire) * time.Minute
+}
+
 // GenerateToken 生成token
-func GenerateToken[T int64 | string | uint](userID T, username string) (accessToken string, refreshToken string, err error) {
+// familyID 为空时表示一次新的登录, 会生成新的 token 家族; 轮换刷新令牌时应传入原来的 familyID
+func GenerateToken[T int64 | string | uint](userID T, username string, familyID string) (*TokenPair, error) {
 	var int64UserID int64
 	switch v := any(userID).(type) {
 	case uint:
@@ -36,67 +57,58 @@ func GenerateToken[T int64 | string | uint](userID T, username string) (accessTo
 		// 尝试将 string 转为 uint
 		parsedID, err := strconv.ParseUint(v, 10, 32) // 假设 uint 是 32 位
 		if err != nil {
-			return "", "", fmt.Errorf("invalid userID format, could not convert to uint: %v", err)
+			return nil, fmt.Errorf("invalid userID format, could not convert to uint: %v", err)
 		}
 		int64UserID = int64(parsedID)
 	default:
-		return "", "", fmt.Errorf("unsupported userID type")
+		return nil, fmt.Errorf("unsupported userID type")
+	}
+
+	if familyID == "" {
+		familyID = uuid.NewString()
 	}
 
-	f := func(userID int64, username string, tokenType string, validTime time.Duration) (string, error) {
+	now := time.Now()
+	f := func(tokenType string, tokenID string, expiresAt time.Time) (string, error) {
 		c := MyClaims{
-			UserID:    userID,
+			UserID:    int64UserID,
 			Username:  username,
 			TokenType: tokenType,
+			FamilyID:  familyID,
 			RegisteredClaims: jwt.RegisteredClaims{
-				ExpiresAt: jwt.NewNumericDate(time.Now().Add(validTime)),
-				Issuer:    "水告木南",
+				ID:        tokenID,
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(expiresAt),
+				Issuer:    settings.GetConfig().JWTConfig.Issuer,
 			},
 		}
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, c)
-		return token.SignedString([]byte(mySecret))
+		return GetKeySet().sign(c)
 	}
 
-	var wg sync.WaitGroup
-	errorChannel := make(chan error)
-	wg.Add(2)
-	go func() {
-		defer wg.Done()
-		accessToken, err = f(int64UserID, username, AccessTokenName, time.Hour*24*7)
-		if err != nil {
-			errorChannel <- err
-			return
-		}
-	}()
-
-	go func() {
-		defer wg.Done()
-		refreshToken, err = f(int64UserID, username, RefreshTokenName, time.Hour*24*7)
-		if err != nil {
-			errorChannel <- err
-			return
-		}
-	}()
-
-	go func() {
-		wg.Wait()
-		close(errorChannel)
-	}()
-
-	for err = range errorChannel {
-		if err != nil {
-			return "", "", err
-		}
+	pair := &TokenPair{
+		RefreshTokenID:   uuid.NewString(),
+		FamilyID:         familyID,
+		RefreshExpiresAt: now.Add(RefreshTokenTTL()),
 	}
 
-	return accessToken, refreshToken, nil
+	var err error
+	pair.AccessToken, err = f(AccessTokenName, uuid.NewString(), now.Add(AccessTokenTTL()))
+	if err != nil {
+		return nil, err
+	}
+	pair.RefreshToken, err = f(RefreshTokenName, pair.RefreshTokenID, pair.RefreshExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return pair, nil
 }
 
 // ParseToken 解析token
+// 根据 token header 中的 kid 选择验签密钥, 因此轮换签名密钥后旧 token 在过期前仍然有效
 func ParseToken(tokenString string) (*MyClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &MyClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(mySecret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &MyClaims{}, GetKeySet().keyFunc,
+		jwt.WithIssuer(settings.GetConfig().JWTConfig.Issuer),
+	)
 	if err != nil {
 		return nil, err
 	}
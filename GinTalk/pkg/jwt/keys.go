@@ -0,0 +1,260 @@
+package jwt
+
+import (
+	"GinTalk/settings"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+var (
+	keys     *KeySet
+	keysOnce sync.Once
+)
+
+// Key 一个签名或验签密钥
+// 对称密钥 (HS256) 同时用于签名和验签, 不会出现在 JWKS 中;
+// 非对称密钥只配置公钥时只能用于验签, 用于轮换后仍需校验旧 token 的场景
+type Key struct {
+	Kid       string
+	Method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+	publicKey crypto.PublicKey
+	canSign   bool
+	symmetric bool
+}
+
+// KeySet 当前生效的所有密钥
+// Signing 为用于签发新 token 的密钥, Keys 为所有可用于验签的密钥
+type KeySet struct {
+	Signing *Key
+	Keys    map[string]*Key
+}
+
+// GetKeySet 获取密钥集合
+// 使用单例模式, 第一次调用时从配置中加载密钥, 配置错误时直接退出
+func GetKeySet() *KeySet {
+	keysOnce.Do(func() {
+		ks, err := loadKeySet(settings.GetConfig().JWTConfig)
+		if err != nil {
+			zap.L().Fatal("加载 JWT 密钥失败", zap.Error(err))
+		}
+		keys = ks
+	})
+	return keys
+}
+
+// loadKeySet 根据配置加载密钥集合
+func loadKeySet(cfg *settings.JWTConfig) (*KeySet, error) {
+	ks := &KeySet{Keys: make(map[string]*Key)}
+
+	if cfg == nil || len(cfg.Keys) == 0 {
+		// 未配置密钥时生成一个随机的 HS256 密钥, 服务重启后所有 token 失效, 仅用于开发环境
+		zap.L().Warn("未配置 JWT 密钥, 使用随机生成的临时密钥")
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		key := &Key{Kid: "ephemeral", Method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret, canSign: true, symmetric: true}
+		ks.Keys[key.Kid] = key
+		ks.Signing = key
+		return ks, nil
+	}
+
+	for _, kc := range cfg.Keys {
+		if kc.Kid == "" {
+			return nil, fmt.Errorf("JWT 密钥缺少 kid")
+		}
+		if _, exist := ks.Keys[kc.Kid]; exist {
+			return nil, fmt.Errorf("JWT 密钥 kid 重复: %s", kc.Kid)
+		}
+		key, err := loadKey(kc)
+		if err != nil {
+			return nil, fmt.Errorf("加载 JWT 密钥 %s 失败: %w", kc.Kid, err)
+		}
+		ks.Keys[kc.Kid] = key
+	}
+
+	signing, ok := ks.Keys[cfg.SigningKey]
+	if !ok {
+		return nil, fmt.Errorf("签名密钥 %q 不存在", cfg.SigningKey)
+	}
+	if !signing.canSign {
+		return nil, fmt.Errorf("签名密钥 %q 缺少私钥", cfg.SigningKey)
+	}
+	ks.Signing = signing
+	return ks, nil
+}
+
+// loadKey 加载单个密钥
+func loadKey(kc settings.JWTKeyConfig) (*Key, error) {
+	key := &Key{Kid: kc.Kid}
+
+	switch kc.Algorithm {
+	case AlgorithmHS256:
+		if kc.Secret == "" {
+			return nil, fmt.Errorf("HS256 密钥缺少 secret")
+		}
+		key.Method = jwt.SigningMethodHS256
+		key.signKey = []byte(kc.Secret)
+		key.verifyKey = []byte(kc.Secret)
+		key.canSign = true
+		key.symmetric = true
+		return key, nil
+
+	case AlgorithmRS256:
+		key.Method = jwt.SigningMethodRS256
+		if kc.PrivateKeyFile != "" {
+			data, err := os.ReadFile(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			priv, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			key.signKey = priv
+			key.publicKey = &priv.PublicKey
+			key.canSign = true
+		} else {
+			data, err := os.ReadFile(kc.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			pub, err := jwt.ParseRSAPublicKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			key.publicKey = pub
+		}
+		key.verifyKey = key.publicKey
+		return key, nil
+
+	case AlgorithmEdDSA:
+		key.Method = jwt.SigningMethodEdDSA
+		if kc.PrivateKeyFile != "" {
+			data, err := os.ReadFile(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			priv, err := jwt.ParseEdPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			edPriv, ok := priv.(ed25519.PrivateKey)
+			if !ok {
+				return nil, fmt.Errorf("不是 Ed25519 私钥")
+			}
+			key.signKey = edPriv
+			key.publicKey = edPriv.Public()
+			key.canSign = true
+		} else {
+			data, err := os.ReadFile(kc.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			pub, err := jwt.ParseEdPublicKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			key.publicKey = pub
+		}
+		key.verifyKey = key.publicKey
+		return key, nil
+
+	default:
+		return nil, fmt.Errorf("不支持的签名算法: %s", kc.Algorithm)
+	}
+}
+
+// sign 使用当前签名密钥签发 token, 并在 header 中写入 kid
+func (ks *KeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.Signing.Method, claims)
+	token.Header["kid"] = ks.Signing.Kid
+	return token.SignedString(ks.Signing.signKey)
+}
+
+// keyFunc 根据 token header 中的 kid 选择验签密钥
+// 同时校验 token 的签名算法与密钥的算法一致, 防止算法混淆攻击
+func (ks *KeySet) keyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key := ks.Signing
+	if kid != "" {
+		var ok bool
+		if key, ok = ks.Keys[kid]; !ok {
+			return nil, fmt.Errorf("未知的 kid: %s", kid)
+		}
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("签名算法不匹配: %s", token.Method.Alg())
+	}
+	return key.verifyKey, nil
+}
+
+// JWK JSON Web Key, 参考 RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA 公钥
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519 公钥
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWKS 返回所有非对称密钥的公钥, 供其他服务验证 GinTalk 签发的 token
+// 对称密钥不会被公开
+func PublicJWKS() *JWKS {
+	ks := GetKeySet()
+	jwks := &JWKS{Keys: make([]JWK, 0, len(ks.Keys))}
+	b64 := base64.RawURLEncoding
+	for _, key := range ks.Keys {
+		if key.symmetric {
+			continue
+		}
+		jwk := JWK{Kid: key.Kid, Use: "sig", Alg: key.Method.Alg()}
+		switch pub := key.publicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = b64.EncodeToString(pub.N.Bytes())
+			jwk.E = b64.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = b64.EncodeToString(pub)
+		default:
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	slices.SortFunc(jwks.Keys, func(a, b JWK) int {
+		return strings.Compare(a.Kid, b.Kid)
+	})
+	return jwks
+}
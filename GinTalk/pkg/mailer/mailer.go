@@ -0,0 +1,69 @@
+package mailer
+
+import (
+	"GinTalk/settings"
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	DriverSMTP = "smtp"
+	DriverFile = "file"
+)
+
+var (
+	mailer     Mailer
+	mailerOnce sync.Once
+)
+
+// Message 一封纯文本邮件
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer 发送邮件的接口
+// 生产环境使用 SMTPMailer, 开发和测试时使用 FileMailer 将邮件写入本地文件
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// GetMailer 获取配置的 Mailer
+// 使用单例模式, 第一次调用时根据配置创建, 配置错误时直接退出
+func GetMailer() Mailer {
+	mailerOnce.Do(func() {
+		cfg := settings.GetConfig().MailConfig
+		switch cfg.Driver {
+		case DriverSMTP:
+			mailer = NewSMTPMailer(cfg.From, cfg.SMTP)
+		case DriverFile:
+			mailer = NewFileMailer(cfg.From, cfg.FileDir)
+		default:
+			zap.L().Fatal("不支持的邮件驱动", zap.String("driver", cfg.Driver))
+		}
+	})
+	return mailer
+}
+
+// build 生成符合 RFC 5322 的邮件内容, 主题和正文均支持中文
+// 正文使用 8bit 编码, 保持链接在 .eml 文件中可以直接复制
+func (m *Message) build(from string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", m.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", m.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	buf.WriteString(strings.ReplaceAll(strings.ReplaceAll(m.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return buf.Bytes()
+}
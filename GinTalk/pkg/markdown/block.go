@@ -0,0 +1,535 @@
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxNesting 引用和列表的最大嵌套层数, 超过后按普通段落处理, 避免恶意输入导致过深的递归
+const maxNesting = 16
+
+type blockKind int
+
+const (
+	blockParagraph blockKind = iota
+	blockHeading
+	blockThematicBreak
+	blockCode
+	blockQuote
+	blockList
+	blockListItem
+	blockTable
+)
+
+// block 块级元素
+type block struct {
+	kind     blockKind
+	level    int    // 标题级别
+	text     string // 段落和标题的行内文本, 或代码块的内容
+	lang     string // 代码块的语言
+	ordered  bool   // 是否为有序列表
+	start    int    // 有序列表的起始序号
+	tight    bool   // 紧凑列表的段落不使用 <p> 包裹
+	align    []string
+	header   []string
+	rows     [][]string
+	children []*block
+}
+
+// linkRef 链接引用定义
+type linkRef struct {
+	dest  string
+	title string
+}
+
+// parser 解析 Markdown 文档, 同时收集链接引用定义, 供之后解析行内元素使用
+type parser struct {
+	refs map[string]linkRef
+}
+
+var (
+	atxHeadingRe    = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	thematicBreakRe = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	setextRe        = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	fenceRe         = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})(.*)$")
+	orderedMarkerRe = regexp.MustCompile(`^(\d{1,9})([.)])`)
+	tableDelimRe    = regexp.MustCompile(`^:?-+:?$`)
+	linkRefDefRe    = regexp.MustCompile(`^\[((?:[^\[\]\\]|\\.){1,999})\]:[ \t]*(<[^<>\n]*>|\S+)(?:[ \t]+("(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'|\((?:[^()\\]|\\.)*\)))?[ \t]*$`)
+)
+
+// splitLines 统一换行符并拆分为行, 行首的制表符按 4 列展开
+func splitLines(src string) []string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\r", "\n")
+	src = strings.ReplaceAll(src, "\x00", "�")
+	lines := strings.Split(src, "\n")
+	for i, line := range lines {
+		lines[i] = expandLeadingTabs(line)
+	}
+	return lines
+}
+
+func expandLeadingTabs(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+	var sb strings.Builder
+	col := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case ' ':
+			sb.WriteByte(' ')
+			col++
+		case '\t':
+			n := 4 - col%4
+			sb.WriteString(strings.Repeat(" ", n))
+			col += n
+		default:
+			sb.WriteString(line[i:])
+			return sb.String()
+		}
+	}
+	return sb.String()
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// indentOf 返回行首空格数
+func indentOf(line string) int {
+	n := 0
+	for n < len(line) && line[n] == ' ' {
+		n++
+	}
+	return n
+}
+
+// stripIndent 去掉行首最多 n 个空格
+func stripIndent(line string, n int) string {
+	i := 0
+	for i < n && i < len(line) && line[i] == ' ' {
+		i++
+	}
+	return line[i:]
+}
+
+// listMarker 列表项的标记
+type listMarker struct {
+	ordered bool
+	char    byte // 无序列表的符号或有序列表的分隔符
+	start   int
+	indent  int  // 列表项内容的缩进
+	empty   bool // 标记之后没有内容
+}
+
+// parseListMarker 解析列表项的标记, 返回标记和第一行的内容
+func parseListMarker(line string) (listMarker, string, bool) {
+	lead := indentOf(line)
+	if lead > 3 || lead == len(line) {
+		return listMarker{}, "", false
+	}
+	s := line[lead:]
+	var m listMarker
+	width := 0
+	switch s[0] {
+	case '-', '+', '*':
+		m.char = s[0]
+		width = 1
+	default:
+		match := orderedMarkerRe.FindStringSubmatch(s)
+		if match == nil {
+			return listMarker{}, "", false
+		}
+		m.ordered = true
+		m.start, _ = strconv.Atoi(match[1])
+		m.char = match[2][0]
+		width = len(match[0])
+	}
+	rest := s[width:]
+	if rest != "" && rest[0] != ' ' {
+		return listMarker{}, "", false
+	}
+	if isBlank(rest) {
+		m.empty = true
+		m.indent = lead + width + 1
+		return m, "", true
+	}
+	spaces := indentOf(rest)
+	if spaces > 4 {
+		// 内容缩进过多时视为缩进代码块, 列表项内容从标记后一个空格开始
+		m.indent = lead + width + 1
+		return m, rest[1:], true
+	}
+	m.indent = lead + width + spaces
+	return m, rest[spaces:], true
+}
+
+// parseQuoteMarker 解析引用的 > 标记, 返回去掉标记后的内容
+func parseQuoteMarker(line string) (string, bool) {
+	lead := indentOf(line)
+	if lead > 3 || lead == len(line) || line[lead] != '>' {
+		return "", false
+	}
+	rest := line[lead+1:]
+	if strings.HasPrefix(rest, " ") {
+		rest = rest[1:]
+	}
+	return rest, true
+}
+
+// parseFence 解析代码块的开始标记
+func parseFence(line string) (indent int, fence string, info string, ok bool) {
+	match := fenceRe.FindStringSubmatch(line)
+	if match == nil {
+		return 0, "", "", false
+	}
+	info = strings.TrimSpace(match[3])
+	if match[2][0] == '`' && strings.Contains(info, "`") {
+		return 0, "", "", false
+	}
+	return len(match[1]), match[2], info, true
+}
+
+// isClosingFence 判断是否为代码块的结束标记
+func isClosingFence(line string, fence string) bool {
+	lead := indentOf(line)
+	if lead > 3 {
+		return false
+	}
+	s := strings.TrimRight(line[lead:], " \t")
+	if len(s) < len(fence) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] != fence[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// interruptsParagraph 判断该行是否会结束当前段落并开始新的块
+func interruptsParagraph(line string) bool {
+	if indentOf(line) >= 4 {
+		return false
+	}
+	if atxHeadingRe.MatchString(line) || thematicBreakRe.MatchString(line) {
+		return true
+	}
+	if _, _, _, ok := parseFence(line); ok {
+		return true
+	}
+	if _, ok := parseQuoteMarker(line); ok {
+		return true
+	}
+	// 空的列表项和不从 1 开始的有序列表不能打断段落
+	if m, _, ok := parseListMarker(line); ok && !m.empty && (!m.ordered || m.start == 1) {
+		return true
+	}
+	return false
+}
+
+// parseBlocks 解析块级元素
+// 第二个返回值表示顶层的块之间是否有空行, 用于判断列表是否紧凑
+func (p *parser) parseBlocks(lines []string, depth int) ([]*block, bool) {
+	var blocks []*block
+	pendingBlank, blankBetween := false, false
+	i := 0
+	for i < len(lines) {
+		line := lines[i]
+		if isBlank(line) {
+			if len(blocks) > 0 {
+				pendingBlank = true
+			}
+			i++
+			continue
+		}
+		if pendingBlank {
+			blankBetween = true
+			pendingBlank = false
+		}
+
+		var b *block
+		if indentOf(line) >= 4 {
+			b, i = p.parseIndentedCode(lines, i)
+		} else if indent, fence, info, ok := parseFence(line); ok {
+			b, i = p.parseFencedCode(lines, i, indent, fence, info)
+		} else if match := atxHeadingRe.FindStringSubmatch(line); match != nil {
+			b = &block{kind: blockHeading, level: len(match[1]), text: strings.TrimSpace(match[2])}
+			i++
+		} else if thematicBreakRe.MatchString(line) {
+			b = &block{kind: blockThematicBreak}
+			i++
+		} else if _, ok := parseQuoteMarker(line); ok && depth < maxNesting {
+			b, i = p.parseQuote(lines, i, depth)
+		} else if _, _, ok := parseListMarker(line); ok && depth < maxNesting {
+			b, i = p.parseList(lines, i, depth)
+		} else if b, i = p.parseTable(lines, i); b == nil {
+			b, i = p.parseParagraph(lines, i)
+		}
+		if b != nil {
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks, blankBetween
+}
+
+func (p *parser) parseIndentedCode(lines []string, i int) (*block, int) {
+	var code []string
+	for i < len(lines) && (indentOf(lines[i]) >= 4 || isBlank(lines[i])) {
+		code = append(code, stripIndent(lines[i], 4))
+		i++
+	}
+	// 末尾的空行不属于代码块
+	end := len(code)
+	for end > 0 && isBlank(code[end-1]) {
+		end--
+	}
+	i -= len(code) - end
+	return &block{kind: blockCode, text: strings.Join(code[:end], "\n") + "\n"}, i
+}
+
+func (p *parser) parseFencedCode(lines []string, i int, indent int, fence string, info string) (*block, int) {
+	b := &block{kind: blockCode}
+	if info != "" {
+		b.lang = unescapeString(strings.Fields(info)[0])
+	}
+	var code []string
+	i++
+	for i < len(lines) {
+		if isClosingFence(lines[i], fence) {
+			i++
+			break
+		}
+		code = append(code, stripIndent(lines[i], indent))
+		i++
+	}
+	if len(code) > 0 {
+		b.text = strings.Join(code, "\n") + "\n"
+	}
+	return b, i
+}
+
+func (p *parser) parseQuote(lines []string, i int, depth int) (*block, int) {
+	var inner []string
+	for i < len(lines) {
+		if rest, ok := parseQuoteMarker(lines[i]); ok {
+			inner = append(inner, rest)
+			i++
+			continue
+		}
+		// 段落的延续行可以省略 > 标记
+		if !isBlank(lines[i]) && len(inner) > 0 && !isBlank(inner[len(inner)-1]) && !interruptsParagraph(lines[i]) {
+			inner = append(inner, lines[i])
+			i++
+			continue
+		}
+		break
+	}
+	children, _ := p.parseBlocks(inner, depth+1)
+	return &block{kind: blockQuote, children: children}, i
+}
+
+func (p *parser) parseList(lines []string, i int, depth int) (*block, int) {
+	first, _, _ := parseListMarker(lines[i])
+	list := &block{kind: blockList, ordered: first.ordered, start: first.start, tight: true}
+	for i < len(lines) {
+		m, content, ok := parseListMarker(lines[i])
+		if !ok || m.ordered != first.ordered || m.char != first.char || thematicBreakRe.MatchString(lines[i]) {
+			break
+		}
+		itemLines := []string{content}
+		i++
+		for i < len(lines) {
+			line := lines[i]
+			if isBlank(line) {
+				// 以空行开始的列表项, 再遇到空行时结束
+				if m.empty && len(itemLines) == 1 {
+					break
+				}
+				itemLines = append(itemLines, "")
+				i++
+				continue
+			}
+			if indentOf(line) >= m.indent {
+				itemLines = append(itemLines, stripIndent(line, m.indent))
+				i++
+				continue
+			}
+			last := itemLines[len(itemLines)-1]
+			if _, _, isItem := parseListMarker(line); !isItem && !isBlank(last) && !interruptsParagraph(line) {
+				// 段落的延续行可以不缩进
+				itemLines = append(itemLines, line)
+				i++
+				continue
+			}
+			break
+		}
+
+		// 末尾的空行留给列表判断是否紧凑
+		trailing := 0
+		for trailing < len(itemLines)-1 && isBlank(itemLines[len(itemLines)-1-trailing]) {
+			trailing++
+		}
+		itemLines = itemLines[:len(itemLines)-trailing]
+		i -= trailing
+
+		children, blankBetween := p.parseBlocks(itemLines, depth+1)
+		if blankBetween {
+			list.tight = false
+		}
+		list.children = append(list.children, &block{kind: blockListItem, children: children})
+
+		// 列表项之间有空行时为松散列表
+		next := i
+		for next < len(lines) && isBlank(lines[next]) {
+			next++
+		}
+		if next == i || next == len(lines) {
+			continue
+		}
+		if nm, _, ok := parseListMarker(lines[next]); ok && nm.ordered == first.ordered && nm.char == first.char && !thematicBreakRe.MatchString(lines[next]) {
+			list.tight = false
+			i = next
+			continue
+		}
+		break
+	}
+	return list, i
+}
+
+// splitTableRow 按未转义的 | 拆分表格的一行
+func splitTableRow(line string) []string {
+	s := strings.TrimSpace(line)
+	s = strings.TrimPrefix(s, "|")
+	if strings.HasSuffix(s, "|") && !strings.HasSuffix(s, `\|`) {
+		s = s[:len(s)-1]
+	}
+	var cells []string
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == '|':
+			sb.WriteByte('|')
+			i++
+		case s[i] == '|':
+			cells = append(cells, strings.TrimSpace(sb.String()))
+			sb.Reset()
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(sb.String()))
+}
+
+// parseTable 解析 GFM 表格, 不是表格时返回 nil
+func (p *parser) parseTable(lines []string, i int) (*block, int) {
+	if !strings.Contains(lines[i], "|") || i+1 >= len(lines) || !strings.Contains(lines[i+1], "-") {
+		return nil, i
+	}
+	header := splitTableRow(lines[i])
+	delims := splitTableRow(lines[i+1])
+	if len(header) != len(delims) {
+		return nil, i
+	}
+	align := make([]string, len(delims))
+	for j, d := range delims {
+		if !tableDelimRe.MatchString(d) {
+			return nil, i
+		}
+		left, right := strings.HasPrefix(d, ":"), strings.HasSuffix(d, ":")
+		switch {
+		case left && right:
+			align[j] = "center"
+		case left:
+			align[j] = "left"
+		case right:
+			align[j] = "right"
+		}
+	}
+
+	b := &block{kind: blockTable, header: header, align: align}
+	i += 2
+	for i < len(lines) && !isBlank(lines[i]) && !interruptsParagraph(lines[i]) {
+		row := splitTableRow(lines[i])
+		// 单元格数量与表头保持一致
+		cells := make([]string, len(header))
+		copy(cells, row)
+		b.rows = append(b.rows, cells)
+		i++
+	}
+	return b, i
+}
+
+func (p *parser) parseParagraph(lines []string, i int) (*block, int) {
+	var para []string
+	for i < len(lines) {
+		line := lines[i]
+		if isBlank(line) {
+			break
+		}
+		if len(para) > 0 {
+			if match := setextRe.FindStringSubmatch(line); match != nil {
+				level := 1
+				if match[1][0] == '-' {
+					level = 2
+				}
+				para = p.parseLinkRefDefs(para)
+				if len(para) == 0 {
+					// 只有链接引用定义时不能作为标题, 按普通行处理
+					return nil, i
+				}
+				return &block{kind: blockHeading, level: level, text: strings.TrimSpace(strings.Join(para, "\n"))}, i + 1
+			}
+			if interruptsParagraph(line) {
+				break
+			}
+		}
+		para = append(para, strings.TrimLeft(line, " "))
+		i++
+	}
+	para = p.parseLinkRefDefs(para)
+	if len(para) == 0 {
+		return nil, i
+	}
+	return &block{kind: blockParagraph, text: strings.TrimRight(strings.Join(para, "\n"), " \t")}, i
+}
+
+// parseLinkRefDefs 从段落开头解析链接引用定义, 返回剩余的行
+// 同一标签有多个定义时使用第一个
+func (p *parser) parseLinkRefDefs(lines []string) []string {
+	for len(lines) > 0 {
+		match := linkRefDefRe.FindStringSubmatch(lines[0])
+		if match == nil {
+			break
+		}
+		label := normalizeLabel(match[1])
+		if label == "" {
+			break
+		}
+		dest := match[2]
+		if strings.HasPrefix(dest, "<") {
+			dest = dest[1 : len(dest)-1]
+		}
+		title := match[3]
+		if title != "" {
+			title = title[1 : len(title)-1]
+		}
+		if _, exists := p.refs[label]; !exists {
+			p.refs[label] = linkRef{dest: unescapeString(dest), title: unescapeString(title)}
+		}
+		lines = lines[1:]
+	}
+	return lines
+}
+
+// normalizeLabel 规范化链接标签: 忽略大小写, 连续的空白视为一个空格
+func normalizeLabel(label string) string {
+	if len(label) > maxLabelLength*utf8.UTFMax {
+		return ""
+	}
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
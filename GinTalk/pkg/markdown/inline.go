@@ -0,0 +1,690 @@
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type inlineKind int
+
+const (
+	inlineText inlineKind = iota
+	inlineCode
+	inlineEmph
+	inlineStrong
+	inlineDel
+	inlineLink
+	inlineImage
+	inlineSoftBreak
+	inlineHardBreak
+)
+
+// inline 行内元素, 子元素使用双向链表保存, 方便处理强调时移动节点
+type inline struct {
+	kind        inlineKind
+	text        string
+	dest        string
+	title       string
+	parent      *inline
+	prev, next  *inline
+	first, last *inline
+}
+
+func (n *inline) appendChild(child *inline) {
+	child.unlink()
+	child.parent = n
+	if n.last == nil {
+		n.first = child
+	} else {
+		n.last.next = child
+		child.prev = n.last
+	}
+	n.last = child
+}
+
+// insertAfter 把 sibling 插入到 n 之后
+func (n *inline) insertAfter(sibling *inline) {
+	sibling.unlink()
+	sibling.parent = n.parent
+	sibling.prev = n
+	sibling.next = n.next
+	if n.next != nil {
+		n.next.prev = sibling
+	} else if n.parent != nil {
+		n.parent.last = sibling
+	}
+	n.next = sibling
+}
+
+func (n *inline) unlink() {
+	if n.prev != nil {
+		n.prev.next = n.next
+	} else if n.parent != nil {
+		n.parent.first = n.next
+	}
+	if n.next != nil {
+		n.next.prev = n.prev
+	} else if n.parent != nil {
+		n.parent.last = n.prev
+	}
+	n.parent, n.prev, n.next = nil, nil, nil
+}
+
+// delimiter 可能作为强调标记的 *、_ 或 ~ 序列
+type delimiter struct {
+	node      *inline
+	char      byte
+	count     int
+	origCount int
+	canOpen   bool
+	canClose  bool
+	prev      *delimiter
+	next      *delimiter
+}
+
+// bracket 链接或图片的开始标记
+type bracket struct {
+	node      *inline
+	image     bool
+	active    bool
+	pos       int // 标记之后的位置, 用于取得链接文本作为引用标签
+	prevDelim *delimiter
+	prev      *bracket
+}
+
+type inlineParser struct {
+	src      string
+	pos      int
+	refs     map[string]linkRef
+	root     *inline
+	delims   *delimiter
+	brackets *bracket
+}
+
+var (
+	entityRe        = regexp.MustCompile(`^&(?:#[xX][0-9a-fA-F]{1,6}|#[0-9]{1,7}|[a-zA-Z][a-zA-Z0-9]{1,31});`)
+	uriAutolinkRe   = regexp.MustCompile(`^<([a-zA-Z][a-zA-Z0-9+.-]{1,31}:[^<>\x00-\x20]*)>`)
+	emailAutolinkRe = regexp.MustCompile(`^<([a-zA-Z0-9.!#$%&'*+/=?^_` + "`" + `{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*)>`)
+	bareURLRe       = regexp.MustCompile(`(?:https?://|www\.)[\x21-\x3b\x3d\x3f-\x7e]+`)
+)
+
+const (
+	// maxLabelLength 链接标签的最大长度
+	maxLabelLength = 999
+	// maxLinkTailLength 链接地址和标题的最大长度, 限制查找结束位置时的扫描范围, 避免恶意输入导致平方级的耗时
+	maxLinkTailLength = 4096
+)
+
+// specialChars 需要特殊处理的字符, 其他字符按普通文本处理
+const specialChars = "\n\\`*_~[]!<&"
+
+// parseInline 解析行内元素
+func parseInline(src string, refs map[string]linkRef) *inline {
+	p := &inlineParser{src: src, refs: refs, root: &inline{}}
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; c {
+		case '\n':
+			p.parseNewline()
+		case '\\':
+			p.parseBackslash()
+		case '`':
+			p.parseCodeSpan()
+		case '*', '_', '~':
+			p.parseDelimiterRun(c)
+		case '[':
+			p.pos++
+			p.pushBracket(p.appendText("["), false)
+		case '!':
+			if strings.HasPrefix(p.src[p.pos:], "![") {
+				p.pos += 2
+				p.pushBracket(p.appendText("!["), true)
+			} else {
+				p.pos++
+				p.appendText("!")
+			}
+		case ']':
+			p.parseCloseBracket()
+		case '<':
+			p.parseAutolink()
+		case '&':
+			p.parseEntity()
+		default:
+			end := strings.IndexAny(p.src[p.pos:], specialChars)
+			if end < 0 {
+				end = len(p.src) - p.pos
+			}
+			p.appendText(p.src[p.pos : p.pos+end])
+			p.pos += end
+		}
+	}
+	p.processEmphasis(nil)
+	mergeText(p.root)
+	linkifyBareURLs(p.root)
+	return p.root
+}
+
+func (p *inlineParser) appendText(text string) *inline {
+	n := &inline{kind: inlineText, text: text}
+	p.root.appendChild(n)
+	return n
+}
+
+// parseNewline 行末有两个以上空格时为硬换行, 否则为软换行
+func (p *inlineParser) parseNewline() {
+	p.pos++
+	kind := inlineSoftBreak
+	if last := p.root.last; last != nil && last.kind == inlineText {
+		if strings.HasSuffix(last.text, "  ") {
+			kind = inlineHardBreak
+		}
+		last.text = strings.TrimRight(last.text, " ")
+	}
+	p.root.appendChild(&inline{kind: kind})
+	// 下一行行首的空格被忽略
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *inlineParser) parseBackslash() {
+	p.pos++
+	if p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '\n' {
+			p.pos++
+			p.root.appendChild(&inline{kind: inlineHardBreak})
+			return
+		}
+		if isASCIIPunct(c) {
+			p.pos++
+			p.appendText(string(c))
+			return
+		}
+	}
+	p.appendText(`\`)
+}
+
+func (p *inlineParser) parseCodeSpan() {
+	start := p.pos
+	n := countRun(p.src, p.pos, '`')
+	p.pos += n
+	for i := p.pos; i < len(p.src); {
+		if p.src[i] != '`' {
+			i++
+			continue
+		}
+		m := countRun(p.src, i, '`')
+		if m == n {
+			code := strings.ReplaceAll(p.src[p.pos:i], "\n", " ")
+			if len(code) >= 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+				code = code[1 : len(code)-1]
+			}
+			p.root.appendChild(&inline{kind: inlineCode, text: code})
+			p.pos = i + m
+			return
+		}
+		i += m
+	}
+	// 没有对应的结束标记, 作为普通文本
+	p.appendText(p.src[start:p.pos])
+}
+
+func (p *inlineParser) parseDelimiterRun(c byte) {
+	n := countRun(p.src, p.pos, c)
+	before, _ := utf8.DecodeLastRuneInString(p.src[:p.pos])
+	if p.pos == 0 {
+		before = '\n'
+	}
+	after, _ := utf8.DecodeRuneInString(p.src[p.pos+n:])
+	if p.pos+n == len(p.src) {
+		after = '\n'
+	}
+	node := p.appendText(p.src[p.pos : p.pos+n])
+	p.pos += n
+	if c == '~' && n > 2 {
+		return
+	}
+
+	leftFlanking := !unicode.IsSpace(after) && (!isPunct(after) || unicode.IsSpace(before) || isPunct(before))
+	rightFlanking := !unicode.IsSpace(before) && (!isPunct(before) || unicode.IsSpace(after) || isPunct(after))
+	canOpen, canClose := leftFlanking, rightFlanking
+	if c == '_' {
+		canOpen = leftFlanking && (!rightFlanking || isPunct(before))
+		canClose = rightFlanking && (!leftFlanking || isPunct(after))
+	}
+	if !canOpen && !canClose {
+		return
+	}
+	d := &delimiter{node: node, char: c, count: n, origCount: n, canOpen: canOpen, canClose: canClose, prev: p.delims}
+	if p.delims != nil {
+		p.delims.next = d
+	}
+	p.delims = d
+}
+
+func (p *inlineParser) removeDelimiter(d *delimiter) {
+	if d.prev != nil {
+		d.prev.next = d.next
+	}
+	if d.next != nil {
+		d.next.prev = d.prev
+	} else {
+		p.delims = d.prev
+	}
+}
+
+func (p *inlineParser) pushBracket(node *inline, image bool) {
+	p.brackets = &bracket{node: node, image: image, active: true, pos: p.pos, prevDelim: p.delims, prev: p.brackets}
+}
+
+func (p *inlineParser) parseCloseBracket() {
+	closePos := p.pos
+	p.pos++
+	opener := p.brackets
+	if opener == nil {
+		p.appendText("]")
+		return
+	}
+	if !opener.active {
+		p.brackets = opener.prev
+		p.appendText("]")
+		return
+	}
+
+	dest, title, end, ok := parseInlineLinkTail(p.src, p.pos)
+	if !ok {
+		// 引用链接: [文本][标签]、[文本][] 或 [文本]
+		label := p.src[opener.pos:closePos]
+		if len(label) > maxLabelLength {
+			label = ""
+		}
+		end = p.pos
+		if strings.HasPrefix(p.src[p.pos:], "[") {
+			if i := strings.IndexByte(p.src[p.pos+1:], ']'); i >= 0 && i <= maxLabelLength {
+				if l := p.src[p.pos+1 : p.pos+1+i]; l != "" {
+					label = l
+				}
+				end = p.pos + i + 2
+			}
+		}
+		var ref linkRef
+		if ref, ok = p.refs[normalizeLabel(label)]; ok {
+			dest, title = ref.dest, ref.title
+		}
+	}
+	if !ok {
+		p.brackets = opener.prev
+		p.appendText("]")
+		return
+	}
+	p.pos = end
+
+	link := &inline{kind: inlineLink, dest: dest, title: title}
+	if opener.image {
+		link.kind = inlineImage
+	}
+	for n := opener.node.next; n != nil; {
+		next := n.next
+		link.appendChild(n)
+		n = next
+	}
+	p.root.appendChild(link)
+	p.processEmphasis(opener.prevDelim)
+	opener.node.unlink()
+	p.brackets = opener.prev
+
+	// 链接中不能再包含链接
+	if !opener.image {
+		for b := p.brackets; b != nil; b = b.prev {
+			if !b.image {
+				b.active = false
+			}
+		}
+	}
+}
+
+// parseInlineLinkTail 解析 ] 之后的 (地址 "标题")
+func parseInlineLinkTail(src string, pos int) (dest string, title string, end int, ok bool) {
+	if pos >= len(src) || src[pos] != '(' {
+		return "", "", 0, false
+	}
+	if len(src) > pos+maxLinkTailLength {
+		src = src[:pos+maxLinkTailLength]
+	}
+	i := skipSpaces(src, pos+1)
+	if i < len(src) && src[i] == '<' {
+		j := i + 1
+		for j < len(src) && src[j] != '>' && src[j] != '<' && src[j] != '\n' {
+			if src[j] == '\\' && j+1 < len(src) {
+				j++
+			}
+			j++
+		}
+		if j >= len(src) || src[j] != '>' {
+			return "", "", 0, false
+		}
+		dest = src[i+1 : j]
+		i = j + 1
+	} else {
+		j, depth := i, 0
+		for j < len(src) && src[j] > ' ' {
+			if src[j] == '\\' && j+1 < len(src) && isASCIIPunct(src[j+1]) {
+				j += 2
+				continue
+			}
+			if src[j] == '(' {
+				depth++
+			} else if src[j] == ')' {
+				if depth == 0 {
+					break
+				}
+				depth--
+			}
+			j++
+		}
+		if depth != 0 {
+			return "", "", 0, false
+		}
+		dest = src[i:j]
+		i = j
+	}
+
+	j := skipSpaces(src, i)
+	if j > i && j < len(src) && (src[j] == '"' || src[j] == '\'' || src[j] == '(') {
+		closer := src[j]
+		if closer == '(' {
+			closer = ')'
+		}
+		k := j + 1
+		for k < len(src) && src[k] != closer {
+			if src[k] == '\\' && k+1 < len(src) {
+				k++
+			}
+			k++
+		}
+		if k >= len(src) {
+			return "", "", 0, false
+		}
+		title = src[j+1 : k]
+		j = skipSpaces(src, k+1)
+	}
+	if j >= len(src) || src[j] != ')' {
+		return "", "", 0, false
+	}
+	return unescapeString(dest), unescapeString(title), j + 1, true
+}
+
+func (p *inlineParser) parseAutolink() {
+	rest := p.src[p.pos:]
+	if match := uriAutolinkRe.FindStringSubmatch(rest); match != nil {
+		link := &inline{kind: inlineLink, dest: match[1]}
+		link.appendChild(&inline{kind: inlineText, text: match[1]})
+		p.root.appendChild(link)
+		p.pos += len(match[0])
+		return
+	}
+	if match := emailAutolinkRe.FindStringSubmatch(rest); match != nil {
+		link := &inline{kind: inlineLink, dest: "mailto:" + match[1]}
+		link.appendChild(&inline{kind: inlineText, text: match[1]})
+		p.root.appendChild(link)
+		p.pos += len(match[0])
+		return
+	}
+	// 不支持原始 HTML, 作为普通文本输出时会被转义
+	p.pos++
+	p.appendText("<")
+}
+
+func (p *inlineParser) parseEntity() {
+	if match := entityRe.FindString(p.src[p.pos:]); match != "" {
+		if decoded := html.UnescapeString(match); decoded != match {
+			p.pos += len(match)
+			p.appendText(decoded)
+			return
+		}
+	}
+	p.pos++
+	p.appendText("&")
+}
+
+// processEmphasis 按 CommonMark 的规则匹配 stackBottom 之上的强调标记
+func (p *inlineParser) processEmphasis(stackBottom *delimiter) {
+	type openerKey struct {
+		char    byte
+		canOpen bool
+		mod     int
+	}
+	openersBottom := make(map[openerKey]*delimiter)
+
+	var closer *delimiter
+	for d := p.delims; d != nil && d != stackBottom; d = d.prev {
+		closer = d
+	}
+	for closer != nil {
+		if !closer.canClose {
+			closer = closer.next
+			continue
+		}
+		key := openerKey{char: closer.char, canOpen: closer.canOpen, mod: closer.origCount % 3}
+		bottom, hasBottom := openersBottom[key]
+		if !hasBottom {
+			bottom = stackBottom
+		}
+
+		var opener *delimiter
+		for d := closer.prev; d != nil && d != stackBottom && d != bottom; d = d.prev {
+			if d.char != closer.char || !d.canOpen {
+				continue
+			}
+			if d.char == '~' {
+				if d.count == closer.count {
+					opener = d
+					break
+				}
+				continue
+			}
+			oddMatch := (d.canClose || closer.canOpen) && closer.origCount%3 != 0 && (d.origCount+closer.origCount)%3 == 0
+			if !oddMatch {
+				opener = d
+				break
+			}
+		}
+
+		if opener == nil {
+			openersBottom[key] = closer.prev
+			next := closer.next
+			if !closer.canOpen {
+				p.removeDelimiter(closer)
+			}
+			closer = next
+			continue
+		}
+
+		n := 1
+		kind := inlineEmph
+		switch {
+		case closer.char == '~':
+			n = closer.count
+			kind = inlineDel
+		case closer.count >= 2 && opener.count >= 2:
+			n = 2
+			kind = inlineStrong
+		}
+		opener.count -= n
+		closer.count -= n
+		opener.node.text = opener.node.text[:len(opener.node.text)-n]
+		closer.node.text = closer.node.text[:len(closer.node.text)-n]
+
+		emph := &inline{kind: kind}
+		for node := opener.node.next; node != nil && node != closer.node; {
+			next := node.next
+			emph.appendChild(node)
+			node = next
+		}
+		opener.node.insertAfter(emph)
+
+		for d := closer.prev; d != nil && d != opener; {
+			prev := d.prev
+			p.removeDelimiter(d)
+			d = prev
+		}
+		if opener.count == 0 {
+			opener.node.unlink()
+			p.removeDelimiter(opener)
+		}
+		if closer.count == 0 {
+			next := closer.next
+			closer.node.unlink()
+			p.removeDelimiter(closer)
+			closer = next
+		}
+	}
+
+	for p.delims != nil && p.delims != stackBottom {
+		p.removeDelimiter(p.delims)
+	}
+}
+
+// mergeText 合并相邻的文本节点, 并删除空的文本节点
+func mergeText(n *inline) {
+	for child := n.first; child != nil; {
+		next := child.next
+		if child.kind == inlineText && next != nil && next.kind == inlineText {
+			var sb strings.Builder
+			sb.WriteString(child.text)
+			for next != nil && next.kind == inlineText {
+				sb.WriteString(next.text)
+				after := next.next
+				next.unlink()
+				next = after
+			}
+			child.text = sb.String()
+		}
+		if child.kind == inlineText {
+			if child.text == "" {
+				child.unlink()
+			}
+		} else {
+			mergeText(child)
+		}
+		child = next
+	}
+}
+
+// linkifyBareURLs 把文本中以 http://、https:// 或 www. 开头的网址转换为链接 (GFM 自动链接扩展)
+func linkifyBareURLs(n *inline) {
+	for child := n.first; child != nil; child = child.next {
+		switch child.kind {
+		case inlineLink, inlineImage:
+			continue
+		case inlineText:
+		default:
+			linkifyBareURLs(child)
+			continue
+		}
+
+		text := child.text
+		loc := bareURLRe.FindStringIndex(text)
+		// 网址前面是英文字母或数字时不是网址的开始, 中文后面可以直接跟网址
+		for loc != nil && loc[0] > 0 && isWordByte(text[loc[0]-1]) {
+			next := bareURLRe.FindStringIndex(text[loc[1]:])
+			if next == nil {
+				loc = nil
+				break
+			}
+			loc = []int{next[0] + loc[1], next[1] + loc[1]}
+		}
+		if loc == nil {
+			continue
+		}
+		url := trimURLTail(text[loc[0]:loc[1]])
+		if url == "www." || strings.HasSuffix(url, "://") {
+			continue
+		}
+		dest := url
+		if strings.HasPrefix(url, "www.") {
+			dest = "http://" + url
+		}
+		link := &inline{kind: inlineLink, dest: dest}
+		link.appendChild(&inline{kind: inlineText, text: url})
+		child.text = text[:loc[0]]
+		child.insertAfter(link)
+		if rest := text[loc[0]+len(url):]; rest != "" {
+			// 剩余文本在下一次循环中继续处理
+			link.insertAfter(&inline{kind: inlineText, text: rest})
+		}
+		child = link
+	}
+}
+
+// trimURLTail 去掉网址末尾的标点和不成对的右括号
+func trimURLTail(url string) string {
+	for url != "" {
+		last := url[len(url)-1]
+		if strings.IndexByte("?!.,:*_~'\"", last) >= 0 {
+			url = url[:len(url)-1]
+			continue
+		}
+		if last == ')' && strings.Count(url, ")") > strings.Count(url, "(") {
+			url = url[:len(url)-1]
+			continue
+		}
+		break
+	}
+	return url
+}
+
+// unescapeString 处理反斜杠转义和 HTML 实体
+func unescapeString(s string) string {
+	if !strings.ContainsAny(s, `\&`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]):
+			sb.WriteByte(s[i+1])
+			i++
+		case s[i] == '&':
+			if match := entityRe.FindString(s[i:]); match != "" {
+				sb.WriteString(html.UnescapeString(match))
+				i += len(match) - 1
+			} else {
+				sb.WriteByte('&')
+			}
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String()
+}
+
+func countRun(s string, pos int, c byte) int {
+	n := 0
+	for pos+n < len(s) && s[pos+n] == c {
+		n++
+	}
+	return n
+}
+
+func skipSpaces(s string, pos int) int {
+	for pos < len(s) && (s[pos] == ' ' || s[pos] == '\t' || s[pos] == '\n') {
+		pos++
+	}
+	return pos
+}
+
+func isASCIIPunct(c byte) bool {
+	return c < utf8.RuneSelf && c > ' ' && !('0' <= c && c <= '9') && !('a' <= c && c <= 'z') && !('A' <= c && c <= 'Z') && c != 0x7f
+}
+
+func isPunct(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
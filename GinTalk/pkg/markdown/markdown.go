@@ -0,0 +1,27 @@
+// Package markdown 把帖子和评论的 Markdown 内容渲染为安全的 HTML
+// 支持 CommonMark 的常用语法和 GFM 的表格、删除线与自动链接;
+// 不支持原始 HTML, 内容中的 HTML 标签会被转义, 渲染结果再经过白名单过滤
+package markdown
+
+import (
+	"strings"
+)
+
+// ToHTML 把 Markdown 渲染为经过白名单过滤的 HTML
+func ToHTML(src string) string {
+	p := &parser{refs: make(map[string]linkRef)}
+	blocks, _ := p.parseBlocks(splitLines(src), 0)
+	r := &renderer{refs: p.refs}
+	r.blocks(blocks, false)
+	return Sanitize(r.sb.String())
+}
+
+// PlainText 返回 Markdown 的纯文本内容, 去掉所有标记, 块之间用换行分隔
+// 用于生成摘要和建立搜索索引
+func PlainText(src string) string {
+	p := &parser{refs: make(map[string]linkRef)}
+	blocks, _ := p.parseBlocks(splitLines(src), 0)
+	var sb strings.Builder
+	plainBlocks(&sb, blocks, p.refs)
+	return strings.TrimSpace(sb.String())
+}
@@ -0,0 +1,251 @@
+package markdown
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	nethtml "golang.org/x/net/html"
+)
+
+func TestToHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "标题和强调",
+			src:  "# Title\n\nHello *world* and **bold** ~~gone~~",
+			want: "<h1>Title</h1>\n<p>Hello <em>world</em> and <strong>bold</strong> <del>gone</del></p>\n",
+		},
+		{
+			name: "硬换行",
+			src:  "line1  \nline2",
+			want: "<p>line1<br />\nline2</p>\n",
+		},
+		{
+			name: "引用和列表",
+			src:  "> quote\n\n- a\n- b\n\n3. x\n4. y",
+			want: "<blockquote>\n<p>quote</p>\n</blockquote>\n<ul>\n<li>a</li>\n<li>b</li>\n</ul>\n<ol start=\"3\">\n<li>x</li>\n<li>y</li>\n</ol>\n",
+		},
+		{
+			name: "GFM 表格",
+			src:  "| a | b |\n|:--|--:|\n| 1 | 2 |",
+			want: "<table>\n<thead>\n<tr><th align=\"left\">a</th><th align=\"right\">b</th></tr>\n</thead>\n" +
+				"<tbody>\n<tr><td align=\"left\">1</td><td align=\"right\">2</td></tr>\n</tbody>\n</table>\n",
+		},
+		{
+			name: "链接",
+			src:  `[x](https://example.com "t")`,
+			want: "<p><a href=\"https://example.com\" title=\"t\" rel=\"nofollow noopener\">x</a></p>\n",
+		},
+		{
+			name: "图片",
+			src:  `![img](/a.png "t")`,
+			want: "<p><img src=\"/a.png\" alt=\"img\" title=\"t\" /></p>\n",
+		},
+		{
+			name: "引用链接",
+			src:  "[ref][r]\n\n[r]: https://example.com \"T\"",
+			want: "<p><a href=\"https://example.com\" title=\"T\" rel=\"nofollow noopener\">ref</a></p>\n",
+		},
+		{
+			name: "未定义的引用链接保留原文",
+			src:  "[ref][missing]",
+			want: "<p>[ref][missing]</p>\n",
+		},
+		{
+			name: "GFM 自动链接",
+			src:  "see https://example.com/x?y=1 now",
+			want: "<p>see <a href=\"https://example.com/x?y=1\" rel=\"nofollow noopener\">https://example.com/x?y=1</a> now</p>\n",
+		},
+		{
+			name: "www 自动链接",
+			src:  "www.example.com",
+			want: "<p><a href=\"http://www.example.com\" rel=\"nofollow noopener\">www.example.com</a></p>\n",
+		},
+		{
+			name: "尖括号自动链接和邮箱",
+			src:  "mail <foo@bar.com> and <https://a.b/c>",
+			want: "<p>mail <a href=\"mailto:foo@bar.com\" rel=\"nofollow noopener\">foo@bar.com</a> and " +
+				"<a href=\"https://a.b/c\" rel=\"nofollow noopener\">https://a.b/c</a></p>\n",
+		},
+		{
+			name: "代码块转义内容并保留语言",
+			src:  "```go\nfmt.Println(\"<x>\")\n```",
+			want: "<pre><code class=\"language-go\">fmt.Println(&#34;&lt;x&gt;&#34;)\n</code></pre>\n",
+		},
+		{
+			name: "行内代码",
+			src:  "`<code>`",
+			want: "<p><code>&lt;code&gt;</code></p>\n",
+		},
+		{
+			name: "原始 HTML 被转义",
+			src:  "<script>alert(1)</script>",
+			want: "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n",
+		},
+		{
+			name: "原始 HTML 属性被转义",
+			src:  "<img src=x onerror=alert(1)>",
+			want: "<p>&lt;img src=x onerror=alert(1)&gt;</p>\n",
+		},
+		{
+			name: "javascript 链接",
+			src:  "[x](javascript:alert(1))",
+			want: "<p><a>x</a></p>\n",
+		},
+		{
+			name: "大小写混合的 javascript 链接",
+			src:  "[x](JaVaScRiPt:alert(1))",
+			want: "<p><a>x</a></p>\n",
+		},
+		{
+			name: "实体编码的 javascript 链接",
+			src:  "[x](&#106;avascript:alert(1))",
+			want: "<p><a>x</a></p>\n",
+		},
+		{
+			name: "data 链接",
+			src:  "[x](data:text/html;base64,PHNjcmlwdD4=)",
+			want: "<p><a>x</a></p>\n",
+		},
+		{
+			name: "vbscript 链接",
+			src:  "[x](vbscript:msgbox)",
+			want: "<p><a>x</a></p>\n",
+		},
+		{
+			name: "尖括号中的 javascript 链接",
+			src:  "<javascript:alert(1)>",
+			want: "<p><a>javascript:alert(1)</a></p>\n",
+		},
+		{
+			name: "javascript 图片被删除",
+			src:  "![img](javascript:alert(1))",
+			want: "<p></p>\n",
+		},
+		{
+			name: "链接地址中的引号不能闭合属性",
+			src:  `[x](https://a.com" onclick="alert(1))`,
+			want: "<p>[x](<a href=\"https://a.com\" rel=\"nofollow noopener\">https://a.com</a>&#34; onclick=&#34;alert(1))</p>\n",
+		},
+		{
+			name: "代码块语言中的引号被忽略",
+			src:  "```js\" onclick=\"x\ncode <b>\n```",
+			want: "<pre><code>code &lt;b&gt;\n</code></pre>\n",
+		},
+	}
+	for _, tt := range tests {
+		if got := ToHTML(tt.src); got != tt.want {
+			t.Errorf("%s: ToHTML(%q)\n得到 %q\n期望 %q", tt.name, tt.src, got, tt.want)
+		}
+	}
+}
+
+// assertSafeHTML 解析 HTML, 检查只包含白名单中的元素和属性, 并且链接地址不使用危险的协议
+// 被转义为文本的标签不会被解析为元素, 因此不影响检查结果
+func assertSafeHTML(t *testing.T, src string, out string) {
+	t.Helper()
+	tokenizer := nethtml.NewTokenizer(strings.NewReader(out))
+	for {
+		tt := tokenizer.Next()
+		if tt == nethtml.ErrorToken {
+			return
+		}
+		if tt != nethtml.StartTagToken && tt != nethtml.SelfClosingTagToken {
+			continue
+		}
+		token := tokenizer.Token()
+		allowed, ok := allowedElements[token.Data]
+		if !ok {
+			t.Errorf("ToHTML(%q) = %q, 包含不允许的元素 <%s>", src, out, token.Data)
+			continue
+		}
+		for _, attr := range token.Attr {
+			if !slices.Contains(allowed, attr.Key) && !(token.Data == "a" && attr.Key == "rel") {
+				t.Errorf("ToHTML(%q) = %q, <%s> 包含不允许的属性 %s", src, out, token.Data, attr.Key)
+			}
+			value := strings.ToLower(strings.Join(strings.Fields(attr.Val), ""))
+			for _, scheme := range []string{"javascript:", "vbscript:", "data:"} {
+				if (attr.Key == "href" || attr.Key == "src") && strings.HasPrefix(value, scheme) {
+					t.Errorf("ToHTML(%q) = %q, 地址使用了 %s 协议", src, out, scheme)
+				}
+			}
+		}
+	}
+}
+
+// TestToHTMLNoScript 检查各种注入方式渲染后都不包含可执行的内容
+func TestToHTMLNoScript(t *testing.T) {
+	sources := []string{
+		"[x](javascript:alert(1))",
+		"[x](java\tscript:alert(1))",
+		"[x](&#x6A;avascript&#58;alert(1))",
+		"[x](<javascript:alert(1)>)",
+		"[x](data:text/html,<script>alert(1)</script>)",
+		"![x](data:image/svg+xml,<svg onload=alert(1)>)",
+		"[x][r]\n\n[r]: javascript:alert(1)",
+		"<a href=\"javascript:alert(1)\">x</a>",
+		"<iframe src=\"https://evil\"></iframe>",
+		"[x](https://a.com \"t\" onmouseover=\"alert(1)\")",
+		"[x](https://a.com \"t\\\" onmouseover=\\\"alert(1)\")",
+		"```\"><script>alert(1)</script>\nx\n```",
+		"| <script>alert(1)</script> |\n|---|\n| x |",
+		"**<img src=x onerror=alert(1)>**",
+	}
+	for _, src := range sources {
+		assertSafeHTML(t, src, ToHTML(src))
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"删除 javascript 地址和事件属性", `<a href="javascript:alert(1)" onclick="x">a</a>`, "<a>a</a>"},
+		{"协议前的空白和大小写", `<a href=" JAVASCRIPT:alert(1)">a</a>`, "<a>a</a>"},
+		{"协议中的实体编码", `<a href="java&#x09;script:alert(1)">a</a>`, "<a>a</a>"},
+		{"路径中的冒号为相对地址", `<a href="/path:x">a</a>`, `<a href="/path:x" rel="nofollow noopener">a</a>`},
+		{"删除不允许的属性", `<p style="x" class="y">t</p>`, "<p>t</p>"},
+		{"删除 script 及其内容", `<script>alert(1)</script>ok`, "ok"},
+		{"删除 svg 及其内容", `<svg><script>alert(1)</script></svg>ok`, "ok"},
+		{"删除 style 和 iframe", `<style>p{}</style><iframe src=x></iframe>after`, "after"},
+		{"没有有效地址的图片被删除", `<img src="data:image/png;base64,AA">`, ""},
+		{"图片删除事件属性", `<img src="https://a/b.png" onerror="x">`, `<img src="https://a/b.png" />`},
+		{"代码语言必须符合格式", `<code class="language-go">x</code><code class="x onclick">y</code>`, `<code class="language-go">x</code><code>y</code>`},
+		{"列表起始值必须是数字", `<ol start="3"><li>a</li></ol><ol start="x"></ol>`, `<ol start="3"><li>a</li></ol><ol></ol>`},
+		{"不在白名单中的元素只保留文本", `<div>text<span>more</span></div>`, "textmore"},
+		{"属性值被转义", `<a title='"><script>'>t</a>`, `<a title="&#34;&gt;&lt;script&gt;">t</a>`},
+		{"表格对齐只允许固定值", `<td align="left">a</td><td align="javascript">b</td>`, `<td align="left">a</td><td>b</td>`},
+	}
+	for _, tt := range tests {
+		if got := Sanitize(tt.src); got != tt.want {
+			t.Errorf("%s: Sanitize(%q)\n得到 %q\n期望 %q", tt.name, tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"# Title\n\nHello *world* and **bold** ~~gone~~", "Title\nHello world and bold gone"},
+		{"> quote\n\n- a\n- b", "quote\na\nb"},
+		{"| a | b |\n|---|---|\n| 1 | 2 |", "a b\n1 2"},
+		{"[ref][r]\n\n[r]: https://example.com", "ref"},
+		{"![img](/a.png)", "img"},
+		{"```go\nfmt.Println(\"<x>\")\n```", "fmt.Println(\"<x>\")"},
+		{"<script>alert(1)</script>", "<script>alert(1)</script>"},
+		{"line1  \nline2", "line1\nline2"},
+	}
+	for _, tt := range tests {
+		if got := PlainText(tt.src); got != tt.want {
+			t.Errorf("PlainText(%q) = %q, 期望 %q", tt.src, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package markdown
+
+import (
+	"html"
+	"strconv"
+	"strings"
+)
+
+type renderer struct {
+	sb   strings.Builder
+	refs map[string]linkRef
+}
+
+func (r *renderer) blocks(blocks []*block, tight bool) {
+	for _, b := range blocks {
+		r.block(b, tight)
+	}
+}
+
+func (r *renderer) block(b *block, tight bool) {
+	switch b.kind {
+	case blockParagraph:
+		if tight {
+			r.inline(parseInline(b.text, r.refs))
+			return
+		}
+		r.sb.WriteString("<p>")
+		r.inline(parseInline(b.text, r.refs))
+		r.sb.WriteString("</p>\n")
+	case blockHeading:
+		tag := "h" + strconv.Itoa(b.level)
+		r.sb.WriteString("<" + tag + ">")
+		r.inline(parseInline(b.text, r.refs))
+		r.sb.WriteString("</" + tag + ">\n")
+	case blockThematicBreak:
+		r.sb.WriteString("<hr />\n")
+	case blockCode:
+		if b.lang != "" {
+			r.sb.WriteString(`<pre><code class="language-` + html.EscapeString(b.lang) + `">`)
+		} else {
+			r.sb.WriteString("<pre><code>")
+		}
+		r.sb.WriteString(html.EscapeString(b.text))
+		r.sb.WriteString("</code></pre>\n")
+	case blockQuote:
+		r.sb.WriteString("<blockquote>\n")
+		r.blocks(b.children, false)
+		r.sb.WriteString("</blockquote>\n")
+	case blockList:
+		tag := "ul"
+		if b.ordered {
+			tag = "ol"
+		}
+		if b.ordered && b.start != 1 {
+			r.sb.WriteString(`<ol start="` + strconv.Itoa(b.start) + `">` + "\n")
+		} else {
+			r.sb.WriteString("<" + tag + ">\n")
+		}
+		for _, item := range b.children {
+			r.sb.WriteString("<li>")
+			r.blocks(item.children, b.tight)
+			r.sb.WriteString("</li>\n")
+		}
+		r.sb.WriteString("</" + tag + ">\n")
+	case blockTable:
+		r.sb.WriteString("<table>\n<thead>\n")
+		r.tableRow(b.header, b.align, "th")
+		r.sb.WriteString("</thead>\n")
+		if len(b.rows) > 0 {
+			r.sb.WriteString("<tbody>\n")
+			for _, row := range b.rows {
+				r.tableRow(row, b.align, "td")
+			}
+			r.sb.WriteString("</tbody>\n")
+		}
+		r.sb.WriteString("</table>\n")
+	}
+}
+
+func (r *renderer) tableRow(cells []string, align []string, tag string) {
+	r.sb.WriteString("<tr>")
+	for i, cell := range cells {
+		if align[i] != "" {
+			r.sb.WriteString("<" + tag + ` align="` + align[i] + `">`)
+		} else {
+			r.sb.WriteString("<" + tag + ">")
+		}
+		r.inline(parseInline(cell, r.refs))
+		r.sb.WriteString("</" + tag + ">")
+	}
+	r.sb.WriteString("</tr>\n")
+}
+
+func (r *renderer) inline(n *inline) {
+	for child := n.first; child != nil; child = child.next {
+		switch child.kind {
+		case inlineText:
+			r.sb.WriteString(html.EscapeString(child.text))
+		case inlineCode:
+			r.sb.WriteString("<code>" + html.EscapeString(child.text) + "</code>")
+		case inlineEmph:
+			r.sb.WriteString("<em>")
+			r.inline(child)
+			r.sb.WriteString("</em>")
+		case inlineStrong:
+			r.sb.WriteString("<strong>")
+			r.inline(child)
+			r.sb.WriteString("</strong>")
+		case inlineDel:
+			r.sb.WriteString("<del>")
+			r.inline(child)
+			r.sb.WriteString("</del>")
+		case inlineLink:
+			r.sb.WriteString(`<a href="` + html.EscapeString(normalizeURL(child.dest)) + `"`)
+			if child.title != "" {
+				r.sb.WriteString(` title="` + html.EscapeString(child.title) + `"`)
+			}
+			r.sb.WriteString(">")
+			r.inline(child)
+			r.sb.WriteString("</a>")
+		case inlineImage:
+			r.sb.WriteString(`<img src="` + html.EscapeString(normalizeURL(child.dest)) + `" alt="` + html.EscapeString(plainInline(child)) + `"`)
+			if child.title != "" {
+				r.sb.WriteString(` title="` + html.EscapeString(child.title) + `"`)
+			}
+			r.sb.WriteString(" />")
+		case inlineSoftBreak:
+			r.sb.WriteString("\n")
+		case inlineHardBreak:
+			r.sb.WriteString("<br />\n")
+		}
+	}
+}
+
+// plainInline 返回行内元素的纯文本, 图片使用替代文本
+func plainInline(n *inline) string {
+	var sb strings.Builder
+	var walk func(n *inline)
+	walk = func(n *inline) {
+		for child := n.first; child != nil; child = child.next {
+			switch child.kind {
+			case inlineText, inlineCode:
+				sb.WriteString(child.text)
+			case inlineSoftBreak, inlineHardBreak:
+				sb.WriteString("\n")
+			default:
+				walk(child)
+			}
+		}
+	}
+	walk(n)
+	return sb.String()
+}
+
+// plainBlocks 返回块级元素的纯文本, 块之间用换行分隔
+func plainBlocks(sb *strings.Builder, blocks []*block, refs map[string]linkRef) {
+	for _, b := range blocks {
+		switch b.kind {
+		case blockParagraph, blockHeading:
+			sb.WriteString(plainInline(parseInline(b.text, refs)))
+			sb.WriteString("\n")
+		case blockCode:
+			sb.WriteString(b.text)
+		case blockQuote, blockList, blockListItem:
+			plainBlocks(sb, b.children, refs)
+		case blockTable:
+			for _, row := range append([][]string{b.header}, b.rows...) {
+				cells := make([]string, 0, len(row))
+				for _, cell := range row {
+					cells = append(cells, plainInline(parseInline(cell, refs)))
+				}
+				sb.WriteString(strings.Join(cells, " "))
+				sb.WriteString("\n")
+			}
+		}
+	}
+}
+
+// normalizeURL 对网址中不安全的字符进行百分号编码, 已编码的部分保持不变
+func normalizeURL(url string) string {
+	const hex = "0123456789ABCDEF"
+	var sb strings.Builder
+	for i := 0; i < len(url); i++ {
+		c := url[i]
+		switch {
+		case c == '%' && i+2 < len(url) && isHex(url[i+1]) && isHex(url[i+2]):
+			sb.WriteByte(c)
+		case ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || strings.IndexByte(";/?:@&=+$,-_.!~*'()#", c) >= 0:
+			sb.WriteByte(c)
+		default:
+			sb.WriteByte('%')
+			sb.WriteByte(hex[c>>4])
+			sb.WriteByte(hex[c&15])
+		}
+	}
+	return sb.String()
+}
+
+func isHex(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
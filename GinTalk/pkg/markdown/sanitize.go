@@ -0,0 +1,155 @@
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"slices"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+)
+
+// allowedElements 允许输出的元素及其允许的属性, 不在列表中的元素只保留文本内容
+var allowedElements = map[string][]string{
+	"p": nil, "br": nil, "hr": nil, "blockquote": nil, "pre": nil,
+	"h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+	"ul": nil, "ol": {"start"}, "li": nil,
+	"em": nil, "strong": nil, "del": nil, "code": {"class"},
+	"a":     {"href", "title"},
+	"img":   {"src", "alt", "title"},
+	"table": nil, "thead": nil, "tbody": nil, "tr": nil, "th": {"align"}, "td": {"align"},
+}
+
+// droppedElements 连同内容一起删除的元素
+var droppedElements = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "embed": true,
+	"template": true, "textarea": true, "title": true, "noscript": true, "svg": true, "math": true,
+}
+
+var voidElements = map[string]bool{"br": true, "hr": true, "img": true}
+
+// allowedSchemes 链接和图片允许使用的协议, 没有协议的相对地址总是允许
+var allowedSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+var (
+	languageClassRe = regexp.MustCompile(`^language-[A-Za-z0-9_+#.-]{1,32}$`)
+	numberRe        = regexp.MustCompile(`^[0-9]{1,9}$`)
+)
+
+// Sanitize 按白名单过滤 HTML
+// 只保留白名单中的元素和属性, 链接只允许 http、https、mailto 和相对地址, 所有链接都加上 rel="nofollow noopener"
+func Sanitize(s string) string {
+	var sb strings.Builder
+	tokenizer := nethtml.NewTokenizer(strings.NewReader(s))
+	dropDepth := 0
+	for {
+		tt := tokenizer.Next()
+		if tt == nethtml.ErrorToken {
+			// 读取完毕或解析失败, 解析失败时丢弃剩余内容
+			return sb.String()
+		}
+		token := tokenizer.Token()
+		switch tt {
+		case nethtml.TextToken:
+			if dropDepth == 0 {
+				sb.WriteString(html.EscapeString(token.Data))
+			}
+		case nethtml.StartTagToken, nethtml.SelfClosingTagToken:
+			if droppedElements[token.Data] {
+				if tt == nethtml.StartTagToken && !voidElements[token.Data] {
+					dropDepth++
+				}
+				continue
+			}
+			if dropDepth > 0 {
+				continue
+			}
+			writeStartTag(&sb, token)
+		case nethtml.EndTagToken:
+			if droppedElements[token.Data] {
+				if dropDepth > 0 {
+					dropDepth--
+				}
+				continue
+			}
+			if dropDepth > 0 || voidElements[token.Data] {
+				continue
+			}
+			if _, ok := allowedElements[token.Data]; ok {
+				sb.WriteString("</" + token.Data + ">")
+			}
+		}
+	}
+}
+
+func writeStartTag(sb *strings.Builder, token nethtml.Token) {
+	allowed, ok := allowedElements[token.Data]
+	if !ok {
+		return
+	}
+	var attrs []nethtml.Attribute
+	for _, attr := range token.Attr {
+		if attr.Namespace != "" || !slices.Contains(allowed, attr.Key) || !validAttr(token.Data, attr.Key, attr.Val) {
+			continue
+		}
+		attrs = append(attrs, attr)
+	}
+	switch token.Data {
+	case "img":
+		// 没有有效地址的图片直接删除
+		if !hasAttr(attrs, "src") {
+			return
+		}
+	case "a":
+		if hasAttr(attrs, "href") {
+			attrs = append(attrs, nethtml.Attribute{Key: "rel", Val: "nofollow noopener"})
+		}
+	}
+
+	sb.WriteString("<" + token.Data)
+	for _, attr := range attrs {
+		sb.WriteString(" " + attr.Key + `="` + html.EscapeString(attr.Val) + `"`)
+	}
+	if voidElements[token.Data] {
+		sb.WriteString(" />")
+	} else {
+		sb.WriteString(">")
+	}
+}
+
+func validAttr(element string, key string, val string) bool {
+	switch key {
+	case "href", "src":
+		return safeURL(val)
+	case "class":
+		return element == "code" && languageClassRe.MatchString(val)
+	case "start":
+		return numberRe.MatchString(val)
+	case "align":
+		return val == "left" || val == "center" || val == "right"
+	}
+	return true
+}
+
+// safeURL 判断地址是否为相对地址或使用允许的协议
+func safeURL(url string) bool {
+	url = strings.TrimSpace(url)
+	colon := strings.IndexByte(url, ':')
+	if colon < 0 {
+		return true
+	}
+	// 冒号出现在路径、查询或片段中时为相对地址
+	if i := strings.IndexAny(url, "/?#"); i >= 0 && i < colon {
+		return true
+	}
+	return allowedSchemes[strings.ToLower(url[:colon])]
+}
+
+func hasAttr(attrs []nethtml.Attribute, key string) bool {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
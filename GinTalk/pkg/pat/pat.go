@@ -0,0 +1,81 @@
+package pat
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"slices"
+	"strings"
+)
+
+// Prefix 个人访问令牌的固定前缀, 用于和 JWT 区分, 也方便密钥扫描工具识别泄露的令牌
+const Prefix = "gtp_"
+
+// displayPrefixLength 列表中展示的令牌前缀长度, 包含固定前缀
+const displayPrefixLength = len(Prefix) + 6
+
+// Scope 个人访问令牌的授权范围
+type Scope string
+
+const (
+	// ScopeRead 读取帖子、评论、社区等公开内容
+	ScopeRead Scope = "read"
+	// ScopePostWrite 发布、修改和删除帖子
+	ScopePostWrite Scope = "post:write"
+	// ScopeCommentWrite 发布、修改和删除评论
+	ScopeCommentWrite Scope = "comment:write"
+)
+
+// AllScopes 所有可用的授权范围
+var AllScopes = []Scope{ScopeRead, ScopePostWrite, ScopeCommentWrite}
+
+// Generate 生成一个新的个人访问令牌
+//
+// 返回:
+//   - token: 令牌明文, 只在创建时返回给用户一次。
+//   - hash: 令牌的哈希, 数据库中只保存哈希。
+//   - displayPrefix: 令牌的前几位, 用于在列表中辨认令牌。
+func Generate() (token string, hash string, displayPrefix string, err error) {
+	buf := make([]byte, 32)
+	if _, err = rand.Read(buf); err != nil {
+		return "", "", "", err
+	}
+	token = Prefix + base64.RawURLEncoding.EncodeToString(buf)
+	return token, Hash(token), token[:displayPrefixLength], nil
+}
+
+// Hash 计算令牌的哈希
+// 令牌是随机生成的高熵字符串, 不需要加盐和慢哈希
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsToken 判断字符串是否是个人访问令牌
+func IsToken(s string) bool {
+	return strings.HasPrefix(s, Prefix)
+}
+
+// JoinScopes 将授权范围拼接为数据库中保存的格式, 去重并排序
+func JoinScopes(scopes []Scope) string {
+	s := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		s = append(s, string(scope))
+	}
+	slices.Sort(s)
+	return strings.Join(slices.Compact(s), ",")
+}
+
+// SplitScopes 解析数据库中保存的授权范围
+func SplitScopes(s string) []Scope {
+	if s == "" {
+		return []Scope{}
+	}
+	parts := strings.Split(s, ",")
+	scopes := make([]Scope, 0, len(parts))
+	for _, part := range parts {
+		scopes = append(scopes, Scope(part))
+	}
+	return scopes
+}
@@ -0,0 +1,79 @@
+package ranking
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// redditEpoch Reddit 算法计算时间分量的起点, 2020-01-01 00:00:00 (UTC+8)
+const redditEpoch = 1577808000
+
+// Reddit Reddit 的 hot 算法
+// 净票数取对数, 再加上与发布时间成正比的分量, 新帖子的分数天然更高, 每 12.5 小时相当于净票数乘以 10
+type Reddit struct{}
+
+func (Reddit) Name() string {
+	return NameReddit
+}
+
+func (Reddit) Score(ups int64, downs int64, publishTime time.Time, _ time.Time) float64 {
+	s := float64(ups - downs)
+	order := math.Log10(math.Max(math.Abs(s), 1))
+	var sign float64
+	if s > 0 {
+		sign = 1
+	} else if s < 0 {
+		sign = -1
+	}
+	seconds := float64(publishTime.Unix() - redditEpoch)
+	return sign*order + seconds/45000
+}
+
+func (Reddit) TimeDependent() bool {
+	return false
+}
+
+// HackerNews Hacker News 的重力衰减算法
+// 分数为净票数除以 (发布后的小时数 + 2) 的 Gravity 次方, Gravity 越大旧帖子下降越快
+type HackerNews struct {
+	Gravity float64
+}
+
+func (h HackerNews) Name() string {
+	return NameHackerNews + ":" + strconv.FormatFloat(h.Gravity, 'g', -1, 64)
+}
+
+func (h HackerNews) Score(ups int64, downs int64, publishTime time.Time, now time.Time) float64 {
+	hours := math.Max(now.Sub(publishTime).Hours(), 0)
+	return float64(ups-downs) / math.Pow(hours+2, h.Gravity)
+}
+
+func (HackerNews) TimeDependent() bool {
+	return true
+}
+
+// Wilson 威尔逊得分区间的下界
+// 按赞成票比例的置信区间下界排序, 票数少的帖子不会因为偶然的高比例排在前面, 与发布时间无关
+type Wilson struct {
+	// Z 置信水平对应的正态分布分位数, 1.96 对应 95% 的置信水平
+	Z float64
+}
+
+func (w Wilson) Name() string {
+	return NameWilson + ":" + strconv.FormatFloat(w.Z, 'g', -1, 64)
+}
+
+func (w Wilson) Score(ups int64, downs int64, _ time.Time, _ time.Time) float64 {
+	n := float64(ups + downs)
+	if n <= 0 {
+		return 0
+	}
+	p := float64(ups) / n
+	z2 := w.Z * w.Z
+	return (p + z2/(2*n) - w.Z*math.Sqrt((p*(1-p)+z2/(4*n))/n)) / (1 + z2/n)
+}
+
+func (Wilson) TimeDependent() bool {
+	return false
+}
@@ -0,0 +1,105 @@
+package ranking
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+const epsilon = 1e-6
+
+func TestReddit(t *testing.T) {
+	epoch := time.Unix(redditEpoch, 0)
+	tests := []struct {
+		name        string
+		ups, downs  int64
+		publishTime time.Time
+		want        float64
+	}{
+		{"没有投票", 0, 0, epoch, 0},
+		{"净票数 10", 10, 0, epoch, 1},
+		{"净票数 100, 晚 12.5 小时", 150, 50, epoch.Add(45000 * time.Second), 3},
+		{"踩多于赞", 0, 100, epoch.Add(45000 * time.Second), -1},
+		{"赞踩相同", 7, 7, epoch.Add(90000 * time.Second), 2},
+	}
+	for _, tt := range tests {
+		got := Reddit{}.Score(tt.ups, tt.downs, tt.publishTime, time.Now())
+		if math.Abs(got-tt.want) > epsilon {
+			t.Errorf("%s: Score(%d, %d) = %v, 期望 %v", tt.name, tt.ups, tt.downs, got, tt.want)
+		}
+	}
+}
+
+func TestHackerNews(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		name       string
+		gravity    float64
+		ups, downs int64
+		age        time.Duration
+		want       float64
+	}{
+		{"刚发布", 2, 10, 2, 0, 2},
+		{"发布 2 小时", 2, 10, 2, 2 * time.Hour, 0.5},
+		{"踩多于赞", 2, 2, 10, 2 * time.Hour, -0.5},
+		{"发布时间晚于当前时间按 0 小时计算", 2, 10, 2, -time.Hour, 2},
+		{"默认重力系数", 1.8, 10, 1, time.Hour, 1.2457309396},
+	}
+	for _, tt := range tests {
+		got := HackerNews{Gravity: tt.gravity}.Score(tt.ups, tt.downs, now.Add(-tt.age), now)
+		if math.Abs(got-tt.want) > epsilon {
+			t.Errorf("%s: Score(%d, %d) = %v, 期望 %v", tt.name, tt.ups, tt.downs, got, tt.want)
+		}
+	}
+	older := HackerNews{Gravity: 1.8}.Score(10, 0, now.Add(-10*time.Hour), now)
+	newer := HackerNews{Gravity: 1.8}.Score(10, 0, now.Add(-time.Hour), now)
+	if older >= newer {
+		t.Errorf("票数相同时旧帖子的分数 %v 不小于新帖子的分数 %v", older, newer)
+	}
+}
+
+func TestWilson(t *testing.T) {
+	tests := []struct {
+		name       string
+		ups, downs int64
+		want       float64
+	}{
+		{"没有投票", 0, 0, 0},
+		{"一个赞", 1, 0, 0.2065432915},
+		{"100 个赞", 100, 0, 0.9630051925},
+		{"60% 的赞", 600, 400, 0.5693088606},
+		{"踩多于赞", 1, 9, 0.0178757495},
+		{"只有踩", 0, 5, 0},
+	}
+	for _, tt := range tests {
+		got := Wilson{Z: defaultConfidence}.Score(tt.ups, tt.downs, time.Time{}, time.Time{})
+		if math.Abs(got-tt.want) > epsilon {
+			t.Errorf("%s: Score(%d, %d) = %v, 期望 %v", tt.name, tt.ups, tt.downs, got, tt.want)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		gravity float64
+		want    string
+	}{
+		{NameReddit, 0, "reddit"},
+		{NameHackerNews, 0, "hackernews:1.8"},
+		{NameHackerNews, 1.5, "hackernews:1.5"},
+		{NameWilson, 0, "wilson:1.96"},
+	}
+	for _, tt := range tests {
+		r, err := New(tt.name, tt.gravity)
+		if err != nil {
+			t.Fatalf("New(%q) 失败: %v", tt.name, err)
+		}
+		if r.Name() != tt.want {
+			t.Errorf("New(%q, %v).Name() = %q, 期望 %q", tt.name, tt.gravity, r.Name(), tt.want)
+		}
+	}
+	if _, err := New("unknown", 0); err == nil {
+		t.Error("不支持的算法没有返回错误")
+	}
+}
@@ -0,0 +1,115 @@
+package ranking
+
+import (
+	"GinTalk/settings"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	NameReddit     = "reddit"
+	NameHackerNews = "hackernews"
+	NameWilson     = "wilson"
+)
+
+// Ranker 帖子热度排行算法
+type Ranker interface {
+	// Name 算法的名称和参数, 用于判断排行使用的算法是否发生变化
+	Name() string
+	// Score 根据赞成票数、反对票数和发布时间计算排行分数, 分数越大排名越靠前
+	Score(ups int64, downs int64, publishTime time.Time, now time.Time) float64
+	// TimeDependent 分数是否随当前时间变化, 随时间变化的排行需要定期重新计算所有帖子的分数
+	TimeDependent() bool
+}
+
+var (
+	defaultRanker     Ranker
+	communityRankers  map[int64]Ranker
+	rankersMu         sync.RWMutex
+	rankersOnce       sync.Once
+	defaultGravity    = 1.8
+	defaultConfidence = 1.96
+)
+
+// New 根据名称创建排行算法, gravity 为 hackernews 算法的重力系数, 不大于 0 时使用默认值 1.8
+func New(name string, gravity float64) (Ranker, error) {
+	switch name {
+	case NameReddit:
+		return Reddit{}, nil
+	case NameHackerNews:
+		if gravity <= 0 {
+			gravity = defaultGravity
+		}
+		return HackerNews{Gravity: gravity}, nil
+	case NameWilson:
+		return Wilson{Z: defaultConfidence}, nil
+	}
+	return nil, fmt.Errorf("不支持的排行算法: %s", name)
+}
+
+// buildRankers 根据配置创建全局和各个社区的排行算法
+func buildRankers(cfg *settings.RankingConfig) (Ranker, map[int64]Ranker, error) {
+	def, err := New(cfg.Default, cfg.Gravity)
+	if err != nil {
+		return nil, nil, err
+	}
+	communities := make(map[int64]Ranker, len(cfg.Communities))
+	for id, name := range cfg.Communities {
+		communityID, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("社区 ID 不正确: %s", id)
+		}
+		if communities[communityID], err = New(name, cfg.Gravity); err != nil {
+			return nil, nil, fmt.Errorf("社区 %d: %w", communityID, err)
+		}
+	}
+	return def, communities, nil
+}
+
+// loadRankers 根据配置创建排行算法, 配置错误时直接退出
+// 之后配置文件修改时重新创建, 新的配置错误时继续使用原来的算法
+func loadRankers() {
+	def, communities, err := buildRankers(settings.GetConfig().RankingConfig)
+	if err != nil {
+		zap.L().Fatal("排行算法配置错误", zap.Error(err))
+	}
+	defaultRanker, communityRankers = def, communities
+
+	settings.OnConfigChange(func(c *settings.Settings) {
+		if c.RankingConfig == nil {
+			return
+		}
+		def, communities, err := buildRankers(c.RankingConfig)
+		if err != nil {
+			zap.L().Error("排行算法配置错误, 继续使用原来的算法", zap.Error(err))
+			return
+		}
+		rankersMu.Lock()
+		defaultRanker, communityRankers = def, communities
+		rankersMu.Unlock()
+		zap.L().Info("排行算法配置已更新", zap.String("default", def.Name()))
+	})
+}
+
+// Default 全局和标签排行使用的算法
+func Default() Ranker {
+	rankersOnce.Do(loadRankers)
+	rankersMu.RLock()
+	defer rankersMu.RUnlock()
+	return defaultRanker
+}
+
+// ForCommunity 社区排行使用的算法, 配置中没有指定的社区使用 Default
+func ForCommunity(communityID int64) Ranker {
+	rankersOnce.Do(loadRankers)
+	rankersMu.RLock()
+	defer rankersMu.RUnlock()
+	if r, ok := communityRankers[communityID]; ok {
+		return r
+	}
+	return defaultRanker
+}
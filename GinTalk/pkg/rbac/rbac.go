@@ -0,0 +1,82 @@
+package rbac
+
+// Role 用户的全局角色
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+// Permission 需要授权的操作
+type Permission string
+
+const (
+	// PermDeleteAnyPost 删除任何人的帖子
+	PermDeleteAnyPost Permission = "post:delete_any"
+	// PermDeleteAnyComment 删除任何人的评论
+	PermDeleteAnyComment Permission = "comment:delete_any"
+	// PermBanUser 封禁用户
+	PermBanUser Permission = "user:ban"
+	// PermManageCommunity 创建和修改社区, 任命社区版主
+	PermManageCommunity Permission = "community:manage"
+	// PermCreateInvite 在仅限邀请注册模式下生成邀请码
+	PermCreateInvite Permission = "invite:create"
+	// PermRestorePostRevision 把任何人的帖子恢复到以前的版本
+	PermRestorePostRevision Permission = "post:restore_revision"
+)
+
+// roleLevels 角色的等级, 高等级的角色可以管理低等级的用户
+var roleLevels = map[Role]int{
+	RoleUser:      0,
+	RoleModerator: 1,
+	RoleAdmin:     2,
+}
+
+// rolePermissions 全局角色拥有的权限, 在所有社区中生效
+var rolePermissions = map[Role][]Permission{
+	RoleUser:      {},
+	RoleModerator: {PermDeleteAnyPost, PermDeleteAnyComment, PermBanUser, PermCreateInvite, PermRestorePostRevision},
+	RoleAdmin:     {PermDeleteAnyPost, PermDeleteAnyComment, PermBanUser, PermManageCommunity, PermCreateInvite, PermRestorePostRevision},
+}
+
+// communityModeratorPermissions 社区版主在自己管理的社区中拥有的权限
+var communityModeratorPermissions = []Permission{PermDeleteAnyPost, PermDeleteAnyComment, PermRestorePostRevision}
+
+// ParseRole 解析角色名称
+func ParseRole(s string) (Role, bool) {
+	role := Role(s)
+	_, ok := roleLevels[role]
+	return role, ok
+}
+
+// Level 角色的等级, 未知角色视为普通用户
+func (r Role) Level() int {
+	return roleLevels[r]
+}
+
+// AtLeast 判断角色的等级是否不低于 other
+func (r Role) AtLeast(other Role) bool {
+	return r.Level() >= other.Level()
+}
+
+// Has 判断全局角色是否拥有某个权限
+func (r Role) Has(p Permission) bool {
+	for _, perm := range rolePermissions[r] {
+		if perm == p {
+			return true
+		}
+	}
+	return false
+}
+
+// CommunityModeratorHas 判断社区版主在自己的社区中是否拥有某个权限
+func CommunityModeratorHas(p Permission) bool {
+	for _, perm := range communityModeratorPermissions {
+		if perm == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,409 @@
+// Package redistest 提供一个进程内的 Redis 服务, 用于在没有 Redis 的环境中测试依赖 Redis 的代码
+// 只实现了字符串和有序集合相关的少量命令和 MULTI/EXEC 事务, 不支持的命令返回错误
+package redistest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type entry struct {
+	value    string
+	zset     map[string]float64 // 不为 nil 时是有序集合
+	expireAt time.Time
+}
+
+const wrongType = "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"
+
+// Server 进程内的 Redis 服务
+type Server struct {
+	listener net.Listener
+	mu       sync.Mutex
+	data     map[string]*entry
+}
+
+// NewServer 在随机端口上启动服务, 测试结束时自动关闭
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("启动 Redis 测试服务失败: %v", err)
+	}
+	s := &Server{listener: listener, data: make(map[string]*entry)}
+	go s.serve()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Addr 服务监听的地址
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close 关闭服务
+func (s *Server) Close() {
+	s.listener.Close()
+}
+
+// FlushAll 清空所有数据
+func (s *Server) FlushAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = make(map[string]*entry)
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	var queued [][]string
+	inMulti := false
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		switch name := strings.ToUpper(args[0]); {
+		case name == "MULTI":
+			inMulti, queued = true, nil
+			w.WriteString("+OK\r\n")
+		case name == "EXEC" && inMulti:
+			inMulti = false
+			fmt.Fprintf(w, "*%d\r\n", len(queued))
+			s.mu.Lock()
+			for _, cmd := range queued {
+				w.WriteString(s.exec(cmd))
+			}
+			s.mu.Unlock()
+		case name == "DISCARD" && inMulti:
+			inMulti, queued = false, nil
+			w.WriteString("+OK\r\n")
+		case inMulti:
+			queued = append(queued, args)
+			w.WriteString("+QUEUED\r\n")
+		default:
+			s.mu.Lock()
+			w.WriteString(s.exec(args))
+			s.mu.Unlock()
+		}
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readCommand 读取一个 RESP 数组格式的命令
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '*' {
+		return nil, fmt.Errorf("不支持的请求格式: %q", line)
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("错误的参数数量: %q", line)
+	}
+	args := make([]string, n)
+	for i := range args {
+		header, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimPrefix(header, "$"))
+		if err != nil {
+			return nil, fmt.Errorf("错误的参数长度: %q", header)
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// get 返回未过期的值, 已过期的值在读取时删除; 调用方需要持有锁
+func (s *Server) get(key string) (*entry, bool) {
+	e, ok := s.data[key]
+	if ok && !e.expireAt.IsZero() && !time.Now().Before(e.expireAt) {
+		delete(s.data, key)
+		return nil, false
+	}
+	return e, ok
+}
+
+// exec 执行一个命令并返回 RESP 格式的结果; 调用方需要持有锁
+func (s *Server) exec(args []string) string {
+	name := strings.ToUpper(args[0])
+	switch {
+	case name == "PING":
+		return "+PONG\r\n"
+	case name == "GET" && len(args) == 2:
+		e, ok := s.get(args[1])
+		if !ok {
+			return "$-1\r\n"
+		}
+		if e.zset != nil {
+			return wrongType
+		}
+		return bulk(e.value)
+	case name == "SET" && len(args) >= 3:
+		return s.set(args[1], args[2], args[3:])
+	case name == "DEL" && len(args) >= 2:
+		count := 0
+		for _, key := range args[1:] {
+			if _, ok := s.get(key); ok {
+				delete(s.data, key)
+				count++
+			}
+		}
+		return integer(int64(count))
+	case name == "EXISTS" && len(args) >= 2:
+		count := 0
+		for _, key := range args[1:] {
+			if _, ok := s.get(key); ok {
+				count++
+			}
+		}
+		return integer(int64(count))
+	case name == "INCR" && len(args) == 2:
+		e, ok := s.get(args[1])
+		if !ok {
+			e = &entry{value: "0"}
+			s.data[args[1]] = e
+		}
+		if e.zset != nil {
+			return wrongType
+		}
+		n, err := strconv.ParseInt(e.value, 10, 64)
+		if err != nil {
+			return "-ERR value is not an integer or out of range\r\n"
+		}
+		e.value = strconv.FormatInt(n+1, 10)
+		return integer(n + 1)
+	case name == "EXPIRE" && len(args) == 3:
+		seconds, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return "-ERR value is not an integer or out of range\r\n"
+		}
+		e, ok := s.get(args[1])
+		if !ok {
+			return integer(0)
+		}
+		e.expireAt = time.Now().Add(time.Duration(seconds) * time.Second)
+		return integer(1)
+	case name == "TTL" && len(args) == 2:
+		e, ok := s.get(args[1])
+		switch {
+		case !ok:
+			return integer(-2)
+		case e.expireAt.IsZero():
+			return integer(-1)
+		}
+		return integer(int64(time.Until(e.expireAt).Round(time.Second).Seconds()))
+	case name == "ZADD" && len(args) >= 4 && len(args)%2 == 0:
+		return s.zadd(args[1], args[2:])
+	case name == "ZREVRANGEBYSCORE" && len(args) >= 4:
+		return s.zrevrangebyscore(args[1], args[2], args[3], args[4:])
+	}
+	return fmt.Sprintf("-ERR unsupported command '%s'\r\n", args[0])
+}
+
+// set 实现 SET key value [EX seconds|PX milliseconds] [NX]
+func (s *Server) set(key string, value string, options []string) string {
+	var ttl time.Duration
+	nx := false
+	for i := 0; i < len(options); i++ {
+		switch strings.ToUpper(options[i]) {
+		case "NX":
+			nx = true
+		case "EX", "PX":
+			if i+1 >= len(options) {
+				return "-ERR syntax error\r\n"
+			}
+			n, err := strconv.ParseInt(options[i+1], 10, 64)
+			if err != nil || n <= 0 {
+				return "-ERR invalid expire time in 'set' command\r\n"
+			}
+			unit := time.Second
+			if strings.ToUpper(options[i]) == "PX" {
+				unit = time.Millisecond
+			}
+			ttl = time.Duration(n) * unit
+			i++
+		default:
+			return "-ERR syntax error\r\n"
+		}
+	}
+	if _, ok := s.get(key); ok && nx {
+		return "$-1\r\n"
+	}
+	e := &entry{value: value}
+	if ttl > 0 {
+		e.expireAt = time.Now().Add(ttl)
+	}
+	s.data[key] = e
+	return "+OK\r\n"
+}
+
+// zadd 实现 ZADD key score member [score member ...], 不支持 NX/XX 等选项
+func (s *Server) zadd(key string, pairs []string) string {
+	e, ok := s.get(key)
+	if ok && e.zset == nil {
+		return wrongType
+	}
+	scores := make(map[string]float64, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		score, err := strconv.ParseFloat(pairs[i], 64)
+		if err != nil || math.IsNaN(score) {
+			return "-ERR value is not a valid float\r\n"
+		}
+		scores[pairs[i+1]] = score
+	}
+	if !ok {
+		e = &entry{zset: make(map[string]float64)}
+		s.data[key] = e
+	}
+	added := 0
+	for member, score := range scores {
+		if _, exist := e.zset[member]; !exist {
+			added++
+		}
+		e.zset[member] = score
+	}
+	return integer(int64(added))
+}
+
+// zrevrangebyscore 实现 ZREVRANGEBYSCORE key max min [WITHSCORES] [LIMIT offset count]
+// 分数相同的成员按字典序倒序排列, 与 Redis 一致
+func (s *Server) zrevrangebyscore(key string, maxArg string, minArg string, options []string) string {
+	maxScore, maxExclusive, err := parseScoreBound(maxArg)
+	if err != nil {
+		return "-ERR min or max is not a float\r\n"
+	}
+	minScore, minExclusive, err := parseScoreBound(minArg)
+	if err != nil {
+		return "-ERR min or max is not a float\r\n"
+	}
+	withScores := false
+	offset, count := 0, -1
+	for i := 0; i < len(options); i++ {
+		switch strings.ToUpper(options[i]) {
+		case "WITHSCORES":
+			withScores = true
+		case "LIMIT":
+			if i+2 >= len(options) {
+				return "-ERR syntax error\r\n"
+			}
+			o, err1 := strconv.Atoi(options[i+1])
+			c, err2 := strconv.Atoi(options[i+2])
+			if err1 != nil || err2 != nil {
+				return "-ERR value is not an integer or out of range\r\n"
+			}
+			offset, count = o, c
+			i += 2
+		default:
+			return "-ERR syntax error\r\n"
+		}
+	}
+
+	e, ok := s.get(key)
+	if ok && e.zset == nil {
+		return wrongType
+	}
+	var members []string
+	if ok {
+		for member, score := range e.zset {
+			if score > maxScore || (maxExclusive && score == maxScore) ||
+				score < minScore || (minExclusive && score == minScore) {
+				continue
+			}
+			members = append(members, member)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		si, sj := e.zset[members[i]], e.zset[members[j]]
+		if si != sj {
+			return si > sj
+		}
+		return members[i] > members[j]
+	})
+	if offset < 0 || offset >= len(members) {
+		members = nil
+	} else {
+		members = members[offset:]
+	}
+	if count >= 0 && count < len(members) {
+		members = members[:count]
+	}
+
+	var b strings.Builder
+	if withScores {
+		fmt.Fprintf(&b, "*%d\r\n", len(members)*2)
+	} else {
+		fmt.Fprintf(&b, "*%d\r\n", len(members))
+	}
+	for _, member := range members {
+		b.WriteString(bulk(member))
+		if withScores {
+			b.WriteString(bulk(strconv.FormatFloat(e.zset[member], 'g', -1, 64)))
+		}
+	}
+	return b.String()
+}
+
+// parseScoreBound 解析有序集合的分数范围, 支持 +inf, -inf 和表示开区间的 ( 前缀
+func parseScoreBound(arg string) (float64, bool, error) {
+	exclusive := strings.HasPrefix(arg, "(")
+	arg = strings.TrimPrefix(arg, "(")
+	switch strings.ToLower(arg) {
+	case "+inf", "inf":
+		return math.Inf(1), exclusive, nil
+	case "-inf":
+		return math.Inf(-1), exclusive, nil
+	}
+	score, err := strconv.ParseFloat(arg, 64)
+	if err != nil || math.IsNaN(score) {
+		return 0, false, fmt.Errorf("无效的分数: %q", arg)
+	}
+	return score, exclusive, nil
+}
+
+func bulk(s string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(s), s)
+}
+
+func integer(n int64) string {
+	return fmt.Sprintf(":%d\r\n", n)
+}
@@ -0,0 +1,96 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+const (
+	// snippetLength 内容摘要的最大字符数
+	snippetLength = 120
+	// snippetLeading 摘要中第一个命中词之前保留的字符数
+	snippetLeading = 30
+)
+
+// matchMask 标记 text 中被任意一个搜索词命中的字符, 不区分大小写
+func matchMask(text []rune, terms []string) []bool {
+	lower := make([]rune, len(text))
+	for i, r := range text {
+		lower[i] = unicode.ToLower(r)
+	}
+	mask := make([]bool, len(text))
+	for _, term := range terms {
+		t := []rune(term)
+		for i := 0; i+len(t) <= len(lower); i++ {
+			if equalRunes(lower[i:i+len(t)], t) {
+				for j := i; j < i+len(t); j++ {
+					mask[j] = true
+				}
+			}
+		}
+	}
+	return mask
+}
+
+func equalRunes(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// render 将 text 转义为 HTML, 并用 <em> 标签包裹连续的命中字符
+func render(text []rune, mask []bool) string {
+	var sb strings.Builder
+	for i := 0; i < len(text); {
+		j := i
+		for j < len(text) && mask[j] == mask[i] {
+			j++
+		}
+		if mask[i] {
+			sb.WriteString("<em>")
+			sb.WriteString(html.EscapeString(string(text[i:j])))
+			sb.WriteString("</em>")
+		} else {
+			sb.WriteString(html.EscapeString(string(text[i:j])))
+		}
+		i = j
+	}
+	return sb.String()
+}
+
+// highlight 返回高亮后的完整文本
+func highlight(text string, terms []string) string {
+	runes := []rune(text)
+	return render(runes, matchMask(runes, terms))
+}
+
+// snippet 截取第一个命中词附近的一段内容并高亮, 截断处使用省略号
+func snippet(text string, terms []string) string {
+	runes := []rune(text)
+	mask := matchMask(runes, terms)
+
+	start := 0
+	for i, matched := range mask {
+		if matched {
+			start = max(i-snippetLeading, 0)
+			break
+		}
+	}
+	end := min(start+snippetLength, len(runes))
+	// 命中位置靠近结尾时向前补足长度
+	start = max(end-snippetLength, 0)
+
+	var sb strings.Builder
+	if start > 0 {
+		sb.WriteString("...")
+	}
+	sb.WriteString(render(runes[start:end], mask[start:end]))
+	if end < len(runes) {
+		sb.WriteString("...")
+	}
+	return sb.String()
+}
@@ -0,0 +1,209 @@
+package search
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+)
+
+const (
+	// BM25 参数, 使用常见的默认值
+	bm25K1 = 1.2
+	bm25B  = 0.75
+	// titleWeight 标题中的词按出现该次数计算词频, 使标题命中的排名更靠前
+	titleWeight = 3
+)
+
+type docKey struct {
+	docType DocType
+	id      int64
+}
+
+type indexedDoc struct {
+	doc    Document
+	length int
+	terms  map[string]int
+}
+
+// MemoryEngine 进程内的倒排索引, 使用 BM25 计算相关度
+// 索引只保存在内存中, 进程重启后需要重新建立
+type MemoryEngine struct {
+	mu       sync.RWMutex
+	docs     map[docKey]*indexedDoc
+	postings map[string]map[docKey]int
+	totalLen int
+}
+
+// NewMemoryEngine 创建一个空的进程内索引
+func NewMemoryEngine() *MemoryEngine {
+	return &MemoryEngine{
+		docs:     make(map[docKey]*indexedDoc),
+		postings: make(map[string]map[docKey]int),
+	}
+}
+
+func (e *MemoryEngine) Index(_ context.Context, doc *Document) error {
+	terms := make(map[string]int)
+	length := 0
+	for _, token := range Tokenize(doc.Title) {
+		terms[token] += titleWeight
+		length += titleWeight
+	}
+	for _, token := range Tokenize(doc.Content) {
+		terms[token]++
+		length++
+	}
+
+	key := docKey{docType: doc.Type, id: doc.ID}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.remove(key)
+	e.docs[key] = &indexedDoc{doc: *doc, length: length, terms: terms}
+	e.totalLen += length
+	for term, tf := range terms {
+		posting, ok := e.postings[term]
+		if !ok {
+			posting = make(map[docKey]int)
+			e.postings[term] = posting
+		}
+		posting[key] = tf
+	}
+	return nil
+}
+
+func (e *MemoryEngine) Delete(_ context.Context, docType DocType, id int64) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.remove(docKey{docType: docType, id: id})
+	return nil
+}
+
+func (e *MemoryEngine) DeletePost(_ context.Context, postID int64) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for key, doc := range e.docs {
+		if doc.doc.PostID == postID {
+			e.remove(key)
+		}
+	}
+	return nil
+}
+
+func (e *MemoryEngine) DeleteAuthor(_ context.Context, authorID int64) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	posts := make(map[int64]bool)
+	for _, doc := range e.docs {
+		if doc.doc.Type == DocPost && doc.doc.AuthorID == authorID {
+			posts[doc.doc.PostID] = true
+		}
+	}
+	for key, doc := range e.docs {
+		if doc.doc.AuthorID == authorID || posts[doc.doc.PostID] {
+			e.remove(key)
+		}
+	}
+	return nil
+}
+
+// remove 从索引中删除文档, 调用方需要持有写锁
+func (e *MemoryEngine) remove(key docKey) {
+	old, ok := e.docs[key]
+	if !ok {
+		return
+	}
+	for term := range old.terms {
+		posting := e.postings[term]
+		delete(posting, key)
+		if len(posting) == 0 {
+			delete(e.postings, term)
+		}
+	}
+	e.totalLen -= old.length
+	delete(e.docs, key)
+}
+
+func (e *MemoryEngine) Search(_ context.Context, query *Query) (*Result, error) {
+	terms := queryTerms(query.Text)
+	if len(terms) == 0 {
+		return &Result{}, nil
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	// 从文档数最少的词开始求交集, 文档需要包含所有搜索词
+	postings := make([]map[docKey]int, 0, len(terms))
+	for _, term := range terms {
+		posting, ok := e.postings[term]
+		if !ok {
+			return &Result{}, nil
+		}
+		postings = append(postings, posting)
+	}
+	sort.Slice(postings, func(i, j int) bool { return len(postings[i]) < len(postings[j]) })
+
+	n := float64(len(e.docs))
+	avgLen := float64(e.totalLen) / n
+	type scored struct {
+		doc   *indexedDoc
+		score float64
+	}
+	var matches []scored
+	for key := range postings[0] {
+		doc := e.docs[key]
+		if query.Type != "" && doc.doc.Type != query.Type {
+			continue
+		}
+		if query.CommunityID != 0 && doc.doc.CommunityID != query.CommunityID {
+			continue
+		}
+		score := 0.0
+		for _, posting := range postings {
+			tf, ok := posting[key]
+			if !ok {
+				score = -1
+				break
+			}
+			df := float64(len(posting))
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			norm := 1 - bm25B + bm25B*float64(doc.length)/avgLen
+			score += idf * float64(tf) * (bm25K1 + 1) / (float64(tf) + bm25K1*norm)
+		}
+		if score < 0 {
+			continue
+		}
+		matches = append(matches, scored{doc: doc, score: score})
+	}
+
+	// 相关度相同时较新的内容排在前面
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		if !matches[i].doc.doc.CreateTime.Equal(matches[j].doc.doc.CreateTime) {
+			return matches[i].doc.doc.CreateTime.After(matches[j].doc.doc.CreateTime)
+		}
+		return matches[i].doc.doc.ID > matches[j].doc.doc.ID
+	})
+
+	result := &Result{Total: len(matches)}
+	start := min(max(query.Offset, 0), len(matches))
+	end := len(matches)
+	if query.Limit > 0 {
+		end = min(start+query.Limit, len(matches))
+	}
+	for _, m := range matches[start:end] {
+		hit := Hit{
+			Document: m.doc.doc,
+			Score:    m.score,
+			Snippet:  snippet(m.doc.doc.Content, terms),
+		}
+		if m.doc.doc.Title != "" {
+			hit.TitleHighlight = highlight(m.doc.doc.Title, terms)
+		}
+		result.Hits = append(result.Hits, hit)
+	}
+	return result, nil
+}
@@ -0,0 +1,97 @@
+// Package search 提供帖子和评论的全文搜索
+// 搜索后端通过 Engine 接口抽象, 默认使用不依赖外部集群的进程内倒排索引
+package search
+
+import (
+	"GinTalk/settings"
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// BackendMemory 进程内倒排索引, 每个实例在启动时从数据库重建并通过 Kafka 消息保持更新
+	BackendMemory = "memory"
+)
+
+// DocType 被索引的内容类型
+type DocType string
+
+const (
+	DocPost    DocType = "post"
+	DocComment DocType = "comment"
+)
+
+var (
+	engine     Engine
+	engineOnce sync.Once
+)
+
+// Document 被索引的一篇帖子或一条评论
+// 类型为帖子时 ID 与 PostID 相同, 评论没有标题
+type Document struct {
+	Type        DocType
+	ID          int64
+	PostID      int64
+	CommunityID int64
+	AuthorID    int64
+	Title       string
+	Content     string
+	CreateTime  time.Time
+}
+
+// Query 搜索条件
+// Type 为空时同时搜索帖子和评论, CommunityID 为 0 时不限制社区
+type Query struct {
+	Text        string
+	Type        DocType
+	CommunityID int64
+	Offset      int
+	Limit       int
+}
+
+// Hit 一条搜索结果
+// TitleHighlight 和 Snippet 已经过 HTML 转义, 命中的词用 <em> 标签包裹
+type Hit struct {
+	Document
+	Score          float64
+	TitleHighlight string
+	Snippet        string
+}
+
+// Result 搜索结果, Total 为满足条件的结果总数
+type Result struct {
+	Total int
+	Hits  []Hit
+}
+
+// Engine 搜索后端的接口
+type Engine interface {
+	// Index 添加或替换一篇文档
+	Index(ctx context.Context, doc *Document) error
+	// Delete 删除一篇文档, 文档不存在时不返回错误
+	Delete(ctx context.Context, docType DocType, id int64) error
+	// DeletePost 删除帖子及其下的所有评论
+	DeletePost(ctx context.Context, postID int64) error
+	// DeleteAuthor 删除作者的所有帖子和评论, 以及这些帖子下的评论
+	DeleteAuthor(ctx context.Context, authorID int64) error
+	// Search 按相关度从高到低返回满足条件的文档
+	Search(ctx context.Context, query *Query) (*Result, error)
+}
+
+// GetEngine 获取配置的搜索后端
+// 使用单例模式, 第一次调用时根据配置创建, 配置错误时直接退出
+func GetEngine() Engine {
+	engineOnce.Do(func() {
+		cfg := settings.GetConfig().SearchConfig
+		switch cfg.Backend {
+		case BackendMemory:
+			engine = NewMemoryEngine()
+		default:
+			zap.L().Fatal("不支持的搜索后端", zap.String("backend", cfg.Backend))
+		}
+	})
+	return engine
+}
@@ -0,0 +1,110 @@
+package search
+
+import (
+	"unicode"
+)
+
+// maxTokenLength 超过该长度的英文单词和数字被截断, 避免超长的无意义字符串占用索引
+const maxTokenLength = 32
+
+// isCJK 判断字符是否为中日韩文字
+// 中日韩文字之间没有空格, 需要按字切分
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r)
+}
+
+// isWordRune 判断字符是否属于英文单词或数字
+func isWordRune(r rune) bool {
+	return !isCJK(r) && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// segments 将文本切分为英文单词和连续的中日韩文字片段, 其他字符作为分隔符丢弃
+// 返回的片段已转换为小写
+func segments(text string) [][]rune {
+	var result [][]rune
+	var current []rune
+	currentCJK := false
+	flush := func() {
+		if len(current) > 0 {
+			result = append(result, current)
+			current = nil
+		}
+	}
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			if !currentCJK {
+				flush()
+			}
+			currentCJK = true
+			current = append(current, r)
+		case isWordRune(r):
+			if currentCJK {
+				flush()
+			}
+			currentCJK = false
+			current = append(current, unicode.ToLower(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+	return result
+}
+
+// Tokenize 将文本切分为建立索引使用的词
+// 英文单词和数字按空白和标点切分并转换为小写;
+// 中日韩文字没有词典可用, 切分为相邻两字的二元组, 同时保留单字, 使单字查询也能命中
+func Tokenize(text string) []string {
+	var tokens []string
+	for _, seg := range segments(text) {
+		if !isCJK(seg[0]) {
+			tokens = append(tokens, truncateToken(seg))
+			continue
+		}
+		for i := range seg {
+			tokens = append(tokens, string(seg[i]))
+			if i+1 < len(seg) {
+				tokens = append(tokens, string(seg[i:i+2]))
+			}
+		}
+	}
+	return tokens
+}
+
+// queryTerms 将查询文本切分为去重后的搜索词
+// 中日韩文字片段只使用二元组, 要求文档包含所有二元组可以近似短语匹配, 只有一个字时使用单字
+func queryTerms(text string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	add := func(term string) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	for _, seg := range segments(text) {
+		if !isCJK(seg[0]) {
+			add(truncateToken(seg))
+			continue
+		}
+		if len(seg) == 1 {
+			add(string(seg))
+			continue
+		}
+		for i := 0; i+1 < len(seg); i++ {
+			add(string(seg[i : i+2]))
+		}
+	}
+	return terms
+}
+
+func truncateToken(seg []rune) string {
+	if len(seg) > maxTokenLength {
+		seg = seg[:maxTokenLength]
+	}
+	return string(seg)
+}
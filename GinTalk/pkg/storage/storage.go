@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"GinTalk/settings"
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+var (
+	storage     Storage
+	storageOnce sync.Once
+)
+
+// ErrNotFound 对象不存在
+var ErrNotFound = errors.New("storage: object not found")
+
+// Storage 保存附件文件的接口
+// key 由调用方生成, 只包含字母、数字、下划线、点和斜杠
+type Storage interface {
+	// Put 保存对象, 对象已存在时覆盖
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get 读取对象, 对象不存在时返回 ErrNotFound, 调用方负责关闭返回的 io.ReadCloser
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete 删除对象, 对象不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+}
+
+// GetStorage 获取配置的存储后端
+// 使用单例模式, 第一次调用时根据配置创建, 配置错误时直接退出
+func GetStorage() Storage {
+	storageOnce.Do(func() {
+		cfg := settings.GetConfig().UploadConfig
+		switch cfg.Storage {
+		case DriverLocal:
+			storage = NewLocalStorage(cfg.Local.Dir)
+		case DriverS3:
+			s3, err := NewS3Storage(cfg.S3)
+			if err != nil {
+				zap.L().Fatal("S3 存储配置错误", zap.Error(err))
+			}
+			storage = s3
+		default:
+			zap.L().Fatal("不支持的存储后端", zap.String("storage", cfg.Storage))
+		}
+	})
+	return storage
+}
@@ -0,0 +1,135 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// 与 Google Authenticator 等常见客户端兼容的默认参数
+const (
+	// Digits 验证码位数
+	Digits = 6
+	// Period 验证码的时间步长, 单位秒
+	Period = 30
+	// Skew 允许前后各偏差的时间步数, 用于容忍客户端时钟误差
+	Skew = 1
+	// secretSize 密钥长度, RFC 4226 推荐 160 位
+	secretSize = 20
+)
+
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret 生成一个随机的 base32 编码的密钥
+func GenerateSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return b32.EncodeToString(secret), nil
+}
+
+// URI 生成 otpauth URI, 客户端可以直接导入或将其渲染为二维码扫描
+// 格式参考 https://github.com/google/google-authenticator/wiki/Key-Uri-Format
+func URI(issuer string, account string, secret string) string {
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", issuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", fmt.Sprint(Digits))
+	v.Set("period", fmt.Sprint(Period))
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + v.Encode()
+}
+
+// Step 返回时间 t 所在的时间步
+func Step(t time.Time) int64 {
+	return t.Unix() / Period
+}
+
+// code 按照 RFC 4226 计算某个时间步的验证码
+func code(key []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", Digits, value%1000000)
+}
+
+// Code 计算时间 t 的验证码
+func Code(secret string, t time.Time) (string, error) {
+	key, err := b32.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+	return code(key, Step(t)), nil
+}
+
+// Validate 校验验证码, 允许前后 Skew 个时间步的误差
+//
+// 返回:
+//   - int64: 匹配的时间步, 调用方应记录已使用的时间步以防止验证码在有效期内被重放。
+//   - bool: 验证码是否正确。
+func Validate(secret string, passcode string, t time.Time) (int64, bool) {
+	passcode = strings.TrimSpace(passcode)
+	if len(passcode) != Digits {
+		return 0, false
+	}
+	key, err := b32.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false
+	}
+	current := Step(t)
+	for i := -Skew; i <= Skew; i++ {
+		step := current + int64(i)
+		if subtle.ConstantTimeCompare([]byte(code(key, step)), []byte(passcode)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// recoveryAlphabet 恢复码使用的字符, 去掉了容易混淆的字符
+const recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// GenerateRecoveryCodes 生成 n 个一次性恢复码, 格式为 xxxxx-xxxxx
+// 用户丢失验证器时可以使用恢复码代替验证码登录
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, n)
+	buf := make([]byte, 10)
+	for i := range codes {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		var sb strings.Builder
+		for j, b := range buf {
+			if j == 5 {
+				sb.WriteByte('-')
+			}
+			sb.WriteByte(recoveryAlphabet[int(b)%len(recoveryAlphabet)])
+		}
+		codes[i] = sb.String()
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode 计算恢复码的哈希, 数据库中只保存哈希
+// 恢复码是随机生成的高熵字符串, 不需要加盐和慢哈希
+func HashRecoveryCode(recoveryCode string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(recoveryCode))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
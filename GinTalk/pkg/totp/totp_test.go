@@ -0,0 +1,96 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret RFC 6238 附录 B 中 SHA1 的密钥 "12345678901234567890" 的 base32 编码
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// TestCodeRFC6238 使用 RFC 6238 附录 B 的测试向量, 附录中为 8 位验证码, 这里取后 6 位
+func TestCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatalf("Code() 失败: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("T=%d 时验证码为 %s, 期望 %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestCodeLowercaseSecret(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	upper, _ := Code(rfcSecret, now)
+	lower, err := Code("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", now)
+	if err != nil || lower != upper {
+		t.Errorf("小写密钥的验证码为 %q (%v), 期望 %q", lower, err, upper)
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := Step(now)
+	tests := []struct {
+		offset time.Duration
+		ok     bool
+	}{
+		{0, true},
+		{-Period * time.Second, true},
+		{Period * time.Second, true},
+		{-2 * Period * time.Second, false},
+		{2 * Period * time.Second, false},
+	}
+	for _, tt := range tests {
+		passcode, err := Code(rfcSecret, now.Add(tt.offset))
+		if err != nil {
+			t.Fatalf("Code() 失败: %v", err)
+		}
+		step, ok := Validate(rfcSecret, passcode, now)
+		if ok != tt.ok {
+			t.Errorf("偏差 %v 的验证码校验结果为 %v, 期望 %v", tt.offset, ok, tt.ok)
+			continue
+		}
+		// 返回匹配的时间步, 调用方用它防止重放
+		if want := Step(now.Add(tt.offset)); ok && step != want {
+			t.Errorf("偏差 %v 的验证码匹配的时间步为 %d, 期望 %d (当前 %d)", tt.offset, step, want, current)
+		}
+	}
+}
+
+func TestValidateInvalid(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	tests := []struct {
+		name     string
+		secret   string
+		passcode string
+	}{
+		{"错误的验证码", rfcSecret, "000000"},
+		{"位数不对", rfcSecret, "05924"},
+		{"8 位验证码", rfcSecret, "89005924"},
+		{"空验证码", rfcSecret, ""},
+		{"无效的密钥", "not base32!", "005924"},
+	}
+	for _, tt := range tests {
+		if _, ok := Validate(tt.secret, tt.passcode, now); ok {
+			t.Errorf("%s: Validate(%q, %q) 校验通过", tt.name, tt.secret, tt.passcode)
+		}
+	}
+	// 前后的空白被忽略
+	if _, ok := Validate(rfcSecret, " 005924 ", now); !ok {
+		t.Error("带空白的正确验证码校验失败")
+	}
+}
@@ -3,6 +3,7 @@ package router
 import (
 	"GinTalk/controller"
 	"GinTalk/logger"
+	"GinTalk/pkg/rbac"
 	"GinTalk/settings"
 	"net/http"
 
@@ -37,9 +38,12 @@ func SetupRouter() *gin.Engine {
 	// 注册 Prometheus 中间件, 用于统计接口访问次数
 	r.Use(controller.PrometheusMiddleware())
 
+	// 公开 JWT 验签公钥, 供其他服务验证 GinTalk 签发的 token
+	r.GET("/.well-known/jwks.json", controller.JWKSHandler)
+
 	// 创建 API v1 路由组
 	v1 := r.Group("/api/v1").Use(
-		controller.LimitBodySizeMiddleware(),
+		controller.LimitBodySizeMiddleware(controller.UploadLimitBodySizeOption()),
 		requestid.New(),
 		controller.TimeoutMiddleware(),
 		controller.CorsMiddleware(
@@ -56,24 +60,87 @@ func SetupRouter() *gin.Engine {
 
 	// 用户登录相关路由
 	v1.POST("/login", controller.LoginHandler)
+	v1.POST("/login/2fa", controller.LoginTwoFactorHandler)
 	v1.POST("/signup", controller.SignUpHandler)
+	v1.GET("/signup/mode", controller.GetRegistrationModeHandler)
+	v1.GET("/captcha", controller.CaptchaHandler)
 	v1.POST("/logout", controller.LogoutHandler)
 	v1.GET("/refresh_token", controller.RefreshHandler)
 
+	// 邮箱验证和找回密码相关路由, 通过邮件中的令牌认证
+	v1.POST("/verify-email", controller.VerifyEmailHandler)
+	v1.POST("/password/forgot", controller.ForgotPasswordHandler)
+	v1.POST("/password/reset", controller.ResetPasswordHandler)
+
+	// 附件下载, 帖子和评论中的图片由浏览器直接加载, 不需要认证
+	v1.GET("/attachment/:id/file", controller.GetAttachmentFileHandler)
+	v1.GET("/attachment/:id/thumbnail", controller.GetAttachmentThumbnailHandler)
+
 	v1.Use(controller.JWTAuthMiddleware())
+
+	// 以下路由邮箱未验证时也可以使用
+	v1.POST("/verify-email/resend", controller.ResendVerificationEmailHandler)
+
+	// 修改资料, 邮箱填错时需要在验证之前修改
+	v1.PUT("/user/me", controller.UpdateProfileHandler)
+
+	// 会话管理相关路由
+	v1.GET("/sessions", controller.GetSessionListHandler)
+	v1.DELETE("/sessions", controller.RevokeOtherSessionsHandler)
+	v1.DELETE("/sessions/:id", controller.RevokeSessionHandler)
+
+	// 个人数据导出和注销账号相关路由
+	v1.POST("/account/export", controller.RequestDataExportHandler)
+	v1.GET("/account/export/:id", controller.GetDataExportHandler)
+	v1.GET("/account/export/:id/download", controller.DownloadDataExportHandler)
+	v1.DELETE("/account", controller.DeleteAccountHandler)
+
+	// 个人访问令牌相关路由, 只能使用 JWT 访问
+	v1.GET("/tokens", controller.GetPersonalAccessTokenListHandler)
+	v1.POST("/tokens", controller.CreatePersonalAccessTokenHandler)
+	v1.DELETE("/tokens/:id", controller.RevokePersonalAccessTokenHandler)
+
+	// 两步验证相关路由
+	v1.POST("/2fa/enroll", controller.EnrollTwoFactorHandler)
+	v1.POST("/2fa/confirm", controller.ConfirmTwoFactorHandler)
+	v1.POST("/2fa/disable", controller.DisableTwoFactorHandler)
+
+	// 邮箱未验证的用户只能浏览
+	v1.Use(controller.RequireVerifiedEmailMiddleware())
 	{
+		// 用户相关路由
+		v1.GET("/user/:id", controller.GetUserProfileHandler)
+
 		// 社区相关路由
 		v1.GET("/community", controller.CommunityHandler)
 		v1.GET("/community/:id", controller.CommunityDetailHandler)
+		v1.GET("/community/:id/moderators", controller.GetCommunityModeratorsHandler)
+		v1.POST("/community", controller.RequirePermission(rbac.PermManageCommunity), controller.CreateCommunityHandler)
+		v1.PUT("/community/:id", controller.RequirePermission(rbac.PermManageCommunity), controller.UpdateCommunityHandler)
+		v1.POST("/community/:id/moderators", controller.RequirePermission(rbac.PermManageCommunity), controller.AddCommunityModeratorHandler)
+		v1.DELETE("/community/:id/moderators/:user_id", controller.RequirePermission(rbac.PermManageCommunity), controller.RemoveCommunityModeratorHandler)
 
 		// 帖子相关路由
 		v1.POST("/post", controller.CreatePostHandler)
 		v1.DELETE("/post", controller.DeletePostHandler)
 		v1.GET("/post", controller.GetPostListHandler)
 		v1.GET("/post/community", controller.GetPostListByCommunityID)
+		v1.GET("/post/drafts", controller.GetDraftListHandler)
 		v1.GET("/post/:id", controller.GetPostDetailHandler)
+		v1.GET("/post/:id/revisions", controller.GetPostRevisionsHandler)
+		v1.GET("/post/:id/revisions/diff", controller.DiffPostRevisionsHandler)
+		v1.GET("/post/:id/revisions/:rev", controller.GetPostRevisionHandler)
+		v1.POST("/post/:id/revisions/:rev/restore", controller.RestorePostRevisionHandler)
 		v1.PUT("/post", controller.UpdatePostHandler)
 
+		// 附件相关路由
+		v1.POST("/upload", controller.UploadHandler)
+		v1.GET("/attachment/:id", controller.GetAttachmentHandler)
+		v1.DELETE("/attachment/:id", controller.DeleteAttachmentHandler)
+
+		// 搜索相关路由
+		v1.GET("/search", controller.SearchHandler)
+
 		// 帖子投票相关路由
 		v1.POST("/vote/post", controller.VotePostHandler)
 		v1.DELETE("/vote/post", controller.RevokeVoteHandler)
@@ -97,11 +164,21 @@ func SetupRouter() *gin.Engine {
 
 		// 评论投票相关路由
 		v1.POST("/vote/comment", controller.VoteCommentController)
-		v1.DELETE("/vote/comment", controller.RevokeVoteHandler)
+		v1.DELETE("/vote/comment", controller.RemoveVoteCommentController)
 		v1.GET("/vote/comment", controller.GetVoteCommentController)
 		v1.GET("/vote/comment/list", controller.GetVoteCommentListController)
 
 		v1.GET("/ws", controller.WebsocketHandle)
+
+		// 邀请码相关路由
+		v1.POST("/invites", controller.RequirePermission(rbac.PermCreateInvite), controller.CreateInviteHandler)
+		v1.GET("/invites", controller.RequirePermission(rbac.PermCreateInvite), controller.GetInviteListHandler)
+		v1.DELETE("/invites/:id", controller.RequirePermission(rbac.PermCreateInvite), controller.RevokeInviteHandler)
+
+		// 管理相关路由
+		v1.PUT("/admin/user/:id/role", controller.RequireRole(rbac.RoleAdmin), controller.SetUserRoleHandler)
+		v1.POST("/admin/user/:id/ban", controller.RequirePermission(rbac.PermBanUser), controller.BanUserHandler)
+		v1.DELETE("/admin/user/:id/ban", controller.RequirePermission(rbac.PermBanUser), controller.UnbanUserHandler)
 	}
 
 	// 404 和 405 路由处理
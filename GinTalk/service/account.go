@@ -0,0 +1,298 @@
+package service
+
+import (
+	"GinTalk/cache"
+	"GinTalk/dao"
+	"GinTalk/model"
+	"GinTalk/pkg"
+	"GinTalk/pkg/apiError"
+	"GinTalk/pkg/code"
+	"GinTalk/pkg/jwt"
+	"GinTalk/pkg/mailer"
+	"GinTalk/settings"
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// mailCooldown 同一用户同一类邮件的最小发送间隔
+	mailCooldown = time.Minute
+	// mailSendTimeout 异步发送邮件的超时时间
+	mailSendTimeout = 30 * time.Second
+	// forgotPasswordWindow 统计忘记密码请求次数的时间窗口
+	forgotPasswordWindow = time.Hour
+	// forgotPasswordEmailLimit 同一邮箱在时间窗口内最多请求的次数
+	forgotPasswordEmailLimit = 5
+	// forgotPasswordIPLimit 同一 IP 在时间窗口内最多请求的次数
+	forgotPasswordIPLimit = 20
+)
+
+// actionLink 生成邮件中的链接, 前端页面从 query 中取出 token 后调用对应的接口
+func actionLink(path string, token string) string {
+	return fmt.Sprintf("%s%s?token=%s", settings.GetConfig().MailConfig.LinkBaseURL, path, url.QueryEscape(token))
+}
+
+// sendActionMail 签发一次性操作令牌并通过邮件发送给用户
+// 冷却时间内不会重复发送, 返回 false
+func sendActionMail(ctx context.Context, user *model.User, purpose string, expiration time.Duration, build func(link string) *mailer.Message) (bool, error) {
+	ok, err := cache.AcquireMailCooldown(ctx, purpose, user.UserID, mailCooldown)
+	if err != nil || !ok {
+		return false, err
+	}
+	token, tokenID, err := jwt.GenerateActionToken(user.UserID, purpose, user.Email, expiration)
+	if err != nil {
+		return false, err
+	}
+	if err := cache.SaveActionToken(ctx, purpose, tokenID, user.UserID, expiration); err != nil {
+		return false, err
+	}
+	var path string
+	switch purpose {
+	case jwt.PurposeVerifyEmail:
+		path = "/verify-email"
+	case jwt.PurposeResetPassword:
+		path = "/password/reset"
+	}
+	if err := mailer.GetMailer().Send(ctx, build(actionLink(path, token))); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// sendVerificationEmail 发送邮箱验证邮件
+func sendVerificationEmail(ctx context.Context, user *model.User) (bool, error) {
+	expiration := time.Duration(settings.GetConfig().MailConfig.VerifyTokenExpire) * time.Minute
+	return sendActionMail(ctx, user, jwt.PurposeVerifyEmail, expiration, func(link string) *mailer.Message {
+		return &mailer.Message{
+			To:      user.Email,
+			Subject: "GinTalk 邮箱验证",
+			Body: fmt.Sprintf("%s, 你好:\n\n请点击下面的链接验证你的邮箱, 链接在 %d 分钟内有效:\n\n%s\n\n如果这不是你本人的操作, 请忽略这封邮件。\n",
+				user.Username, int(expiration.Minutes()), link),
+		}
+	})
+}
+
+// sendVerificationEmailAsync 注册成功后异步发送验证邮件, 发送失败不影响注册, 用户可以稍后重新发送
+func sendVerificationEmailAsync(user *model.User) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+		defer cancel()
+		if _, err := sendVerificationEmail(ctx, user); err != nil {
+			zap.L().Error("发送验证邮件失败", zap.Int64("user_id", user.UserID), zap.Error(err))
+		}
+	}()
+}
+
+// ResendVerificationEmailService 重新发送邮箱验证邮件
+func ResendVerificationEmailService(ctx context.Context, userID int64) *apiError.ApiError {
+	user, err := dao.FindUserByID(ctx, userID)
+	if err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "发送验证邮件失败",
+		}
+	}
+	if user.UserID == 0 {
+		return &apiError.ApiError{
+			Code: code.UserNotExist,
+			Msg:  "用户不存在",
+		}
+	}
+	if user.EmailVerified {
+		return &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "邮箱已验证",
+		}
+	}
+
+	sent, err := sendVerificationEmail(ctx, user)
+	if err != nil {
+		zap.L().Error("发送验证邮件失败", zap.Int64("user_id", userID), zap.Error(err))
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "发送验证邮件失败",
+		}
+	}
+	if !sent {
+		return &apiError.ApiError{
+			Code: code.RequestTooFrequent,
+			Msg:  "发送过于频繁, 请稍后再试",
+		}
+	}
+	return nil
+}
+
+// VerifyEmailService 使用邮件中的令牌验证邮箱
+// 令牌只能使用一次, 用户在签发令牌后修改了邮箱时令牌失效
+func VerifyEmailService(ctx context.Context, token string) *apiError.ApiError {
+	claims, err := jwt.ParseActionToken(token, jwt.PurposeVerifyEmail)
+	if err != nil {
+		return &apiError.ApiError{
+			Code: code.ActionTokenInvalid,
+			Msg:  "验证链接无效或已过期",
+		}
+	}
+	valid, err := cache.ConsumeActionToken(ctx, jwt.PurposeVerifyEmail, claims.ID, claims.UserID)
+	if err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "验证邮箱失败",
+		}
+	}
+	if !valid {
+		return &apiError.ApiError{
+			Code: code.ActionTokenInvalid,
+			Msg:  "验证链接无效或已过期",
+		}
+	}
+
+	updated, err := dao.MarkUserEmailVerified(ctx, claims.UserID, claims.Email)
+	if err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "验证邮箱失败",
+		}
+	}
+	if !updated {
+		// 邮箱已经验证过时数据库不会返回受影响的行
+		verified, err := dao.IsUserEmailVerified(ctx, claims.UserID)
+		if err != nil {
+			return &apiError.ApiError{
+				Code: code.ServerError,
+				Msg:  "验证邮箱失败",
+			}
+		}
+		if !verified {
+			return &apiError.ApiError{
+				Code: code.ActionTokenInvalid,
+				Msg:  "验证链接无效或已过期",
+			}
+		}
+	}
+	return nil
+}
+
+// ForgotPasswordService 向使用该邮箱的所有账号发送重置密码邮件
+// 邮件在后台查询和发送, 无论邮箱是否存在、发送是否成功都返回成功, 避免通过响应内容或响应时间探测已注册的邮箱;
+// 同一邮箱或同一 IP 在 forgotPasswordWindow 内的请求次数超过限制时返回 RequestTooFrequent
+func ForgotPasswordService(ctx context.Context, email string, ip string) *apiError.ApiError {
+	email = strings.ToLower(strings.TrimSpace(email))
+	limits := []struct {
+		dimension string
+		value     string
+		limit     int64
+	}{
+		{"email", email, forgotPasswordEmailLimit},
+		{"ip", ip, forgotPasswordIPLimit},
+	}
+	for _, l := range limits {
+		allowed, err := cache.AllowRequest(ctx, jwt.PurposeResetPassword, l.dimension, l.value, l.limit, forgotPasswordWindow)
+		if err != nil {
+			zap.L().Error("cache.AllowRequest() 失败", zap.String(l.dimension, l.value), zap.Error(err))
+			return &apiError.ApiError{
+				Code: code.ServerError,
+				Msg:  "发送重置密码邮件失败",
+			}
+		}
+		if !allowed {
+			return &apiError.ApiError{
+				Code: code.RequestTooFrequent,
+				Msg:  "请求过于频繁, 请稍后再试",
+			}
+		}
+	}
+
+	go sendResetPasswordEmails(email)
+	return nil
+}
+
+// sendResetPasswordEmails 向使用该邮箱的所有账号发送重置密码邮件, 失败只记录日志
+func sendResetPasswordEmails(email string) {
+	ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+	defer cancel()
+	users, err := dao.FindUsersByEmail(ctx, email)
+	if err != nil {
+		zap.L().Error("dao.FindUsersByEmail() 失败", zap.Error(err))
+		return
+	}
+
+	expiration := time.Duration(settings.GetConfig().MailConfig.ResetTokenExpire) * time.Minute
+	for i := range users {
+		user := &users[i]
+		_, err := sendActionMail(ctx, user, jwt.PurposeResetPassword, expiration, func(link string) *mailer.Message {
+			return &mailer.Message{
+				To:      user.Email,
+				Subject: "GinTalk 重置密码",
+				Body: fmt.Sprintf("%s, 你好:\n\n我们收到了重置你的 GinTalk 密码的请求, 请点击下面的链接设置新密码, 链接在 %d 分钟内有效:\n\n%s\n\n如果这不是你本人的操作, 请忽略这封邮件, 你的密码不会被修改。\n",
+					user.Username, int(expiration.Minutes()), link),
+			}
+		})
+		if err != nil {
+			zap.L().Error("发送重置密码邮件失败", zap.Int64("user_id", user.UserID), zap.Error(err))
+		}
+	}
+}
+
+// ResetPasswordService 使用邮件中的令牌重置密码
+// 重置成功后撤销该用户的所有会话和个人访问令牌, 所有设备都需要使用新密码重新登录, 令牌需要重新创建
+func ResetPasswordService(ctx context.Context, token string, password string) *apiError.ApiError {
+	claims, err := jwt.ParseActionToken(token, jwt.PurposeResetPassword)
+	if err != nil {
+		return &apiError.ApiError{
+			Code: code.ActionTokenInvalid,
+			Msg:  "重置链接无效或已过期",
+		}
+	}
+	valid, err := cache.ConsumeActionToken(ctx, jwt.PurposeResetPassword, claims.ID, claims.UserID)
+	if err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "重置密码失败",
+		}
+	}
+	if !valid {
+		return &apiError.ApiError{
+			Code: code.ActionTokenInvalid,
+			Msg:  "重置链接无效或已过期",
+		}
+	}
+
+	hashed, err := pkg.HashPassword(password)
+	if err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "重置密码失败",
+		}
+	}
+	if err := dao.UpdateUserPassword(ctx, claims.UserID, hashed); err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "重置密码失败",
+		}
+	}
+
+	if _, err := cache.RevokeUserSessions(ctx, claims.UserID, ""); err != nil {
+		zap.L().Error("重置密码后撤销会话失败", zap.Int64("user_id", claims.UserID), zap.Error(err))
+	}
+	if _, err := dao.DeleteUserPersonalAccessTokens(ctx, claims.UserID); err != nil {
+		zap.L().Error("重置密码后撤销个人访问令牌失败", zap.Int64("user_id", claims.UserID), zap.Error(err))
+	}
+	return nil
+}
+
+// IsEmailVerifiedService 查询用户的邮箱是否已验证
+func IsEmailVerifiedService(ctx context.Context, userID int64) (bool, *apiError.ApiError) {
+	verified, err := dao.IsUserEmailVerified(ctx, userID)
+	if err != nil {
+		return false, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "查询用户状态失败",
+		}
+	}
+	return verified, nil
+}
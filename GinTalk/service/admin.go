@@ -0,0 +1,141 @@
+package service
+
+import (
+	"GinTalk/cache"
+	"GinTalk/dao"
+	"GinTalk/pkg/apiError"
+	"GinTalk/pkg/code"
+	"GinTalk/pkg/rbac"
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// permanentBanUntil 永久封禁时使用的截止时间 (9999-12-31)
+const permanentBanUntil int64 = 253402300799
+
+// checkManageableUser 操作者的角色必须高于目标用户, 且不能操作自己
+func checkManageableUser(ctx context.Context, operatorID int64, targetID int64) *apiError.ApiError {
+	if operatorID == targetID {
+		return &apiError.ApiError{
+			Code: code.PermissionDenied,
+			Msg:  "不能操作自己",
+		}
+	}
+	operatorRole, err := GetUserRole(ctx, operatorID)
+	if err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "权限校验失败",
+		}
+	}
+	targetRole, err := GetUserRole(ctx, targetID)
+	if err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "权限校验失败",
+		}
+	}
+	if operatorRole.Level() <= targetRole.Level() {
+		return &apiError.ApiError{
+			Code: code.PermissionDenied,
+			Msg:  "不能操作同级或更高级别的用户",
+		}
+	}
+	return nil
+}
+
+// SetUserRoleService 修改用户的全局角色, 只有管理员可以调用
+func SetUserRoleService(ctx context.Context, operatorID int64, targetID int64, role rbac.Role) *apiError.ApiError {
+	user, err := dao.FindUserByID(ctx, targetID)
+	if err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "修改角色失败",
+		}
+	}
+	if user.UserID == 0 {
+		return &apiError.ApiError{
+			Code: code.UserNotExist,
+			Msg:  "用户不存在",
+		}
+	}
+	if apiErr := checkManageableUser(ctx, operatorID, targetID); apiErr != nil {
+		return apiErr
+	}
+
+	if err := dao.SetUserRole(ctx, targetID, string(role)); err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "修改角色失败",
+		}
+	}
+	if err := cache.DeleteUserRole(ctx, targetID); err != nil {
+		zap.L().Error("cache.DeleteUserRole() 失败", zap.Int64("user_id", targetID), zap.Error(err))
+	}
+	zap.L().Info("修改用户角色",
+		zap.Int64("operator_id", operatorID),
+		zap.Int64("user_id", targetID),
+		zap.String("from", user.Role),
+		zap.String("to", string(role)),
+	)
+	return nil
+}
+
+// BanUserService 封禁用户, 并撤销该用户的所有会话
+// duration 为 0 时永久封禁
+func BanUserService(ctx context.Context, operatorID int64, targetID int64, duration time.Duration, reason string) *apiError.ApiError {
+	user, err := dao.FindUserByID(ctx, targetID)
+	if err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "封禁用户失败",
+		}
+	}
+	if user.UserID == 0 {
+		return &apiError.ApiError{
+			Code: code.UserNotExist,
+			Msg:  "用户不存在",
+		}
+	}
+	if apiErr := checkManageableUser(ctx, operatorID, targetID); apiErr != nil {
+		return apiErr
+	}
+
+	bannedUntil := permanentBanUntil
+	if duration > 0 {
+		bannedUntil = time.Now().Add(duration).Unix()
+	}
+	if err := dao.SetUserBannedUntil(ctx, targetID, bannedUntil); err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "封禁用户失败",
+		}
+	}
+	if _, err := cache.RevokeUserSessions(ctx, targetID, ""); err != nil {
+		zap.L().Error("封禁用户后撤销会话失败", zap.Int64("user_id", targetID), zap.Error(err))
+	}
+	zap.L().Info("封禁用户",
+		zap.Int64("operator_id", operatorID),
+		zap.Int64("user_id", targetID),
+		zap.Time("banned_until", time.Unix(bannedUntil, 0)),
+		zap.String("reason", reason),
+	)
+	return nil
+}
+
+// UnbanUserService 解除封禁
+func UnbanUserService(ctx context.Context, operatorID int64, targetID int64) *apiError.ApiError {
+	if apiErr := checkManageableUser(ctx, operatorID, targetID); apiErr != nil {
+		return apiErr
+	}
+	if err := dao.SetUserBannedUntil(ctx, targetID, 0); err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "解除封禁失败",
+		}
+	}
+	zap.L().Info("解除封禁", zap.Int64("operator_id", operatorID), zap.Int64("user_id", targetID))
+	return nil
+}
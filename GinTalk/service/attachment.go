@@ -0,0 +1,460 @@
+package service
+
+import (
+	"GinTalk/DTO"
+	"GinTalk/dao"
+	"GinTalk/model"
+	"GinTalk/pkg/apiError"
+	"GinTalk/pkg/code"
+	"GinTalk/pkg/imaging"
+	"GinTalk/pkg/snowflake"
+	"GinTalk/pkg/storage"
+	"GinTalk/settings"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// MaxAttachmentsPerContent 每个帖子或评论最多引用的附件数量
+	MaxAttachmentsPerContent = 20
+	// maxAttachmentFileNameLength 保存的文件名的最大字符数
+	maxAttachmentFileNameLength = 100
+	// maxConcurrentImageProcessing 同时解码和重新编码的图片数量上限
+	maxConcurrentImageProcessing = 2
+)
+
+// imageProcessing 限制同时处理的图片数量, 解码一张图片最多占用 MaxImagePixels*4 字节内存,
+// 不加限制时并发上传大图会耗尽内存
+var imageProcessing = make(chan struct{}, maxConcurrentImageProcessing)
+
+// 附件的种类, 每个种类有各自的大小上限
+const (
+	attachmentKindImage    = "image"
+	attachmentKindDocument = "document"
+	attachmentKindArchive  = "archive"
+)
+
+// attachmentType 允许上传的文件类型, 根据文件内容识别, 与文件扩展名和请求中的 Content-Type 无关
+type attachmentType struct {
+	kind string
+	ext  string
+}
+
+var attachmentTypes = map[string]attachmentType{
+	"image/jpeg":                {kind: attachmentKindImage, ext: ".jpg"},
+	"image/png":                 {kind: attachmentKindImage, ext: ".png"},
+	"image/gif":                 {kind: attachmentKindImage, ext: ".gif"},
+	"application/pdf":           {kind: attachmentKindDocument, ext: ".pdf"},
+	"text/plain; charset=utf-8": {kind: attachmentKindDocument, ext: ".txt"},
+	"application/zip":           {kind: attachmentKindArchive, ext: ".zip"},
+}
+
+// attachmentMaxSize 返回某种附件的大小上限, 单位字节
+func attachmentMaxSize(kind string) int64 {
+	cfg := settings.GetConfig().UploadConfig
+	switch kind {
+	case attachmentKindImage:
+		return cfg.ImageMaxSize * 1024
+	case attachmentKindDocument:
+		return cfg.DocumentMaxSize * 1024
+	case attachmentKindArchive:
+		return cfg.ArchiveMaxSize * 1024
+	}
+	return 0
+}
+
+// processImage 等待空闲的处理名额后处理图片, 等待期间请求被取消时返回 ctx 的错误
+func processImage(ctx context.Context, data []byte, contentType string) (*imaging.Result, error) {
+	select {
+	case imageProcessing <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-imageProcessing }()
+	cfg := settings.GetConfig().UploadConfig
+	return imaging.Process(data, contentType, cfg.MaxImagePixels, cfg.ThumbnailSize)
+}
+
+// MaxUploadSize 返回所有种类附件中最大的大小上限, 单位字节, 用于限制上传接口的请求体大小
+func MaxUploadSize() int64 {
+	return max(attachmentMaxSize(attachmentKindImage), attachmentMaxSize(attachmentKindDocument), attachmentMaxSize(attachmentKindArchive))
+}
+
+// UploadAttachment 上传附件
+// 文件类型根据内容识别, 图片重新编码以去掉 EXIF 等元数据并生成缩略图;
+// 上传的附件需要在 OrphanExpire 小时内被帖子或评论引用, 否则会被清理
+func UploadAttachment(ctx context.Context, uploaderID int64, fileName string, file io.Reader) (*DTO.Attachment, *apiError.ApiError) {
+	data, err := io.ReadAll(io.LimitReader(file, MaxUploadSize()+1))
+	if err != nil {
+		zap.L().Error("读取上传文件失败", zap.Error(err))
+		return nil, &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "读取上传文件失败",
+		}
+	}
+	if len(data) == 0 {
+		return nil, &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "文件不能为空",
+		}
+	}
+	contentType := http.DetectContentType(data)
+	fileType, ok := attachmentTypes[contentType]
+	if !ok {
+		return nil, &apiError.ApiError{
+			Code: code.UnsupportedFileType,
+			Msg:  "只支持上传 JPEG、PNG、GIF 图片和 PDF、纯文本、ZIP 文件",
+		}
+	}
+	if maxSize := attachmentMaxSize(fileType.kind); int64(len(data)) > maxSize {
+		return nil, &apiError.ApiError{
+			Code: code.FileTooLarge,
+			Msg:  fmt.Sprintf("文件过大, 该类型的文件不能超过 %d KB", maxSize/1024),
+		}
+	}
+
+	id, err := snowflake.GetID()
+	if err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  fmt.Sprintf("生成附件ID失败: %v", err),
+		}
+	}
+	prefix := fmt.Sprintf("attachments/%s/%d", time.Now().Format("2006/01"), id)
+	attachment := &model.Attachment{
+		AttachmentID: id,
+		UploaderID:   uploaderID,
+		FileName:     cleanFileName(fileName, fileType.ext),
+		ContentType:  contentType,
+		StorageKey:   prefix + fileType.ext,
+	}
+
+	var thumbnail []byte
+	var thumbnailType string
+	if fileType.kind == attachmentKindImage {
+		result, err := processImage(ctx, data, contentType)
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, &apiError.ApiError{
+				Code: code.TimeOut,
+				Msg:  "服务繁忙, 请稍后再试",
+			}
+		}
+		if errors.Is(err, imaging.ErrTooLarge) {
+			return nil, &apiError.ApiError{
+				Code: code.FileTooLarge,
+				Msg:  "图片尺寸过大",
+			}
+		}
+		if err != nil {
+			zap.L().Info("图片解码失败", zap.String("content_type", contentType), zap.Error(err))
+			return nil, &apiError.ApiError{
+				Code: code.InvalidParam,
+				Msg:  "图片已损坏或无法识别",
+			}
+		}
+		data = result.Data
+		thumbnail, thumbnailType = result.Thumbnail, result.ThumbnailType
+		attachment.Width, attachment.Height = int32(result.Width), int32(result.Height)
+		attachment.ThumbnailKey = prefix + "_thumb" + thumbnailExt(thumbnailType)
+	}
+	attachment.Size = int64(len(data))
+
+	store := storage.GetStorage()
+	if err := store.Put(ctx, attachment.StorageKey, data, contentType); err != nil {
+		zap.L().Error("保存附件失败", zap.String("key", attachment.StorageKey), zap.Error(err))
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "保存附件失败",
+		}
+	}
+	if thumbnail != nil {
+		if err := store.Put(ctx, attachment.ThumbnailKey, thumbnail, thumbnailType); err != nil {
+			zap.L().Error("保存缩略图失败", zap.String("key", attachment.ThumbnailKey), zap.Error(err))
+			deleteAttachmentFiles(attachment)
+			return nil, &apiError.ApiError{
+				Code: code.ServerError,
+				Msg:  "保存附件失败",
+			}
+		}
+	}
+	if err := dao.CreateAttachment(ctx, attachment); err != nil {
+		zap.L().Error("dao.CreateAttachment() 失败", zap.Int64("attachment_id", id), zap.Error(err))
+		deleteAttachmentFiles(attachment)
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "保存附件失败",
+		}
+	}
+	return attachmentDTO(attachment), nil
+}
+
+// GetAttachment 获取附件信息
+func GetAttachment(ctx context.Context, attachmentID int64) (*DTO.Attachment, *apiError.ApiError) {
+	attachment, apiErr := getAttachment(ctx, attachmentID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return attachmentDTO(attachment), nil
+}
+
+func getAttachment(ctx context.Context, attachmentID int64) (*model.Attachment, *apiError.ApiError) {
+	attachment, err := dao.GetAttachment(ctx, attachmentID)
+	if err != nil {
+		zap.L().Error("dao.GetAttachment() 失败", zap.Int64("attachment_id", attachmentID), zap.Error(err))
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "获取附件失败",
+		}
+	}
+	if attachment == nil {
+		return nil, &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "附件不存在",
+		}
+	}
+	return attachment, nil
+}
+
+// AttachmentFile 附件文件的内容, 调用方负责关闭 Reader
+type AttachmentFile struct {
+	Reader      io.ReadCloser
+	Size        int64
+	ContentType string
+	FileName    string
+}
+
+// OpenAttachmentFile 打开附件或附件缩略图的文件
+// 缩略图的大小未知, Size 为 -1
+func OpenAttachmentFile(ctx context.Context, attachmentID int64, thumbnail bool) (*AttachmentFile, *apiError.ApiError) {
+	attachment, apiErr := getAttachment(ctx, attachmentID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	file := &AttachmentFile{
+		Size:        attachment.Size,
+		ContentType: attachment.ContentType,
+		FileName:    attachment.FileName,
+	}
+	key := attachment.StorageKey
+	if thumbnail {
+		if attachment.ThumbnailKey == "" {
+			return nil, &apiError.ApiError{
+				Code: code.InvalidParam,
+				Msg:  "该附件没有缩略图",
+			}
+		}
+		key = attachment.ThumbnailKey
+		file.Size = -1
+		file.ContentType = thumbnailType(key)
+	}
+	reader, err := storage.GetStorage().Get(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "附件不存在",
+		}
+	}
+	if err != nil {
+		zap.L().Error("读取附件失败", zap.String("key", key), zap.Error(err))
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "读取附件失败",
+		}
+	}
+	file.Reader = reader
+	return file, nil
+}
+
+// DeleteAttachment 删除自己上传的附件, 已被帖子或评论引用的附件需要先从内容中移除
+func DeleteAttachment(ctx context.Context, attachmentID int64, operatorID int64) *apiError.ApiError {
+	attachment, apiErr := getAttachment(ctx, attachmentID)
+	if apiErr != nil {
+		return apiErr
+	}
+	if attachment.UploaderID != operatorID {
+		return &apiError.ApiError{
+			Code: code.PermissionDenied,
+			Msg:  "只能删除自己上传的附件",
+		}
+	}
+	deleted, err := dao.DeleteUnreferencedAttachment(ctx, attachmentID)
+	if err != nil {
+		zap.L().Error("dao.DeleteUnreferencedAttachment() 失败", zap.Int64("attachment_id", attachmentID), zap.Error(err))
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "删除附件失败",
+		}
+	}
+	if !deleted {
+		return &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "附件已被帖子或评论引用, 请先从内容中移除",
+		}
+	}
+	deleteAttachmentFiles(attachment)
+	return nil
+}
+
+// checkAttachmentIDs 检查帖子或评论引用的附件
+// 附件必须是 uploaderID 上传且尚未被引用的, 或者已经被该帖子 (postID) 或评论 (commentID) 引用; 返回去重后的附件 ID
+func checkAttachmentIDs(ctx context.Context, attachmentIDs []int64, uploaderID int64, postID int64, commentID int64) ([]int64, *apiError.ApiError) {
+	if attachmentIDs == nil {
+		return nil, nil
+	}
+	ids := make([]int64, 0, len(attachmentIDs))
+	seen := make(map[int64]bool, len(attachmentIDs))
+	for _, id := range attachmentIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > MaxAttachmentsPerContent {
+		return nil, &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  fmt.Sprintf("最多引用 %d 个附件", MaxAttachmentsPerContent),
+		}
+	}
+	if len(ids) == 0 {
+		return ids, nil
+	}
+	count, err := dao.CountUsableAttachments(ctx, ids, uploaderID, postID, commentID)
+	if err != nil {
+		zap.L().Error("dao.CountUsableAttachments() 失败", zap.Error(err))
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "检查附件失败",
+		}
+	}
+	if count != int64(len(ids)) {
+		return nil, &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "附件不存在或已被其他内容引用",
+		}
+	}
+	return ids, nil
+}
+
+// deleteAttachmentFiles 删除附件在存储后端中的文件, 失败只记录日志
+func deleteAttachmentFiles(attachment *model.Attachment) {
+	ctx := context.Background()
+	for _, key := range []string{attachment.StorageKey, attachment.ThumbnailKey} {
+		if key == "" {
+			continue
+		}
+		if err := storage.GetStorage().Delete(ctx, key); err != nil {
+			zap.L().Error("删除附件文件失败", zap.String("key", key), zap.Error(err))
+		}
+	}
+}
+
+func attachmentDTO(attachment *model.Attachment) *DTO.Attachment {
+	result := &DTO.Attachment{
+		AttachmentID: attachment.AttachmentID,
+		FileName:     attachment.FileName,
+		ContentType:  attachment.ContentType,
+		Size:         attachment.Size,
+		Width:        attachment.Width,
+		Height:       attachment.Height,
+		URL:          fmt.Sprintf("/api/v1/attachment/%d/file", attachment.AttachmentID),
+	}
+	if attachment.ThumbnailKey != "" {
+		result.ThumbnailURL = fmt.Sprintf("/api/v1/attachment/%d/thumbnail", attachment.AttachmentID)
+	}
+	return result
+}
+
+func attachmentDTOs(attachments []model.Attachment) []DTO.Attachment {
+	if len(attachments) == 0 {
+		return nil
+	}
+	result := make([]DTO.Attachment, len(attachments))
+	for i := range attachments {
+		result[i] = *attachmentDTO(&attachments[i])
+	}
+	return result
+}
+
+// fillPostAttachments 为帖子填充引用的附件
+func fillPostAttachments(ctx context.Context, posts ...*DTO.PostDetail) *apiError.ApiError {
+	postIDs := make([]int64, len(posts))
+	for i := range posts {
+		postIDs[i] = posts[i].PostID
+	}
+	attachments, err := dao.GetAttachmentsByPostIDs(ctx, postIDs)
+	if err != nil {
+		zap.L().Error("dao.GetAttachmentsByPostIDs() 失败", zap.Error(err))
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "获取附件失败",
+		}
+	}
+	for i := range posts {
+		posts[i].Attachments = attachmentDTOs(attachments[posts[i].PostID])
+	}
+	return nil
+}
+
+// fillCommentAttachments 为评论填充引用的附件
+func fillCommentAttachments(ctx context.Context, comments ...*DTO.Comment) *apiError.ApiError {
+	commentIDs := make([]int64, len(comments))
+	for i := range comments {
+		commentIDs[i] = comments[i].CommentID
+	}
+	attachments, err := dao.GetAttachmentsByCommentIDs(ctx, commentIDs)
+	if err != nil {
+		zap.L().Error("dao.GetAttachmentsByCommentIDs() 失败", zap.Error(err))
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "获取附件失败",
+		}
+	}
+	for i := range comments {
+		comments[i].Attachments = attachmentDTOs(attachments[comments[i].CommentID])
+	}
+	return nil
+}
+
+// cleanFileName 去掉文件名中的路径和控制字符并限制长度, 扩展名改为与识别出的类型一致
+func cleanFileName(name string, ext string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.TrimSuffix(name, path.Ext(name))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == utf8.RuneError || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		name = "file"
+	}
+	if runes := []rune(name); len(runes) > maxAttachmentFileNameLength {
+		name = string(runes[:maxAttachmentFileNameLength])
+	}
+	return name + ext
+}
+
+func thumbnailExt(contentType string) string {
+	if contentType == "image/jpeg" {
+		return ".jpg"
+	}
+	return ".png"
+}
+
+func thumbnailType(key string) string {
+	if strings.HasSuffix(key, ".jpg") {
+		return "image/jpeg"
+	}
+	return "image/png"
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"GinTalk/cache"
+	"GinTalk/dao"
+	"GinTalk/settings"
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// AttachmentCleanInterval 清理未引用附件的间隔
+	AttachmentCleanInterval = time.Hour
+
+	// attachmentCleanBatchSize 每次最多清理的附件数量, 剩余的附件在下一次清理时删除
+	attachmentCleanBatchSize = 500
+)
+
+// StartAttachmentCleaner 启动清理未引用附件的后台任务
+// 上传后超过 OrphanExpire 小时仍未被帖子或评论引用, 以及从内容中移除的附件会被删除
+func StartAttachmentCleaner() {
+	go func() {
+		for range time.Tick(AttachmentCleanInterval) {
+			cleanOrphanAttachments(context.Background())
+		}
+	}()
+}
+
+// cleanOrphanAttachments 删除过期的未引用附件
+// 多个实例同时运行时, 通过 Redis 锁保证同一时间只有一个实例清理
+func cleanOrphanAttachments(ctx context.Context) {
+	ok, err := cache.AcquireAttachmentCleanLock(ctx, AttachmentCleanInterval/2)
+	if err != nil {
+		zap.L().Error("获取附件清理锁失败", zap.Error(err))
+		return
+	}
+	if !ok {
+		return
+	}
+
+	expire := time.Duration(settings.GetConfig().UploadConfig.OrphanExpire) * time.Hour
+	attachments, err := dao.GetOrphanAttachments(ctx, time.Now().Add(-expire), attachmentCleanBatchSize)
+	if err != nil {
+		zap.L().Error("获取未引用的附件失败", zap.Error(err))
+		return
+	}
+	for i := range attachments {
+		// 先删除记录, 记录删除失败 (例如刚被引用) 时保留文件
+		deleted, err := dao.DeleteUnreferencedAttachment(ctx, attachments[i].AttachmentID)
+		if err != nil {
+			zap.L().Error("dao.DeleteUnreferencedAttachment() 失败", zap.Int64("attachment_id", attachments[i].AttachmentID), zap.Error(err))
+			continue
+		}
+		if deleted {
+			deleteAttachmentFiles(&attachments[i])
+		}
+	}
+	if len(attachments) > 0 {
+		zap.L().Info("清理未引用的附件", zap.Int("count", len(attachments)))
+	}
+}
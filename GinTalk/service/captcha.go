@@ -0,0 +1,96 @@
+package service
+
+import (
+	"GinTalk/DTO"
+	"GinTalk/cache"
+	"GinTalk/pkg/apiError"
+	"GinTalk/pkg/captcha"
+	"GinTalk/pkg/code"
+	"GinTalk/settings"
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// CaptchaEnabled 是否开启验证码
+func CaptchaEnabled() bool {
+	return settings.GetConfig().CaptchaConfig.Enabled
+}
+
+// NewCaptchaService 生成一个新的验证码, 答案保存在 Redis 中, 过期后自动删除
+func NewCaptchaService(ctx context.Context) (*DTO.CaptchaResponseDTO, *apiError.ApiError) {
+	failed := &apiError.ApiError{
+		Code: code.ServerError,
+		Msg:  "生成验证码失败",
+	}
+	img, answer, err := captcha.GetDriver().Generate()
+	if err != nil {
+		zap.L().Error("生成验证码失败", zap.Error(err))
+		return nil, failed
+	}
+	expire := settings.GetConfig().CaptchaConfig.Expire
+	captchaID := uuid.NewString()
+	if err := cache.SaveCaptcha(ctx, captchaID, answer, time.Duration(expire)*time.Second); err != nil {
+		zap.L().Error("cache.SaveCaptcha() 失败", zap.Error(err))
+		return nil, failed
+	}
+	return &DTO.CaptchaResponseDTO{
+		CaptchaID: captchaID,
+		Image:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(img),
+		ExpireIn:  expire,
+	}, nil
+}
+
+// verifyCaptcha 校验验证码, 无论答案是否正确验证码都会失效
+func verifyCaptcha(ctx context.Context, captchaID string, answer string) *apiError.ApiError {
+	if captchaID == "" || answer == "" {
+		return &apiError.ApiError{
+			Code: code.CaptchaRequired,
+			Msg:  "请输入验证码",
+		}
+	}
+	expected, exist, err := cache.TakeCaptcha(ctx, captchaID)
+	if err != nil {
+		zap.L().Error("cache.TakeCaptcha() 失败", zap.Error(err))
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "校验验证码失败",
+		}
+	}
+	if !exist || subtle.ConstantTimeCompare([]byte(expected), []byte(captcha.Normalize(answer))) != 1 {
+		return &apiError.ApiError{
+			Code: code.CaptchaInvalid,
+			Msg:  "验证码错误或已过期, 请重新获取",
+		}
+	}
+	return nil
+}
+
+// checkSignupCaptcha 开启验证码时注册必须通过验证码
+func checkSignupCaptcha(ctx context.Context, captchaID string, answer string) *apiError.ApiError {
+	if !CaptchaEnabled() {
+		return nil
+	}
+	return verifyCaptcha(ctx, captchaID, answer)
+}
+
+// checkLoginCaptcha 同一用户名或 IP 登录失败次数达到 captcha.loginFailures 后, 登录需要通过验证码
+// Redis 出错时不要求验证码, 与登录锁定的处理方式一致
+func checkLoginCaptcha(ctx context.Context, username string, ip string, captchaID string, answer string) *apiError.ApiError {
+	if !CaptchaEnabled() {
+		return nil
+	}
+	failures, err := cache.GetLoginFailures(ctx, loginDimensions(username, ip))
+	if err != nil {
+		zap.L().Error("cache.GetLoginFailures() 失败", zap.Error(err))
+		return nil
+	}
+	if failures < int64(settings.GetConfig().CaptchaConfig.LoginFailures) {
+		return nil
+	}
+	return verifyCaptcha(ctx, captchaID, answer)
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"GinTalk/cache"
+	"GinTalk/dao/Redis"
+	"GinTalk/pkg/code"
+	"GinTalk/pkg/redistest"
+	"GinTalk/settings"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func setupCaptcha(t *testing.T, enabled bool, loginFailures int) *redis.Client {
+	t.Helper()
+	server := redistest.NewServer(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	Redis.SetRedisClient(client)
+	settings.SetConfig(&settings.Settings{
+		CaptchaConfig: &settings.CaptchaConfig{
+			Enabled:       enabled,
+			Driver:        "fixed",
+			Expire:        300,
+			LoginFailures: loginFailures,
+		},
+	})
+	return client
+}
+
+// setLoginFailures 设置用户名维度在当前时间窗口内的登录失败次数
+func setLoginFailures(t *testing.T, client *redis.Client, username string, failures int) {
+	t.Helper()
+	key := cache.GenerateRedisKey(cache.LoginFailureTemplate, cache.LoginDimensionUser, username)
+	if err := client.Set(context.Background(), key, failures, time.Minute).Err(); err != nil {
+		t.Fatalf("设置登录失败次数失败: %v", err)
+	}
+}
+
+func TestCheckLoginCaptcha(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		enabled  bool
+		failures int
+		save     bool
+		answer   string
+		wantCode code.RespCode
+	}{
+		{name: "未开启验证码", enabled: false, failures: 10},
+		{name: "失败次数未达到阈值", enabled: true, failures: 2},
+		{name: "达到阈值且未提交验证码", enabled: true, failures: 3, wantCode: code.CaptchaRequired},
+		{name: "达到阈值且验证码正确", enabled: true, failures: 3, save: true, answer: " abcde "},
+		{name: "达到阈值且验证码错误", enabled: true, failures: 5, save: true, answer: "wrong", wantCode: code.CaptchaInvalid},
+		{name: "达到阈值且验证码不存在", enabled: true, failures: 3, answer: "ABCDE", wantCode: code.CaptchaInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := setupCaptcha(t, tt.enabled, 3)
+			setLoginFailures(t, client, "alice", tt.failures)
+			if tt.save {
+				if err := cache.SaveCaptcha(ctx, "captcha", "ABCDE", time.Minute); err != nil {
+					t.Fatalf("SaveCaptcha() 失败: %v", err)
+				}
+			}
+			captchaID := ""
+			if tt.answer != "" {
+				captchaID = "captcha"
+			}
+
+			err := checkLoginCaptcha(ctx, "Alice", "127.0.0.1", captchaID, tt.answer)
+			if tt.wantCode == 0 {
+				if err != nil {
+					t.Fatalf("checkLoginCaptcha() = %v, 期望 nil", err)
+				}
+				return
+			}
+			if err == nil || err.Code != tt.wantCode {
+				t.Fatalf("checkLoginCaptcha() = %v, 期望错误码 %v", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestVerifyCaptchaSingleUse(t *testing.T) {
+	setupCaptcha(t, true, 3)
+	ctx := context.Background()
+	if err := cache.SaveCaptcha(ctx, "captcha", "ABCDE", time.Minute); err != nil {
+		t.Fatalf("SaveCaptcha() 失败: %v", err)
+	}
+	if err := verifyCaptcha(ctx, "captcha", "abcde"); err != nil {
+		t.Fatalf("第一次 verifyCaptcha() = %v, 期望 nil", err)
+	}
+	if err := verifyCaptcha(ctx, "captcha", "abcde"); err == nil || err.Code != code.CaptchaInvalid {
+		t.Fatalf("第二次 verifyCaptcha() = %v, 期望错误码 %v", err, code.CaptchaInvalid)
+	}
+}
@@ -6,13 +6,24 @@ import (
 	"GinTalk/model"
 	"GinTalk/pkg/apiError"
 	"GinTalk/pkg/code"
+	"GinTalk/pkg/cursor"
+	"GinTalk/pkg/markdown"
+	"GinTalk/pkg/rbac"
 	"GinTalk/pkg/snowflake"
 	"context"
 )
 
 // GetTopComments 获取帖子的顶级评论
-func GetTopComments(ctx context.Context, postID int64, pageSize, pageNum int) ([]DTO.Comment, *apiError.ApiError) {
-	comments, err := dao.GetTopComments(ctx, postID, pageSize, pageNum)
+func GetTopComments(ctx context.Context, postID int64, after string, pageSize int) (*DTO.Page[DTO.Comment], *apiError.ApiError) {
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	position, apiErr := decodeCursor(after)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	// 多取一条评论, 用于判断是否还有下一页
+	comments, err := dao.GetTopComments(ctx, postID, position, pageSize+1)
 	if err != nil {
 		return nil, &apiError.ApiError{
 			Code: code.ServerError,
@@ -20,22 +31,36 @@ func GetTopComments(ctx context.Context, postID int64, pageSize, pageNum int) ([
 		}
 	}
 	resp := make([]DTO.Comment, len(comments))
+	list := make([]*DTO.Comment, len(comments))
 	for i, comment := range comments {
 		resp[i] = DTO.Comment{
-			CommentID:  comment.CommentID,
-			PostID:     comment.PostID,
-			AuthorID:   comment.AuthorID,
-			AuthorName: comment.AuthorName,
-			Content:    comment.Content,
+			CommentID:   comment.CommentID,
+			PostID:      comment.PostID,
+			AuthorID:    comment.AuthorID,
+			AuthorName:  comment.AuthorName,
+			Content:     comment.Content,
+			ContentHTML: commentHTML(&comment),
+			CreateTime:  comment.CreateTime,
 		}
+		list[i] = &resp[i]
 	}
-
-	return resp, nil
+	if apiErr := fillCommentAttachments(ctx, list...); apiErr != nil {
+		return nil, apiErr
+	}
+	return newPage(resp, pageSize, commentPosition), nil
 }
 
 // GetSubComments 获取帖子的子评论
-func GetSubComments(ctx context.Context, postID, parentID int64, pageSize, pageNum int) ([]DTO.Comment, *apiError.ApiError) {
-	comments, err := dao.GetSubComments(ctx, postID, parentID, pageSize, pageNum)
+func GetSubComments(ctx context.Context, postID, parentID int64, after string, pageSize int) (*DTO.Page[DTO.Comment], *apiError.ApiError) {
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	position, apiErr := decodeCursor(after)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	// 多取一条评论, 用于判断是否还有下一页
+	comments, err := dao.GetSubComments(ctx, postID, parentID, position, pageSize+1)
 	if err != nil {
 		return nil, &apiError.ApiError{
 			Code: code.ServerError,
@@ -43,16 +68,28 @@ func GetSubComments(ctx context.Context, postID, parentID int64, pageSize, pageN
 		}
 	}
 	resp := make([]DTO.Comment, len(comments))
+	list := make([]*DTO.Comment, len(comments))
 	for i, comment := range comments {
 		resp[i] = DTO.Comment{
-			CommentID:  comment.CommentID,
-			PostID:     comment.PostID,
-			AuthorID:   comment.AuthorID,
-			AuthorName: comment.AuthorName,
-			Content:    comment.Content,
+			CommentID:   comment.CommentID,
+			PostID:      comment.PostID,
+			AuthorID:    comment.AuthorID,
+			AuthorName:  comment.AuthorName,
+			Content:     comment.Content,
+			ContentHTML: commentHTML(&comment),
+			CreateTime:  comment.CreateTime,
 		}
+		list[i] = &resp[i]
 	}
-	return resp, nil
+	if apiErr := fillCommentAttachments(ctx, list...); apiErr != nil {
+		return nil, apiErr
+	}
+	return newPage(resp, pageSize, commentPosition), nil
+}
+
+// commentPosition 返回评论在列表中的位置, 排序值为评论的创建时间
+func commentPosition(comment *DTO.Comment) cursor.Cursor {
+	return cursor.Cursor{Score: float64(comment.CreateTime.Unix()), ID: comment.CommentID}
 }
 
 // GetCommentByID 获取评论
@@ -65,57 +102,130 @@ func GetCommentByID(ctx context.Context, commentID int64) (*DTO.Comment, *apiErr
 		}
 	}
 	resp := &DTO.Comment{
-		CommentID:  comment.CommentID,
-		PostID:     comment.PostID,
-		AuthorID:   comment.AuthorID,
-		AuthorName: comment.AuthorName,
-		Content:    comment.Content,
+		CommentID:   comment.CommentID,
+		PostID:      comment.PostID,
+		AuthorID:    comment.AuthorID,
+		AuthorName:  comment.AuthorName,
+		Content:     comment.Content,
+		ContentHTML: commentHTML(comment),
+		Version:     comment.Version,
+		CreateTime:  comment.CreateTime,
+	}
+	if resp.CommentID != 0 {
+		if apiErr := fillCommentAttachments(ctx, resp); apiErr != nil {
+			return nil, apiErr
+		}
 	}
 	return resp, nil
 }
 
 // CreateComment 创建评论
 func CreateComment(ctx context.Context, comment *DTO.CreateCommentRequest) *apiError.ApiError {
+	attachmentIDs, apiErr := checkAttachmentIDs(ctx, comment.AttachmentIDs, comment.AuthorID, 0, 0)
+	if apiErr != nil {
+		return apiErr
+	}
 	id, _ := snowflake.GetID()
 	commentModel := &model.Comment{
-		CommentID:  id,
-		PostID:     comment.PostID,
-		AuthorID:   comment.AuthorID,
-		AuthorName: comment.AuthorName,
-		Content:    comment.Content,
-		Status:     1,
-	}
-	err := dao.CreateComment(ctx, commentModel, comment.ReplyID, comment.ParentID)
+		CommentID:   id,
+		PostID:      comment.PostID,
+		AuthorID:    comment.AuthorID,
+		AuthorName:  comment.AuthorName,
+		Content:     comment.Content,
+		ContentHTML: markdown.ToHTML(comment.Content),
+		Status:      1,
+	}
+	err := dao.CreateComment(ctx, commentModel, comment.ReplyID, comment.ParentID, attachmentIDs)
 	if err != nil {
 		return &apiError.ApiError{
 			Code: code.ServerError,
 			Msg:  "创建评论失败",
 		}
 	}
+	// 评论直接写入数据库, 写入后通知所有实例将评论加入搜索索引
+	go syncCommentSearch(context.Background(), id)
 	return nil
 }
 
 // UpdateComment 更新评论
-func UpdateComment(ctx context.Context, comment *DTO.Comment) *apiError.ApiError {
-	err := dao.UpdateComment(ctx, comment.CommentID, comment.Content)
+// ifMatch 为请求 If-Match 中的版本号, 为 nil 时不检查版本; 新引用的附件必须由 editorID 上传
+func UpdateComment(ctx context.Context, comment *DTO.Comment, editorID int64, ifMatch []int64) *apiError.ApiError {
+	current, err := dao.GetCommentByID(ctx, comment.CommentID)
 	if err != nil {
 		return &apiError.ApiError{
 			Code: code.ServerError,
 			Msg:  "更新评论失败",
 		}
 	}
+	if current.CommentID == 0 {
+		return &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "评论不存在",
+		}
+	}
+	expectedVersion, apiErr := matchVersion(ifMatch, current.Version)
+	if apiErr != nil {
+		return apiErr
+	}
+	attachmentIDs, apiErr := checkAttachmentIDs(ctx, comment.AttachmentIDs, editorID, 0, comment.CommentID)
+	if apiErr != nil {
+		return apiErr
+	}
+	updated, err := dao.UpdateComment(ctx, comment.CommentID, comment.Content, markdown.ToHTML(comment.Content), attachmentIDs, editorID, expectedVersion)
+	if err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "更新评论失败",
+		}
+	}
+	if !updated {
+		// 评论在检查版本之后被修改或删除
+		return &apiError.ApiError{
+			Code: code.PreconditionFailed,
+			Msg:  code.PreconditionFailed.GetMsg(),
+		}
+	}
+	go syncCommentSearch(context.Background(), comment.CommentID)
 	return nil
 }
 
 // DeleteComment 删除评论
-func DeleteComment(ctx context.Context, commentID int64) *apiError.ApiError {
-	err := dao.DeleteComment(ctx, commentID)
+// 作者本人, 全局版主或评论所在帖子的社区版主可以删除评论
+func DeleteComment(ctx context.Context, commentID int64, operatorID int64) *apiError.ApiError {
+	comment, err := dao.GetCommentByID(ctx, commentID)
+	if err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "删除评论失败",
+		}
+	}
+	if comment.CommentID == 0 {
+		return &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "评论不存在",
+		}
+	}
+	var communityID int64
+	if comment.AuthorID != operatorID {
+		if _, communityID, err = dao.GetPostOwner(ctx, comment.PostID); err != nil {
+			return &apiError.ApiError{
+				Code: code.ServerError,
+				Msg:  "删除评论失败",
+			}
+		}
+	}
+	if apiErr := checkOwnerOrPermission(ctx, operatorID, comment.AuthorID, rbac.PermDeleteAnyComment, communityID); apiErr != nil {
+		return apiErr
+	}
+
+	err = dao.DeleteComment(ctx, commentID)
 	if err != nil {
 		return &apiError.ApiError{
 			Code: code.ServerError,
 			Msg:  "删除评论失败",
 		}
 	}
+	go syncCommentSearch(context.Background(), commentID)
 	return nil
 }
 
@@ -55,3 +55,72 @@ func GetCommunityDetail(ctx context.Context, communityID int32) (*DTO.CommunityD
 
 	return community, nil
 }
+
+// CreateCommunity 创建社区
+func CreateCommunity(ctx context.Context, dto *DTO.CommunityRequestDTO) *apiError.ApiError {
+	if err := dao.CreateCommunity(ctx, dto.CommunityName, dto.Introduction); err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "创建社区失败",
+		}
+	}
+	return nil
+}
+
+// UpdateCommunity 修改社区的名称和简介
+func UpdateCommunity(ctx context.Context, communityID int64, dto *DTO.CommunityRequestDTO) *apiError.ApiError {
+	if err := dao.UpdateCommunity(ctx, communityID, dto.CommunityName, dto.Introduction); err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "修改社区失败",
+		}
+	}
+	return nil
+}
+
+// GetCommunityModerators 获取社区的版主列表
+func GetCommunityModerators(ctx context.Context, communityID int64) ([]int64, *apiError.ApiError) {
+	userIDs, err := dao.GetCommunityModerators(ctx, communityID)
+	if err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "获取版主列表失败",
+		}
+	}
+	return userIDs, nil
+}
+
+// AddCommunityModerator 任命社区版主
+func AddCommunityModerator(ctx context.Context, communityID int64, userID int64) *apiError.ApiError {
+	user, err := dao.FindUserByID(ctx, userID)
+	if err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "任命版主失败",
+		}
+	}
+	if user.UserID == 0 {
+		return &apiError.ApiError{
+			Code: code.UserNotExist,
+			Msg:  "用户不存在",
+		}
+	}
+	if err := dao.AddCommunityModerator(ctx, communityID, userID); err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "任命版主失败",
+		}
+	}
+	return nil
+}
+
+// RemoveCommunityModerator 撤销社区版主
+func RemoveCommunityModerator(ctx context.Context, communityID int64, userID int64) *apiError.ApiError {
+	if err := dao.RemoveCommunityModerator(ctx, communityID, userID); err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "撤销版主失败",
+		}
+	}
+	return nil
+}
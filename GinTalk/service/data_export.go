@@ -0,0 +1,284 @@
+package service
+
+import (
+	"GinTalk/DTO"
+	"GinTalk/cache"
+	"GinTalk/dao"
+	"GinTalk/pkg/apiError"
+	"GinTalk/pkg/code"
+	"GinTalk/settings"
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// dataExportTimeout 后台生成数据压缩包的超时时间
+	dataExportTimeout = 10 * time.Minute
+
+	// DataExportCleanInterval 清理过期数据压缩包的间隔
+	DataExportCleanInterval = time.Hour
+)
+
+// dataExportPath 数据压缩包在导出目录中的路径
+func dataExportPath(jobID string) string {
+	return filepath.Join(settings.GetConfig().AccountConfig.ExportDir, jobID+".zip")
+}
+
+// RequestDataExportService 申请导出个人数据
+// 用户已有未完成的导出任务时直接返回该任务; 数据量较小时同步生成压缩包, 否则在后台生成,
+// 客户端通过任务状态接口查询进度
+func RequestDataExportService(ctx context.Context, userID int64) (*DTO.DataExportJob, *apiError.ApiError) {
+	failed := &apiError.ApiError{
+		Code: code.ServerError,
+		Msg:  "申请导出数据失败",
+	}
+	previous, err := cache.GetUserDataExportJob(ctx, userID)
+	if err != nil {
+		zap.L().Error("cache.GetUserDataExportJob() 失败", zap.Int64("user_id", userID), zap.Error(err))
+		return nil, failed
+	}
+	if previous != nil {
+		if previous.Status == DTO.DataExportStatusPending || previous.Status == DTO.DataExportStatusRunning {
+			return previous, nil
+		}
+		// 每个用户只保留最近一次导出的压缩包
+		removeDataExport(ctx, previous)
+	}
+
+	rows, err := dao.CountUserDataRows(ctx, userID)
+	if err != nil {
+		zap.L().Error("dao.CountUserDataRows() 失败", zap.Int64("user_id", userID), zap.Error(err))
+		return nil, failed
+	}
+	now := time.Now()
+	job := &DTO.DataExportJob{
+		JobID:      uuid.NewString(),
+		UserID:     userID,
+		Status:     DTO.DataExportStatusPending,
+		CreateTime: now,
+		ExpireTime: now.Add(time.Duration(settings.GetConfig().AccountConfig.ExportExpire) * time.Hour),
+	}
+	if err := cache.SaveDataExportJob(ctx, job); err != nil {
+		zap.L().Error("cache.SaveDataExportJob() 失败", zap.Int64("user_id", userID), zap.Error(err))
+		return nil, failed
+	}
+
+	if rows <= int64(settings.GetConfig().AccountConfig.ExportSyncLimit) {
+		runDataExport(ctx, job)
+		return job, nil
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), dataExportTimeout)
+		defer cancel()
+		runDataExport(ctx, job)
+	}()
+	return job, nil
+}
+
+// runDataExport 执行导出任务并更新任务状态
+func runDataExport(ctx context.Context, job *DTO.DataExportJob) {
+	job.Status = DTO.DataExportStatusRunning
+	if err := cache.SaveDataExportJob(ctx, job); err != nil {
+		zap.L().Error("cache.SaveDataExportJob() 失败", zap.String("job_id", job.JobID), zap.Error(err))
+	}
+
+	err := writeDataExport(ctx, job.UserID, dataExportPath(job.JobID))
+	finishTime := time.Now()
+	job.FinishTime = &finishTime
+	if err != nil {
+		zap.L().Error("导出个人数据失败", zap.Int64("user_id", job.UserID), zap.String("job_id", job.JobID), zap.Error(err))
+		job.Status = DTO.DataExportStatusFailed
+		job.Error = "导出数据失败, 请稍后重试"
+	} else {
+		job.Status = DTO.DataExportStatusDone
+	}
+	if err := cache.SaveDataExportJob(ctx, job); err != nil {
+		zap.L().Error("cache.SaveDataExportJob() 失败", zap.String("job_id", job.JobID), zap.Error(err))
+	}
+}
+
+// writeDataExport 将用户的资料、帖子、评论和投票记录写入 ZIP 压缩包, 每类数据一个 JSON 文件
+// 先写入临时文件, 完成后再重命名, 避免下载到不完整的压缩包
+func writeDataExport(ctx context.Context, userID int64, path string) (err error) {
+	user, err := dao.FindUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	files := []struct {
+		name string
+		load func() (any, error)
+	}{
+		{"profile.json", func() (any, error) {
+			return DTO.DataExportProfile{
+				UserID:        user.UserID,
+				Username:      user.Username,
+				Email:         user.Email,
+				EmailVerified: user.EmailVerified,
+				Gender:        user.Gender,
+				Avatar:        user.Avatar,
+				Bio:           user.Bio,
+				Role:          user.Role,
+				CreateTime:    user.CreateTime,
+			}, nil
+		}},
+		{"posts.json", func() (any, error) { return dao.GetUserPostsForExport(ctx, userID) }},
+		{"post_contents.json", func() (any, error) { return dao.GetUserPostContentsForExport(ctx, userID) }},
+		{"comments.json", func() (any, error) { return dao.GetUserCommentsForExport(ctx, userID) }},
+		{"vote_posts.json", func() (any, error) { return dao.GetUserPostVotesForExport(ctx, userID) }},
+		{"vote_comments.json", func() (any, error) { return dao.GetUserCommentVotesForExport(ctx, userID) }},
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp)
+		}
+	}()
+
+	zw := zip.NewWriter(f)
+	for _, file := range files {
+		data, err := file.load()
+		if err != nil {
+			_ = f.Close()
+			return fmt.Errorf("读取 %s 失败: %w", file.name, err)
+		}
+		w, err := zw.Create(file.name)
+		if err != nil {
+			_ = f.Close()
+			return err
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(data); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// StartDataExportCleaner 启动清理过期数据压缩包的后台任务
+// 导出任务在 Redis 中过期后没有其他地方会删除压缩包, 修改时间超过 ExportExpire 小时的压缩包和未完成的临时文件会被删除
+func StartDataExportCleaner() {
+	go func() {
+		for range time.Tick(DataExportCleanInterval) {
+			cleanExpiredDataExports(context.Background())
+		}
+	}()
+}
+
+// cleanExpiredDataExports 删除导出目录中过期的压缩包
+// 多个实例共享导出目录时, 通过 Redis 锁保证同一时间只有一个实例清理
+func cleanExpiredDataExports(ctx context.Context) {
+	ok, err := cache.AcquireDataExportCleanLock(ctx, DataExportCleanInterval/2)
+	if err != nil {
+		zap.L().Error("获取数据压缩包清理锁失败", zap.Error(err))
+		return
+	}
+	if !ok {
+		return
+	}
+
+	cfg := settings.GetConfig().AccountConfig
+	deadline := time.Now().Add(-time.Duration(cfg.ExportExpire) * time.Hour)
+	entries, err := os.ReadDir(cfg.ExportDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			zap.L().Error("读取导出目录失败", zap.String("dir", cfg.ExportDir), zap.Error(err))
+		}
+		return
+	}
+	removed := 0
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || (filepath.Ext(name) != ".zip" && filepath.Ext(name) != ".tmp") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(deadline) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(cfg.ExportDir, name)); err != nil && !os.IsNotExist(err) {
+			zap.L().Warn("删除过期的数据压缩包失败", zap.String("file", name), zap.Error(err))
+			continue
+		}
+		removed++
+	}
+	if removed > 0 {
+		zap.L().Info("清理过期的数据压缩包", zap.Int("count", removed))
+	}
+}
+
+// removeDataExport 删除导出任务和压缩包
+func removeDataExport(ctx context.Context, job *DTO.DataExportJob) {
+	if err := os.Remove(dataExportPath(job.JobID)); err != nil && !os.IsNotExist(err) {
+		zap.L().Warn("删除数据压缩包失败", zap.String("job_id", job.JobID), zap.Error(err))
+	}
+	if err := cache.DeleteDataExportJob(ctx, job); err != nil {
+		zap.L().Warn("cache.DeleteDataExportJob() 失败", zap.String("job_id", job.JobID), zap.Error(err))
+	}
+}
+
+// GetDataExportJobService 查询数据导出任务的状态, 只能查询自己的任务
+func GetDataExportJobService(ctx context.Context, userID int64, jobID string) (*DTO.DataExportJob, *apiError.ApiError) {
+	job, err := cache.GetDataExportJob(ctx, jobID)
+	if err != nil {
+		zap.L().Error("cache.GetDataExportJob() 失败", zap.String("job_id", jobID), zap.Error(err))
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "查询导出任务失败",
+		}
+	}
+	if job == nil || job.UserID != userID {
+		return nil, &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "导出任务不存在或已过期",
+		}
+	}
+	return job, nil
+}
+
+// GetDataExportFileService 获取已完成的导出任务的压缩包路径
+func GetDataExportFileService(ctx context.Context, userID int64, jobID string) (string, *apiError.ApiError) {
+	job, apiErr := GetDataExportJobService(ctx, userID, jobID)
+	if apiErr != nil {
+		return "", apiErr
+	}
+	if job.Status != DTO.DataExportStatusDone {
+		return "", &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "导出任务尚未完成",
+		}
+	}
+	path := dataExportPath(job.JobID)
+	if _, err := os.Stat(path); err != nil {
+		zap.L().Error("数据压缩包不存在", zap.String("job_id", job.JobID), zap.Error(err))
+		return "", &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "压缩包不存在, 请重新申请导出",
+		}
+	}
+	return path, nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"GinTalk/cache"
+	"GinTalk/dao"
+	"GinTalk/kafka"
+	"GinTalk/pkg"
+	"GinTalk/pkg/apiError"
+	"GinTalk/pkg/code"
+	"GinTalk/settings"
+	"context"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// DeleteContentKeep 注销后保留用户的帖子和评论
+	DeleteContentKeep = "keep"
+	// DeleteContentRemove 注销后删除用户的帖子和评论
+	DeleteContentRemove = "remove"
+
+	// deletedUserName 注销用户的帖子和评论中显示的作者名, 帖子中显示时后面加上用户 ID
+	deletedUserName = "已注销用户"
+)
+
+// DeleteAccountService 注销账号
+// 需要验证密码, 启用两步验证时还需要验证码或恢复码; 注销后所有设备立即下线,
+// 是否删除用户的帖子和评论由配置 account.deleteContent 决定
+func DeleteAccountService(ctx context.Context, userID int64, password string, passcode string) *apiError.ApiError {
+	failed := &apiError.ApiError{
+		Code: code.ServerError,
+		Msg:  "注销账号失败",
+	}
+	user, err := dao.FindUserByID(ctx, userID)
+	if err != nil {
+		return failed
+	}
+	if user.UserID == 0 {
+		return &apiError.ApiError{
+			Code: code.UserNotExist,
+			Msg:  "用户不存在",
+		}
+	}
+	match, _, err := pkg.VerifyPassword(password, user.Password)
+	if err != nil {
+		return failed
+	}
+	if !match {
+		return &apiError.ApiError{
+			Code: code.PasswordError,
+			Msg:  "密码错误",
+		}
+	}
+
+	twoFactor, err := dao.FindUserTwoFactor(ctx, userID)
+	if err != nil {
+		return failed
+	}
+	if twoFactor != nil && twoFactor.Enabled {
+		ok, err := verifyTwoFactorCode(ctx, userID, twoFactor.Secret, passcode)
+		if err != nil {
+			return failed
+		}
+		if !ok {
+			return &apiError.ApiError{
+				Code: code.TwoFactorCodeError,
+				Msg:  "验证码错误",
+			}
+		}
+	}
+
+	removeContent := settings.GetConfig().AccountConfig.DeleteContent == DeleteContentRemove
+	postIDs, deleted, err := dao.DeleteUserAccount(ctx, userID, deletedUserName, removeContent)
+	if err != nil {
+		zap.L().Error("dao.DeleteUserAccount() 失败", zap.Int64("user_id", userID), zap.Error(err))
+		return failed
+	}
+	if !deleted {
+		return &apiError.ApiError{
+			Code: code.UserNotExist,
+			Msg:  "用户不存在",
+		}
+	}
+
+	if _, err := cache.RevokeUserSessions(ctx, userID, ""); err != nil {
+		zap.L().Error("注销账号后撤销会话失败", zap.Int64("user_id", userID), zap.Error(err))
+	}
+	if err := cache.DeleteUserRole(ctx, userID); err != nil {
+		zap.L().Error("cache.DeleteUserRole() 失败", zap.Int64("user_id", userID), zap.Error(err))
+	}
+	for _, postID := range postIDs {
+		if removeContent {
+			err = cache.DeletePost(ctx, postID)
+		} else {
+			// 缓存的帖子摘要中还是原来的用户名, 删除后重新从数据库读取
+			err = cache.DeletePostSummary(ctx, postID)
+		}
+		if err != nil {
+			zap.L().Error("删除 Redis 中的帖子数据失败", zap.Int64("post_id", postID), zap.Error(err))
+		}
+	}
+	if removeContent {
+		// 通知所有实例删除搜索索引中该用户的帖子和评论
+		if err := kafka.SendSearchIndexMessage(ctx, kafka.SearchIndexAuthor, userID); err != nil {
+			zap.L().Error("删除搜索索引中的用户内容失败", zap.Int64("user_id", userID), zap.Error(err))
+		}
+	}
+	if job, err := cache.GetUserDataExportJob(ctx, userID); err == nil && job != nil {
+		removeDataExport(ctx, job)
+	}
+	zap.L().Info("用户注销账号", zap.Int64("user_id", userID), zap.Bool("remove_content", removeContent), zap.Int("posts", len(postIDs)))
+	return nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"GinTalk/DTO"
+	"GinTalk/dao"
+	"GinTalk/model"
+	"GinTalk/pkg/apiError"
+	"GinTalk/pkg/code"
+	"GinTalk/settings"
+	"context"
+	"crypto/rand"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// RegistrationOpen 开放注册
+	RegistrationOpen = "open"
+	// RegistrationInvite 仅限邀请注册, 注册时需要提供邀请码
+	RegistrationInvite = "invite"
+	// RegistrationClosed 关闭注册
+	RegistrationClosed = "closed"
+
+	// inviteCodeLength 邀请码的长度
+	inviteCodeLength = 12
+	// inviteCodeAlphabet 邀请码使用的字符, 去掉了容易混淆的字符
+	inviteCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+// RegistrationMode 当前的注册模式, 未知的配置按关闭注册处理
+func RegistrationMode() string {
+	switch mode := settings.GetConfig().RegistrationConfig.Mode; mode {
+	case RegistrationOpen, RegistrationInvite:
+		return mode
+	default:
+		return RegistrationClosed
+	}
+}
+
+// generateInviteCode 生成随机邀请码
+func generateInviteCode() (string, error) {
+	buf := make([]byte, inviteCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = inviteCodeAlphabet[int(b)%len(inviteCodeAlphabet)]
+	}
+	return string(buf), nil
+}
+
+func toInviteCodeDTO(invite *model.InviteCode) DTO.InviteCode {
+	return DTO.InviteCode{
+		ID:         invite.ID,
+		Code:       invite.Code,
+		MaxUses:    invite.MaxUses,
+		UsedCount:  invite.UsedCount,
+		ExpireTime: time.Unix(invite.ExpireTime, 0),
+		Revoked:    invite.Revoked,
+		CreateTime: invite.CreateTime,
+	}
+}
+
+// CreateInviteService 生成邀请码
+func CreateInviteService(ctx context.Context, creatorID int64, dto *DTO.CreateInviteRequestDTO) (*DTO.InviteCode, *apiError.ApiError) {
+	inviteCode, err := generateInviteCode()
+	if err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "生成邀请码失败",
+		}
+	}
+	now := time.Now()
+	invite := &model.InviteCode{
+		Code:       inviteCode,
+		CreatorID:  creatorID,
+		MaxUses:    dto.MaxUses,
+		ExpireTime: now.AddDate(0, 0, dto.ExpireDays).Unix(),
+		CreateTime: now,
+	}
+	if err := dao.CreateInviteCode(ctx, invite); err != nil {
+		zap.L().Error("dao.CreateInviteCode() 失败", zap.Int64("creator_id", creatorID), zap.Error(err))
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "生成邀请码失败",
+		}
+	}
+	zap.L().Info("生成邀请码", zap.Int64("creator_id", creatorID), zap.Int64("invite_id", invite.ID), zap.Int32("max_uses", invite.MaxUses))
+	result := toInviteCodeDTO(invite)
+	return &result, nil
+}
+
+// GetInviteListService 获取用户生成的所有邀请码
+func GetInviteListService(ctx context.Context, creatorID int64) ([]DTO.InviteCode, *apiError.ApiError) {
+	invites, err := dao.GetInviteCodesByCreator(ctx, creatorID)
+	if err != nil {
+		zap.L().Error("dao.GetInviteCodesByCreator() 失败", zap.Int64("creator_id", creatorID), zap.Error(err))
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "获取邀请码列表失败",
+		}
+	}
+	list := make([]DTO.InviteCode, 0, len(invites))
+	for i := range invites {
+		list = append(list, toInviteCodeDTO(&invites[i]))
+	}
+	return list, nil
+}
+
+// RevokeInviteService 作废自己生成的邀请码
+func RevokeInviteService(ctx context.Context, creatorID int64, id int64) *apiError.ApiError {
+	revoked, err := dao.RevokeInviteCode(ctx, creatorID, id)
+	if err != nil {
+		zap.L().Error("dao.RevokeInviteCode() 失败", zap.Int64("invite_id", id), zap.Error(err))
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "作废邀请码失败",
+		}
+	}
+	if !revoked {
+		return &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "邀请码不存在或已作废",
+		}
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"GinTalk/DTO"
 	"GinTalk/cache"
 	"GinTalk/dao"
+	"GinTalk/metrics"
 	"GinTalk/model"
 	"GinTalk/pkg"
 	"GinTalk/pkg/apiError"
@@ -14,10 +15,14 @@ import (
 	"time"
 
 	"github.com/jinzhu/copier"
+	"go.uber.org/zap"
 )
 
 // LoginService 登录服务
 // 登录服务，根据用户名和密码查询用户，如果用户存在且密码正确，则生成token返回
+// 用户启用了两步验证时不返回token, 而是返回一个短期有效的登录挑战令牌
+// 同一用户名或同一 IP 登录失败次数过多时暂时锁定, 返回 code.LoginLocked
+// 开启验证码时, 失败次数达到 captcha.loginFailures 后需要同时提交验证码
 //
 // 参数
 //   - ctx: 上下文
@@ -37,6 +42,13 @@ import (
 //	}
 //	ResponseSuccess(c, resp)
 func LoginService(ctx context.Context, dto *DTO.LoginRequestDTO) (*DTO.LoginResponseDTO, *apiError.ApiError) {
+	if apiErr := checkLoginLockout(ctx, dto.Username, dto.IP); apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := checkLoginCaptcha(ctx, dto.Username, dto.IP, dto.CaptchaID, dto.CaptchaAnswer); apiErr != nil {
+		return nil, apiErr
+	}
+
 	user, err := dao.FindUserByUsername(ctx, dto.Username)
 	if err != nil {
 		return nil, &apiError.ApiError{
@@ -45,18 +57,69 @@ func LoginService(ctx context.Context, dto *DTO.LoginRequestDTO) (*DTO.LoginResp
 		}
 	}
 	if user == nil {
+		if lockout := recordLoginFailure(ctx, dto.Username, dto.IP); lockout > 0 {
+			return nil, loginLockedError(lockout)
+		}
 		return nil, &apiError.ApiError{
 			Code: code.UserNotExist,
 			Msg:  "用户不存在",
 		}
 	}
-	if pkg.EncryptPassword(dto.Password) != user.Password {
+	match, needsRehash, err := pkg.VerifyPassword(dto.Password, user.Password)
+	if err != nil {
+		zap.L().Error("解析密码哈希失败", zap.Int64("user_id", user.UserID), zap.Error(err))
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "登录失败",
+		}
+	}
+	if !match {
+		if lockout := recordLoginFailure(ctx, dto.Username, dto.IP); lockout > 0 {
+			return nil, loginLockedError(lockout)
+		}
 		return nil, &apiError.ApiError{
 			Code: code.PasswordError,
 			Msg:  "密码错误",
 		}
 	}
-	accessToken, refreshToken, err := jwt.GenerateToken(user.UserID, user.Username)
+	// 旧格式或参数过期的密码哈希, 在登录成功后升级为当前格式
+	if needsRehash {
+		rehashPassword(ctx, user.UserID, dto.Password)
+	}
+
+	// 启用了两步验证时只返回登录挑战, 验证码校验通过后才签发令牌
+	twoFactor, err := dao.FindUserTwoFactor(ctx, user.UserID)
+	if err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "登录失败",
+		}
+	}
+	// 启用了两步验证时, 失败次数在第二步验证通过后才清除
+	if twoFactor != nil && twoFactor.Enabled {
+		return issueLoginChallenge(ctx, user)
+	}
+	resetLoginFailures(ctx, dto.Username)
+
+	return issueLoginTokens(ctx, user, &DTO.Session{
+		Device:    dto.Device,
+		IP:        dto.IP,
+		UserAgent: dto.UserAgent,
+	})
+}
+
+// issueLoginTokens 为通过认证的用户创建新的会话并签发令牌
+// session 中只需要填写设备信息, 会话 ID 和用户 ID 在这里生成
+// 被封禁的用户在封禁结束之前不能登录
+func issueLoginTokens(ctx context.Context, user *model.User, session *DTO.Session) (*DTO.LoginResponseDTO, *apiError.ApiError) {
+	if user.BannedUntil > time.Now().Unix() {
+		return nil, &apiError.ApiError{
+			Code: code.UserBanned,
+			Msg:  "账号已被封禁, 解封时间: " + time.Unix(user.BannedUntil, 0).Format(time.DateTime),
+		}
+	}
+
+	pair, err := jwt.GenerateToken(user.UserID, user.Username, "")
 	if err != nil {
 		return nil, &apiError.ApiError{
 			Code: code.ServerError,
@@ -64,19 +127,48 @@ func LoginService(ctx context.Context, dto *DTO.LoginRequestDTO) (*DTO.LoginResp
 		}
 	}
 
+	// 每次登录创建一个新的会话, 会话 ID 即刷新令牌家族 ID
+	session.SessionID = pair.FamilyID
+	session.UserID = user.UserID
+	err = cache.CreateSession(ctx, session, pair.RefreshTokenID, jwt.RefreshTokenTTL())
+	if err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "登录失败",
+		}
+	}
+
 	return &DTO.LoginResponseDTO{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		UserID:       user.UserID,
-		Username:     user.Username,
+		AccessToken:   pair.AccessToken,
+		RefreshToken:  pair.RefreshToken,
+		UserID:        user.UserID,
+		Username:      user.Username,
+		EmailVerified: user.EmailVerified,
 	}, nil
 }
 
+// rehashPassword 使用当前的哈希算法重新哈希密码并写回数据库
+// 升级失败不影响本次登录, 下次登录时会再次尝试
+func rehashPassword(ctx context.Context, userID int64, password string) {
+	hashed, err := pkg.HashPassword(password)
+	if err != nil {
+		zap.L().Error("重新哈希密码失败", zap.Int64("user_id", userID), zap.Error(err))
+		return
+	}
+	if err := dao.UpdateUserPassword(ctx, userID, hashed); err != nil {
+		zap.L().Error("更新密码哈希失败", zap.Int64("user_id", userID), zap.Error(err))
+		return
+	}
+	zap.L().Info("密码哈希已升级", zap.Int64("user_id", userID))
+}
+
 // SignupService 注册服务
 // SignupService 处理用户注册过程。
 // 它接受一个上下文和一个 SignUpRequestDTO 作为输入，加密密码，
-// 将 DTO 复制到 User 模型，生成唯一的用户 ID，并在数据库中创建用户。
+// 将 DTO 复制到 User 模型，生成唯一的用户 ID，并在数据库中创建用户，然后向注册邮箱发送验证邮件。
 // 如果任何步骤失败，它将返回一个包含适当错误代码和消息的 ApiError。
+// 关闭注册时直接拒绝; 仅限邀请注册时需要有效的邀请码, 用户记录中保存使用的邀请码。
+// 开启验证码时必须先通过验证码。
 //
 // 参数:
 //   - ctx: 用于管理请求范围值、取消和截止日期的上下文。
@@ -95,18 +187,34 @@ func LoginService(ctx context.Context, dto *DTO.LoginRequestDTO) (*DTO.LoginResp
 //	}
 //	ResponseSuccess(c, nil)
 func SignupService(ctx context.Context, dto *DTO.SignUpRequestDTO) *apiError.ApiError {
-	dto.Password = pkg.EncryptPassword(dto.Password)
-	var user model.User
+	mode := RegistrationMode()
+	switch {
+	case mode == RegistrationClosed:
+		return &apiError.ApiError{
+			Code: code.RegistrationClosed,
+			Msg:  "暂不开放注册",
+		}
+	case mode == RegistrationInvite && dto.InviteCode == "":
+		return &apiError.ApiError{
+			Code: code.InviteCodeInvalid,
+			Msg:  "需要邀请码才能注册",
+		}
+	}
+	if apiErr := checkSignupCaptcha(ctx, dto.CaptchaID, dto.CaptchaAnswer); apiErr != nil {
+		return apiErr
+	}
 
-	err := copier.Copy(&user, dto)
+	hashed, err := pkg.HashPassword(dto.Password)
 	if err != nil {
 		return &apiError.ApiError{
 			Code: code.ServerError,
 			Msg:  "注册失败",
 		}
 	}
+	dto.Password = hashed
+	var user model.User
 
-	user.UserID, err = snowflake.GetID()
+	err = copier.Copy(&user, dto)
 	if err != nil {
 		return &apiError.ApiError{
 			Code: code.ServerError,
@@ -114,13 +222,41 @@ func SignupService(ctx context.Context, dto *DTO.SignUpRequestDTO) *apiError.Api
 		}
 	}
 
-	err = dao.CreateUser(ctx, &user)
+	user.UserID, err = snowflake.GetID()
 	if err != nil {
 		return &apiError.ApiError{
 			Code: code.ServerError,
 			Msg:  "注册失败",
 		}
 	}
+
+	if mode == RegistrationInvite {
+		valid, err := dao.CreateUserWithInvite(ctx, &user, dto.InviteCode)
+		if err != nil {
+			return &apiError.ApiError{
+				Code: code.ServerError,
+				Msg:  "注册失败",
+			}
+		}
+		if !valid {
+			return &apiError.ApiError{
+				Code: code.InviteCodeInvalid,
+				Msg:  "邀请码无效或已过期",
+			}
+		}
+		zap.L().Info("使用邀请码注册", zap.Int64("user_id", user.UserID), zap.Int64("invite_id", user.InviteCodeID))
+	} else {
+		err = dao.CreateUser(ctx, &user)
+		if err != nil {
+			return &apiError.ApiError{
+				Code: code.ServerError,
+				Msg:  "注册失败",
+			}
+		}
+	}
+
+	// 邮箱验证之前账号只能浏览, 不能发帖、评论和投票
+	sendVerificationEmailAsync(&user)
 	return nil
 }
 
@@ -128,6 +264,10 @@ func SignupService(ctx context.Context, dto *DTO.SignUpRequestDTO) *apiError.Api
 // RefreshTokenService 从传入的 token 中解析出用户 ID 和用户名，然后生成新的访问令牌和刷新令牌。
 // 如果解析失败，它将返回一个包含错误代码和消息的 ApiError。
 //
+// 每个刷新令牌只能使用一次, 使用后由同一家族中的新令牌替代。
+// 如果一个已经被轮换过的刷新令牌再次被使用, 说明令牌可能已经泄露,
+// 此时撤销整个令牌家族, 该次登录签发的所有令牌都将失效, 并记录安全事件。
+//
 // 参数:
 //   - ctx: 用于管理请求范围值、取消和截止日期的上下文。
 //   - token: 包含用户 ID 和用户名的 token 字符串。
@@ -145,12 +285,6 @@ func SignupService(ctx context.Context, dto *DTO.SignUpRequestDTO) *apiError.Api
 //	  zap.L().Error("AuthServiceInterface.RefreshTokenService() 失败", zap.Error(apiError))
 //	  return
 //	}
-//	go func() {
-//	  err := service.LogoutService(context.Background(), oldRefreshToken)
-//	  if err != nil {
-//	    zap.L().Error("refresh token logout failed", zap.Error(err))
-//	  }
-//	}()
 //	ResponseSuccess(c, gin.H{
 //	  "access_token":  accessToken,
 //	  "refresh_token": refreshToken,
@@ -171,7 +305,15 @@ func RefreshTokenService(ctx context.Context, token string) (string, string, *ap
 		}
 	}
 
-	accessToken, refreshToken, err := jwt.GenerateToken(myClaims.UserID, myClaims.Username)
+	// 旧版本签发的刷新令牌不属于任何家族, 需要重新登录
+	if myClaims.FamilyID == "" || myClaims.ID == "" {
+		return "", "", &apiError.ApiError{
+			Code: code.UserRefreshTokenError,
+			Msg:  "token已失效, 请重新登录",
+		}
+	}
+
+	pair, err := jwt.GenerateToken(myClaims.UserID, myClaims.Username, myClaims.FamilyID)
 	if err != nil {
 		return "", "", &apiError.ApiError{
 			Code: code.ServerError,
@@ -179,8 +321,7 @@ func RefreshTokenService(ctx context.Context, token string) (string, string, *ap
 		}
 	}
 
-	err = cache.AddTokenToBlacklist(ctx, token, time.Until(myClaims.ExpiresAt.Time))
-
+	result, err := cache.RotateRefreshToken(ctx, myClaims.FamilyID, myClaims.ID, pair.RefreshTokenID, jwt.RefreshTokenTTL())
 	if err != nil {
 		return "", "", &apiError.ApiError{
 			Code: code.ServerError,
@@ -188,12 +329,27 @@ func RefreshTokenService(ctx context.Context, token string) (string, string, *ap
 		}
 	}
 
-	return accessToken, refreshToken, nil
-
+	switch result {
+	case cache.RefreshTokenRotated:
+		return pair.AccessToken, pair.RefreshToken, nil
+	case cache.RefreshTokenReused:
+		metrics.SecurityEvents.AddCounter(metrics.SecurityEventRefreshTokenReuse)
+		zap.L().Warn("检测到刷新令牌被重复使用, 已撤销整个令牌家族",
+			zap.String("event", metrics.SecurityEventRefreshTokenReuse),
+			zap.Int64("user_id", myClaims.UserID),
+			zap.String("family_id", myClaims.FamilyID),
+			zap.String("token_id", myClaims.ID),
+		)
+	}
+	return "", "", &apiError.ApiError{
+		Code: code.UserRefreshTokenError,
+		Msg:  "token已失效, 请重新登录",
+	}
 }
 
 // LogoutService 退出登录
 // LogoutService 将传入的 token 添加到黑名单中，以便用户无法再使用它。
+// 同时撤销 token 所属的会话, 该次登录轮换出的其他令牌也将失效。
 // 如果添加失败，它将返回一个包含错误代码和消息的 ApiError。
 //
 // 参数:
@@ -228,6 +384,15 @@ func LogoutService(ctx context.Context, token ...string) *apiError.ApiError {
 				Msg:  "登出失败",
 			}
 		}
+
+		if myClaims.FamilyID != "" {
+			if err := cache.RevokeSession(ctx, myClaims.FamilyID); err != nil {
+				return &apiError.ApiError{
+					Code: code.ServerError,
+					Msg:  "登出失败",
+				}
+			}
+		}
 	}
 
 	return nil
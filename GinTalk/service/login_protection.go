@@ -0,0 +1,103 @@
+package service
+
+import (
+	"GinTalk/cache"
+	"GinTalk/metrics"
+	"GinTalk/pkg/apiError"
+	"GinTalk/pkg/code"
+	"GinTalk/settings"
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// loginFailurePolicies 根据配置生成各个维度的锁定策略
+func loginFailurePolicies() map[string]cache.LoginFailurePolicy {
+	cfg := settings.GetConfig().LoginProtectionConfig
+	window := time.Duration(cfg.FailureWindow) * time.Minute
+	base := time.Duration(cfg.BaseLockout) * time.Second
+	maxLockout := time.Duration(cfg.MaxLockout) * time.Second
+	return map[string]cache.LoginFailurePolicy{
+		cache.LoginDimensionUser: {Window: window, Threshold: cfg.UserThreshold, BaseLockout: base, MaxLockout: maxLockout},
+		cache.LoginDimensionIP:   {Window: window, Threshold: cfg.IPThreshold, BaseLockout: base, MaxLockout: maxLockout},
+	}
+}
+
+// loginDimensions 登录请求在各个维度上的值, 用户名不区分大小写
+func loginDimensions(username string, ip string) map[string]string {
+	return map[string]string{
+		cache.LoginDimensionUser: strings.ToLower(username),
+		cache.LoginDimensionIP:   ip,
+	}
+}
+
+// loginLockedError 锁定期间返回的错误, 携带需要等待的时间
+func loginLockedError(lockout time.Duration) *apiError.ApiError {
+	seconds := int64(math.Ceil(lockout.Seconds()))
+	return &apiError.ApiError{
+		Code:       code.LoginLocked,
+		Msg:        fmt.Sprintf("登录失败次数过多, 请 %d 秒后再试", seconds),
+		RetryAfter: time.Duration(seconds) * time.Second,
+	}
+}
+
+// checkLoginLockout 检查用户名或 IP 是否处于锁定期间
+// Redis 出错时不阻止登录, 避免 Redis 故障导致所有用户无法登录
+func checkLoginLockout(ctx context.Context, username string, ip string) *apiError.ApiError {
+	lockout, err := cache.GetLoginLockout(ctx, loginDimensions(username, ip))
+	if err != nil {
+		zap.L().Error("cache.GetLoginLockout() 失败", zap.Error(err))
+		return nil
+	}
+	if lockout > 0 {
+		return loginLockedError(lockout)
+	}
+	return nil
+}
+
+// recordLoginFailure 记录一次登录失败, 达到阈值时锁定并记录安全事件
+// 返回本次失败导致的最长锁定时间, 未锁定时为 0
+func recordLoginFailure(ctx context.Context, username string, ip string) time.Duration {
+	policies := loginFailurePolicies()
+	var lockout time.Duration
+	for dimension, value := range loginDimensions(username, ip) {
+		if value == "" {
+			continue
+		}
+		d, err := cache.RecordLoginFailure(ctx, dimension, value, policies[dimension])
+		if err != nil {
+			zap.L().Error("cache.RecordLoginFailure() 失败", zap.String("dimension", dimension), zap.Error(err))
+			continue
+		}
+		if d <= 0 {
+			continue
+		}
+
+		event := metrics.SecurityEventLoginLockoutUser
+		if dimension == cache.LoginDimensionIP {
+			event = metrics.SecurityEventLoginLockoutIP
+		}
+		metrics.SecurityEvents.AddCounter(event)
+		zap.L().Warn("登录失败次数过多, 已暂时锁定",
+			zap.String("event", event),
+			zap.String("username", username),
+			zap.String("ip", ip),
+			zap.Duration("lockout", d),
+		)
+		lockout = max(lockout, d)
+	}
+	return lockout
+}
+
+// resetLoginFailures 登录成功后清除该用户名的失败次数
+// IP 的失败次数不清除, 否则攻击者可以用自己的账号登录来重置同一 IP 上的计数
+func resetLoginFailures(ctx context.Context, username string) {
+	err := cache.ResetLoginFailures(ctx, cache.LoginDimensionUser, strings.ToLower(username))
+	if err != nil {
+		zap.L().Error("cache.ResetLoginFailures() 失败", zap.Error(err))
+	}
+}
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"GinTalk/DTO"
+	"GinTalk/model"
+	"GinTalk/pkg/markdown"
+)
+
+// fillPostHTML 为没有渲染结果的帖子渲染 HTML
+// 增加 content_html 字段之前保存的帖子没有渲染结果, 读取时再渲染
+func fillPostHTML(post *DTO.PostDetail) {
+	if post.ContentHTML == "" && post.Content != "" {
+		post.ContentHTML = markdown.ToHTML(post.Content)
+	}
+}
+
+// commentHTML 返回评论渲染后的 HTML, 旧评论没有渲染结果时在读取时渲染
+func commentHTML(comment *model.Comment) string {
+	if comment.ContentHTML == "" && comment.Content != "" {
+		return markdown.ToHTML(comment.Content)
+	}
+	return comment.ContentHTML
+}
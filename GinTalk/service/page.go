@@ -0,0 +1,35 @@
+package service
+
+import (
+	"GinTalk/DTO"
+	"GinTalk/pkg/apiError"
+	"GinTalk/pkg/code"
+	"GinTalk/pkg/cursor"
+)
+
+// decodeCursor 解析客户端传入的游标, 空字符串表示第一页
+func decodeCursor(token string) (*cursor.Cursor, *apiError.ApiError) {
+	after, err := cursor.Decode(token)
+	if err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "cursor 参数错误",
+		}
+	}
+	return after, nil
+}
+
+// newPage 生成一页数据, list 为多取一条的查询结果
+// 多取的那条存在时说明还有下一页, 下一页的游标为本页最后一条记录的位置
+func newPage[T any](list []T, pageSize int, position func(*T) cursor.Cursor) *DTO.Page[T] {
+	page := &DTO.Page[T]{List: list}
+	if len(list) > pageSize {
+		page.List = list[:pageSize]
+		page.HasMore = true
+		page.NextCursor = position(&page.List[pageSize-1]).Encode()
+	}
+	if page.List == nil {
+		page.List = []T{}
+	}
+	return page
+}
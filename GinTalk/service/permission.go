@@ -0,0 +1,82 @@
+package service
+
+import (
+	"GinTalk/cache"
+	"GinTalk/dao"
+	"GinTalk/pkg/apiError"
+	"GinTalk/pkg/code"
+	"GinTalk/pkg/rbac"
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// userRoleCacheExpire 用户角色的缓存时间, 修改角色时会主动删除缓存
+const userRoleCacheExpire = 10 * time.Minute
+
+// GetUserRole 获取用户的全局角色, 优先从缓存中读取
+// 数据库中的未知角色按普通用户处理
+func GetUserRole(ctx context.Context, userID int64) (rbac.Role, error) {
+	role, hit, err := cache.GetUserRole(ctx, userID)
+	if err != nil {
+		zap.L().Error("cache.GetUserRole() 失败", zap.Int64("user_id", userID), zap.Error(err))
+	}
+	if !hit {
+		if role, err = dao.GetUserRole(ctx, userID); err != nil {
+			return rbac.RoleUser, err
+		}
+		if err := cache.SetUserRole(ctx, userID, role, userRoleCacheExpire); err != nil {
+			zap.L().Error("cache.SetUserRole() 失败", zap.Int64("user_id", userID), zap.Error(err))
+		}
+	}
+	r, ok := rbac.ParseRole(role)
+	if !ok {
+		return rbac.RoleUser, nil
+	}
+	return r, nil
+}
+
+// HasPermission 判断用户是否拥有某个权限
+// communityID 大于 0 时, 该社区的版主也拥有社区范围内的权限
+func HasPermission(ctx context.Context, userID int64, perm rbac.Permission, communityID int64) (bool, error) {
+	role, err := GetUserRole(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if role.Has(perm) {
+		return true, nil
+	}
+	if communityID > 0 && rbac.CommunityModeratorHas(perm) {
+		return dao.IsCommunityModerator(ctx, communityID, userID)
+	}
+	return false, nil
+}
+
+// checkOwnerOrPermission 资源的作者本人, 或者拥有 perm 权限的用户才能操作
+func checkOwnerOrPermission(ctx context.Context, operatorID int64, ownerID int64, perm rbac.Permission, communityID int64) *apiError.ApiError {
+	if operatorID == ownerID {
+		return nil
+	}
+	ok, err := HasPermission(ctx, operatorID, perm, communityID)
+	if err != nil {
+		zap.L().Error("HasPermission() 失败", zap.Int64("user_id", operatorID), zap.Error(err))
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "权限校验失败",
+		}
+	}
+	if !ok {
+		return &apiError.ApiError{
+			Code: code.PermissionDenied,
+			Msg:  "无权限操作",
+		}
+	}
+	zap.L().Info("管理员操作他人的内容",
+		zap.Int64("operator_id", operatorID),
+		zap.Int64("owner_id", ownerID),
+		zap.String("permission", string(perm)),
+		zap.Int64("community_id", communityID),
+	)
+	return nil
+}
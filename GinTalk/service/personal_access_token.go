@@ -0,0 +1,182 @@
+package service
+
+import (
+	"GinTalk/DTO"
+	"GinTalk/dao"
+	"GinTalk/model"
+	"GinTalk/pkg/apiError"
+	"GinTalk/pkg/code"
+	"GinTalk/pkg/pat"
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// maxPersonalAccessTokens 每个用户最多可以创建的个人访问令牌数量
+	maxPersonalAccessTokens = 50
+	// personalAccessTokenTouchInterval 最后使用时间的更新间隔, 避免每次请求都写数据库
+	personalAccessTokenTouchInterval = time.Minute
+)
+
+// PersonalAccessTokenIdentity 个人访问令牌认证通过后得到的身份
+type PersonalAccessTokenIdentity struct {
+	UserID   int64
+	Username string
+	Scopes   []pat.Scope
+}
+
+// toPersonalAccessTokenDTO 转换为返回给用户的令牌信息, 不包含令牌明文和哈希
+func toPersonalAccessTokenDTO(token *model.PersonalAccessToken) DTO.PersonalAccessToken {
+	scopes := pat.SplitScopes(token.Scopes)
+	dto := DTO.PersonalAccessToken{
+		ID:         token.ID,
+		Name:       token.Name,
+		Prefix:     token.TokenPrefix,
+		Scopes:     make([]string, 0, len(scopes)),
+		CreateTime: token.CreateTime,
+	}
+	for _, scope := range scopes {
+		dto.Scopes = append(dto.Scopes, string(scope))
+	}
+	if token.ExpireTime > 0 {
+		expireTime := time.Unix(token.ExpireTime, 0)
+		dto.ExpireTime = &expireTime
+	}
+	if token.LastUsedTime > 0 {
+		lastUsedTime := time.Unix(token.LastUsedTime, 0)
+		dto.LastUsedTime = &lastUsedTime
+	}
+	return dto
+}
+
+// CreatePersonalAccessTokenService 创建个人访问令牌
+// 令牌明文只在创建时返回一次, 数据库中只保存哈希
+func CreatePersonalAccessTokenService(ctx context.Context, userID int64, dto *DTO.CreatePersonalAccessTokenRequestDTO) (*DTO.CreatePersonalAccessTokenResponseDTO, *apiError.ApiError) {
+	count, err := dao.CountPersonalAccessTokens(ctx, userID)
+	if err != nil {
+		zap.L().Error("dao.CountPersonalAccessTokens() 失败", zap.Int64("user_id", userID), zap.Error(err))
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "创建令牌失败",
+		}
+	}
+	if count >= maxPersonalAccessTokens {
+		return nil, &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "令牌数量已达上限, 请先删除不再使用的令牌",
+		}
+	}
+
+	plain, hash, prefix, err := pat.Generate()
+	if err != nil {
+		zap.L().Error("pat.Generate() 失败", zap.Error(err))
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "创建令牌失败",
+		}
+	}
+	scopes := make([]pat.Scope, 0, len(dto.Scopes))
+	for _, scope := range dto.Scopes {
+		scopes = append(scopes, pat.Scope(scope))
+	}
+	now := time.Now()
+	token := &model.PersonalAccessToken{
+		UserID:      userID,
+		Name:        dto.Name,
+		TokenHash:   hash,
+		TokenPrefix: prefix,
+		Scopes:      pat.JoinScopes(scopes),
+		CreateTime:  now,
+	}
+	if dto.ExpireDays > 0 {
+		token.ExpireTime = now.AddDate(0, 0, dto.ExpireDays).Unix()
+	}
+	if err := dao.CreatePersonalAccessToken(ctx, token); err != nil {
+		zap.L().Error("dao.CreatePersonalAccessToken() 失败", zap.Int64("user_id", userID), zap.Error(err))
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "创建令牌失败",
+		}
+	}
+	return &DTO.CreatePersonalAccessTokenResponseDTO{
+		PersonalAccessToken: toPersonalAccessTokenDTO(token),
+		Token:               plain,
+	}, nil
+}
+
+// GetPersonalAccessTokenListService 获取用户的所有个人访问令牌
+func GetPersonalAccessTokenListService(ctx context.Context, userID int64) ([]DTO.PersonalAccessToken, *apiError.ApiError) {
+	tokens, err := dao.GetPersonalAccessTokens(ctx, userID)
+	if err != nil {
+		zap.L().Error("dao.GetPersonalAccessTokens() 失败", zap.Int64("user_id", userID), zap.Error(err))
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "获取令牌列表失败",
+		}
+	}
+	list := make([]DTO.PersonalAccessToken, 0, len(tokens))
+	for i := range tokens {
+		list = append(list, toPersonalAccessTokenDTO(&tokens[i]))
+	}
+	return list, nil
+}
+
+// RevokePersonalAccessTokenService 撤销用户的一个个人访问令牌, 撤销后立即失效
+// 令牌不存在或不属于该用户时返回 TokenNotExist
+func RevokePersonalAccessTokenService(ctx context.Context, userID int64, id int64) *apiError.ApiError {
+	deleted, err := dao.DeletePersonalAccessToken(ctx, userID, id)
+	if err != nil {
+		zap.L().Error("dao.DeletePersonalAccessToken() 失败", zap.Int64("token_id", id), zap.Error(err))
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "撤销令牌失败",
+		}
+	}
+	if !deleted {
+		return &apiError.ApiError{
+			Code: code.TokenNotExist,
+			Msg:  "令牌不存在",
+		}
+	}
+	return nil
+}
+
+// AuthenticatePersonalAccessToken 校验个人访问令牌
+// 令牌不存在、已过期或所属用户已被封禁时返回 InvalidAuth
+func AuthenticatePersonalAccessToken(ctx context.Context, token string) (*PersonalAccessTokenIdentity, *apiError.ApiError) {
+	owner, err := dao.FindPersonalAccessTokenByHash(ctx, pat.Hash(token))
+	if err != nil {
+		zap.L().Error("dao.FindPersonalAccessTokenByHash() 失败", zap.Error(err))
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "校验令牌失败",
+		}
+	}
+	now := time.Now()
+	if owner == nil || (owner.ExpireTime > 0 && owner.ExpireTime <= now.Unix()) {
+		return nil, &apiError.ApiError{
+			Code: code.InvalidAuth,
+			Msg:  "token 已失效",
+		}
+	}
+	if owner.BannedUntil > now.Unix() {
+		return nil, &apiError.ApiError{
+			Code: code.UserBanned,
+			Msg:  "账号已被封禁, 解封时间: " + time.Unix(owner.BannedUntil, 0).Format(time.DateTime),
+		}
+	}
+
+	if now.Unix()-owner.LastUsedTime >= int64(personalAccessTokenTouchInterval.Seconds()) {
+		if err := dao.UpdatePersonalAccessTokenLastUsed(ctx, owner.ID, now.Unix()); err != nil {
+			// 最后使用时间只用于展示, 更新失败不影响本次请求
+			zap.L().Warn("dao.UpdatePersonalAccessTokenLastUsed() 失败", zap.Int64("token_id", owner.ID), zap.Error(err))
+		}
+	}
+	return &PersonalAccessTokenIdentity{
+		UserID:   owner.UserID,
+		Username: owner.Username,
+		Scopes:   pat.SplitScopes(owner.Scopes),
+	}, nil
+}
@@ -7,10 +7,14 @@ import (
 	"GinTalk/kafka"
 	"GinTalk/pkg/apiError"
 	"GinTalk/pkg/code"
+	"GinTalk/pkg/cursor"
+	"GinTalk/pkg/markdown"
+	"GinTalk/pkg/rbac"
 	"GinTalk/pkg/snowflake"
 	"context"
 	"fmt"
 	"slices"
+	"strconv"
 	"time"
 
 	"go.uber.org/zap"
@@ -31,6 +35,39 @@ func CreatePost(ctx context.Context, postDTO *DTO.PostDetail) *apiError.ApiError
 
 	postDTO.PostID = postID
 
+	tags, apiErr := normalizeTags(postDTO.Tags)
+	if apiErr != nil {
+		return apiErr
+	}
+	postDTO.Tags = tags
+	if postDTO.AttachmentIDs, apiErr = checkAttachmentIDs(ctx, postDTO.AttachmentIDs, postDTO.AuthorId, 0, 0); apiErr != nil {
+		return apiErr
+	}
+	postDTO.ContentHTML = markdown.ToHTML(postDTO.Content)
+
+	switch postDTO.Status {
+	case DTO.PostStatusHidden, DTO.PostStatusPublished:
+		postDTO.Status = DTO.PostStatusPublished
+		postDTO.PublishAt = 0
+	case DTO.PostStatusDraft, DTO.PostStatusScheduled:
+		// 草稿和定时发布的帖子直接保存到数据库, 发布时再通过 Kafka 写入 Redis
+		if apiErr := checkPostSchedule(postDTO); apiErr != nil {
+			return apiErr
+		}
+		if err := dao.CreatePost(ctx, postDTO); err != nil {
+			return &apiError.ApiError{
+				Code: code.ServerError,
+				Msg:  fmt.Sprintf("保存草稿失败: %v", err),
+			}
+		}
+		return nil
+	default:
+		return &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "帖子状态不正确",
+		}
+	}
+
 	// 将帖子 ID 存入 Redis
 	go func() {
 		err := kafka.SendPostMessage(context.Background(), postDTO)
@@ -42,86 +79,164 @@ func CreatePost(ctx context.Context, postDTO *DTO.PostDetail) *apiError.ApiError
 	return nil
 }
 
-// GetPostList 根据提供的分页和排序参数检索帖子摘要列表。
+// GetPostList 根据提供的游标和排序参数检索帖子摘要列表。
 // 它使用 singleflight 机制防止缓存雪崩，并尝试首先从 Redis 缓存中获取数据。
 // 如果缓存中缺少一些帖子，它会从数据库中获取这些帖子并更新缓存。
 //
 // 参数:
 //   - ctx: 用于管理请求生命周期的上下文。
-//   - pageNum: 分页的页码。如果小于或等于 0，则默认为 1。
+//   - after: 上一页返回的游标。为空时获取第一页。
 //   - pageSize: 每页的帖子数量。如果小于或等于 0，则默认为 10。
-//   - order: 帖子检索的排序方式。
+//   - order: 帖子检索的排序方式。1 为热度, 2 为时间, 3 为时间窗口内的投票数。
+//   - tag: 只获取带有该标签的帖子, 为空时获取所有帖子。按投票数排序时不支持。
+//   - window: 按投票数排序时的时间窗口, 为 day、week、month、year 或 all, 为空时默认为 day。
 //
 // 返回:
-//   - []DTO.PostSummary: 帖子摘要的切片。
+//   - *DTO.Page[DTO.PostSummary]: 一页帖子摘要及下一页的游标。
 //   - *apiError.ApiError: 如果过程中发生错误，则返回错误对象。
-func GetPostList(ctx context.Context, pageNum int, pageSize int, order int) ([]DTO.PostSummary, *apiError.ApiError) {
-	// pageNum 和 pageSize 不能小于等于 0
-	if pageNum <= 0 {
-		pageNum = 1
-	}
+func GetPostList(ctx context.Context, after string, pageSize int, order int, tag string, window string) (*DTO.Page[DTO.PostSummary], *apiError.ApiError) {
+	// pageSize 不能小于等于 0
 	if pageSize <= 0 {
 		pageSize = 10
 	}
+	position, apiErr := decodeCursor(after)
+	if apiErr != nil {
+		return nil, apiErr
+	}
 
-	// 使用单飞模式, 从 Redis 中获取帖子列表
-	sgKey := GenerateSingleFlightKey(SingleFlightKeyPostList, order, pageNum, pageSize)
-
-	// 使用 singleflight 防止缓存雪崩
-	var group singleflight.Group
-
-	result, err, _ := group.Do(sgKey, func() (interface{}, error) {
-		postIDs, err := cache.GetPostIDs(ctx, order, pageNum, pageSize)
-		if err != nil {
+	if tag != "" {
+		normalized, ok := normalizeTag(tag)
+		if !ok {
 			return nil, &apiError.ApiError{
-				Code: code.ServerError,
-				Msg:  fmt.Sprintf("获取帖子列表失败: %v", err),
+				Code: code.InvalidParam,
+				Msg:  "标签不正确",
 			}
 		}
+		tag = normalized
+	}
 
-		// 首先从 Redis 中获取帖子列表
-		redisList, missingIDs, err := cache.GetPostSummary(ctx, postIDs)
-		if err != nil {
+	if order == cache.OrderByTop {
+		if tag != "" {
 			return nil, &apiError.ApiError{
-				Code: code.ServerError,
-				Msg:  fmt.Sprintf("获取帖子列表失败: %v", err),
+				Code: code.InvalidParam,
+				Msg:  "按投票数排序时不支持按标签筛选",
 			}
 		}
-
-		// 如果缓存中没有缺失的帖子, 则直接返回
-		if len(missingIDs) == 0 {
-			return redisList, nil
+		if window == "" {
+			window = DefaultTopWindow
 		}
-
-		list, err := dao.GetPostListBatch(ctx, missingIDs)
-		if err != nil {
+		if !cache.IsTopWindow(window) {
 			return nil, &apiError.ApiError{
-				Code: code.ServerError,
-				Msg:  fmt.Sprintf("获取帖子列表失败: %v", err),
+				Code: code.InvalidParam,
+				Msg:  "window 只能为 day、week、month、year 或 all",
 			}
 		}
+	} else {
+		window = ""
+	}
 
-		// 将缺失的帖子存入 Redis
-		go func() {
-			for _, post := range list {
-				err := cache.SavePost(context.Background(), &post)
-				if err != nil {
-					zap.L().Error("保存帖子到 Redis 失败", zap.Error(err))
+	// 使用单飞模式, 从 Redis 中获取帖子列表
+	sgKey := GenerateSingleFlightKey(SingleFlightKeyPostList, order, after, pageSize, tag, window)
+
+	// 使用 singleflight 防止缓存雪崩
+	var group singleflight.Group
+
+	result, err, _ := group.Do(sgKey, func() (interface{}, error) {
+		key := cache.PostListKey(order, tag)
+		if order == cache.OrderByTop {
+			var err error
+			if key, err = cache.TopWindowKey(ctx, window); err != nil {
+				return nil, &apiError.ApiError{
+					Code: code.ServerError,
+					Msg:  fmt.Sprintf("获取帖子列表失败: %v", err),
 				}
 			}
-		}()
-
-		return slices.Concat(redisList, list), nil
+		}
+		return getPostPage(ctx, key, position, pageSize)
 	})
 
 	if err != nil {
 		return nil, err.(*apiError.ApiError)
 	}
 
-	return result.([]DTO.PostSummary), nil
+	return result.(*DTO.Page[DTO.PostSummary]), nil
+}
+
+// getPostPage 从 Redis 有序集合中获取一页帖子 ID, 再从 Redis 中获取帖子摘要
+// 缓存中缺失的摘要从数据库中获取并重新存入 Redis, 数据库中也不存在的帖子已被删除, 直接跳过
+func getPostPage(ctx context.Context, key string, position *cursor.Cursor, pageSize int) (*DTO.Page[DTO.PostSummary], *apiError.ApiError) {
+	// 多取一个帖子, 用于判断是否还有下一页
+	positions, err := cache.GetPostIDs(ctx, key, position, pageSize+1)
+	if err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  fmt.Sprintf("获取帖子列表失败: %v", err),
+		}
+	}
+	page := newPage(positions, pageSize, func(c *cursor.Cursor) cursor.Cursor { return *c })
+	postIDs := make([]int64, len(page.List))
+	for i, c := range page.List {
+		postIDs[i] = c.ID
+	}
+	resp := &DTO.Page[DTO.PostSummary]{
+		List:       []DTO.PostSummary{},
+		NextCursor: page.NextCursor,
+		HasMore:    page.HasMore,
+	}
+	if len(postIDs) == 0 {
+		return resp, nil
+	}
+
+	// 首先从 Redis 中获取帖子列表
+	redisList, missingIDs, err := cache.GetPostSummary(ctx, postIDs)
+	if err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  fmt.Sprintf("获取帖子列表失败: %v", err),
+		}
+	}
+
+	// 如果缓存中没有缺失的帖子, 则直接返回
+	if len(missingIDs) == 0 {
+		resp.List = redisList
+		return resp, nil
+	}
+
+	list, err := dao.GetPostListBatch(ctx, missingIDs)
+	if err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  fmt.Sprintf("获取帖子列表失败: %v", err),
+		}
+	}
+
+	// 将缺失的帖子摘要存入 Redis, 帖子在有序集合中的分数保持不变
+	go func() {
+		for _, post := range list {
+			err := cache.SavePostSummary(context.Background(), &post)
+			if err != nil {
+				zap.L().Error("保存帖子到 Redis 失败", zap.Error(err))
+			}
+		}
+	}()
+
+	// 按有序集合中的顺序合并缓存和数据库中的帖子
+	fromDB := make(map[int64]DTO.PostSummary, len(list))
+	for _, post := range list {
+		fromDB[post.PostID] = post
+	}
+	for i, postID := range postIDs {
+		if !slices.Contains(missingIDs, postID) {
+			resp.List = append(resp.List, redisList[i])
+		} else if post, ok := fromDB[postID]; ok {
+			resp.List = append(resp.List, post)
+		}
+	}
+	return resp, nil
 }
 
-func GetPostDetail(ctx context.Context, postID int64) (*DTO.PostDetail, *apiError.ApiError) {
+// GetPostDetail 获取帖子详情, 草稿和定时发布的帖子只有作者本人可以查看
+func GetPostDetail(ctx context.Context, postID int64, viewerID int64) (*DTO.PostDetail, *apiError.ApiError) {
 	postDetail, err := dao.GetPostDetail(ctx, postID)
 	if err != nil {
 		return nil, &apiError.ApiError{
@@ -129,21 +244,59 @@ func GetPostDetail(ctx context.Context, postID int64) (*DTO.PostDetail, *apiErro
 			Msg:  fmt.Sprintf("获取帖子详情失败: %v", err),
 		}
 	}
+	if postDetail.IsPending() && postDetail.AuthorId != viewerID {
+		return nil, &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "帖子不存在",
+		}
+	}
+	fillPostHTML(postDetail)
+	if postDetail.PostID != 0 {
+		if apiErr := fillPostAttachments(ctx, postDetail); apiErr != nil {
+			return nil, apiErr
+		}
+	}
 	return postDetail, nil
 }
 
-func UpdatePost(ctx context.Context, postDTO *DTO.PostDetail) *apiError.ApiError {
-	// 延迟双删, 保证数据一致性
-
-	// 第一次删除 Redis 中数据
-	err := cache.DeletePostSummary(ctx, postDTO.PostID)
+// GetDraftList 获取作者本人尚未发布的草稿和定时发布帖子
+func GetDraftList(ctx context.Context, authorID int64, pageNum int, pageSize int) ([]DTO.PostDetail, *apiError.ApiError) {
+	list, err := dao.GetPendingPostsByAuthor(ctx, authorID, pageNum, pageSize)
 	if err != nil {
-		return &apiError.ApiError{
+		return nil, &apiError.ApiError{
 			Code: code.ServerError,
-			Msg:  fmt.Sprintf("删除Redis数据失败, %v", err.Error()),
+			Msg:  fmt.Sprintf("获取草稿列表失败: %v", err),
+		}
+	}
+	posts := make([]*DTO.PostDetail, len(list))
+	for i := range list {
+		fillPostHTML(&list[i])
+		posts[i] = &list[i]
+	}
+	if apiErr := fillPostAttachments(ctx, posts...); apiErr != nil {
+		return nil, apiErr
+	}
+	return list, nil
+}
+
+// checkPostSchedule 校验草稿和定时发布帖子的发布时间, 草稿没有发布时间
+func checkPostSchedule(postDTO *DTO.PostDetail) *apiError.ApiError {
+	if postDTO.Status == DTO.PostStatusDraft {
+		postDTO.PublishAt = 0
+		return nil
+	}
+	if postDTO.PublishAt <= time.Now().Unix() {
+		return &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "定时发布的时间必须晚于当前时间",
 		}
 	}
+	return nil
+}
 
+// UpdatePost 更新帖子
+// ifMatch 为请求 If-Match 中的版本号, 为 nil 时不检查版本
+func UpdatePost(ctx context.Context, postDTO *DTO.PostDetail, ifMatch []int64) *apiError.ApiError {
 	if postDTO.PostID == 0 {
 		return &apiError.ApiError{
 			Code: code.InvalidParam,
@@ -151,17 +304,74 @@ func UpdatePost(ctx context.Context, postDTO *DTO.PostDetail) *apiError.ApiError
 		}
 	}
 
+	current, err := dao.GetPostDetail(ctx, postDTO.PostID)
+	if err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  fmt.Sprintf("更新帖子失败: %v", err),
+		}
+	}
+	if current.PostID == 0 {
+		return &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "帖子不存在",
+		}
+	}
+	if current.AuthorId != postDTO.AuthorId {
+		return &apiError.ApiError{
+			Code: code.PermissionDenied,
+			Msg:  "无权限操作",
+		}
+	}
+	expectedVersion, apiErr := matchVersion(ifMatch, current.Version)
+	if apiErr != nil {
+		return apiErr
+	}
+	if postDTO.Tags, apiErr = normalizeTags(postDTO.Tags); apiErr != nil {
+		return apiErr
+	}
+	if postDTO.AttachmentIDs, apiErr = checkAttachmentIDs(ctx, postDTO.AttachmentIDs, postDTO.AuthorId, postDTO.PostID, 0); apiErr != nil {
+		return apiErr
+	}
+	postDTO.ContentHTML = markdown.ToHTML(postDTO.Content)
+	if current.IsPending() {
+		return updatePendingPost(ctx, current, postDTO, expectedVersion)
+	}
+
+	// 延迟双删, 保证数据一致性
+
+	// 第一次删除 Redis 中数据
+	err = cache.DeletePostSummary(ctx, postDTO.PostID)
+	if err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  fmt.Sprintf("删除Redis数据失败, %v", err.Error()),
+		}
+	}
+
 	fmt.Printf("截断前: %s\n", postDTO.Content)
 	summary := TruncateByWords(postDTO.Content, MaxSummaryLength)
 	fmt.Printf("截断后: %s\n", summary)
 
-	err = dao.UpdatePost(ctx, postDTO, summary)
+	updated, err := dao.UpdatePost(ctx, postDTO, summary, postDTO.AuthorId, expectedVersion)
 	if err != nil {
 		return &apiError.ApiError{
 			Code: code.ServerError,
 			Msg:  fmt.Sprintf("更新帖子失败: %v", err),
 		}
 	}
+	if !updated {
+		return &apiError.ApiError{
+			Code: code.PreconditionFailed,
+			Msg:  code.PreconditionFailed.GetMsg(),
+		}
+	}
+	if postDTO.Tags != nil {
+		if err := cache.UpdatePostTags(ctx, postDTO.PostID, postDTO.Tags); err != nil {
+			zap.L().Error("更新 Redis 中的帖子标签失败", zap.Int64("post_id", postDTO.PostID), zap.Error(err))
+		}
+	}
+	go syncPostSearch(context.Background(), postDTO.PostID)
 
 	// 等待 2s 后第二次删除 Redis 中数据
 	go func() {
@@ -177,26 +387,166 @@ func UpdatePost(ctx context.Context, postDTO *DTO.PostDetail) *apiError.ApiError
 	return nil
 }
 
-func GetPostListByCommunityID(ctx context.Context, communityID int64, pageNum int, pageSize int) ([]DTO.PostSummary, *apiError.ApiError) {
-	// pageNum 和 pageSize 不能小于等于 0
-	if pageNum <= 0 {
-		pageNum = 1
+// matchVersion 检查 If-Match 中的版本号是否包含当前版本
+// 返回更新时使用的期望版本号, 数据库在更新时会再次检查, 防止检查之后内容又被其他请求修改
+func matchVersion(ifMatch []int64, current int64) (int64, *apiError.ApiError) {
+	if ifMatch == nil {
+		return 0, nil
+	}
+	if !slices.Contains(ifMatch, current) {
+		return 0, &apiError.ApiError{
+			Code: code.PreconditionFailed,
+			Msg:  code.PreconditionFailed.GetMsg(),
+		}
+	}
+	return current, nil
+}
+
+// updatePendingPost 更新尚未发布的帖子, 可以同时修改状态和定时发布时间
+// 状态改为已发布时通过 Kafka 发布帖子, 与定时发布的处理方式相同
+func updatePendingPost(ctx context.Context, current *DTO.PostDetail, postDTO *DTO.PostDetail, expectedVersion int64) *apiError.ApiError {
+	if postDTO.Status == DTO.PostStatusDraft || postDTO.Status == DTO.PostStatusScheduled {
+		if apiErr := checkPostSchedule(postDTO); apiErr != nil {
+			return apiErr
+		}
+	}
+
+	summary := TruncateByWords(postDTO.Content, MaxSummaryLength)
+	updated, err := dao.UpdatePost(ctx, postDTO, summary, postDTO.AuthorId, expectedVersion)
+	if err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  fmt.Sprintf("更新帖子失败: %v", err),
+		}
+	}
+	if !updated {
+		return &apiError.ApiError{
+			Code: code.PreconditionFailed,
+			Msg:  code.PreconditionFailed.GetMsg(),
+		}
+	}
+
+	switch postDTO.Status {
+	case DTO.PostStatusHidden:
+		// 未指定状态时保持原来的状态
+		return nil
+	case DTO.PostStatusDraft, DTO.PostStatusScheduled:
+		if err := dao.UpdatePostSchedule(ctx, postDTO.PostID, postDTO.Status, postDTO.PublishAt); err != nil {
+			return &apiError.ApiError{
+				Code: code.ServerError,
+				Msg:  fmt.Sprintf("更新帖子失败: %v", err),
+			}
+		}
+		return nil
+	case DTO.PostStatusPublished:
+		publishMsg := *current
+		publishMsg.Title = postDTO.Title
+		publishMsg.Content = postDTO.Content
+		publishMsg.ContentHTML = postDTO.ContentHTML
+		if postDTO.Tags != nil {
+			publishMsg.Tags = postDTO.Tags
+		}
+		if err := kafka.SendPostMessage(ctx, &publishMsg); err != nil {
+			return &apiError.ApiError{
+				Code: code.ServerError,
+				Msg:  fmt.Sprintf("发布帖子失败: %v", err),
+			}
+		}
+		return nil
+	default:
+		return &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "帖子状态不正确",
+		}
 	}
+}
+
+// communityRankingGroup 合并同一社区并发的有序集合重建
+var communityRankingGroup singleflight.Group
+
+// GetPostListByCommunityID 按热度或时间获取社区中的帖子, after 为上一页返回的游标
+// 帖子 ID 从社区的 Redis 有序集合中获取, 有序集合不存在时先从数据库重建;
+// Redis 不可用时按时间排序的列表直接从数据库中读取
+func GetPostListByCommunityID(ctx context.Context, communityID int64, after string, pageSize int, order int) (*DTO.Page[DTO.PostSummary], *apiError.ApiError) {
+	// pageSize 不能小于等于 0
 	if pageSize <= 0 {
 		pageSize = 10
 	}
-	list, err := dao.GetPostListByCommunityID(ctx, communityID, pageNum, pageSize)
+	if order != cache.OrderByHot && order != cache.OrderByTime {
+		return nil, &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "order 字段不正确",
+		}
+	}
+	position, apiErr := decodeCursor(after)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if err := ensureCommunityRanking(ctx, communityID); err != nil {
+		zap.L().Error("建立社区帖子排行失败", zap.Int64("community_id", communityID), zap.Error(err))
+		if order != cache.OrderByTime {
+			return nil, &apiError.ApiError{
+				Code: code.ServerError,
+				Msg:  fmt.Sprintf("获取社区帖子列表失败: %v", err),
+			}
+		}
+		return getCommunityPostPageFromDB(ctx, communityID, position, pageSize)
+	}
+	return getPostPage(ctx, cache.CommunityPostListKey(order, communityID), position, pageSize)
+}
+
+// ensureCommunityRanking 社区的有序集合不存在时, 根据数据库中的发布时间和点赞数重建
+// 新发布的帖子和点赞由 Kafka 消息处理时写入有序集合
+func ensureCommunityRanking(ctx context.Context, communityID int64) error {
+	exists, err := cache.HasCommunityPosts(ctx, communityID)
+	if err != nil || exists {
+		return err
+	}
+	_, err, _ = communityRankingGroup.Do(strconv.FormatInt(communityID, 10), func() (interface{}, error) {
+		posts, err := dao.GetCommunityPostRankData(ctx, communityID)
+		if err != nil {
+			return nil, err
+		}
+		return nil, cache.RebuildCommunityPosts(ctx, communityID, posts)
+	})
+	return err
+}
+
+// getCommunityPostPageFromDB 按发布时间从数据库中获取社区帖子
+func getCommunityPostPageFromDB(ctx context.Context, communityID int64, position *cursor.Cursor, pageSize int) (*DTO.Page[DTO.PostSummary], *apiError.ApiError) {
+	list, err := dao.GetPostListByCommunityID(ctx, communityID, position, pageSize+1)
 	if err != nil {
 		return nil, &apiError.ApiError{
 			Code: code.ServerError,
 			Msg:  fmt.Sprintf("获取社区帖子列表失败: %v", err),
 		}
 	}
-	return list, nil
+	return newPage(list, pageSize, func(post *DTO.PostSummary) cursor.Cursor {
+		return cursor.Cursor{Score: float64(post.PublishAt), ID: post.PostID}
+	}), nil
 }
 
-func DeletePost(ctx context.Context, postID int64) *apiError.ApiError {
-	err := dao.DeletePost(ctx, postID)
+func DeletePost(ctx context.Context, postID int64, operatorID int64) *apiError.ApiError {
+	authorID, communityID, err := dao.GetPostOwner(ctx, postID)
+	if err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  fmt.Sprintf("删除帖子失败: %v", err),
+		}
+	}
+	if authorID == 0 {
+		return &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "帖子不存在",
+		}
+	}
+	// 作者本人, 全局版主或该社区的版主可以删除帖子
+	if apiErr := checkOwnerOrPermission(ctx, operatorID, authorID, rbac.PermDeleteAnyPost, communityID); apiErr != nil {
+		return apiErr
+	}
+
+	err = dao.DeletePost(ctx, postID)
 	if err != nil {
 		return &apiError.ApiError{
 			Code: code.ServerError,
@@ -209,6 +559,7 @@ func DeletePost(ctx context.Context, postID int64) *apiError.ApiError {
 			zap.L().Error("删除 Redis 中的帖子数据失败, ", zap.Error(err))
 		}
 	}()
+	go syncPostSearch(context.Background(), postID)
 
 	return nil
 }
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"GinTalk/cache"
+	"GinTalk/dao"
+	"GinTalk/kafka"
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// PostPublishInterval 扫描到期的定时发布帖子的间隔
+	PostPublishInterval = 30 * time.Second
+
+	// postPublishBatchSize 每次扫描最多发布的帖子数量, 剩余的帖子在下一次扫描时发布
+	postPublishBatchSize = 100
+)
+
+// StartPostPublisher 启动定时发布帖子的后台任务
+// 到期的帖子通过 Kafka 发布, 与直接发布的帖子一样写入 Redis 的摘要和排行
+func StartPostPublisher() {
+	go func() {
+		for range time.Tick(PostPublishInterval) {
+			publishDuePosts(context.Background())
+		}
+	}()
+}
+
+// publishDuePosts 发布所有已到发布时间的定时发布帖子
+// 多个实例同时运行时, 通过 Redis 锁保证同一时间只有一个实例扫描
+// 消息被重复消费时, 数据库中的状态保证帖子只被发布一次
+func publishDuePosts(ctx context.Context) {
+	ok, err := cache.AcquirePostPublishLock(ctx, PostPublishInterval/2)
+	if err != nil {
+		zap.L().Error("获取定时发布锁失败", zap.Error(err))
+		return
+	}
+	if !ok {
+		return
+	}
+
+	posts, err := dao.GetDuePosts(ctx, time.Now().Unix(), postPublishBatchSize)
+	if err != nil {
+		zap.L().Error("获取到期的定时发布帖子失败", zap.Error(err))
+		return
+	}
+	for i := range posts {
+		if err := kafka.SendPostMessage(ctx, &posts[i]); err != nil {
+			zap.L().Error("Kafka 生产消息失败", zap.Int64("post_id", posts[i].PostID), zap.Error(err))
+			continue
+		}
+		zap.L().Info("定时发布帖子", zap.Int64("post_id", posts[i].PostID))
+	}
+}
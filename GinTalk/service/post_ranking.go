@@ -0,0 +1,111 @@
+package service
+
+import (
+	"GinTalk/cache"
+	"GinTalk/dao"
+	"GinTalk/pkg/ranking"
+	"GinTalk/settings"
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// StartPostRankingRebuild 启动检查和重建热度排行的后台任务, 启动时立即检查一次
+// 配置的排行算法与有序集合记录的算法不同时, 按新算法从数据库重建; 分数随时间变化的算法每次都重新计算
+func StartPostRankingRebuild() {
+	interval := time.Duration(settings.GetConfig().RankingConfig.RefreshInterval) * time.Minute
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	go func() {
+		rebuildPostRanking(context.Background(), interval)
+		for range time.Tick(interval) {
+			rebuildPostRanking(context.Background(), interval)
+		}
+	}()
+}
+
+// rebuildPostRanking 重建需要更新的全局、标签和社区热度排行
+// 重建时先写入临时有序集合再替换, 重建过程中列表接口继续使用原有的排行
+// 多个实例同时运行时, 通过 Redis 锁保证同一时间只有一个实例重建
+func rebuildPostRanking(ctx context.Context, interval time.Duration) {
+	ok, err := cache.AcquirePostRankingRebuildLock(ctx, interval/2)
+	if err != nil {
+		zap.L().Error("获取热度排行重建锁失败", zap.Error(err))
+		return
+	}
+	if !ok {
+		return
+	}
+
+	outdated, err := cache.GlobalRankingOutdated(ctx)
+	if err != nil {
+		zap.L().Error("cache.GlobalRankingOutdated() 失败", zap.Error(err))
+		return
+	}
+	if outdated {
+		// 记录读取数据库之前的时间, 重建完成后补上这段时间内发布和投票的帖子
+		since := time.Now().Unix()
+		posts, err := dao.GetAllPostRankData(ctx)
+		if err != nil {
+			zap.L().Error("dao.GetAllPostRankData() 失败", zap.Error(err))
+			return
+		}
+		if err := cache.RebuildPostRanking(ctx, posts); err != nil {
+			zap.L().Error("重建全局热度排行失败", zap.Error(err))
+			return
+		}
+		restorePostRanking(ctx, since)
+		zap.L().Info("重建全局热度排行完成", zap.String("ranker", ranking.Default().Name()), zap.Int("posts", len(posts)))
+	}
+
+	communities, err := dao.GetCommunityList(ctx)
+	if err != nil {
+		zap.L().Error("dao.GetCommunityList() 失败", zap.Error(err))
+		return
+	}
+	for _, community := range communities {
+		communityID := int64(community.CommunityID)
+		outdated, err := cache.CommunityRankingOutdated(ctx, communityID)
+		if err != nil {
+			zap.L().Error("cache.CommunityRankingOutdated() 失败", zap.Int64("community_id", communityID), zap.Error(err))
+			continue
+		}
+		if !outdated {
+			continue
+		}
+		since := time.Now().Unix()
+		posts, err := dao.GetCommunityPostRankData(ctx, communityID)
+		if err != nil {
+			zap.L().Error("dao.GetCommunityPostRankData() 失败", zap.Int64("community_id", communityID), zap.Error(err))
+			continue
+		}
+		if err := cache.RebuildCommunityPosts(ctx, communityID, posts); err != nil {
+			zap.L().Error("重建社区热度排行失败", zap.Int64("community_id", communityID), zap.Error(err))
+			continue
+		}
+		if changed, err := dao.GetPostRankDataSince(ctx, since, communityID); err != nil {
+			zap.L().Error("dao.GetPostRankDataSince() 失败", zap.Int64("community_id", communityID), zap.Error(err))
+		} else if err := cache.RestoreCommunityPosts(ctx, communityID, changed); err != nil {
+			zap.L().Error("cache.RestoreCommunityPosts() 失败", zap.Int64("community_id", communityID), zap.Error(err))
+		}
+		zap.L().Info("重建社区热度排行完成", zap.Int64("community_id", communityID),
+			zap.String("ranker", ranking.ForCommunity(communityID).Name()), zap.Int("posts", len(posts)))
+	}
+}
+
+// restorePostRanking 重新计算 since 之后发布或投票的帖子在全局和标签热度排行中的分数
+// 这些变化在重建过程中写入的是被替换掉的旧有序集合, 或者因为算法记录不一致被跳过
+func restorePostRanking(ctx context.Context, since int64) {
+	posts, err := dao.GetPostRankDataSince(ctx, since, 0)
+	if err != nil {
+		zap.L().Error("dao.GetPostRankDataSince() 失败", zap.Error(err))
+		return
+	}
+	for i := range posts {
+		if err := cache.RestorePostRanking(ctx, &posts[i]); err != nil {
+			zap.L().Error("cache.RestorePostRanking() 失败", zap.Int64("post_id", posts[i].PostID), zap.Error(err))
+		}
+	}
+}
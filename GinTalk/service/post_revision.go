@@ -0,0 +1,169 @@
+package service
+
+import (
+	"GinTalk/DTO"
+	"GinTalk/cache"
+	"GinTalk/dao"
+	"GinTalk/pkg/apiError"
+	"GinTalk/pkg/code"
+	"GinTalk/pkg/diff"
+	"GinTalk/pkg/markdown"
+	"GinTalk/pkg/rbac"
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// getVisiblePost 获取当前用户可以查看的帖子, 帖子不存在或是他人的草稿时返回错误
+func getVisiblePost(ctx context.Context, postID int64, viewerID int64) (*DTO.PostDetail, *apiError.ApiError) {
+	post, apiErr := GetPostDetail(ctx, postID, viewerID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if post.PostID == 0 {
+		return nil, &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "帖子不存在",
+		}
+	}
+	return post, nil
+}
+
+// GetPostRevisions 获取帖子的版本列表
+func GetPostRevisions(ctx context.Context, postID int64, viewerID int64) ([]DTO.PostRevisionSummary, *apiError.ApiError) {
+	if _, apiErr := getVisiblePost(ctx, postID, viewerID); apiErr != nil {
+		return nil, apiErr
+	}
+	revisions, err := dao.GetPostRevisions(ctx, postID)
+	if err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  fmt.Sprintf("获取帖子版本失败: %v", err),
+		}
+	}
+	return revisions, nil
+}
+
+// GetPostRevision 获取帖子某个版本的完整内容
+func GetPostRevision(ctx context.Context, postID int64, revision int32, viewerID int64) (*DTO.PostRevision, *apiError.ApiError) {
+	if _, apiErr := getVisiblePost(ctx, postID, viewerID); apiErr != nil {
+		return nil, apiErr
+	}
+	rev, apiErr := getPostRevision(ctx, postID, revision)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	// 历史版本不保存渲染结果, 查看时再渲染
+	rev.ContentHTML = markdown.ToHTML(rev.Content)
+	return rev, nil
+}
+
+func getPostRevision(ctx context.Context, postID int64, revision int32) (*DTO.PostRevision, *apiError.ApiError) {
+	rev, err := dao.GetPostRevision(ctx, postID, revision)
+	if err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  fmt.Sprintf("获取帖子版本失败: %v", err),
+		}
+	}
+	if rev == nil {
+		return nil, &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  fmt.Sprintf("版本 %d 不存在", revision),
+		}
+	}
+	return rev, nil
+}
+
+// DiffPostRevisions 计算帖子两个版本之间按行的差异
+func DiffPostRevisions(ctx context.Context, postID int64, from int32, to int32, viewerID int64) (*DTO.PostRevisionDiff, *apiError.ApiError) {
+	if _, apiErr := getVisiblePost(ctx, postID, viewerID); apiErr != nil {
+		return nil, apiErr
+	}
+	fromRev, apiErr := getPostRevision(ctx, postID, from)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	toRev, apiErr := getPostRevision(ctx, postID, to)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	lines := diff.Lines(fromRev.Content, toRev.Content)
+	result := &DTO.PostRevisionDiff{
+		PostID:    postID,
+		From:      from,
+		To:        to,
+		FromTitle: fromRev.Title,
+		ToTitle:   toRev.Title,
+		Lines:     make([]DTO.PostRevisionDiffLine, len(lines)),
+	}
+	for i, line := range lines {
+		result.Lines[i] = DTO.PostRevisionDiffLine{
+			Op:      string(line.Op),
+			Text:    line.Text,
+			OldLine: line.OldLine,
+			NewLine: line.NewLine,
+		}
+	}
+	return result, nil
+}
+
+// RestorePostRevision 把帖子恢复到以前的版本
+// 恢复操作会保存为一个新的版本, 不会删除之后的版本
+// 作者本人, 全局版主或该社区的版主可以恢复
+func RestorePostRevision(ctx context.Context, postID int64, revision int32, operatorID int64) *apiError.ApiError {
+	post, apiErr := getVisiblePost(ctx, postID, operatorID)
+	if apiErr != nil {
+		return apiErr
+	}
+	if apiErr := checkOwnerOrPermission(ctx, operatorID, post.AuthorId, rbac.PermRestorePostRevision, post.CommunityID); apiErr != nil {
+		return apiErr
+	}
+	rev, apiErr := getPostRevision(ctx, postID, revision)
+	if apiErr != nil {
+		return apiErr
+	}
+	if rev.Title == post.Title && rev.Content == post.Content {
+		return &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "该版本与当前内容相同",
+		}
+	}
+
+	// 与更新帖子相同, 使用延迟双删保证缓存一致
+	if err := cache.DeletePostSummary(ctx, postID); err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  fmt.Sprintf("删除Redis数据失败, %v", err.Error()),
+		}
+	}
+	restored := &DTO.PostDetail{
+		PostID:      postID,
+		Title:       rev.Title,
+		Content:     rev.Content,
+		ContentHTML: markdown.ToHTML(rev.Content),
+	}
+	if _, err := dao.UpdatePost(ctx, restored, TruncateByWords(rev.Content, MaxSummaryLength), operatorID, 0); err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  fmt.Sprintf("恢复帖子版本失败: %v", err),
+		}
+	}
+	go syncPostSearch(context.Background(), postID)
+	go func() {
+		time.Sleep(DelayDeleteTime)
+		if err := cache.DeletePostSummary(context.Background(), postID); err != nil {
+			zap.L().Error("删除 Redis 数据失败", zap.Error(err))
+		}
+	}()
+
+	zap.L().Info("恢复帖子版本",
+		zap.Int64("post_id", postID),
+		zap.Int32("revision", revision),
+		zap.Int64("operator_id", operatorID),
+	)
+	return nil
+}
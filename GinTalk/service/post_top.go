@@ -0,0 +1,64 @@
+package service
+
+import (
+	"GinTalk/cache"
+	"GinTalk/dao"
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// PostTopRebuildInterval 从数据库重建投票数统计的间隔
+	// 投票数由 Kafka 消息处理时实时更新, 定期重建用于修正消息处理失败等原因造成的偏差
+	PostTopRebuildInterval = 6 * time.Hour
+
+	// DefaultTopWindow 按投票数排行时默认的时间窗口
+	DefaultTopWindow = cache.TopWindowDay
+)
+
+// StartPostTopRebuild 启动重建投票数统计的后台任务, 启动时立即重建一次
+func StartPostTopRebuild() {
+	go func() {
+		rebuildPostTop(context.Background())
+		for range time.Tick(PostTopRebuildInterval) {
+			rebuildPostTop(context.Background())
+		}
+	}()
+}
+
+// rebuildPostTop 根据 vote_post 中投票的创建时间重建各个时间段的投票数统计
+// 多个实例同时运行时, 通过 Redis 锁保证同一时间只有一个实例重建
+func rebuildPostTop(ctx context.Context) {
+	ok, err := cache.AcquirePostTopRebuildLock(ctx, PostTopRebuildInterval/2)
+	if err != nil {
+		zap.L().Error("获取投票数统计重建锁失败", zap.Error(err))
+		return
+	}
+	if !ok {
+		return
+	}
+
+	hourlySince, dailySince := cache.TopRebuildSince(time.Now())
+	hourly, err := dao.GetPostVoteBuckets(ctx, hourlySince, int64(time.Hour/time.Second))
+	if err != nil {
+		zap.L().Error("dao.GetPostVoteBuckets() 失败", zap.Error(err))
+		return
+	}
+	daily, err := dao.GetPostVoteBuckets(ctx, dailySince, int64(24*time.Hour/time.Second))
+	if err != nil {
+		zap.L().Error("dao.GetPostVoteBuckets() 失败", zap.Error(err))
+		return
+	}
+	total, err := dao.GetAllPostVoteCounts(ctx)
+	if err != nil {
+		zap.L().Error("dao.GetAllPostVoteCounts() 失败", zap.Error(err))
+		return
+	}
+	if err := cache.RebuildPostTop(ctx, hourly, daily, total); err != nil {
+		zap.L().Error("重建投票数统计失败", zap.Error(err))
+		return
+	}
+	zap.L().Info("重建投票数统计完成", zap.Int("posts", len(total)))
+}
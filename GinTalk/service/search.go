@@ -0,0 +1,200 @@
+package service
+
+import (
+	"GinTalk/DTO"
+	"GinTalk/dao"
+	"GinTalk/kafka"
+	"GinTalk/pkg/apiError"
+	"GinTalk/pkg/code"
+	"GinTalk/pkg/search"
+	"context"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// MaxSearchQueryLength 搜索词的最大字符数
+	MaxSearchQueryLength = 100
+	// searchRebuildBatchSize 重建索引时每次从数据库读取的行数
+	searchRebuildBatchSize = 500
+)
+
+// Search 搜索帖子和评论
+// docType 为 post、comment 或空字符串 (同时搜索两者), communityID 为 0 时不限制社区
+func Search(ctx context.Context, text string, docType string, communityID int64, pageNum int, pageSize int) (*DTO.SearchResult, *apiError.ApiError) {
+	if text == "" {
+		return nil, &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "搜索词不能为空",
+		}
+	}
+	if utf8.RuneCountInString(text) > MaxSearchQueryLength {
+		return nil, &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "搜索词过长",
+		}
+	}
+	switch search.DocType(docType) {
+	case "", search.DocPost, search.DocComment:
+	default:
+		return nil, &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "搜索类型只能为 post 或 comment",
+		}
+	}
+
+	result, err := search.GetEngine().Search(ctx, &search.Query{
+		Text:        text,
+		Type:        search.DocType(docType),
+		CommunityID: communityID,
+		Offset:      (pageNum - 1) * pageSize,
+		Limit:       pageSize,
+	})
+	if err != nil {
+		zap.L().Error("搜索失败", zap.String("q", text), zap.Error(err))
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "搜索失败",
+		}
+	}
+
+	visible, err := visibleSearchHits(ctx, result.Hits)
+	if err != nil {
+		zap.L().Error("过滤搜索结果失败", zap.String("q", text), zap.Error(err))
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "搜索失败",
+		}
+	}
+
+	hits := make([]DTO.SearchHit, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		if !visible[hit.Type][hit.ID] {
+			continue
+		}
+		hits = append(hits, DTO.SearchHit{
+			Type:        string(hit.Type),
+			ID:          hit.ID,
+			PostID:      hit.PostID,
+			CommunityID: hit.CommunityID,
+			AuthorID:    hit.AuthorID,
+			Title:       hit.TitleHighlight,
+			Snippet:     hit.Snippet,
+			Score:       hit.Score,
+			CreateTime:  hit.CreateTime,
+		})
+	}
+	return &DTO.SearchResult{Total: result.Total, Hits: hits}, nil
+}
+
+// visibleSearchHits 按数据库中的状态检查搜索结果, 返回仍然可见的帖子和评论 ID
+// 索引由 Kafka 消息异步更新, 刚删除或改为不可见的内容可能仍在索引中, 这些结果不返回给用户, 并从本实例的索引中删除;
+// 过滤发生在分页之后, 因此一页的结果数可能少于 pageSize
+func visibleSearchHits(ctx context.Context, hits []search.Hit) (map[search.DocType]map[int64]bool, error) {
+	var postIDs, commentIDs []int64
+	for _, hit := range hits {
+		if hit.Type == search.DocPost {
+			postIDs = append(postIDs, hit.ID)
+		} else {
+			commentIDs = append(commentIDs, hit.ID)
+		}
+	}
+	visiblePosts, err := dao.GetVisiblePostIDs(ctx, postIDs)
+	if err != nil {
+		return nil, err
+	}
+	visibleComments, err := dao.GetVisibleCommentIDs(ctx, commentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := map[search.DocType]map[int64]bool{
+		search.DocPost:    make(map[int64]bool, len(visiblePosts)),
+		search.DocComment: make(map[int64]bool, len(visibleComments)),
+	}
+	for _, id := range visiblePosts {
+		visible[search.DocPost][id] = true
+	}
+	for _, id := range visibleComments {
+		visible[search.DocComment][id] = true
+	}
+	for _, hit := range hits {
+		if !visible[hit.Type][hit.ID] {
+			if err := search.GetEngine().Delete(ctx, hit.Type, hit.ID); err != nil {
+				zap.L().Error("删除过期的搜索索引失败", zap.String("type", string(hit.Type)), zap.Int64("id", hit.ID), zap.Error(err))
+			}
+		}
+	}
+	return visible, nil
+}
+
+// StartSearchIndexRebuild 在后台从数据库重建本实例的搜索索引
+// 之后的发布、修改和删除通过 Kafka 搜索索引主题通知所有实例, 各实例按数据库中的最新状态更新自己的索引
+func StartSearchIndexRebuild() {
+	go func() {
+		if err := rebuildSearchIndex(context.Background()); err != nil {
+			zap.L().Error("重建搜索索引失败", zap.Error(err))
+		}
+	}()
+}
+
+// rebuildSearchIndex 按 ID 顺序分批读取所有已发布的帖子和评论并加入索引
+func rebuildSearchIndex(ctx context.Context) error {
+	engine := search.GetEngine()
+	posts, comments := 0, 0
+
+	var lastPostID int64
+	for {
+		batch, err := dao.GetPostsForSearch(ctx, lastPostID, searchRebuildBatchSize)
+		if err != nil {
+			return err
+		}
+		for i := range batch {
+			if err := engine.Index(ctx, batch[i].Document()); err != nil {
+				return err
+			}
+		}
+		posts += len(batch)
+		if len(batch) < searchRebuildBatchSize {
+			break
+		}
+		lastPostID = batch[len(batch)-1].PostID
+	}
+
+	var lastCommentID int64
+	for {
+		batch, err := dao.GetCommentsForSearch(ctx, lastCommentID, searchRebuildBatchSize)
+		if err != nil {
+			return err
+		}
+		for i := range batch {
+			if err := engine.Index(ctx, batch[i].Document()); err != nil {
+				return err
+			}
+		}
+		comments += len(batch)
+		if len(batch) < searchRebuildBatchSize {
+			break
+		}
+		lastCommentID = batch[len(batch)-1].CommentID
+	}
+
+	zap.L().Info("重建搜索索引完成", zap.Int("posts", posts), zap.Int("comments", comments))
+	return nil
+}
+
+// syncPostSearch 通知所有实例按数据库中的最新内容更新帖子的索引, 帖子已删除或未发布时从索引中删除帖子及其评论
+// 索引更新失败只记录日志, 不影响帖子本身的修改
+func syncPostSearch(ctx context.Context, postID int64) {
+	if err := kafka.SendSearchIndexMessage(ctx, kafka.SearchIndexPost, postID); err != nil {
+		zap.L().Error("发送搜索索引消息失败", zap.Int64("post_id", postID), zap.Error(err))
+	}
+}
+
+// syncCommentSearch 通知所有实例按数据库中的最新内容更新评论的索引, 评论已删除时从索引中删除
+func syncCommentSearch(ctx context.Context, commentID int64) {
+	if err := kafka.SendSearchIndexMessage(ctx, kafka.SearchIndexComment, commentID); err != nil {
+		zap.L().Error("发送搜索索引消息失败", zap.Int64("comment_id", commentID), zap.Error(err))
+	}
+}
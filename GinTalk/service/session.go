@@ -0,0 +1,72 @@
+package service
+
+import (
+	"GinTalk/DTO"
+	"GinTalk/cache"
+	"GinTalk/pkg/apiError"
+	"GinTalk/pkg/code"
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// GetSessionList 获取用户当前登录的所有会话
+// currentSessionID 为发起请求的会话, 在结果中标记为 Current
+func GetSessionList(ctx context.Context, userID int64, currentSessionID string) ([]DTO.Session, *apiError.ApiError) {
+	sessions, err := cache.ListSessions(ctx, userID)
+	if err != nil {
+		zap.L().Error("cache.ListSessions() 失败", zap.Int64("user_id", userID), zap.Error(err))
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "获取会话列表失败",
+		}
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].SessionID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession 移除用户的一个会话, 该会话签发的所有令牌立即失效
+// 只能移除属于自己的会话, 会话不存在或不属于该用户时返回 SessionNotExist
+func RevokeSession(ctx context.Context, userID int64, sessionID string) *apiError.ApiError {
+	owner, exist, err := cache.GetSessionUserID(ctx, sessionID)
+	if err != nil {
+		zap.L().Error("cache.GetSessionUserID() 失败", zap.String("session_id", sessionID), zap.Error(err))
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "移除会话失败",
+		}
+	}
+	if !exist || owner != userID {
+		return &apiError.ApiError{
+			Code: code.SessionNotExist,
+			Msg:  "会话不存在",
+		}
+	}
+	if err := cache.RevokeSession(ctx, sessionID); err != nil {
+		zap.L().Error("cache.RevokeSession() 失败", zap.String("session_id", sessionID), zap.Error(err))
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "移除会话失败",
+		}
+	}
+	return nil
+}
+
+// RevokeOtherSessions 退出除当前会话以外的所有设备
+//
+// 返回:
+//   - int: 被移除的会话数量。
+//   - *apiError.ApiError: 如果操作失败，则返回包含错误代码和消息的错误对象，否则返回 nil。
+func RevokeOtherSessions(ctx context.Context, userID int64, currentSessionID string) (int, *apiError.ApiError) {
+	count, err := cache.RevokeUserSessions(ctx, userID, currentSessionID)
+	if err != nil {
+		zap.L().Error("cache.RevokeUserSessions() 失败", zap.Int64("user_id", userID), zap.Error(err))
+		return count, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "退出其他设备失败",
+		}
+	}
+	return count, nil
+}
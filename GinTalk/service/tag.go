@@ -0,0 +1,78 @@
+package service
+
+import (
+	"GinTalk/pkg/apiError"
+	"GinTalk/pkg/code"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MaxPostTags 每个帖子最多的标签数量
+	MaxPostTags = 5
+	// MaxTagLength 标签的最大长度, 按字符计算
+	MaxTagLength = 20
+)
+
+// normalizeTag 规范化标签
+// 去掉开头的 #, 转为小写, 空白和连字符合并为一个连字符, 只允许字母, 数字, 下划线和连字符
+func normalizeTag(tag string) (string, bool) {
+	tag = strings.TrimSpace(tag)
+	tag = strings.TrimPrefix(tag, "#")
+	tag = strings.ToLower(tag)
+
+	var b strings.Builder
+	lastDash := false
+	for _, r := range tag {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			b.WriteRune(r)
+			lastDash = false
+		case unicode.IsSpace(r) || r == '-':
+			if b.Len() > 0 && !lastDash {
+				b.WriteRune('-')
+				lastDash = true
+			}
+		default:
+			return "", false
+		}
+	}
+	result := strings.TrimRight(b.String(), "-")
+	if result == "" || utf8.RuneCountInString(result) > MaxTagLength {
+		return "", false
+	}
+	return result, true
+}
+
+// normalizeTags 规范化帖子的标签并去重, 保持原有顺序
+// tags 为 nil 时返回 nil, 表示不修改标签
+func normalizeTags(tags []string) ([]string, *apiError.ApiError) {
+	if tags == nil {
+		return nil, nil
+	}
+	result := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		normalized, ok := normalizeTag(tag)
+		if !ok {
+			return nil, &apiError.ApiError{
+				Code: code.InvalidParam,
+				Msg:  fmt.Sprintf("标签 %q 不正确, 标签只能包含字母, 数字, 下划线和连字符, 最长 %d 个字符", tag, MaxTagLength),
+			}
+		}
+		if seen[normalized] {
+			continue
+		}
+		seen[normalized] = true
+		result = append(result, normalized)
+	}
+	if len(result) > MaxPostTags {
+		return nil, &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  fmt.Sprintf("每个帖子最多 %d 个标签", MaxPostTags),
+		}
+	}
+	return result, nil
+}
@@ -1,14 +1,17 @@
 package service
 
+import "GinTalk/pkg/markdown"
+
 const (
 	MaxSummaryLength = 100
 )
 
-// TruncateByWords 按单词截断字符串
+// TruncateByWords 取 Markdown 内容的纯文本并按字符截断, 用于生成摘要
 func TruncateByWords(s string, maxWords int) string {
-	runes := []rune(s)
+	text := markdown.PlainText(s)
+	runes := []rune(text)
 	if len(runes) <= maxWords {
-		return s
+		return text
 	}
 	return string(runes[:maxWords]) + "..."
 }
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"GinTalk/DTO"
+	"GinTalk/cache"
+	"GinTalk/dao"
+	"GinTalk/model"
+	"GinTalk/pkg"
+	"GinTalk/pkg/apiError"
+	"GinTalk/pkg/code"
+	"GinTalk/pkg/jwt"
+	"GinTalk/pkg/totp"
+	"context"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// totpIssuer 验证器中显示的服务名称
+	totpIssuer = "GinTalk"
+	// recoveryCodeCount 启用两步验证时生成的恢复码数量
+	recoveryCodeCount = 10
+	// loginChallengeExpire 登录挑战令牌的有效期
+	loginChallengeExpire = 5 * time.Minute
+	// loginChallengeMaxAttempts 每个登录挑战允许尝试验证码的次数, 超过后需要重新输入密码
+	loginChallengeMaxAttempts = 5
+)
+
+// issueLoginChallenge 密码验证通过后签发登录挑战令牌
+func issueLoginChallenge(ctx context.Context, user *model.User) (*DTO.LoginResponseDTO, *apiError.ApiError) {
+	token, tokenID, err := jwt.GenerateActionToken(user.UserID, jwt.PurposeLoginChallenge, "", loginChallengeExpire)
+	if err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "登录失败",
+		}
+	}
+	if err := cache.SaveActionToken(ctx, jwt.PurposeLoginChallenge, tokenID, user.UserID, loginChallengeExpire); err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "登录失败",
+		}
+	}
+	return &DTO.LoginResponseDTO{
+		UserID:            user.UserID,
+		Username:          user.Username,
+		EmailVerified:     user.EmailVerified,
+		TwoFactorRequired: true,
+		ChallengeToken:    token,
+	}, nil
+}
+
+// verifyTwoFactorCode 校验 TOTP 验证码或恢复码
+// 验证码在同一个时间步内只能使用一次, 恢复码只能使用一次
+func verifyTwoFactorCode(ctx context.Context, userID int64, secret string, passcode string) (bool, error) {
+	passcode = strings.TrimSpace(passcode)
+	if len(passcode) == totp.Digits {
+		step, ok := totp.Validate(secret, passcode, time.Now())
+		if !ok {
+			return false, nil
+		}
+		return cache.MarkTOTPStepUsed(ctx, userID, step, (2*totp.Skew+1)*totp.Period*time.Second)
+	}
+	return dao.UseRecoveryCode(ctx, userID, totp.HashRecoveryCode(passcode))
+}
+
+// LoginTwoFactorService 使用登录挑战令牌和验证码完成登录
+// 每个挑战令牌最多尝试 loginChallengeMaxAttempts 次, 成功后失效
+// 验证码错误与密码错误一样计入用户名和 IP 的登录失败次数, 锁定期间不能完成登录, 验证通过后才清除失败次数
+func LoginTwoFactorService(ctx context.Context, dto *DTO.LoginTwoFactorRequestDTO) (*DTO.LoginResponseDTO, *apiError.ApiError) {
+	invalid := &apiError.ApiError{
+		Code: code.ActionTokenInvalid,
+		Msg:  "登录已过期, 请重新登录",
+	}
+	claims, err := jwt.ParseActionToken(dto.ChallengeToken, jwt.PurposeLoginChallenge)
+	if err != nil {
+		return nil, invalid
+	}
+	valid, err := cache.PeekActionToken(ctx, jwt.PurposeLoginChallenge, claims.ID, claims.UserID)
+	if err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "登录失败",
+		}
+	}
+	if !valid {
+		return nil, invalid
+	}
+
+	user, err := dao.FindUserByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "登录失败",
+		}
+	}
+	if user.UserID == 0 {
+		return nil, invalid
+	}
+	if apiErr := checkLoginLockout(ctx, user.Username, dto.IP); apiErr != nil {
+		return nil, apiErr
+	}
+
+	attempts, err := cache.IncrLoginChallengeAttempts(ctx, claims.ID, loginChallengeExpire)
+	if err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "登录失败",
+		}
+	}
+	if attempts > loginChallengeMaxAttempts {
+		if _, err := cache.ConsumeActionToken(ctx, jwt.PurposeLoginChallenge, claims.ID, claims.UserID); err != nil {
+			zap.L().Error("cache.ConsumeActionToken() 失败", zap.Error(err))
+		}
+		return nil, &apiError.ApiError{
+			Code: code.ActionTokenInvalid,
+			Msg:  "验证码错误次数过多, 请重新登录",
+		}
+	}
+
+	twoFactor, err := dao.FindUserTwoFactor(ctx, claims.UserID)
+	if err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "登录失败",
+		}
+	}
+	if twoFactor == nil || !twoFactor.Enabled {
+		return nil, invalid
+	}
+	ok, err := verifyTwoFactorCode(ctx, claims.UserID, twoFactor.Secret, dto.Code)
+	if err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "登录失败",
+		}
+	}
+	if !ok {
+		if lockout := recordLoginFailure(ctx, user.Username, dto.IP); lockout > 0 {
+			return nil, loginLockedError(lockout)
+		}
+		return nil, &apiError.ApiError{
+			Code: code.TwoFactorCodeError,
+			Msg:  "验证码错误",
+		}
+	}
+
+	// 并发使用同一个挑战令牌时只有一个请求能完成登录
+	valid, err = cache.ConsumeActionToken(ctx, jwt.PurposeLoginChallenge, claims.ID, claims.UserID)
+	if err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "登录失败",
+		}
+	}
+	if !valid {
+		return nil, invalid
+	}
+	resetLoginFailures(ctx, user.Username)
+
+	return issueLoginTokens(ctx, user, &DTO.Session{
+		Device:    dto.Device,
+		IP:        dto.IP,
+		UserAgent: dto.UserAgent,
+	})
+}
+
+// EnrollTwoFactorService 开始设置两步验证, 生成新的 TOTP 密钥
+// 密钥在使用验证码确认之前不会生效, 重复调用会替换未确认的密钥
+func EnrollTwoFactorService(ctx context.Context, userID int64) (*DTO.TwoFactorEnrollResponseDTO, *apiError.ApiError) {
+	user, err := dao.FindUserByID(ctx, userID)
+	if err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "设置两步验证失败",
+		}
+	}
+	if user.UserID == 0 {
+		return nil, &apiError.ApiError{
+			Code: code.UserNotExist,
+			Msg:  "用户不存在",
+		}
+	}
+	twoFactor, err := dao.FindUserTwoFactor(ctx, userID)
+	if err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "设置两步验证失败",
+		}
+	}
+	if twoFactor != nil && twoFactor.Enabled {
+		return nil, &apiError.ApiError{
+			Code: code.TwoFactorAlreadyEnabled,
+			Msg:  "两步验证已启用",
+		}
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "设置两步验证失败",
+		}
+	}
+	if err := dao.SavePendingTwoFactor(ctx, userID, secret); err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "设置两步验证失败",
+		}
+	}
+	return &DTO.TwoFactorEnrollResponseDTO{
+		Secret: secret,
+		URI:    totp.URI(totpIssuer, user.Username, secret),
+	}, nil
+}
+
+// ConfirmTwoFactorService 使用验证器生成的验证码确认并启用两步验证
+// 启用成功后返回一次性恢复码, 恢复码只会返回这一次
+func ConfirmTwoFactorService(ctx context.Context, userID int64, passcode string) (*DTO.TwoFactorConfirmResponseDTO, *apiError.ApiError) {
+	twoFactor, err := dao.FindUserTwoFactor(ctx, userID)
+	if err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "启用两步验证失败",
+		}
+	}
+	if twoFactor == nil {
+		return nil, &apiError.ApiError{
+			Code: code.TwoFactorNotEnabled,
+			Msg:  "请先设置两步验证",
+		}
+	}
+	if twoFactor.Enabled {
+		return nil, &apiError.ApiError{
+			Code: code.TwoFactorAlreadyEnabled,
+			Msg:  "两步验证已启用",
+		}
+	}
+	step, ok := totp.Validate(twoFactor.Secret, passcode, time.Now())
+	if !ok {
+		return nil, &apiError.ApiError{
+			Code: code.TwoFactorCodeError,
+			Msg:  "验证码错误",
+		}
+	}
+
+	codes, err := totp.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "启用两步验证失败",
+		}
+	}
+	hashes := make([]string, len(codes))
+	for i, c := range codes {
+		hashes[i] = totp.HashRecoveryCode(c)
+	}
+	enabled, err := dao.EnableTwoFactor(ctx, userID, hashes)
+	if err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "启用两步验证失败",
+		}
+	}
+	if !enabled {
+		return nil, &apiError.ApiError{
+			Code: code.TwoFactorAlreadyEnabled,
+			Msg:  "两步验证已启用",
+		}
+	}
+
+	// 确认时使用的验证码不能再用于登录
+	if _, err := cache.MarkTOTPStepUsed(ctx, userID, step, (2*totp.Skew+1)*totp.Period*time.Second); err != nil {
+		zap.L().Error("cache.MarkTOTPStepUsed() 失败", zap.Int64("user_id", userID), zap.Error(err))
+	}
+	return &DTO.TwoFactorConfirmResponseDTO{RecoveryCodes: codes}, nil
+}
+
+// DisableTwoFactorService 关闭两步验证, 需要重新输入密码
+// 密码错误与登录失败使用同一个计数, 防止持有会话的人借此接口猜测密码
+func DisableTwoFactorService(ctx context.Context, userID int64, dto *DTO.TwoFactorDisableRequestDTO) *apiError.ApiError {
+	user, err := dao.FindUserByID(ctx, userID)
+	if err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "关闭两步验证失败",
+		}
+	}
+	if user.UserID == 0 {
+		return &apiError.ApiError{
+			Code: code.UserNotExist,
+			Msg:  "用户不存在",
+		}
+	}
+	if apiErr := checkLoginLockout(ctx, user.Username, dto.IP); apiErr != nil {
+		return apiErr
+	}
+	match, _, err := pkg.VerifyPassword(dto.Password, user.Password)
+	if err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "关闭两步验证失败",
+		}
+	}
+	if !match {
+		if lockout := recordLoginFailure(ctx, user.Username, dto.IP); lockout > 0 {
+			return loginLockedError(lockout)
+		}
+		return &apiError.ApiError{
+			Code: code.PasswordError,
+			Msg:  "密码错误",
+		}
+	}
+
+	twoFactor, err := dao.FindUserTwoFactor(ctx, userID)
+	if err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "关闭两步验证失败",
+		}
+	}
+	if twoFactor == nil || !twoFactor.Enabled {
+		return &apiError.ApiError{
+			Code: code.TwoFactorNotEnabled,
+			Msg:  "两步验证未启用",
+		}
+	}
+	resetLoginFailures(ctx, user.Username)
+	if err := dao.DeleteTwoFactor(ctx, userID); err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "关闭两步验证失败",
+		}
+	}
+	return nil
+}
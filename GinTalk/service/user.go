@@ -0,0 +1,122 @@
+package service
+
+import (
+	"GinTalk/DTO"
+	"GinTalk/dao"
+	"GinTalk/pkg/apiError"
+	"GinTalk/pkg/code"
+	"context"
+	"net/url"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// GetUserProfileService 获取用户主页信息
+// viewerID 为当前登录的用户, 查看自己的主页时会额外返回邮箱等私有信息
+func GetUserProfileService(ctx context.Context, userID int64, viewerID int64) (*DTO.UserProfileDTO, *apiError.ApiError) {
+	user, err := dao.FindUserByID(ctx, userID)
+	if err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "获取用户信息失败",
+		}
+	}
+	if user.UserID == 0 {
+		return nil, &apiError.ApiError{
+			Code: code.UserNotExist,
+			Msg:  "用户不存在",
+		}
+	}
+
+	profile := &DTO.UserProfileDTO{
+		UserID:     user.UserID,
+		Username:   user.Username,
+		Avatar:     user.Avatar,
+		Bio:        user.Bio,
+		Gender:     user.Gender,
+		CreateTime: user.CreateTime,
+	}
+
+	if profile.PostCount, err = dao.GetPostCountByUserID(ctx, userID); err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "获取用户信息失败",
+		}
+	}
+	if profile.CommentCount, err = dao.GetCommentCountByUserID(ctx, userID); err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "获取用户信息失败",
+		}
+	}
+	if profile.Karma, err = dao.GetUserKarma(ctx, userID); err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "获取用户信息失败",
+		}
+	}
+
+	if viewerID == userID {
+		profile.Email = user.Email
+		profile.EmailVerified = &user.EmailVerified
+	}
+	return profile, nil
+}
+
+// UpdateProfileService 更新当前用户的资料
+// 修改邮箱后邮箱变为未验证状态, 并向新邮箱发送验证邮件
+func UpdateProfileService(ctx context.Context, userID int64, dto *DTO.UpdateProfileRequestDTO) *apiError.ApiError {
+	if dto.Bio != nil {
+		bio := strings.TrimSpace(*dto.Bio)
+		dto.Bio = &bio
+	}
+	if dto.Avatar != nil && *dto.Avatar != "" {
+		u, err := url.Parse(*dto.Avatar)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return &apiError.ApiError{
+				Code: code.InvalidParam,
+				Msg:  "头像地址必须是 http 或 https 链接",
+			}
+		}
+	}
+	if dto.Email != nil {
+		email := strings.TrimSpace(*dto.Email)
+		if email == "" {
+			return &apiError.ApiError{
+				Code: code.InvalidParam,
+				Msg:  "邮箱不能为空",
+			}
+		}
+		dto.Email = &email
+	}
+
+	user, err := dao.FindUserByID(ctx, userID)
+	if err != nil {
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "更新资料失败",
+		}
+	}
+	if user.UserID == 0 {
+		return &apiError.ApiError{
+			Code: code.UserNotExist,
+			Msg:  "用户不存在",
+		}
+	}
+
+	if err := dao.UpdateUserProfile(ctx, userID, dto); err != nil {
+		zap.L().Error("dao.UpdateUserProfile() 失败", zap.Int64("user_id", userID), zap.Error(err))
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "更新资料失败",
+		}
+	}
+
+	if dto.Email != nil && *dto.Email != user.Email {
+		user.Email = *dto.Email
+		user.EmailVerified = false
+		sendVerificationEmailAsync(user)
+	}
+	return nil
+}
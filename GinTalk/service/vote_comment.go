@@ -7,28 +7,32 @@ import (
 	"go.uber.org/zap"
 )
 
-func VoteComment(userID, commentID int64) *apiError.ApiError {
-	err := dao.VoteComment(userID, commentID)
-	// 如果是由于索引冲突的插入失败,则表示已经投过票
-	if err != nil {
+// VoteComment 对评论投赞或踩, 已经投过票时改为新的投票值
+func VoteComment(userID, commentID int64, vote int) *apiError.ApiError {
+	if vote != 1 && vote != -1 {
+		return &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "投票值只能为 1 或 -1",
+		}
+	}
+	if _, err := dao.VoteCommentWithTx(userID, commentID, vote); err != nil {
+		zap.L().Error("dao.VoteCommentWithTx() 失败", zap.Int64("comment_id", commentID), zap.Error(err))
 		return &apiError.ApiError{
 			Code: code.ServerError,
 			Msg:  "投票失败",
 		}
 	}
-	go updateCommentVoteCount(commentID, 1)
 	return nil
 }
 
 func RemoveVoteComment(userID, commentID int64) *apiError.ApiError {
-	err := dao.RemoveVoteComment(userID, commentID)
-	if err != nil {
+	if _, err := dao.VoteCommentWithTx(userID, commentID, 0); err != nil {
+		zap.L().Error("dao.VoteCommentWithTx() 失败", zap.Int64("comment_id", commentID), zap.Error(err))
 		return &apiError.ApiError{
 			Code: code.ServerError,
 			Msg:  "取消投票失败",
 		}
 	}
-	go updateCommentVoteCount(commentID, -1)
 	return nil
 }
 
@@ -53,16 +57,3 @@ func GetVoteCommentList(userID int64, commentIDs []int64) (map[int64]int, *apiEr
 	}
 	return result, nil
 }
-
-func updateCommentVoteCount(commentID int64, count int) {
-	var err error
-	if count > 0 {
-		err = dao.IncrCommentVoteCount(commentID)
-	} else {
-		err = dao.DecrCommentVoteCount(commentID)
-	}
-	if err != nil {
-		zap.L().Error("更新评论投票数失败", zap.Int64("commentID", commentID), zap.Error(err))
-		return
-	}
-}
@@ -21,23 +21,31 @@ var postVoteCountGroup singleflight.Group
 // 投票使用kafka异步处理
 // VotePost 处理用户对帖子的投票过程。
 // 它使用 singleflight 机制确保投票操作仅执行一次，以防止重复投票。
+// 已经投过票时改为新的投票值，例如由赞改为踩。
 //
 // 参数:
 //   - ctx: 请求的上下文，用于取消和截止日期。
 //   - postID: 被投票的帖子的ID。
 //   - userID: 投票用户的ID。
+//   - vote: 投票值，1 表示赞，-1 表示踩。
 //
 // 返回值:
 //   - *apiError.ApiError: 如果投票过程失败，返回包含错误代码和消息的错误对象；
 //     如果投票成功，则返回nil。
-func VotePost(ctx context.Context, postID int64, userID int64) *apiError.ApiError {
-	key := GenerateSingleFlightKey(SingleFlightKeyVotePost, postID, userID)
+func VotePost(ctx context.Context, postID int64, userID int64, vote int) *apiError.ApiError {
+	if vote != 1 && vote != -1 {
+		return &apiError.ApiError{
+			Code: code.InvalidParam,
+			Msg:  "投票值只能为 1 或 -1",
+		}
+	}
+	key := GenerateSingleFlightKey(SingleFlightKeyVotePost, postID, userID, vote)
 	go func() {
 		_, err, _ := voteGroup.Do(key, func() (interface{}, error) {
 			err := kafka.SendLikeMessage(ctx, &kafka.Vote{
 				PostID: strconv.FormatInt(postID, 10),
 				UserID: strconv.FormatInt(userID, 10),
-				Vote:   1,
+				Vote:   vote,
 			})
 			if err != nil {
 				zap.L().Error("消息发送失败", zap.Error(err))
@@ -65,7 +73,7 @@ func VotePost(ctx context.Context, postID int64, userID int64) *apiError.ApiErro
 //   - *apiError.ApiError: 如果取消投票过程失败，返回包含错误代码和消息的错误对象；
 //     如果取消投票成功，则返回nil。
 func RevokeVotePost(ctx context.Context, postID int64, userID int64) *apiError.ApiError {
-	key := GenerateSingleFlightKey(SingleFlightKeyVotePost, postID, userID)
+	key := GenerateSingleFlightKey(SingleFlightKeyVotePost, postID, userID, 0)
 	go func() {
 		_, err, _ := voteGroup.Do(key, func() (interface{}, error) {
 			err := kafka.SendLikeMessage(ctx, &kafka.Vote{
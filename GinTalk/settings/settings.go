@@ -12,6 +12,11 @@ import (
 var conf = new(Settings)
 var once sync.Once
 
+var (
+	changeHandlers []func(*Settings)
+	changeMu       sync.Mutex
+)
+
 type MysqlConfig struct {
 	Host               string `mapstructure:"host"`
 	Port               int    `mapstructure:"port"`
@@ -63,18 +68,172 @@ type KafkaConfig struct {
 	Brokers []string `mapstructure:"brokers"`
 }
 
+// PasswordHashConfig argon2id 密码哈希参数
+type PasswordHashConfig struct {
+	Memory      uint32 `mapstructure:"memory"`
+	Iterations  uint32 `mapstructure:"iterations"`
+	Parallelism uint8  `mapstructure:"parallelism"`
+}
+
+// JWTKeyConfig JWT 密钥配置
+// HS256 使用 secret; RS256 和 EdDSA 使用 PEM 格式的密钥文件,
+// 只配置公钥文件时该密钥只用于验签
+type JWTKeyConfig struct {
+	Kid            string `mapstructure:"kid"`
+	Algorithm      string `mapstructure:"algorithm"`
+	Secret         string `mapstructure:"secret"`
+	PrivateKeyFile string `mapstructure:"privateKeyFile"`
+	PublicKeyFile  string `mapstructure:"publicKeyFile"`
+}
+
+// JWTConfig JWT 配置
+// SigningKey 为签发新 token 使用的密钥 kid, Keys 中的其他密钥仍可用于验签
+// AccessTokenExpire 和 RefreshTokenExpire 的单位为分钟
+type JWTConfig struct {
+	Issuer             string         `mapstructure:"issuer"`
+	SigningKey         string         `mapstructure:"signingKey"`
+	Keys               []JWTKeyConfig `mapstructure:"keys"`
+	AccessTokenExpire  int            `mapstructure:"accessTokenExpire"`
+	RefreshTokenExpire int            `mapstructure:"refreshTokenExpire"`
+}
+
+// SMTPConfig SMTP 服务器配置
+// TLS 为 true 时使用隐式 TLS (通常为 465 端口), 否则在服务器支持时使用 STARTTLS
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	TLS      bool   `mapstructure:"tls"`
+}
+
+// MailConfig 邮件配置
+// Driver 为 smtp 或 file, file 将邮件写入 FileDir 目录而不真正发送, 用于开发和离线测试
+// LinkBaseURL 为邮件中链接的前缀, VerifyTokenExpire 和 ResetTokenExpire 的单位为分钟
+type MailConfig struct {
+	Driver            string     `mapstructure:"driver"`
+	From              string     `mapstructure:"from"`
+	LinkBaseURL       string     `mapstructure:"linkBaseURL"`
+	FileDir           string     `mapstructure:"fileDir"`
+	VerifyTokenExpire int        `mapstructure:"verifyTokenExpire"`
+	ResetTokenExpire  int        `mapstructure:"resetTokenExpire"`
+	SMTP              SMTPConfig `mapstructure:"smtp"`
+}
+
+// LoginProtectionConfig 登录失败保护配置
+// 同一用户名或同一 IP 在 FailureWindow 分钟内失败次数达到阈值后暂时锁定 BaseLockout 秒,
+// 此后每次失败锁定时间加倍, 最长 MaxLockout 秒
+type LoginProtectionConfig struct {
+	FailureWindow int `mapstructure:"failureWindow"`
+	UserThreshold int `mapstructure:"userThreshold"`
+	IPThreshold   int `mapstructure:"ipThreshold"`
+	BaseLockout   int `mapstructure:"baseLockout"`
+	MaxLockout    int `mapstructure:"maxLockout"`
+}
+
+// AccountConfig 账号注销和个人数据导出配置
+// DeleteContent 为 keep 时注销后保留用户的帖子和评论 (作者显示为已注销用户), 为 remove 时一并删除
+// 导出的数据行数不超过 ExportSyncLimit 时同步生成压缩包, 否则在后台生成;
+// 压缩包保存在 ExportDir 目录中, ExportExpire 小时后过期
+type AccountConfig struct {
+	DeleteContent   string `mapstructure:"deleteContent"`
+	ExportDir       string `mapstructure:"exportDir"`
+	ExportSyncLimit int    `mapstructure:"exportSyncLimit"`
+	ExportExpire    int    `mapstructure:"exportExpire"`
+}
+
+// RegistrationConfig 注册配置
+// Mode 为 open (开放注册)、invite (仅限邀请) 或 closed (关闭注册)
+type RegistrationConfig struct {
+	Mode string `mapstructure:"mode"`
+}
+
+// CaptchaConfig 验证码配置
+// Enabled 为 true 时注册必须通过验证码, 同一用户名或 IP 登录失败 LoginFailures 次后登录也需要验证码;
+// Driver 为 text (扭曲文字)、math (算术) 或 fixed (固定答案 FixedAnswer, 只用于自动化测试);
+// Length 为文字验证码的字符数, Expire 为验证码的有效期, 单位秒
+type CaptchaConfig struct {
+	Enabled       bool   `mapstructure:"enabled"`
+	Driver        string `mapstructure:"driver"`
+	Length        int    `mapstructure:"length"`
+	Expire        int    `mapstructure:"expire"`
+	LoginFailures int    `mapstructure:"loginFailures"`
+	FixedAnswer   string `mapstructure:"fixedAnswer"`
+}
+
+// SearchConfig 全文搜索配置
+// Backend 为搜索后端, 目前只支持 memory (进程内倒排索引, 每个实例启动时从数据库重建, 并各自消费 Kafka 搜索索引主题的消息保持更新)
+type SearchConfig struct {
+	Backend string `mapstructure:"backend"`
+}
+
+// UploadConfig 附件上传配置
+// Storage 为 local (本地目录) 或 s3 (兼容 S3 协议的对象存储, 如 MinIO);
+// ImageMaxSize、DocumentMaxSize 和 ArchiveMaxSize 为各类文件的大小上限, 单位 KB;
+// MaxImagePixels 为图片的最大像素数, 防止解码超大图片耗尽内存; ThumbnailSize 为缩略图的最大边长, 单位像素;
+// 上传后 OrphanExpire 小时内没有被帖子或评论引用的附件会被删除
+type UploadConfig struct {
+	Storage         string             `mapstructure:"storage"`
+	ImageMaxSize    int64              `mapstructure:"imageMaxSize"`
+	DocumentMaxSize int64              `mapstructure:"documentMaxSize"`
+	ArchiveMaxSize  int64              `mapstructure:"archiveMaxSize"`
+	MaxImagePixels  int                `mapstructure:"maxImagePixels"`
+	ThumbnailSize   int                `mapstructure:"thumbnailSize"`
+	OrphanExpire    int                `mapstructure:"orphanExpire"`
+	Local           LocalStorageConfig `mapstructure:"local"`
+	S3              S3StorageConfig    `mapstructure:"s3"`
+}
+
+// LocalStorageConfig 本地存储配置, 多实例部署时 Dir 需要使用共享存储
+type LocalStorageConfig struct {
+	Dir string `mapstructure:"dir"`
+}
+
+// S3StorageConfig S3 存储配置
+// Endpoint 为带协议的服务地址, 如 http://localhost:9000; PathStyle 为 true 时使用路径风格的地址, MinIO 需要开启
+type S3StorageConfig struct {
+	Endpoint  string `mapstructure:"endpoint"`
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"accessKey"`
+	SecretKey string `mapstructure:"secretKey"`
+	PathStyle bool   `mapstructure:"pathStyle"`
+}
+
+// RankingConfig 帖子热度排行算法配置
+// Default 为全局和标签排行使用的算法, Communities 按社区 ID 指定社区排行使用的算法, 未指定的社区使用 Default;
+// 算法为 reddit、hackernews 或 wilson, Gravity 为 hackernews 算法的重力系数;
+// RefreshInterval 为检查排行算法是否切换以及重新计算随时间衰减的分数的间隔, 单位分钟;
+// 修改配置文件中的算法后不需要重启, RefreshInterval 的修改在重启后生效
+type RankingConfig struct {
+	Default         string            `mapstructure:"default"`
+	Communities     map[string]string `mapstructure:"communities"`
+	Gravity         float64           `mapstructure:"gravity"`
+	RefreshInterval int               `mapstructure:"refreshInterval"`
+}
+
 type Settings struct {
-	Host             string `mapstructure:"host"`
-	Port             int    `mapstructure:"port"`
-	Timeout          int    `mapstructure:"timeout"`
-	PasswordSecret   string `mapstructure:"password_secret"`
-	Mode             string `mapstructure:"mode"`
-	*MysqlConfig     `mapstructure:"mysql"`
-	*RedisConfig     `mapstructure:"redis"`
-	*LoggerConfig    `mapstructure:"logger"`
-	*Etcd            `mapstructure:"etcd"`
-	*ServiceRegistry `mapstructure:"service_registry"`
-	*KafkaConfig     `mapstructure:"kafka"`
+	Host                   string `mapstructure:"host"`
+	Port                   int    `mapstructure:"port"`
+	Timeout                int    `mapstructure:"timeout"`
+	PasswordSecret         string `mapstructure:"password_secret"`
+	Mode                   string `mapstructure:"mode"`
+	*MysqlConfig           `mapstructure:"mysql"`
+	*RedisConfig           `mapstructure:"redis"`
+	*LoggerConfig          `mapstructure:"logger"`
+	*Etcd                  `mapstructure:"etcd"`
+	*ServiceRegistry       `mapstructure:"service_registry"`
+	*KafkaConfig           `mapstructure:"kafka"`
+	*PasswordHashConfig    `mapstructure:"password_hash"`
+	*JWTConfig             `mapstructure:"jwt"`
+	*MailConfig            `mapstructure:"mail"`
+	*LoginProtectionConfig `mapstructure:"login_protection"`
+	*AccountConfig         `mapstructure:"account"`
+	*RegistrationConfig    `mapstructure:"registration"`
+	*CaptchaConfig         `mapstructure:"captcha"`
+	*SearchConfig          `mapstructure:"search"`
+	*UploadConfig          `mapstructure:"upload"`
+	*RankingConfig         `mapstructure:"ranking"`
 }
 
 // mustInitConfig 用于初始化配置文件
@@ -105,10 +264,64 @@ func mustInitConfig() {
 	viper.SetDefault("timeout", 10)
 	viper.SetDefault("mode", "release")
 
+	// argon2id 默认参数, 参考 RFC 9106 推荐值
+	viper.SetDefault("password_hash.memory", 64*1024)
+	viper.SetDefault("password_hash.iterations", 3)
+	viper.SetDefault("password_hash.parallelism", 2)
+
+	viper.SetDefault("jwt.issuer", "水告木南")
+	viper.SetDefault("jwt.accessTokenExpire", 15)
+	viper.SetDefault("jwt.refreshTokenExpire", 7*24*60)
+
+	viper.SetDefault("mail.driver", "file")
+	viper.SetDefault("mail.from", "GinTalk <no-reply@localhost>")
+	viper.SetDefault("mail.linkBaseURL", "http://localhost:8080")
+	viper.SetDefault("mail.fileDir", "./mails")
+	viper.SetDefault("mail.verifyTokenExpire", 24*60)
+	viper.SetDefault("mail.resetTokenExpire", 30)
+	viper.SetDefault("mail.smtp.port", 587)
+
+	viper.SetDefault("login_protection.failureWindow", 15)
+	viper.SetDefault("login_protection.userThreshold", 5)
+	viper.SetDefault("login_protection.ipThreshold", 20)
+	viper.SetDefault("login_protection.baseLockout", 30)
+	viper.SetDefault("login_protection.maxLockout", 3600)
+
+	viper.SetDefault("registration.mode", "open")
+
+	viper.SetDefault("captcha.enabled", true)
+	viper.SetDefault("captcha.driver", "text")
+	viper.SetDefault("captcha.length", 5)
+	viper.SetDefault("captcha.expire", 300)
+	viper.SetDefault("captcha.loginFailures", 3)
+
+	viper.SetDefault("search.backend", "memory")
+
+	viper.SetDefault("upload.storage", "local")
+	viper.SetDefault("upload.imageMaxSize", 10*1024)
+	viper.SetDefault("upload.documentMaxSize", 20*1024)
+	viper.SetDefault("upload.archiveMaxSize", 50*1024)
+	viper.SetDefault("upload.maxImagePixels", 16_000_000)
+	viper.SetDefault("upload.thumbnailSize", 320)
+	viper.SetDefault("upload.orphanExpire", 24)
+	viper.SetDefault("upload.local.dir", "./uploads")
+	viper.SetDefault("upload.s3.region", "us-east-1")
+	viper.SetDefault("upload.s3.pathStyle", true)
+
+	viper.SetDefault("ranking.default", "reddit")
+	viper.SetDefault("ranking.gravity", 1.8)
+	viper.SetDefault("ranking.refreshInterval", 10)
+
+	viper.SetDefault("account.deleteContent", "keep")
+	viper.SetDefault("account.exportDir", "./exports")
+	viper.SetDefault("account.exportSyncLimit", 1000)
+	viper.SetDefault("account.exportExpire", 72)
+
 	// 用于判断配置文件是否被修改
 	viper.WatchConfig()
 	viper.OnConfigChange(func(e fsnotify.Event) {
 		fmt.Println("Config file changed:", e.Name)
+		notifyConfigChange()
 	})
 
 	// 读取配置文件
@@ -128,3 +341,32 @@ func GetConfig() *Settings {
 	once.Do(mustInitConfig)
 	return conf
 }
+
+// OnConfigChange 注册配置文件修改后的回调, 回调的参数为重新解析的配置
+// GetConfig 返回的始终是启动时的配置, 只有注册了回调的模块会使用修改后的配置
+func OnConfigChange(fn func(*Settings)) {
+	changeMu.Lock()
+	defer changeMu.Unlock()
+	changeHandlers = append(changeHandlers, fn)
+}
+
+// notifyConfigChange 重新解析配置文件并调用所有回调, 解析失败时不调用回调
+func notifyConfigChange() {
+	c := new(Settings)
+	if err := viper.Unmarshal(c); err != nil {
+		fmt.Println("unmarshal changed config failed, err:", err)
+		return
+	}
+	changeMu.Lock()
+	handlers := append([]func(*Settings){}, changeHandlers...)
+	changeMu.Unlock()
+	for _, fn := range handlers {
+		fn(c)
+	}
+}
+
+// SetConfig 直接设置配置而不读取配置文件, 用于测试
+func SetConfig(c *Settings) {
+	once.Do(func() {})
+	conf = c
+}